@@ -52,7 +52,7 @@ echo ""
 
 # --- Stage 3: Build Server ---
 echo "--- Stage 3: Building App ---"
-if go build -o ./bin/server ./cmd/api; then
+if go build -o ./bin/server ./cmd/api && go build -o ./bin/worker ./cmd/worker; then
 	echo "App built successfully"
 else
 	echo "❌ App build failed"
@@ -18,6 +18,9 @@ func main() {
 	if *config.MigrateFlag {
 		app.RunMigrations()
 	}
+	if *config.RestorePath != "" {
+		app.RestoreArchive(*config.RestorePath)
+	}
 
 	app.Run()
 }
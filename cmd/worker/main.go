@@ -1,3 +1,26 @@
-package worker
+package main
 
-// Entry point for RabbitMQ consumer
+import (
+	"flag"
+
+	"github.com/abikandiah/task-worker/config"
+	"github.com/abikandiah/task-worker/internal/app"
+)
+
+// Entry point for standalone worker, runs jobs from the shared database without the HTTP API
+func main() {
+	flag.Parse()
+
+	app := app.NewApplication(&app.AppDependencies{
+		Config: config.MustLoad(),
+	})
+
+	if *config.MigrateFlag {
+		app.RunMigrations()
+	}
+	if *config.RestorePath != "" {
+		app.RestoreArchive(*config.RestorePath)
+	}
+
+	app.RunWorker()
+}
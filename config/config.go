@@ -6,7 +6,9 @@ import (
 
 	"github.com/abikandiah/task-worker/internal/platform/db"
 	"github.com/abikandiah/task-worker/internal/platform/logging"
+	"github.com/abikandiah/task-worker/internal/platform/metrics"
 	"github.com/abikandiah/task-worker/internal/platform/server"
+	"github.com/abikandiah/task-worker/internal/platform/tracing"
 	"github.com/abikandiah/task-worker/internal/service"
 )
 
@@ -17,11 +19,13 @@ const EnvPrefix = "APP"
 var (
 	MigrateFlag *bool
 	ConfigPath  *string
+	RestorePath *string
 )
 
 func init() {
 	MigrateFlag = flag.Bool("migrate", false, "Run database migrations and exit.")
 	ConfigPath = flag.String("config", "", "Path to the configuration file (e.g., config.yaml)")
+	RestorePath = flag.String("restore", "", "Restore jobs from an archive file or directory and exit.")
 }
 
 type Config struct {
@@ -33,6 +37,8 @@ type Config struct {
 	Server      *server.Config  `mapstructure:"server"`
 	Database    *db.Config      `mapstructure:"database"`
 	Logger      *logging.Config `mapstructure:"logger"`
+	Metrics     *metrics.Config `mapstructure:"metrics"`
+	Tracing     *tracing.Config `mapstructure:"tracing"`
 }
 
 func (config *Config) updateLoggingEnvironment() {
@@ -8,7 +8,9 @@ import (
 
 	"github.com/abikandiah/task-worker/internal/platform/db"
 	"github.com/abikandiah/task-worker/internal/platform/logging"
+	"github.com/abikandiah/task-worker/internal/platform/metrics"
 	"github.com/abikandiah/task-worker/internal/platform/server"
+	"github.com/abikandiah/task-worker/internal/platform/tracing"
 	"github.com/abikandiah/task-worker/internal/service"
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
@@ -105,6 +107,8 @@ func setDefaults(v *viper.Viper) {
 	db.SetConfigDefaults(v)
 	service.SetConfigDefaults(v)
 	logging.SetConfigDefaults(v)
+	metrics.SetConfigDefaults(v)
+	tracing.SetConfigDefaults(v)
 }
 
 // bindEnvironmentVariables explicitly binds environment variables
@@ -118,6 +122,8 @@ func bindEnvironmentVariables(v *viper.Viper) {
 	db.BindEnvironmentVariables(v)
 	service.BindEnvironmentVariables(v)
 	logging.BindEnvironmentVariables(v)
+	metrics.BindEnvironmentVariables(v)
+	tracing.BindEnvironmentVariables(v)
 }
 
 func initDefaultViper() *viper.Viper {
@@ -147,5 +153,11 @@ func (config *Config) validate() error {
 	if err := config.Worker.Validate(); err != nil {
 		return err
 	}
+	if err := config.Metrics.Validate(); err != nil {
+		return err
+	}
+	if err := config.Tracing.Validate(); err != nil {
+		return err
+	}
 	return nil
 }
@@ -14,7 +14,9 @@ import (
 	"github.com/abikandiah/task-worker/internal/factory"
 	"github.com/abikandiah/task-worker/internal/platform/db"
 	"github.com/abikandiah/task-worker/internal/platform/logging"
+	"github.com/abikandiah/task-worker/internal/platform/metrics"
 	"github.com/abikandiah/task-worker/internal/platform/server"
+	"github.com/abikandiah/task-worker/internal/platform/tracing"
 	"github.com/abikandiah/task-worker/internal/repository"
 	"github.com/abikandiah/task-worker/internal/repository/postgres"
 	"github.com/abikandiah/task-worker/internal/repository/sqlite3"
@@ -22,18 +24,26 @@ import (
 	"github.com/abikandiah/task-worker/internal/task"
 )
 
+// leaderElectionName identifies the election for the job service's singleton duties
+const leaderElectionName = "task-worker"
+
 type AppDependencies struct {
 	Config      *config.Config
 	Repository  repository.ServiceRepository
 	TaskFactory *factory.TaskFactory
 	Logger      *slog.Logger
 	db          *db.DB
+	// metrics is nil when disabled
+	metrics *metrics.Metrics
 }
 
 // internal/app/app.go
 type Application struct {
 	*AppDependencies
-	JobService *service.JobService
+	JobService    *service.JobService
+	metricsServer *http.Server
+	// shutdownTracing flushes the spans that were not exported yet
+	shutdownTracing func(context.Context) error
 }
 
 // NewApplication constructs the entire application stack.
@@ -44,6 +54,13 @@ func NewApplication(deps *AppDependencies) *Application {
 
 	app.Logger = logging.SetupLogger(deps.Config.Logger)
 
+	// Tracing is set up before the database, whose queries are traced
+	shutdownTracing, err := tracing.Setup(context.Background(), app.Config.Tracing, app.Config.ServiceName, app.Config.Version)
+	if err != nil {
+		panic(err.Error())
+	}
+	app.shutdownTracing = shutdownTracing
+
 	db, err := db.New(app.Config.Database)
 	if err != nil {
 		panic(err.Error())
@@ -52,24 +69,65 @@ func NewApplication(deps *AppDependencies) *Application {
 	app.db = db
 	app.Repository = initServiceRepository(app.db)
 
+	// A nil *Metrics would not be a nil service.Metrics, so the service only gets enabled metrics
+	var serviceMetrics service.Metrics
+	if app.Config.Metrics.Enabled {
+		app.metrics = metrics.New()
+		app.metrics.RegisterDB(app.db.DB.DB, app.db.Driver())
+		serviceMetrics = app.metrics
+	}
+
 	taskFactory := factory.NewTaskFactory()
 	app.TaskFactory = taskFactory
 
 	registerDepdenencies(taskFactory, app.AppDependencies)
 	registerTasks(taskFactory)
 
+	// The worker ID doubles as this instance's identity in leader election
+	if app.Config.Worker.ID == "" {
+		app.Config.Worker.ID = service.NewWorkerID()
+	}
+
 	app.JobService = service.NewJobService(&service.JobServiceParams{
-		Config:      app.Config.Worker,
-		TaskFactory: app.TaskFactory,
-		Repository:  app.Repository,
+		Config:        app.Config.Worker,
+		TaskFactory:   app.TaskFactory,
+		Repository:    app.Repository,
+		Version:       app.Config.Version,
+		LeaderElector: app.db.NewLeaderElector(leaderElectionName, app.Config.Worker.ID),
+		Metrics:       serviceMetrics,
 	})
 
+	// Tee logs emitted during task execution into the repository
+	app.Logger = slog.New(app.JobService.TaskLogHandler(app.Logger.Handler()))
+	slog.SetDefault(app.Logger)
+
 	return app
 }
 
 func (app *Application) Run() {
+	app.startMetricsServer()
 	app.startService()
 	app.startHttpServer()
+	app.Close()
+}
+
+// RunWorker runs only the job and task workers, without the HTTP server. Worker processes
+// claim jobs from the shared database, so they scale separately from the API tier.
+func (app *Application) RunWorker() {
+	// A worker process always executes jobs, even if the shared config disables them for the API
+	app.Config.Worker.Enabled = true
+	app.startMetricsServer()
+	app.startService()
+
+	slog.Info("worker is running", slog.String("workerId", app.JobService.WorkerID()))
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	slog.Info("worker shutting down...")
+	app.Close()
+	slog.Info("worker stopped")
 }
 
 func (app *Application) RunMigrations() {
@@ -83,6 +141,26 @@ func (app *Application) RunMigrations() {
 	os.Exit(0)
 }
 
+// RestoreArchive saves archived jobs back into the repository for investigation, then exits
+func (app *Application) RestoreArchive(path string) {
+	slog.Info("restoring archive...", slog.String("path", path))
+
+	report, err := app.JobService.RestoreArchive(context.Background(), path)
+	if err != nil {
+		slog.Error("failed to restore archive", slog.Any("error", err))
+	}
+	if report != nil {
+		slog.Info("restored archive", slog.Int("files", report.Files), slog.Int("jobs", report.Jobs),
+			slog.Int("taskRuns", report.TaskRuns), slog.Int("configs", report.Configs))
+	}
+	app.Repository.Close()
+	slog.Info("exiting")
+	if err != nil {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
 func (app *Application) startService() {
 	app.JobService.StartWorkers(context.Background())
 }
@@ -92,6 +170,7 @@ func (app *Application) startHttpServer() {
 	httpServer := server.NewServer(&server.ServerParams{
 		ServerConfig: app.Config.Server,
 		JobService:   app.JobService,
+		Metrics:      app.metrics,
 	})
 
 	// Start server in a goroutine
@@ -120,8 +199,35 @@ func (app *Application) startHttpServer() {
 	slog.Info("server stopped")
 }
 
+// startMetricsServer serves the metrics on their own listener, when enabled
+func (app *Application) startMetricsServer() {
+	if app.metrics == nil {
+		return
+	}
+	app.metricsServer = app.metrics.NewServer(app.Config.Metrics)
+
+	go func() {
+		if err := app.metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("metrics server failed to start", slog.Any("error", err))
+		}
+	}()
+
+	slog.Info("metrics server is listening", slog.Any("metrics_config", app.Config.Metrics))
+}
+
 func (app *Application) Close() {
 	app.JobService.Close(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if app.metricsServer != nil {
+		if err := app.metricsServer.Shutdown(ctx); err != nil {
+			slog.Error("metrics server forced to shutdown", slog.Any("error", err))
+		}
+	}
+	if err := app.shutdownTracing(ctx); err != nil {
+		slog.Error("failed to flush traces", slog.Any("error", err))
+	}
 	app.Repository.Close()
 }
 
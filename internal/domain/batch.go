@@ -0,0 +1,39 @@
+package domain
+
+import "github.com/google/uuid"
+
+// BatchMode decides what happens to a batch submission when some of its jobs fail
+type BatchMode string
+
+const (
+	// BatchAllOrNothing saves the jobs in one transaction, none are saved if any fails, the default
+	BatchAllOrNothing BatchMode = "all_or_nothing"
+	// BatchBestEffort saves every job that is valid and reports the rest
+	BatchBestEffort BatchMode = "best_effort"
+)
+
+// BatchItemStatus is the outcome of one submission of a batch
+type BatchItemStatus string
+
+const (
+	BatchItemSubmitted BatchItemStatus = "submitted"
+	BatchItemFailed    BatchItemStatus = "failed"
+	// BatchItemSkipped is a valid submission of an all or nothing batch that was not saved
+	BatchItemSkipped BatchItemStatus = "skipped"
+)
+
+// BatchSubmissionResult reports the outcome of a submission by its index in the batch
+type BatchSubmissionResult struct {
+	Index  int             `json:"index"`
+	Status BatchItemStatus `json:"status"`
+	JobID  *uuid.UUID      `json:"jobId,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// BatchSubmissionReport sums the outcomes of a batch submission
+type BatchSubmissionReport struct {
+	Mode      BatchMode               `json:"mode"`
+	Submitted int                     `json:"submitted"`
+	Failed    int                     `json:"failed"`
+	Results   []BatchSubmissionResult `json:"results"`
+}
@@ -36,8 +36,17 @@ const (
 	StateWarning  ExecutionState = "WARNING"
 	StateError    ExecutionState = "ERROR"
 	StateRejected ExecutionState = "REJECTED"
+	StateSkipped  ExecutionState = "SKIPPED"
+	// StateExpired ends jobs, and their TaskRuns, that did not start before their deadline
+	StateExpired ExecutionState = "EXPIRED"
 )
 
+// ExecutionStates lists every state, in the order above
+var ExecutionStates = []ExecutionState{
+	StatePending, StateRunning, StateFinished, StateStopped, StatePaused,
+	StateWarning, StateError, StateRejected, StateSkipped, StateExpired,
+}
+
 func GetStateName(state ExecutionState) string {
 	return string(state)
 }
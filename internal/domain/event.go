@@ -0,0 +1,17 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EventRecord is a lifecycle event persisted by the process that published it, so other
+// processes, such as API instances without workers, can relay it to their streams.
+// Seq orders records.
+type EventRecord struct {
+	Seq int64 `json:"seq"`
+	// Origin is the worker ID of the publishing process
+	Origin      string          `json:"origin"`
+	Payload     json.RawMessage `json:"payload"`
+	CreatedDate time.Time       `json:"createdDate"`
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrInvalidQuery is returned when the filters or sort of a list request are not supported
+var ErrInvalidQuery = errors.New("invalid list query")
+
+// FilterOp compares a field with the values of a Filter
+type FilterOp string
+
+const (
+	// FilterEq matches any of the values, e.g. state=RUNNING,ERROR
+	FilterEq FilterOp = "="
+	// FilterNe matches none of the values
+	FilterNe FilterOp = "!="
+	// FilterContains matches text containing the value, ignoring case, e.g. name~report
+	FilterContains FilterOp = "~"
+	FilterGt       FilterOp = ">"
+	FilterGte      FilterOp = ">="
+	FilterLt       FilterOp = "<"
+	FilterLte      FilterOp = "<="
+)
+
+// filterOps are matched in order, so two character operators come before their prefixes
+var filterOps = []FilterOp{FilterNe, FilterGte, FilterLte, FilterEq, FilterContains, FilterGt, FilterLt}
+
+var filterFieldPattern = regexp.MustCompile(`^[a-zA-Z]+$`)
+
+// Filter narrows a list request to the items whose field compares to its values
+type Filter struct {
+	Field  string   `json:"field"`
+	Op     FilterOp `json:"op"`
+	Values []string `json:"values"`
+}
+
+// ParseFilter parses a filter expression such as submitDate>=2026-01-01. Equality filters take a
+// comma separated list of values, other operators take one value.
+func ParseFilter(expr string) (Filter, error) {
+	index := strings.IndexAny(expr, "!=~<>")
+	if index <= 0 {
+		return Filter{}, fmt.Errorf("%w: filter %q has no field or operator", ErrInvalidQuery, expr)
+	}
+
+	field, rest := expr[:index], expr[index:]
+	if !filterFieldPattern.MatchString(field) {
+		return Filter{}, fmt.Errorf("%w: filter field %q must be letters only", ErrInvalidQuery, field)
+	}
+
+	for _, op := range filterOps {
+		value, ok := strings.CutPrefix(rest, string(op))
+		if !ok {
+			continue
+		}
+		if value == "" {
+			return Filter{}, fmt.Errorf("%w: filter %q has no value", ErrInvalidQuery, expr)
+		}
+
+		values := []string{value}
+		if op == FilterEq || op == FilterNe {
+			values = strings.Split(value, ",")
+		}
+		return Filter{Field: field, Op: op, Values: values}, nil
+	}
+	return Filter{}, fmt.Errorf("%w: filter %q has an unknown operator", ErrInvalidQuery, expr)
+}
+
+// ParseFilterTime parses the value of a date filter, an RFC 3339 time or a date in UTC
+func ParseFilterTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %q is not an RFC 3339 time or a date", ErrInvalidQuery, value)
+	}
+	return t, nil
+}
+
+// ParseFilterState parses the value of a state filter, ignoring case
+func ParseFilterState(value string) (ExecutionState, error) {
+	state := ExecutionState(strings.ToUpper(value))
+	for _, known := range ExecutionStates {
+		if state == known {
+			return state, nil
+		}
+	}
+	return "", fmt.Errorf("%w: unknown state %q", ErrInvalidQuery, value)
+}
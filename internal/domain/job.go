@@ -3,6 +3,8 @@ package domain
 import (
 	"time"
 
+	"github.com/abikandiah/task-worker/internal/util"
+
 	"github.com/google/uuid"
 )
 
@@ -14,31 +16,121 @@ type Job struct {
 	SubmitDate    time.Time  `json:"submitDate"`
 	StartDate     *time.Time `json:"startDate,omitempty"`
 	EndDate       *time.Time `json:"endDate,omitempty"`
+	// ExpiresAt is the deadline for the job to start, it expires rather than running late
+	ExpiresAt *time.Time        `json:"expiresAt,omitempty"`
+	Variables Variables         `json:"variables,omitempty"`
+	Callbacks []WebhookCallback `json:"callbacks,omitempty"`
+	Tags      []string          `json:"tags,omitempty"`
+	Queue     string            `json:"queue"`
+	// Capabilities a worker must provide to run the job, required by its tasks
+	Capabilities []string `json:"capabilities,omitempty"`
+	// Jobs sharing a ConcurrencyKey run at most ConcurrencyLimit at a time, in submission order
+	ConcurrencyKey   string `json:"concurrencyKey,omitempty"`
+	ConcurrencyLimit int    `json:"concurrencyLimit,omitempty"`
+	// TemplateID and TemplateVersion are the template the job was instantiated from, if any
+	TemplateID      *uuid.UUID `json:"templateId,omitempty"`
+	TemplateVersion *uuid.UUID `json:"templateVersion,omitempty"`
+	// WorkerID is the worker that claimed the job, set by the repository
+	WorkerID string `json:"workerId,omitempty"`
+	// TraceContext is the W3C trace context of the submission, execution continues its trace
+	TraceContext map[string]string `json:"traceContext,omitempty"`
 }
 
 type JobSubmission struct {
 	IdentitySubmission
-	ConfigID      uuid.UUID `json:"configId,omitempty"`
-	ConfigVersion uuid.UUID `json:"configVersion,omitempty"`
-	TaskRuns      []TaskRun `json:"taskRuns"`
+	ConfigID      uuid.UUID         `json:"configId,omitempty"`
+	ConfigVersion uuid.UUID         `json:"configVersion,omitempty"`
+	Variables     Variables         `json:"variables,omitempty"`
+	Callbacks     []WebhookCallback `json:"callbacks,omitempty"`
+	Tags          []string          `json:"tags,omitempty"`
+	// Queue routes the job to the workers subscribed to it, overriding the config's queue
+	Queue string `json:"queue,omitempty"`
+	// ConcurrencyKey makes the job queue behind unfinished jobs with the same key, e.g. an account ID
+	ConcurrencyKey string `json:"concurrencyKey,omitempty"`
+	// ExpiresAt overrides the config's expireAfter
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	// ConcurrencyLimit is how many jobs of the key may run at once, defaults to 1
+	ConcurrencyLimit  int               `json:"concurrencyLimit,omitempty"`
+	ConcurrencyPolicy ConcurrencyPolicy `json:"concurrencyPolicy,omitempty"`
+	TaskRuns          []TaskRun         `json:"taskRuns"`
+}
+
+// ConcurrencyPolicy decides what happens to a submission whose concurrency key is in use
+type ConcurrencyPolicy string
+
+const (
+	// ConcurrencyQueue runs the job once earlier jobs of the key leave room, the default
+	ConcurrencyQueue ConcurrencyPolicy = "queue"
+	// ConcurrencyReplace stops pending jobs of the key that have not been claimed
+	ConcurrencyReplace ConcurrencyPolicy = "replace"
+	// ConcurrencyReject refuses the job while the key's unfinished jobs reach the limit
+	ConcurrencyReject ConcurrencyPolicy = "reject"
+)
+
+// JobWithTaskRuns is a job saved together with its task runs
+type JobWithTaskRuns struct {
+	Job      Job
+	TaskRuns []TaskRun
+	// ReplacePending stops the unclaimed pending jobs of the job's concurrency key before it is saved
+	ReplacePending bool
 }
 
+// Variables are submitted with a job and are available to TaskRun conditions as vars.<name>
+type Variables map[string]any
+
 // GetID implements the required method for cursor pagination.
 func (job Job) GetID() uuid.UUID {
 	return job.ID
 }
 
+// JobConfig versions are immutable, a change publishes a new version and jobs keep the version they were submitted with
 type JobConfig struct {
 	IdentityVersion
-	IsDefault        bool `json:"isDefault"`
+	IsDefault        bool      `json:"isDefault"`
+	CreateDate       time.Time `json:"createDate"`
+	JobConfigDetails `json:"details"`
+}
+
+// JobConfigSubmission creates a config, or publishes a new version of one
+type JobConfigSubmission struct {
+	IdentitySubmission
 	JobConfigDetails `json:"details"`
 }
 
+// JobConfigChange is a value that differs between two versions of a config, by its JSON path
+type JobConfigChange struct {
+	Path string `json:"path"`
+	From any    `json:"from"`
+	To   any    `json:"to"`
+}
+
+// JobConfigDiff lists the changes from one version of a config to another
+type JobConfigDiff struct {
+	ID      uuid.UUID         `json:"id"`
+	From    uuid.UUID         `json:"from"`
+	To      uuid.UUID         `json:"to"`
+	Changes []JobConfigChange `json:"changes"`
+}
+
 type JobConfigDetails struct {
 	JobTimeout          int  `json:"jobTimeout"`
 	TaskTimeout         int  `json:"taskTimeout"`
 	EnableParallelTasks bool `json:"enableParallelTasks"`
 	MaxParallelTasks    int  `json:"maxParallelTasks"`
+	// Upper bounds for TaskRun overrides. A MaxTaskTimeout of 0 falls back to TaskTimeout, unset
+	// retry and priority bounds fall back to the Default* limits, which keeps configs saved before
+	// the bounds existed usable. A bound of 0 allows no override.
+	MaxTaskTimeout  int  `json:"maxTaskTimeout"`
+	MaxTaskRetries  *int `json:"maxTaskRetries,omitempty"`
+	MaxTaskPriority *int `json:"maxTaskPriority,omitempty"`
+	// Callbacks added to every job submitted with this config
+	Callbacks []WebhookCallback `json:"callbacks,omitempty"`
+	// Queue that jobs submitted with this config run on, unless the submission names one
+	Queue string `json:"queue,omitempty"`
+	// ExpireAfter is how many seconds after submission jobs expire if not started, 0 never expires
+	ExpireAfter int `json:"expireAfter,omitempty"`
+	// Retention overrides the global retention of the config's ended jobs
+	Retention *RetentionPolicy `json:"retention,omitempty"`
 }
 
 // GetID implements the required method for cursor pagination.
@@ -46,6 +138,28 @@ func (config JobConfig) GetID() uuid.UUID {
 	return config.ID
 }
 
+// Limits of TaskRun overrides for configs that set none
+const (
+	DefaultMaxTaskRetries  = 3
+	DefaultMaxTaskPriority = 10
+)
+
+// TaskRetryLimit is the most retries a TaskRun may override, DefaultMaxTaskRetries when unset
+func (details *JobConfigDetails) TaskRetryLimit() int {
+	if details.MaxTaskRetries == nil {
+		return DefaultMaxTaskRetries
+	}
+	return *details.MaxTaskRetries
+}
+
+// TaskPriorityLimit is the highest priority a TaskRun may override, DefaultMaxTaskPriority when unset
+func (details *JobConfigDetails) TaskPriorityLimit() int {
+	if details.MaxTaskPriority == nil {
+		return DefaultMaxTaskPriority
+	}
+	return *details.MaxTaskPriority
+}
+
 func NewDefaultJobConfig() *JobConfig {
 	submission := IdentitySubmission{
 		Name: "Default JobConfig",
@@ -60,11 +174,15 @@ func NewDefaultJobConfig() *JobConfig {
 	return &JobConfig{
 		IdentityVersion: identityVersion,
 		IsDefault:       true,
+		CreateDate:      time.Now().UTC(),
 		JobConfigDetails: JobConfigDetails{
 			JobTimeout:          600,
 			TaskTimeout:         120,
 			EnableParallelTasks: true,
 			MaxParallelTasks:    2,
+			MaxTaskTimeout:      3600,
+			MaxTaskRetries:      util.IntPtr(DefaultMaxTaskRetries),
+			MaxTaskPriority:     util.IntPtr(DefaultMaxTaskPriority),
 		},
 	}
 }
@@ -10,6 +10,7 @@ type LogKeys struct {
 	TaskName   LogKey
 	ConfigID   LogKey
 	ConfigName LogKey
+	TemplateID LogKey
 	RequestID  LogKey
 	Method     LogKey
 	Path       LogKey
@@ -23,6 +24,7 @@ var LKeys = LogKeys{
 	TaskName:   "task_name",
 	ConfigID:   "config_id",
 	ConfigName: "config_name",
+	TemplateID: "template_id",
 	RequestID:  "request_id",
 	Method:     "method",
 	Path:       "path",
@@ -36,6 +38,7 @@ var ContextLKeys = []LogKey{
 	LKeys.TaskName,
 	LKeys.ConfigID,
 	LKeys.ConfigName,
+	LKeys.TemplateID,
 	LKeys.RequestID,
 	LKeys.Method,
 	LKeys.Path,
@@ -1,9 +1,10 @@
 package domain
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
 	"strings"
-
-	"github.com/google/uuid"
 )
 
 type SortDirection string
@@ -15,13 +16,45 @@ const (
 	MaxSortLimit                   = 100
 )
 
+// SortOrder sorts a list by one field
+type SortOrder struct {
+	Field string        `json:"field"`
+	Dir   SortDirection `json:"dir"`
+}
+
+// ParseSort parses comma separated sort fields and their directions, e.g. state,submit_date and ASC,DESC.
+// Fields without a direction take the last one given, or ascending.
+func ParseSort(fields string, dirs string) []SortOrder {
+	if fields == "" {
+		return nil
+	}
+
+	var dirList []string
+	if dirs != "" {
+		dirList = strings.Split(dirs, ",")
+	}
+
+	var sorts []SortOrder
+	dir := SortASC
+	for i, field := range strings.Split(fields, ",") {
+		if i < len(dirList) {
+			dir = SortDirection(strings.TrimSpace(dirList[i]))
+		}
+		sorts = append(sorts, SortOrder{Field: strings.TrimSpace(field), Dir: dir})
+	}
+	return sorts
+}
+
 // CursorInput defines the data needed to request the next/previous page.
 type CursorInput struct {
-	AfterID   uuid.UUID     `json:"afterId,omitempty"`
-	BeforeID  uuid.UUID     `json:"beforeId,omitempty"`
-	Limit     int           `json:"limit,omitempty"`
-	SortField string        `json:"sortField,omitempty"`
-	SortDir   SortDirection `json:"sortDir,omitempty"`
+	// After and Before are cursor tokens of a previous page's CursorOutput
+	After  string `json:"after,omitempty"`
+	Before string `json:"before,omitempty"`
+	Limit  int    `json:"limit,omitempty"`
+	// Sort orders the list by each field in turn, and lastly by the list's key
+	Sort []SortOrder `json:"sort,omitempty"`
+	// Filters that every item of the list matches
+	Filters []Filter `json:"filters,omitempty"`
 }
 
 func (c *CursorInput) SetDefaults() {
@@ -30,34 +63,61 @@ func (c *CursorInput) SetDefaults() {
 		c.Limit = DefaultSortLimit
 	}
 
-	// 2. SortField Default
-	if c.SortField == "" {
-		c.SortField = "id"
-	}
-
-	// 3. SortDir Validation and Default
-	upperDir := SortDirection(strings.ToUpper(string(c.SortDir)))
-	if upperDir != SortASC && upperDir != SortDESC {
-		c.SortDir = SortASC // Default to ascending
-	} else {
-		c.SortDir = upperDir
+	// 2. SortDir Validation and Default
+	for i, sort := range c.Sort {
+		upperDir := SortDirection(strings.ToUpper(string(sort.Dir)))
+		if upperDir != SortASC && upperDir != SortDESC {
+			c.Sort[i].Dir = SortASC // Default to ascending
+		} else {
+			c.Sort[i].Dir = upperDir
+		}
 	}
 }
 
 func (c *CursorInput) HasAfterCursor() bool {
-	return c.AfterID != uuid.Nil
+	return c.After != ""
 }
 
 func (c *CursorInput) HasBeforeCursor() bool {
-	return c.BeforeID != uuid.Nil
+	return c.Before != ""
 }
 
 // CursorOutput includes cursors for the next/previous request.
 type CursorOutput[T any] struct {
-	// NextCursor will be uuid.Nil if no next page exists.
-	NextCursor *uuid.UUID `json:"nextCursor,omitempty"`
-	// PrevCursor will be uuid.Nil if this is the first page.
-	PrevCursor *uuid.UUID `json:"prevCursor,omitempty"`
-	Limit      int        `json:"limit"`
-	Data       []T        `json:"data"`
+	// NextCursor will be nil if no next page exists.
+	NextCursor *string `json:"nextCursor,omitempty"`
+	// PrevCursor will be nil if this is the first page.
+	PrevCursor *string `json:"prevCursor,omitempty"`
+	Limit      int     `json:"limit"`
+	Data       []T     `json:"data"`
+}
+
+// Cursor is the position of an item in a sorted list, clients only see it as an opaque token
+type Cursor struct {
+	// Sort the list was in, the cursor's values are meaningless in any other
+	Sort []SortOrder `json:"s"`
+	// Values of the item's sort fields, the last is its key
+	Values []any `json:"v"`
+}
+
+// EncodeCursor encodes a cursor as a URL safe token
+func EncodeCursor(cursor Cursor) (string, error) {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor decodes a cursor token of EncodeCursor
+func DecodeCursor(token string) (Cursor, error) {
+	var cursor Cursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err == nil {
+		err = json.Unmarshal(data, &cursor)
+	}
+	if err != nil || len(cursor.Values) == 0 || len(cursor.Values) != len(cursor.Sort) {
+		return Cursor{}, fmt.Errorf("%w: invalid cursor", ErrInvalidQuery)
+	}
+	return cursor, nil
 }
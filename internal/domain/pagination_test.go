@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := Cursor{
+		Sort:   []SortOrder{{Field: "score", Dir: SortDESC}, {Field: "id", Dir: SortDESC}},
+		Values: []any{nil, "r01"},
+	}
+
+	token, err := EncodeCursor(cursor)
+	if err != nil {
+		t.Fatalf("EncodeCursor: %v", err)
+	}
+	decoded, err := DecodeCursor(token)
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, cursor) {
+		t.Errorf("DecodeCursor = %+v, want %+v", decoded, cursor)
+	}
+}
+
+func TestDecodeCursorRejectsInvalidTokens(t *testing.T) {
+	encode := func(json string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(json))
+	}
+	valid, _ := EncodeCursor(Cursor{Sort: []SortOrder{{Field: "id", Dir: SortASC}}, Values: []any{"r01"}})
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"not base64", "not a token!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"s":[{"field":"id","dir":"ASC"}],"v":["r01"]}`))},
+		{"not json", encode("cursor")},
+		{"truncated", valid[:len(valid)-4]},
+		{"no values", encode(`{"s":[{"field":"id","dir":"ASC"}],"v":[]}`)},
+		{"fewer values than sorts", encode(`{"s":[{"field":"name","dir":"ASC"},{"field":"id","dir":"ASC"}],"v":["r01"]}`)},
+		{"more values than sorts", encode(`{"s":[{"field":"id","dir":"ASC"}],"v":["a","r01"]}`)},
+		{"values not a list", encode(`{"s":[{"field":"id","dir":"ASC"}],"v":"r01"}`)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := DecodeCursor(test.token); !errors.Is(err, ErrInvalidQuery) {
+				t.Errorf("DecodeCursor(%q) error = %v, want ErrInvalidQuery", test.token, err)
+			}
+		})
+	}
+}
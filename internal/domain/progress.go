@@ -0,0 +1,23 @@
+package domain
+
+import "context"
+
+type progressKey struct{}
+
+// ProgressReporter receives task progress between 0 and 1
+type ProgressReporter func(progress float32)
+
+// WithProgressReporter returns a context that tasks can report progress through
+func WithProgressReporter(ctx context.Context, reporter ProgressReporter) context.Context {
+	return context.WithValue(ctx, progressKey{}, reporter)
+}
+
+// ReportProgress reports task progress between 0 and 1. It is a no-op if the
+// context has no reporter.
+func ReportProgress(ctx context.Context, progress float32) {
+	reporter, ok := ctx.Value(progressKey{}).(ProgressReporter)
+	if !ok {
+		return
+	}
+	reporter(min(max(progress, 0), 1))
+}
@@ -0,0 +1,23 @@
+package domain
+
+// DefaultQueue receives jobs that neither their submission nor their config route elsewhere
+const DefaultQueue = "default"
+
+// Queue reports the depth of a named queue and the live workers serving it
+type Queue struct {
+	Name string `json:"name"`
+	// Pending jobs are waiting to be claimed, Claimed jobs are waiting for a JobWorker
+	Pending int `json:"pending"`
+	Claimed int `json:"claimed"`
+	Running int `json:"running"`
+	// Workers lists the live workers subscribed to the queue
+	Workers        []string `json:"workers"`
+	JobWorkerCount int      `json:"jobWorkerCount"`
+}
+
+// WorkerQueue is a queue a worker subscribes to, with the pool sizes serving it
+type WorkerQueue struct {
+	Name            string `json:"name"`
+	JobWorkerCount  int    `json:"jobWorkerCount"`
+	TaskWorkerCount int    `json:"taskWorkerCount"`
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EndedStates are the terminal job states that retention applies to
+var EndedStates = []ExecutionState{
+	StateFinished, StateWarning, StateError, StateStopped, StateRejected, StateExpired,
+}
+
+// RetentionPolicy limits how long ended jobs of a JobConfig are kept, overriding the global
+// policy for the states and limits it sets
+type RetentionPolicy struct {
+	// MaxAge is how many seconds jobs are kept after ending, by state. States without an age are kept.
+	// Jobs age out by the policy of the config version they ran with.
+	MaxAge map[ExecutionState]int `json:"maxAge,omitempty"`
+	// MaxJobs keeps at most this many ended jobs of the config, of any version, newest first, 0
+	// keeps all. The latest version's limit applies.
+	MaxJobs int `json:"maxJobs,omitempty"`
+}
+
+// ConfigVersionRef identifies one version of a JobConfig
+type ConfigVersionRef struct {
+	ConfigID uuid.UUID
+	Version  uuid.UUID
+}
+
+// PurgeFilter selects the ended jobs of a config that a retention rule purges
+type PurgeFilter struct {
+	ConfigID uuid.UUID
+	// ConfigVersion limits the filter to the jobs of one version of the config, any version when empty
+	ConfigVersion uuid.UUID
+	// State limits the filter to one ended state, any ended state when empty
+	State ExecutionState
+	// EndedBefore selects jobs that ended before it, when set
+	EndedBefore *time.Time
+	// KeepNewest excludes the newest ended jobs of the config, when positive
+	KeepNewest int
+}
+
+// PurgeRule reports the jobs of one retention rule that were, or would be, purged
+type PurgeRule struct {
+	ConfigID      uuid.UUID      `json:"configId"`
+	ConfigVersion uuid.UUID      `json:"configVersion,omitempty"`
+	State         ExecutionState `json:"state,omitempty"`
+	// MaxAge in seconds or MaxJobs, whichever the rule applies
+	MaxAge   int   `json:"maxAge,omitempty"`
+	MaxJobs  int   `json:"maxJobs,omitempty"`
+	Jobs     int64 `json:"jobs"`
+	TaskRuns int64 `json:"taskRuns"`
+}
+
+// PurgeReport sums what a retention pass purged, or would purge in a dry run
+type PurgeReport struct {
+	DryRun   bool        `json:"dryRun"`
+	Rules    []PurgeRule `json:"rules"`
+	Jobs     int64       `json:"jobs"`
+	TaskRuns int64       `json:"taskRuns"`
+}
+
+// ArchivedJob is a purged job as written to an archive, one per JSONL line, with its task runs
+// and the config version it ran with so it can be restored into an empty repository
+type ArchivedJob struct {
+	Job      Job        `json:"job"`
+	TaskRuns []TaskRun  `json:"taskRuns"`
+	Config   *JobConfig `json:"config,omitempty"`
+}
+
+// ArchiveFile is the manifest entry of an archive file
+type ArchiveFile struct {
+	// Path of the file relative to the archive directory
+	Path string `json:"path"`
+	// Date the archived jobs ended, the file's partition
+	Date      string    `json:"date"`
+	Jobs      int       `json:"jobs"`
+	TaskRuns  int       `json:"taskRuns"`
+	Bytes     int64     `json:"bytes"`
+	SHA256    string    `json:"sha256"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// RestoreReport sums what was restored from archive files
+type RestoreReport struct {
+	Files    int `json:"files"`
+	Jobs     int `json:"jobs"`
+	TaskRuns int `json:"taskRuns"`
+	Configs  int `json:"configs"`
+}
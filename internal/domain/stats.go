@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StatsRange is the time range that statistics cover, From inclusive and To exclusive
+type StatsRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// Stats aggregates jobs and task runs over a time range for capacity planning
+type Stats struct {
+	StatsRange
+	// JobStates counts the jobs submitted in the range by state, TaskStates counts their task runs
+	JobStates  map[ExecutionState]int `json:"jobStates"`
+	TaskStates map[ExecutionState]int `json:"taskStates"`
+	// Throughput counts the jobs that ended in each hour of the range, hours without any are left out
+	Throughput []HourlyThroughput `json:"throughput"`
+	// QueueWait is how long the jobs that started in the range waited after submission, by queue
+	QueueWait []QueueWaitStats `json:"queueWait"`
+	// TaskDurations and ConfigDurations are how long the task runs and jobs that ended in the range ran
+	TaskDurations   []TaskDurationStats   `json:"taskDurations"`
+	ConfigDurations []ConfigDurationStats `json:"configDurations"`
+}
+
+// HourlyThroughput counts the jobs that ended in an hour
+type HourlyThroughput struct {
+	Hour     time.Time `json:"hour"`
+	Ended    int       `json:"ended"`
+	Finished int       `json:"finished"`
+	Failed   int       `json:"failed"`
+}
+
+// DurationStats summarizes durations in seconds, percentiles are nearest rank
+type DurationStats struct {
+	Count int     `json:"count"`
+	Avg   float64 `json:"avg"`
+	P50   float64 `json:"p50"`
+	P95   float64 `json:"p95"`
+	P99   float64 `json:"p99"`
+}
+
+type QueueWaitStats struct {
+	Queue string `json:"queue"`
+	DurationStats
+}
+
+type TaskDurationStats struct {
+	TaskName string `json:"taskName"`
+	DurationStats
+}
+
+type ConfigDurationStats struct {
+	ConfigID uuid.UUID `json:"configId"`
+	DurationStats
+}
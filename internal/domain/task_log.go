@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TaskLog is a log record emitted while a TaskRun executed. Seq orders records and is
+// used as the pagination cursor.
+type TaskLog struct {
+	Seq       int64          `json:"seq"`
+	JobID     uuid.UUID      `json:"jobId"`
+	TaskRunID uuid.UUID      `json:"taskRunId"`
+	Time      time.Time      `json:"time"`
+	Level     string         `json:"level"`
+	Message   string         `json:"message"`
+	Attrs     map[string]any `json:"attrs,omitempty"`
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/google/uuid"
 )
 
+// Task is the work of a TaskRun. ctx carries the attempt's trace span, so tasks can trace their own
+// work with otel.Tracer(name).Start(ctx, spanName).
 type Task interface {
 	Execute(ctx context.Context) (any, error)
 }
@@ -22,9 +24,39 @@ type TaskRun struct {
 	TaskRunDetails `json:"details"`
 }
 
+// Status is the state and progress of the TaskRun, as reported in events
+func (taskRun *TaskRun) Status() Status {
+	return Status{State: taskRun.State, Progress: taskRun.Progress}
+}
+
 type TaskRunDetails struct {
 	Parallel bool            `json:"parallel"`
 	Params   json.RawMessage `json:"params"`
 	Result   any             `json:"result"`
 	Progress float32         `json:"progress"`
+	When     *TaskCondition  `json:"when,omitempty"`
+	// Per-run overrides, validated against the maximums in JobConfigDetails
+	Timeout  int          `json:"timeout,omitempty"`
+	Retry    *RetryPolicy `json:"retry,omitempty"`
+	Priority int          `json:"priority,omitempty"`
+	Attempts int          `json:"attempts"`
+	// Error of the last attempt of a TaskRun that failed
+	Error string `json:"error,omitempty"`
+}
+
+// RetryPolicy controls how a failed TaskRun is retried
+type RetryPolicy struct {
+	MaxRetries int `json:"maxRetries"`
+	// Backoff in seconds before the first retry, doubled for each subsequent retry
+	Backoff int `json:"backoff"`
+}
+
+// TaskCondition gates a TaskRun at dispatch time. Both parts must hold for the task to run.
+type TaskCondition struct {
+	// Task is the name (or taskName if unnamed) of an earlier TaskRun in the same job
+	Task string `json:"task,omitempty"`
+	// States the referenced task must have ended in (e.g. ["ERROR"] for cleanup tasks)
+	States []ExecutionState `json:"states,omitempty"`
+	// Expression over vars.<name> and tasks.<name>.state / tasks.<name>.result
+	Expression string `json:"expression,omitempty"`
 }
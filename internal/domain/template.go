@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ParameterType is the JSON type a template parameter's value must have
+type ParameterType string
+
+const (
+	ParameterString  ParameterType = "string"
+	ParameterNumber  ParameterType = "number"
+	ParameterInteger ParameterType = "integer"
+	ParameterBoolean ParameterType = "boolean"
+	ParameterObject  ParameterType = "object"
+	ParameterArray   ParameterType = "array"
+)
+
+// TemplateParameter declares an input of a JobTemplate
+type TemplateParameter struct {
+	Name        string        `json:"name"`
+	Type        ParameterType `json:"type"`
+	Description string        `json:"description,omitempty"`
+	// Required parameters must be given when instantiating, others fall back to Default
+	Required bool `json:"required,omitempty"`
+	Default  any  `json:"default,omitempty"`
+}
+
+// JobTemplate is a named, versioned job definition. Task run params reference the template's
+// parameters as {{ params.<name> }}, which are filled in when the template is instantiated.
+// Every update saves a new version, jobs keep the version they were instantiated from.
+type JobTemplate struct {
+	IdentityVersion
+	CreateDate         time.Time `json:"createDate"`
+	JobTemplateDetails `json:"details"`
+}
+
+type JobTemplateDetails struct {
+	Parameters []TemplateParameter `json:"parameters"`
+	// ConfigID, Queue and Tags of jobs instantiated from the template, instantiations can override them
+	ConfigID uuid.UUID `json:"configId,omitempty"`
+	// ConfigVersion pins the config's version, jobs use its latest version when empty
+	ConfigVersion uuid.UUID `json:"configVersion,omitempty"`
+	Queue         string    `json:"queue,omitempty"`
+	Tags          []string  `json:"tags,omitempty"`
+	TaskRuns      []TaskRun `json:"taskRuns"`
+}
+
+// GetID implements the required method for cursor pagination.
+func (template JobTemplate) GetID() uuid.UUID {
+	return template.ID
+}
+
+// JobTemplateSubmission creates a template, or a new version of one
+type JobTemplateSubmission struct {
+	IdentitySubmission
+	JobTemplateDetails `json:"details"`
+}
+
+// TemplateInstantiation creates a job from a template. Its job fields override the template's,
+// the task runs come from the template.
+type TemplateInstantiation struct {
+	JobSubmission
+	// Version of the template to instantiate, the latest when empty
+	Version uuid.UUID      `json:"version,omitempty"`
+	Inputs  map[string]any `json:"inputs,omitempty"`
+}
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookCallback is a URL that receives signed POSTs when a job changes state
+type WebhookCallback struct {
+	URL string `json:"url"`
+	// Events limits deliveries to these event types (e.g. "job.finished"), empty means all job events
+	Events []string `json:"events,omitempty"`
+}
+
+// WebhookDelivery is a persisted delivery of one event to one callback URL.
+// State is PENDING until delivered (FINISHED) or out of attempts (ERROR).
+type WebhookDelivery struct {
+	ID              uuid.UUID       `json:"id"`
+	JobID           uuid.UUID       `json:"jobId"`
+	URL             string          `json:"url"`
+	EventType       string          `json:"eventType"`
+	Payload         json.RawMessage `json:"payload"`
+	State           ExecutionState  `json:"state"`
+	Attempts        int             `json:"attempts"`
+	LastStatusCode  int             `json:"lastStatusCode,omitempty"`
+	LastError       string          `json:"lastError,omitempty"`
+	NextAttemptDate time.Time       `json:"nextAttemptDate"`
+	CreatedDate     time.Time       `json:"createdDate"`
+	DeliveredDate   *time.Time      `json:"deliveredDate,omitempty"`
+}
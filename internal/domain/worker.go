@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+type WorkerState string
+
+const (
+	WorkerAlive   WorkerState = "ALIVE"
+	WorkerDead    WorkerState = "DEAD"
+	WorkerStopped WorkerState = "STOPPED"
+)
+
+// Worker is a process executing jobs, registered with its pool sizes and the tasks it can run.
+// A worker whose heartbeat is older than the configured threshold is considered dead.
+type Worker struct {
+	ID              string        `json:"id"`
+	Hostname        string        `json:"hostname"`
+	Version         string        `json:"version,omitempty"`
+	JobWorkerCount  int           `json:"jobWorkerCount"`
+	TaskWorkerCount int           `json:"taskWorkerCount"`
+	TaskNames       []string      `json:"taskNames"`
+	Queues          []WorkerQueue `json:"queues"`
+	Capabilities    []string      `json:"capabilities,omitempty"`
+	State           WorkerState   `json:"state"`
+	StartedDate     time.Time     `json:"startedDate"`
+	HeartbeatDate   time.Time     `json:"heartbeatDate"`
+	StoppedDate     *time.Time    `json:"stoppedDate,omitempty"`
+	// Leader is set on the worker running the singleton background duties
+	Leader bool `json:"leader"`
+}
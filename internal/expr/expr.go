@@ -0,0 +1,477 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Node is a parsed expression that can be evaluated against an environment.
+//
+// Supported syntax:
+//   - literals: numbers, 'single' or "double" quoted strings, true, false, null
+//   - paths: vars.region, tasks.fetch.state, tasks["my task"].result.count
+//   - comparison: ==, !=, <, <=, >, >=
+//   - logical: &&, ||, ! and parentheses
+type Node interface {
+	Eval(env map[string]any) (any, error)
+}
+
+// Parse parses src into an evaluable Node.
+func Parse(src string) (Node, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected token %q at position %d", tok.text, tok.pos)
+	}
+	return node, nil
+}
+
+// EvalBool parses and evaluates src, returning the truthiness of the result.
+func EvalBool(src string, env map[string]any) (bool, error) {
+	node, err := Parse(src)
+	if err != nil {
+		return false, err
+	}
+	val, err := node.Eval(env)
+	if err != nil {
+		return false, err
+	}
+	return Truthy(val), nil
+}
+
+// Truthy reports whether a value is considered true in a boolean context.
+func Truthy(val any) bool {
+	switch v := val.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		return v != ""
+	case []any:
+		return len(v) > 0
+	case map[string]any:
+		return len(v) > 0
+	default:
+		return true
+	}
+}
+
+// --- Lexer ---
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")", ".", "[", "]"}
+
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	runes := []rune(src)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: string(runes[start:i]), pos: start})
+
+		case r == '"' || r == '\'':
+			start := i
+			quote := r
+			var sb strings.Builder
+			i++
+			for i < len(runes) && runes[i] != quote {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			i++
+			tokens = append(tokens, token{kind: tokString, text: sb.String(), pos: start})
+
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: string(runes[start:i]), pos: start})
+
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(string(runes[i:]), op) {
+					tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+					i += len([]rune(op))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at position %d", r, i)
+			}
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokEOF, pos: len(runes)})
+	return tokens, nil
+}
+
+// --- Parser ---
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) acceptOp(ops ...string) (string, bool) {
+	tok := p.peek()
+	if tok.kind != tokOp {
+		return "", false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			p.next()
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) parseOr() (Node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.acceptOp("||"); !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: "||", left: left, right: right}
+	}
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.acceptOp("&&"); !ok {
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: "&&", left: left, right: right}
+	}
+}
+
+func (p *parser) parseNot() (Node, error) {
+	if _, ok := p.acceptOp("!"); ok {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (Node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.acceptOp("==", "!=", "<=", ">=", "<", ">")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	return &compareNode{op: op, left: left, right: right}, nil
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokNumber:
+		val, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", tok.text, tok.pos)
+		}
+		return &literalNode{value: val}, nil
+
+	case tokString:
+		return &literalNode{value: tok.text}, nil
+
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		}
+		return p.parsePath(tok.text)
+
+	case tokOp:
+		if tok.text == "(" {
+			node, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := p.acceptOp(")"); !ok {
+				return nil, fmt.Errorf("expected ')' at position %d", p.peek().pos)
+			}
+			return node, nil
+		}
+	}
+
+	if tok.kind == tokEOF {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected token %q at position %d", tok.text, tok.pos)
+}
+
+func (p *parser) parsePath(root string) (Node, error) {
+	segments := []string{root}
+	for {
+		if _, ok := p.acceptOp("."); ok {
+			tok := p.next()
+			if tok.kind != tokIdent {
+				return nil, fmt.Errorf("expected identifier after '.' at position %d", tok.pos)
+			}
+			segments = append(segments, tok.text)
+			continue
+		}
+		if _, ok := p.acceptOp("["); ok {
+			tok := p.next()
+			if tok.kind != tokString && tok.kind != tokNumber {
+				return nil, fmt.Errorf("expected string or number index at position %d", tok.pos)
+			}
+			if _, ok := p.acceptOp("]"); !ok {
+				return nil, fmt.Errorf("expected ']' at position %d", p.peek().pos)
+			}
+			segments = append(segments, tok.text)
+			continue
+		}
+		return &pathNode{segments: segments}, nil
+	}
+}
+
+// --- Nodes ---
+
+type literalNode struct {
+	value any
+}
+
+func (n *literalNode) Eval(_ map[string]any) (any, error) {
+	return n.value, nil
+}
+
+type pathNode struct {
+	segments []string
+}
+
+// Eval resolves the path against env. The root must be a name in env, missing keys below it
+// resolve to nil rather than an error, so conditions such as `vars.flag == true` are false when
+// the variable is unset.
+func (n *pathNode) Eval(env map[string]any) (any, error) {
+	current, ok := env[n.segments[0]]
+	if !ok {
+		return nil, fmt.Errorf("unknown identifier %q", n.segments[0])
+	}
+	for _, segment := range n.segments[1:] {
+		switch v := current.(type) {
+		case map[string]any:
+			current = v[segment]
+		case []any:
+			idx, err := strconv.Atoi(segment)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, nil
+			}
+			current = v[idx]
+		default:
+			return nil, nil
+		}
+	}
+	return normalize(current), nil
+}
+
+type notNode struct {
+	operand Node
+}
+
+func (n *notNode) Eval(env map[string]any) (any, error) {
+	val, err := n.operand.Eval(env)
+	if err != nil {
+		return nil, err
+	}
+	return !Truthy(val), nil
+}
+
+type logicalNode struct {
+	op          string
+	left, right Node
+}
+
+func (n *logicalNode) Eval(env map[string]any) (any, error) {
+	left, err := n.left.Eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	// Short-circuit
+	if n.op == "&&" && !Truthy(left) {
+		return false, nil
+	}
+	if n.op == "||" && Truthy(left) {
+		return true, nil
+	}
+
+	right, err := n.right.Eval(env)
+	if err != nil {
+		return nil, err
+	}
+	return Truthy(right), nil
+}
+
+type compareNode struct {
+	op          string
+	left, right Node
+}
+
+func (n *compareNode) Eval(env map[string]any) (any, error) {
+	left, err := n.left.Eval(env)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.Eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	}
+
+	// Ordering comparisons require operands of the same type
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return false, nil
+		}
+		return compareOrdered(n.op, l, r), nil
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return false, nil
+		}
+		return compareOrdered(n.op, l, r), nil
+	}
+	return false, nil
+}
+
+func compareOrdered[T float64 | string](op string, l, r T) bool {
+	switch op {
+	case "<":
+		return l < r
+	case "<=":
+		return l <= r
+	case ">":
+		return l > r
+	case ">=":
+		return l >= r
+	}
+	return false
+}
+
+func equal(left, right any) bool {
+	switch l := left.(type) {
+	case nil:
+		return right == nil
+	case float64, string, bool:
+		return left == right
+	default:
+		return fmt.Sprint(l) == fmt.Sprint(right)
+	}
+}
+
+// normalize converts numeric types to float64 so that comparisons against literals behave.
+func normalize(val any) any {
+	switch v := val.(type) {
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return val
+	}
+}
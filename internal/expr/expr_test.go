@@ -0,0 +1,200 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+func testEnv() map[string]any {
+	return map[string]any{
+		"vars": map[string]any{
+			"region":  "eu-west",
+			"count":   float64(3),
+			"enabled": true,
+			"empty":   "",
+			"list":    []any{"a", "b"},
+			"nested":  map[string]any{"level": float64(2)},
+		},
+		"tasks": map[string]any{
+			"fetch": map[string]any{
+				"state":  "FINISHED",
+				"result": map[string]any{"count": float64(10), "ids": []any{float64(7), float64(8)}},
+			},
+			"my task": map[string]any{
+				"state":  "ERROR",
+				"result": nil,
+			},
+		},
+	}
+}
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want any
+	}{
+		// Precedence and associativity
+		{"and binds tighter than or", "true || false && false", true},
+		{"and binds tighter than or, left", "false && false || true", true},
+		{"parentheses override precedence", "(true || false) && false", false},
+		{"not binds tighter than and", "!false && true", true},
+		{"not applies to the whole comparison", "!vars.enabled == false", true},
+		{"double not", "!!vars.region", true},
+		{"or is left associative", "false || false || true", true},
+		{"and is left associative", "true && true && false", false},
+		{"comparison binds tighter than and", "vars.count > 2 && vars.count < 4", true},
+		{"or short-circuits errors", "true || unknown.path", true},
+		{"and short-circuits errors", "false && unknown.path", false},
+
+		// Literals
+		{"integer literal", "3", float64(3)},
+		{"decimal literal", "2.5", 2.5},
+		{"single quoted string", "'eu-west'", "eu-west"},
+		{"double quoted string", `"eu-west"`, "eu-west"},
+		{"escaped quote", `'it\'s'`, "it's"},
+		{"escaped backslash", `"a\\b"`, `a\b`},
+		{"other quote inside string", `"it's"`, "it's"},
+		{"true literal", "true", true},
+		{"false literal", "false", false},
+		{"null literal", "null", nil},
+
+		// Paths
+		{"job variable", "vars.region", "eu-west"},
+		{"nested job variable", "vars.nested.level", float64(2)},
+		{"list index", "vars.list[1]", "b"},
+		{"list index out of range", "vars.list[5]", nil},
+		{"missing variable is null", "vars.missing", nil},
+		{"path below a scalar is null", "vars.region.name", nil},
+		{"task state", "tasks.fetch.state", "FINISHED"},
+		{"task result", "tasks.fetch.result.count", float64(10)},
+		{"task result list", "tasks.fetch.result.ids[0]", float64(7)},
+		{"quoted task name", `tasks["my task"].state`, "ERROR"},
+		{"null task result", `tasks["my task"].result.count`, nil},
+
+		// Comparisons
+		{"number equality", "vars.count == 3", true},
+		{"string equality", "vars.region == 'eu-west'", true},
+		{"inequality", "tasks.fetch.state != 'ERROR'", true},
+		{"number ordering", "tasks.fetch.result.count >= 10", true},
+		{"string ordering", "'abc' < 'abd'", true},
+		{"missing variable equals null", "vars.missing == null", true},
+		{"null is not false", "vars.missing == false", false},
+
+		// Mixed types
+		{"number does not equal its string", "vars.count == '3'", false},
+		{"number and string differ", "vars.count != '3'", true},
+		{"ordering number against string is false", "vars.count < 'z'", false},
+		{"ordering string against number is false", "'a' >= 1", false},
+		{"ordering bools is false", "true > false", false},
+		{"ordering null is false", "vars.missing < 1", false},
+		{"bool does not equal number", "true == 1", false},
+	}
+
+	env := testEnv()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node, err := Parse(test.src)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", test.src, err)
+			}
+			got, err := node.Eval(env)
+			if err != nil {
+				t.Fatalf("Eval(%q): %v", test.src, err)
+			}
+			if got != test.want {
+				t.Errorf("Eval(%q) = %#v, want %#v", test.src, got, test.want)
+			}
+		})
+	}
+}
+
+func TestEvalBool(t *testing.T) {
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{"vars.region", true},
+		{"vars.empty", false},
+		{"vars.count", true},
+		{"0", false},
+		{"vars.list", true},
+		{"vars.missing", false},
+		{"vars.nested", true},
+		{"tasks.fetch.state == 'FINISHED' && vars.count > 1", true},
+	}
+
+	env := testEnv()
+	for _, test := range tests {
+		got, err := EvalBool(test.src, env)
+		if err != nil {
+			t.Fatalf("EvalBool(%q): %v", test.src, err)
+		}
+		if got != test.want {
+			t.Errorf("EvalBool(%q) = %v, want %v", test.src, got, test.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{"unterminated single quote", "vars.region == 'eu", "unterminated string at position 15"},
+		{"unterminated double quote", `"abc`, "unterminated string at position 0"},
+		{"unterminated after escape", `'abc\'`, "unterminated string"},
+		{"unexpected character", "vars.count = 3", `unexpected character '=' at position 11`},
+		{"trailing tokens", "vars.count 3", `unexpected token "3" at position 11`},
+		{"trailing operator", "true )", `unexpected token ")" at position 5`},
+		{"chained comparison", "1 < 2 < 3", `unexpected token "<" at position 6`},
+		{"empty expression", "", "unexpected end of expression"},
+		{"missing operand", "vars.count ==", "unexpected end of expression"},
+		{"missing closing parenthesis", "(true", "expected ')' at position 5"},
+		{"invalid number", "1.2.3", `invalid number "1.2.3" at position 0`},
+		{"operator as operand", "&& true", `unexpected token "&&" at position 0`},
+		{"missing identifier after dot", "vars.", "expected identifier after '.' at position 5"},
+		{"identifier index", "vars[region]", "expected string or number index at position 5"},
+		{"unclosed index", "vars['region'", "expected ']' at position 13"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse(test.src)
+			if err == nil {
+				t.Fatalf("Parse(%q) succeeded, want error %q", test.src, test.wantErr)
+			}
+			if !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("Parse(%q) error = %q, want %q", test.src, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{"unknown identifier", "region == 'eu-west'", `unknown identifier "region"`},
+		{"unknown identifier in path", "job.vars.region", `unknown identifier "job"`},
+		{"unknown identifier on the right", "vars.count == count", `unknown identifier "count"`},
+		{"unknown identifier under not", "!missing", `unknown identifier "missing"`},
+		{"unknown identifier after and", "true && missing", `unknown identifier "missing"`},
+	}
+
+	env := testEnv()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := EvalBool(test.src, env)
+			if err == nil {
+				t.Fatalf("EvalBool(%q) succeeded, want error %q", test.src, test.wantErr)
+			}
+			if !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("EvalBool(%q) error = %q, want %q", test.src, err, test.wantErr)
+			}
+		})
+	}
+}
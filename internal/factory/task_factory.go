@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"reflect"
+	"slices"
 	"sync"
 
 	"github.com/abikandiah/task-worker/internal/domain"
@@ -12,12 +13,28 @@ import (
 
 type TaskConstructor[P any, D any] func(params P, deps D) (domain.Task, error)
 
+// RegisterOption configures a task when it is registered
+type RegisterOption func(options *taskOptions)
+
+type taskOptions struct {
+	capabilities []string
+}
+
+// RequireCapabilities restricts a task to workers that provide every one of the capabilities,
+// such as a mounted share or a licensed binary.
+func RequireCapabilities(capabilities ...string) RegisterOption {
+	return func(options *taskOptions) {
+		options.capabilities = append(options.capabilities, capabilities...)
+	}
+}
+
 // TaskFactory manages task registration, dependency injection, and task creation.
 type TaskFactory struct {
 	mu           sync.RWMutex
 	constructors map[string]any          // Stores constructor wrappers
 	paramTypes   map[string]reflect.Type // Expected parameter types
 	depTypes     map[string]reflect.Type // Expected dependency types
+	capabilities map[string][]string     // Capabilities required by tasks, sorted
 	dependencies map[reflect.Type]any    // Registry of available dependencies by type
 }
 
@@ -26,6 +43,7 @@ func NewTaskFactory() *TaskFactory {
 		constructors: make(map[string]any),
 		paramTypes:   make(map[string]reflect.Type),
 		depTypes:     make(map[string]reflect.Type),
+		capabilities: make(map[string][]string),
 		dependencies: make(map[reflect.Type]any),
 	}
 }
@@ -52,7 +70,7 @@ func RegisterDependency[D any](factory *TaskFactory, dep D) {
 // Register registers a task constructor with automatic dependency injection.
 // P is the params type, D is the dependencies type.
 // The factory will automatically inject D when CreateTask is called.
-func Register[P any, D any](factory *TaskFactory, name string, constructor TaskConstructor[P, D]) {
+func Register[P any, D any](factory *TaskFactory, name string, constructor TaskConstructor[P, D], opts ...RegisterOption) {
 	if name == "" {
 		panic("task name cannot be empty")
 	}
@@ -76,6 +94,15 @@ func Register[P any, D any](factory *TaskFactory, name string, constructor TaskC
 	factory.paramTypes[name] = paramType
 	factory.depTypes[name] = depType
 
+	var options taskOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if len(options.capabilities) > 0 {
+		slices.Sort(options.capabilities)
+		factory.capabilities[name] = slices.Compact(options.capabilities)
+	}
+
 	// Create a wrapper that handles dependency resolution and injection
 	wrapper := func(params P) (domain.Task, error) {
 		// Resolve dependencies
@@ -310,6 +337,13 @@ func (f *TaskFactory) GetTaskNames() []string {
 	return names
 }
 
+// RequiredCapabilities returns the capabilities a worker must provide to run the task, if any.
+func (f *TaskFactory) RequiredCapabilities(name string) []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.capabilities[name]
+}
+
 // Count returns the number of registered tasks.
 func (f *TaskFactory) Count() int {
 	f.mu.RLock()
@@ -0,0 +1,158 @@
+package mock
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/google/uuid"
+)
+
+// jobFilterValues are the values of a job that filters compare, by filter field as in queries.JobFilterFields
+func jobFilterValues(job *domain.Job, taskRuns []domain.TaskRun) map[string]any {
+	taskNames := make([]string, len(taskRuns))
+	for i, taskRun := range taskRuns {
+		taskNames[i] = taskRun.TaskName
+	}
+
+	return map[string]any{
+		"name":            job.Name,
+		"state":           job.State,
+		"queue":           job.Queue,
+		"concurrencyKey":  job.ConcurrencyKey,
+		"workerId":        job.WorkerID,
+		"configId":        job.ConfigID,
+		"configVersion":   job.ConfigVersion,
+		"templateId":      job.TemplateID,
+		"templateVersion": job.TemplateVersion,
+		"submitDate":      &job.SubmitDate,
+		"startDate":       job.StartDate,
+		"endDate":         job.EndDate,
+		"expiresAt":       job.ExpiresAt,
+		"taskName":        taskNames,
+	}
+}
+
+// taskRunFilterValues are the values of a task run that filters compare, as in queries.TaskRunFilterFields
+func taskRunFilterValues(taskRun *domain.TaskRun) map[string]any {
+	return map[string]any{
+		"name":      taskRun.Name,
+		"taskName":  taskRun.TaskName,
+		"state":     taskRun.State,
+		"jobId":     taskRun.JobID,
+		"startDate": taskRun.StartDate,
+		"endDate":   taskRun.EndDate,
+	}
+}
+
+// matchesFilters reports whether the values match every filter, like the SQL the repositories build.
+// Null values match no filter, a list of values matches a filter if any of them does.
+func matchesFilters(filters []domain.Filter, values map[string]any) (bool, error) {
+	for _, filter := range filters {
+		value, ok := values[filter.Field]
+		if !ok {
+			return false, fmt.Errorf("%w: cannot filter on %s", domain.ErrInvalidQuery, filter.Field)
+		}
+
+		var matches bool
+		var err error
+		if list, ok := value.([]string); ok {
+			for _, item := range list {
+				if matches, err = matchesFilter(filter, item); matches || err != nil {
+					break
+				}
+			}
+		} else {
+			matches, err = matchesFilter(filter, value)
+		}
+		if err != nil || !matches {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchesFilter(filter domain.Filter, value any) (bool, error) {
+	switch v := value.(type) {
+	case string:
+		if filter.Op == domain.FilterContains {
+			return strings.Contains(strings.ToLower(v), strings.ToLower(filter.Values[0])), nil
+		}
+		return matchesEquality(filter, slices.Contains(filter.Values, v))
+	case domain.ExecutionState:
+		states := make([]domain.ExecutionState, len(filter.Values))
+		for i, value := range filter.Values {
+			state, err := domain.ParseFilterState(value)
+			if err != nil {
+				return false, err
+			}
+			states[i] = state
+		}
+		return matchesEquality(filter, slices.Contains(states, v))
+	case uuid.UUID:
+		return matchesUUID(filter, &v)
+	case *uuid.UUID:
+		return matchesUUID(filter, v)
+	case *time.Time:
+		return matchesTime(filter, v)
+	}
+	return false, fmt.Errorf("%w: cannot filter on %s", domain.ErrInvalidQuery, filter.Field)
+}
+
+func matchesUUID(filter domain.Filter, value *uuid.UUID) (bool, error) {
+	ids := make([]uuid.UUID, len(filter.Values))
+	for i, value := range filter.Values {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return false, fmt.Errorf("%w: %q is not a UUID", domain.ErrInvalidQuery, value)
+		}
+		ids[i] = id
+	}
+	if value == nil {
+		return false, nil
+	}
+	return matchesEquality(filter, slices.Contains(ids, *value))
+}
+
+func matchesTime(filter domain.Filter, value *time.Time) (bool, error) {
+	if filter.Op == domain.FilterContains {
+		return false, fmt.Errorf("%w: %s does not support %s", domain.ErrInvalidQuery, filter.Field, filter.Op)
+	}
+
+	times := make([]time.Time, len(filter.Values))
+	for i, value := range filter.Values {
+		t, err := domain.ParseFilterTime(value)
+		if err != nil {
+			return false, err
+		}
+		times[i] = t
+	}
+	if value == nil {
+		return false, nil
+	}
+
+	switch filter.Op {
+	case domain.FilterGt:
+		return value.After(times[0]), nil
+	case domain.FilterGte:
+		return !value.Before(times[0]), nil
+	case domain.FilterLt:
+		return value.Before(times[0]), nil
+	case domain.FilterLte:
+		return !value.After(times[0]), nil
+	}
+	return matchesEquality(filter, slices.ContainsFunc(times, value.Equal))
+}
+
+// matchesEquality applies an equality filter's operator to whether the value is one of its values
+func matchesEquality(filter domain.Filter, found bool) (bool, error) {
+	switch filter.Op {
+	case domain.FilterEq:
+		return found, nil
+	case domain.FilterNe:
+		return !found, nil
+	}
+	return false, fmt.Errorf("%w: %s does not support %s", domain.ErrInvalidQuery, filter.Field, filter.Op)
+}
@@ -3,8 +3,10 @@ package mock
 import (
 	"context"
 	"errors"
+	"slices"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/abikandiah/task-worker/internal/domain"
 	"github.com/google/uuid"
@@ -12,8 +14,16 @@ import (
 
 type MockRepo struct {
 	jobs     map[uuid.UUID]*domain.Job
-	configs  map[uuid.UUID]*domain.JobConfig
+	configs  map[uuid.UUID][]domain.JobConfig
 	taskRuns map[uuid.UUID]*domain.TaskRun
+	webhooks map[uuid.UUID]*domain.WebhookDelivery
+	taskLogs []domain.TaskLog
+	events   []domain.EventRecord
+	eventSeq int64
+	claims   map[uuid.UUID]string
+	workers  map[string]*domain.Worker
+	// configs and templates hold the versions of each, oldest first
+	templates map[uuid.UUID][]domain.JobTemplate
 
 	// Add a Mutex for concurrent access safety
 	mu sync.RWMutex
@@ -29,8 +39,13 @@ type MockRepo struct {
 func NewMockRepo() *MockRepo {
 	return &MockRepo{
 		jobs:     make(map[uuid.UUID]*domain.Job),
-		configs:  make(map[uuid.UUID]*domain.JobConfig),
+		configs:  make(map[uuid.UUID][]domain.JobConfig),
 		taskRuns: make(map[uuid.UUID]*domain.TaskRun),
+		webhooks: make(map[uuid.UUID]*domain.WebhookDelivery),
+		claims:   make(map[uuid.UUID]string),
+		workers:  make(map[string]*domain.Worker),
+
+		templates: make(map[uuid.UUID][]domain.JobTemplate),
 	}
 }
 
@@ -42,13 +57,23 @@ func (repo *MockRepo) GetAllJobs(ctx context.Context, cursor *domain.CursorInput
 
 	allJobs := make([]domain.Job, 0, len(repo.jobs))
 	for _, job := range repo.jobs {
-		allJobs = append(allJobs, *job)
+		matches, err := matchesFilters(cursor.Filters, jobFilterValues(job, repo.jobTaskRuns(job.ID)))
+		if err != nil {
+			return nil, err
+		}
+		if matches {
+			allJobs = append(allJobs, *job)
+		}
 	}
 
-	// Sort based on SortField and SortDir
+	// Sort by ID only for simplicity, in the direction of the first sort
+	sortDir := domain.SortASC
+	if len(cursor.Sort) > 0 {
+		sortDir = cursor.Sort[0].Dir
+	}
+	sorts := []domain.SortOrder{{Field: "id", Dir: sortDir}}
 	sort.Slice(allJobs, func(i, j int) bool {
-		// Only handling ID for simplicity
-		if cursor.SortDir == domain.SortASC {
+		if sortDir == domain.SortASC {
 			return allJobs[i].ID.String() < allJobs[j].ID.String()
 		}
 		return allJobs[i].ID.String() > allJobs[j].ID.String()
@@ -57,9 +82,13 @@ func (repo *MockRepo) GetAllJobs(ctx context.Context, cursor *domain.CursorInput
 	// Find the Starting Index based on the cursor
 	startIndex := 0
 	if cursor.HasAfterCursor() {
+		position, err := domain.DecodeCursor(cursor.After)
+		if err != nil {
+			return nil, err
+		}
 		// Find the index of the cursor ID
 		for i, job := range allJobs {
-			if job.ID == cursor.AfterID {
+			if job.ID.String() == position.Values[len(position.Values)-1] {
 				// Start index is the element *after* the cursor
 				startIndex = i + 1
 				break
@@ -83,25 +112,34 @@ func (repo *MockRepo) GetAllJobs(ctx context.Context, cursor *domain.CursorInput
 	pageJobs := allJobs[startIndex:endIndex]
 
 	// Calculate Cursors for the Output
-	nextCursor := uuid.Nil
-	if len(pageJobs) > 0 && endIndex < len(allJobs) {
-		// The next cursor is the ID of the last element in the *current* page
-		nextCursor = pageJobs[len(pageJobs)-1].ID
+	output := &domain.CursorOutput[domain.Job]{
+		Limit: cursor.Limit,
+		Data:  pageJobs,
+	}
+	if endIndex < len(allJobs) {
+		// The next cursor is the last element in the *current* page
+		nextCursor, err := jobCursor(sorts, pageJobs[len(pageJobs)-1])
+		if err != nil {
+			return nil, err
+		}
+		output.NextCursor = &nextCursor
 	}
-
-	prevCursor := uuid.Nil
 	if startIndex > 0 {
-		// The previous cursor is the ID of the first element in the *current* page
-		prevCursor = pageJobs[0].ID
+		// The previous cursor is the first element in the *current* page
+		prevCursor, err := jobCursor(sorts, pageJobs[0])
+		if err != nil {
+			return nil, err
+		}
+		output.PrevCursor = &prevCursor
 	}
 
 	// Return the strongly-typed generic output
-	return &domain.CursorOutput[domain.Job]{
-		NextCursor: &nextCursor,
-		PrevCursor: &prevCursor,
-		Limit:      cursor.Limit,
-		Data:       pageJobs,
-	}, nil
+	return output, nil
+}
+
+// jobCursor encodes the cursor of a job like the repositories, its ID is the only sort value
+func jobCursor(sorts []domain.SortOrder, job domain.Job) (string, error) {
+	return domain.EncodeCursor(domain.Cursor{Sort: sorts, Values: []any{job.ID.String()}})
 }
 
 func (repo *MockRepo) GetJob(ctx context.Context, jobID uuid.UUID) (*domain.Job, error) {
@@ -134,18 +172,245 @@ func (repo *MockRepo) SaveJob(ctx context.Context, job domain.Job) (*domain.Job,
 	if jobCopy.ID == uuid.Nil {
 		jobCopy.ID = uuid.New()
 	}
+	// Only claims assign workers
+	jobCopy.WorkerID = repo.claims[jobCopy.ID]
 
 	repo.jobs[jobCopy.ID] = &jobCopy
 	return &jobCopy, nil
 }
 
+func (repo *MockRepo) SaveJobsWithTaskRuns(ctx context.Context, jobs []domain.JobWithTaskRuns) ([]uuid.UUID, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	// Test method failure, nothing is saved
+	if repo.FailSaveJob != nil {
+		return nil, repo.FailSaveJob
+	}
+	if repo.FailSaveTaskRuns != nil {
+		return nil, repo.FailSaveTaskRuns
+	}
+
+	replaced := make([]uuid.UUID, 0)
+	for _, job := range jobs {
+		if job.ReplacePending {
+			replaced = append(replaced, repo.stopPendingJobs(job.Job.ConcurrencyKey, job.Job.SubmitDate)...)
+		}
+
+		jobCopy := job.Job
+		if jobCopy.ID == uuid.Nil {
+			jobCopy.ID = uuid.New()
+		}
+		jobCopy.WorkerID = repo.claims[jobCopy.ID]
+		repo.jobs[jobCopy.ID] = &jobCopy
+
+		for _, taskRun := range job.TaskRuns {
+			copyTaskRun := taskRun
+			if copyTaskRun.ID == uuid.Nil {
+				copyTaskRun.ID = uuid.New()
+			}
+			repo.taskRuns[copyTaskRun.ID] = &copyTaskRun
+		}
+	}
+	return replaced, nil
+}
+
+func (repo *MockRepo) ClaimJobs(ctx context.Context, workerID string, queue string, capabilities []string, limit int) ([]uuid.UUID, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	pending := make([]*domain.Job, 0)
+	for _, job := range repo.jobs {
+		if _, claimed := repo.claims[job.ID]; claimed || job.State != domain.StatePending || job.Queue != queue {
+			continue
+		}
+		provided := true
+		for _, capability := range job.Capabilities {
+			provided = provided && slices.Contains(capabilities, capability)
+		}
+		if provided {
+			pending = append(pending, job)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].SubmitDate.Before(pending[j].SubmitDate)
+	})
+
+	jobIDs := make([]uuid.UUID, 0, limit)
+	for _, job := range pending {
+		if len(jobIDs) == limit {
+			break
+		}
+		if job.ConcurrencyKey != "" && repo.concurrencySlotsUsed(job) >= max(job.ConcurrencyLimit, 1) {
+			continue
+		}
+		repo.claims[job.ID] = workerID
+		job.WorkerID = workerID
+		jobIDs = append(jobIDs, job.ID)
+	}
+	return jobIDs, nil
+}
+
+// concurrencySlotsUsed counts jobs of the job's key that are claimed, running or pending ahead of it
+func (repo *MockRepo) concurrencySlotsUsed(job *domain.Job) int {
+	used := 0
+	for _, other := range repo.jobs {
+		if other.ID == job.ID || other.ConcurrencyKey != job.ConcurrencyKey {
+			continue
+		}
+		_, claimed := repo.claims[other.ID]
+		if other.State == domain.StateRunning ||
+			(other.State == domain.StatePending && (claimed || other.SubmitDate.Before(job.SubmitDate))) {
+			used++
+		}
+	}
+	return used
+}
+
+func (repo *MockRepo) CountActiveJobs(ctx context.Context, concurrencyKey string) (int, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	count := 0
+	for _, job := range repo.jobs {
+		if job.ConcurrencyKey == concurrencyKey && (job.State == domain.StatePending || job.State == domain.StateRunning) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// stopPendingJobs must be called with mu held
+func (repo *MockRepo) stopPendingJobs(concurrencyKey string, endDate time.Time) []uuid.UUID {
+	jobIDs := make([]uuid.UUID, 0)
+	for _, job := range repo.jobs {
+		if _, claimed := repo.claims[job.ID]; claimed || job.ConcurrencyKey != concurrencyKey || job.State != domain.StatePending {
+			continue
+		}
+		job.State = domain.StateStopped
+		job.EndDate = &endDate
+		jobIDs = append(jobIDs, job.ID)
+
+		for _, taskRun := range repo.taskRuns {
+			if taskRun.JobID == job.ID && taskRun.State == domain.StatePending {
+				taskRun.State = domain.StateStopped
+				taskRun.EndDate = &endDate
+			}
+		}
+	}
+	return jobIDs
+}
+
+func (repo *MockRepo) ExpireJobs(ctx context.Context, now time.Time) ([]uuid.UUID, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	jobIDs := make([]uuid.UUID, 0)
+	for _, job := range repo.jobs {
+		_, claimed := repo.claims[job.ID]
+		if claimed || job.State != domain.StatePending || job.StartDate != nil || job.ExpiresAt == nil || job.ExpiresAt.After(now) {
+			continue
+		}
+		job.State = domain.StateExpired
+		job.EndDate = &now
+		jobIDs = append(jobIDs, job.ID)
+
+		for _, taskRun := range repo.taskRuns {
+			if taskRun.JobID == job.ID && taskRun.State == domain.StatePending {
+				taskRun.State = domain.StateExpired
+				taskRun.EndDate = &now
+			}
+		}
+	}
+	return jobIDs, nil
+}
+
+func (repo *MockRepo) GetUnclaimedCapabilityJobs(ctx context.Context, submittedBefore time.Time, limit int) ([]domain.Job, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	jobs := make([]domain.Job, 0)
+	for _, job := range repo.jobs {
+		_, claimed := repo.claims[job.ID]
+		if !claimed && job.State == domain.StatePending && len(job.Capabilities) > 0 && job.SubmitDate.Before(submittedBefore) {
+			jobs = append(jobs, *job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].SubmitDate.Before(jobs[j].SubmitDate)
+	})
+	return jobs[:min(limit, len(jobs))], nil
+}
+
+func (repo *MockRepo) FailUnclaimedJob(ctx context.Context, jobID uuid.UUID, endDate time.Time) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	job, ok := repo.jobs[jobID]
+	if _, claimed := repo.claims[jobID]; !ok || claimed || job.State != domain.StatePending {
+		return false, nil
+	}
+	job.State = domain.StateError
+	job.EndDate = &endDate
+	return true, nil
+}
+
+func (repo *MockRepo) GetQueueDepths(ctx context.Context) ([]domain.Queue, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	depths := make(map[string]*domain.Queue)
+	for _, job := range repo.jobs {
+		if job.State != domain.StatePending && job.State != domain.StateRunning {
+			continue
+		}
+		depth, ok := depths[job.Queue]
+		if !ok {
+			depth = &domain.Queue{Name: job.Queue}
+			depths[job.Queue] = depth
+		}
+
+		_, claimed := repo.claims[job.ID]
+		switch {
+		case job.State == domain.StateRunning:
+			depth.Running++
+		case claimed:
+			depth.Claimed++
+		default:
+			depth.Pending++
+		}
+	}
+
+	queues := make([]domain.Queue, 0, len(depths))
+	for _, depth := range depths {
+		queues = append(queues, *depth)
+	}
+	sort.Slice(queues, func(i, j int) bool {
+		return queues[i].Name < queues[j].Name
+	})
+	return queues, nil
+}
+
+func (repo *MockRepo) ReleaseJobClaims(ctx context.Context, workerID string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for jobID, claimedBy := range repo.claims {
+		if job, ok := repo.jobs[jobID]; claimedBy == workerID && ok && job.State == domain.StatePending {
+			delete(repo.claims, jobID)
+			job.WorkerID = ""
+		}
+	}
+	return nil
+}
+
 func (repo *MockRepo) GetAllJobConfigs(ctx context.Context, cursor *domain.CursorInput) (*domain.CursorOutput[domain.JobConfig], error) {
 	repo.mu.RLock()
 	defer repo.mu.RUnlock()
 
 	configs := make([]domain.JobConfig, 0, len(repo.configs))
-	for _, config := range repo.configs {
-		configs = append(configs, *config)
+	for _, versions := range repo.configs {
+		configs = append(configs, versions[len(versions)-1])
 	}
 
 	return &domain.CursorOutput[domain.JobConfig]{
@@ -156,11 +421,33 @@ func (repo *MockRepo) GetAllJobConfigs(ctx context.Context, cursor *domain.Curso
 	}, nil
 }
 
+func (repo *MockRepo) GetJobConfigVersions(ctx context.Context, configID uuid.UUID, cursor *domain.CursorInput) (*domain.CursorOutput[domain.JobConfig], error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	versions := slices.Clone(repo.configs[configID])
+	slices.Reverse(versions)
+	return &domain.CursorOutput[domain.JobConfig]{
+		Limit: cursor.Limit,
+		Data:  versions,
+	}, nil
+}
+
 func (repo *MockRepo) GetOrCreateDefaultJobConfig(ctx context.Context) (*domain.JobConfig, error) {
 	return repo.GetDefaultJobConfig(ctx)
 }
 
 func (repo *MockRepo) GetDefaultJobConfig(ctx context.Context) (*domain.JobConfig, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	for _, versions := range repo.configs {
+		for _, config := range versions {
+			if config.IsDefault {
+				return &config, nil
+			}
+		}
+	}
 	return domain.NewDefaultJobConfig(), nil
 }
 
@@ -168,14 +455,27 @@ func (repo *MockRepo) GetJobConfig(ctx context.Context, configID uuid.UUID) (*do
 	repo.mu.RLock()
 	defer repo.mu.RUnlock()
 
-	config, ok := repo.configs[configID]
+	versions, ok := repo.configs[configID]
 	if ok {
-		return config, nil
+		config := versions[len(versions)-1]
+		return &config, nil
 	}
 
 	return nil, errors.New("config not found")
 }
 
+func (repo *MockRepo) GetJobConfigVersion(ctx context.Context, configID uuid.UUID, version uuid.UUID) (*domain.JobConfig, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	for _, config := range repo.configs[configID] {
+		if config.Version == version {
+			return &config, nil
+		}
+	}
+	return nil, nil
+}
+
 func (repo *MockRepo) SaveJobConfig(ctx context.Context, config domain.JobConfig) (*domain.JobConfig, error) {
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
@@ -185,20 +485,66 @@ func (repo *MockRepo) SaveJobConfig(ctx context.Context, config domain.JobConfig
 		copyConfig.ID = uuid.New()
 	}
 
-	repo.configs[copyConfig.ID] = &copyConfig
+	versions := repo.configs[copyConfig.ID]
+	for i, existing := range versions {
+		if existing.Version == copyConfig.Version {
+			versions[i] = copyConfig
+			return &copyConfig, nil
+		}
+	}
+	repo.configs[copyConfig.ID] = append(versions, copyConfig)
 	return &copyConfig, nil
 }
 
+func (repo *MockRepo) SetDefaultJobConfig(ctx context.Context, configID uuid.UUID, version uuid.UUID) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if !slices.ContainsFunc(repo.configs[configID], func(config domain.JobConfig) bool {
+		return config.Version == version
+	}) {
+		return false, nil
+	}
+	for id, versions := range repo.configs {
+		for i := range versions {
+			versions[i].IsDefault = id == configID && versions[i].Version == version
+		}
+	}
+	return true, nil
+}
+
+func (repo *MockRepo) DeleteJobConfigVersion(ctx context.Context, configID uuid.UUID, version uuid.UUID) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, job := range repo.jobs {
+		if job.ConfigID == configID && job.ConfigVersion == version {
+			return false, nil
+		}
+	}
+
+	versions := repo.configs[configID]
+	index := slices.IndexFunc(versions, func(config domain.JobConfig) bool {
+		return config.Version == version && !config.IsDefault
+	})
+	if index < 0 {
+		return false, nil
+	}
+	versions = slices.Delete(versions, index, index+1)
+	if len(versions) == 0 {
+		delete(repo.configs, configID)
+	} else {
+		repo.configs[configID] = versions
+	}
+	return true, nil
+}
+
 func (repo *MockRepo) GetTaskRun(ctx context.Context, taskRunID uuid.UUID) (*domain.TaskRun, error) {
 	repo.mu.RLock()
 	defer repo.mu.RUnlock()
 
-	taskRun, ok := repo.taskRuns[taskRunID]
-	if ok {
-		return taskRun, nil
-	}
-
-	return nil, errors.New("taskRun not found")
+	// Like the repositories, a TaskRun that does not exist is nil without an error
+	return repo.taskRuns[taskRunID], nil
 }
 
 func (repo *MockRepo) SaveTaskRun(ctx context.Context, taskRun domain.TaskRun) (*domain.TaskRun, error) {
@@ -255,9 +601,414 @@ func (repo *MockRepo) GetTaskRuns(ctx context.Context, jobID uuid.UUID) ([]domai
 }
 
 func (repo *MockRepo) GetAllTaskRuns(ctx context.Context, cursor *domain.CursorInput) (*domain.CursorOutput[domain.TaskRun], error) {
-	return nil, nil
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	taskRuns := make([]domain.TaskRun, 0, len(repo.taskRuns))
+	for _, taskRun := range repo.taskRuns {
+		matches, err := matchesFilters(cursor.Filters, taskRunFilterValues(taskRun))
+		if err != nil {
+			return nil, err
+		}
+		if matches {
+			taskRuns = append(taskRuns, *taskRun)
+		}
+	}
+
+	return &domain.CursorOutput[domain.TaskRun]{
+		Limit: cursor.Limit,
+		Data:  taskRuns,
+	}, nil
+}
+
+// jobTaskRuns returns the task runs of a job, the caller holds the lock
+func (repo *MockRepo) jobTaskRuns(jobID uuid.UUID) []domain.TaskRun {
+	var taskRuns []domain.TaskRun
+	for _, taskRun := range repo.taskRuns {
+		if taskRun.JobID == jobID {
+			taskRuns = append(taskRuns, *taskRun)
+		}
+	}
+	return taskRuns
+}
+
+func (repo *MockRepo) SaveTaskLogs(ctx context.Context, taskLogs []domain.TaskLog) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, taskLog := range taskLogs {
+		taskLog.Seq = int64(len(repo.taskLogs) + 1)
+		repo.taskLogs = append(repo.taskLogs, taskLog)
+	}
+	return nil
+}
+
+func (repo *MockRepo) GetTaskLogs(ctx context.Context, taskRunID uuid.UUID, afterSeq int64, limit int) ([]domain.TaskLog, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	taskLogs := []domain.TaskLog{}
+	for _, taskLog := range repo.taskLogs {
+		if taskLog.TaskRunID == taskRunID && taskLog.Seq > afterSeq && len(taskLogs) < limit {
+			taskLogs = append(taskLogs, taskLog)
+		}
+	}
+	return taskLogs, nil
+}
+
+func (repo *MockRepo) SaveEvents(ctx context.Context, events []domain.EventRecord) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, event := range events {
+		repo.eventSeq++
+		event.Seq = repo.eventSeq
+		repo.events = append(repo.events, event)
+	}
+	return nil
+}
+
+func (repo *MockRepo) GetEvents(ctx context.Context, afterSeq int64, limit int) ([]domain.EventRecord, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	events := []domain.EventRecord{}
+	for _, event := range repo.events {
+		if event.Seq > afterSeq && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (repo *MockRepo) GetLastEventSeq(ctx context.Context) (int64, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	if len(repo.events) == 0 {
+		return 0, nil
+	}
+	return repo.events[len(repo.events)-1].Seq, nil
+}
+
+func (repo *MockRepo) DeleteEvents(ctx context.Context, before time.Time) (int64, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	kept := repo.events[:0]
+	for _, event := range repo.events {
+		if !event.CreatedDate.Before(before) {
+			kept = append(kept, event)
+		}
+	}
+	deleted := int64(len(repo.events) - len(kept))
+	repo.events = kept
+	return deleted, nil
+}
+
+func (repo *MockRepo) SaveWebhookDelivery(ctx context.Context, delivery domain.WebhookDelivery) (*domain.WebhookDelivery, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	copyDelivery := delivery
+	if copyDelivery.ID == uuid.Nil {
+		copyDelivery.ID = uuid.New()
+	}
+
+	repo.webhooks[copyDelivery.ID] = &copyDelivery
+	return &copyDelivery, nil
+}
+
+func (repo *MockRepo) GetWebhookDeliveries(ctx context.Context, jobID uuid.UUID) ([]domain.WebhookDelivery, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	deliveries := []domain.WebhookDelivery{}
+	for _, delivery := range repo.webhooks {
+		if delivery.JobID == jobID {
+			deliveries = append(deliveries, *delivery)
+		}
+	}
+
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedDate.Before(deliveries[j].CreatedDate)
+	})
+	return deliveries, nil
+}
+
+func (repo *MockRepo) GetDueWebhookDeliveries(ctx context.Context, before time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	deliveries := []domain.WebhookDelivery{}
+	for _, delivery := range repo.webhooks {
+		if delivery.State == domain.StatePending && !delivery.NextAttemptDate.After(before) {
+			deliveries = append(deliveries, *delivery)
+		}
+	}
+
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].NextAttemptDate.Before(deliveries[j].NextAttemptDate)
+	})
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+func (repo *MockRepo) ClaimWebhookDelivery(ctx context.Context, deliveryID uuid.UUID, now time.Time, leaseUntil time.Time) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	delivery, ok := repo.webhooks[deliveryID]
+	if !ok || delivery.State != domain.StatePending || delivery.NextAttemptDate.After(now) {
+		return false, nil
+	}
+
+	delivery.NextAttemptDate = leaseUntil
+	return true, nil
+}
+
+func (repo *MockRepo) SaveWorker(ctx context.Context, worker domain.Worker) (*domain.Worker, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	workerCopy := worker
+	repo.workers[worker.ID] = &workerCopy
+	return &workerCopy, nil
+}
+
+func (repo *MockRepo) UpdateWorkerHeartbeat(ctx context.Context, workerID string, heartbeat time.Time, leader bool) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	worker, ok := repo.workers[workerID]
+	if !ok {
+		return false, nil
+	}
+	worker.HeartbeatDate = heartbeat
+	worker.Leader = leader
+	return true, nil
+}
+
+func (repo *MockRepo) StopWorker(ctx context.Context, workerID string, stopped time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if worker, ok := repo.workers[workerID]; ok {
+		worker.StoppedDate = &stopped
+		worker.Leader = false
+	}
+	return nil
+}
+
+func (repo *MockRepo) GetWorkers(ctx context.Context) ([]domain.Worker, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	workers := make([]domain.Worker, 0, len(repo.workers))
+	for _, worker := range repo.workers {
+		workers = append(workers, *worker)
+	}
+	sort.Slice(workers, func(i, j int) bool {
+		return workers[i].StartedDate.After(workers[j].StartedDate)
+	})
+	return workers, nil
+}
+
+func (repo *MockRepo) RecoverJobs(ctx context.Context, aliveSince time.Time) ([]uuid.UUID, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	jobIDs := make([]uuid.UUID, 0)
+	for jobID, workerID := range repo.claims {
+		job, ok := repo.jobs[jobID]
+		if !ok || (job.State != domain.StatePending && job.State != domain.StateRunning) {
+			continue
+		}
+		if worker, ok := repo.workers[workerID]; ok && worker.StoppedDate == nil && !worker.HeartbeatDate.Before(aliveSince) {
+			continue
+		}
+
+		delete(repo.claims, jobID)
+		job.WorkerID = ""
+		job.State = domain.StatePending
+		for _, taskRun := range repo.taskRuns {
+			if taskRun.JobID == jobID && taskRun.State == domain.StateRunning {
+				taskRun.State = domain.StatePending
+			}
+		}
+		jobIDs = append(jobIDs, jobID)
+	}
+	return jobIDs, nil
+}
+
+func (repo *MockRepo) GetEndedJobConfigVersions(ctx context.Context) ([]domain.ConfigVersionRef, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	refs := make([]domain.ConfigVersionRef, 0)
+	for _, job := range repo.jobs {
+		ref := domain.ConfigVersionRef{ConfigID: job.ConfigID, Version: job.ConfigVersion}
+		if slices.Contains(domain.EndedStates, job.State) && !slices.Contains(refs, ref) {
+			refs = append(refs, ref)
+		}
+	}
+	return refs, nil
+}
+
+// purgeableJobs must be called with mu held
+func (repo *MockRepo) purgeableJobs(filter domain.PurgeFilter) []*domain.Job {
+	ended := make([]*domain.Job, 0)
+	for _, job := range repo.jobs {
+		if job.ConfigID == filter.ConfigID && slices.Contains(domain.EndedStates, job.State) {
+			ended = append(ended, job)
+		}
+	}
+	// Newest first, so the newest can be kept
+	sort.Slice(ended, func(i, j int) bool {
+		return endDate(ended[i]).After(endDate(ended[j]))
+	})
+
+	jobs := make([]*domain.Job, 0)
+	for i, job := range ended {
+		if filter.KeepNewest > 0 && i < filter.KeepNewest {
+			continue
+		}
+		if filter.ConfigVersion != uuid.Nil && job.ConfigVersion != filter.ConfigVersion {
+			continue
+		}
+		if filter.State != "" && job.State != filter.State {
+			continue
+		}
+		if filter.EndedBefore != nil && !endDate(job).Before(*filter.EndedBefore) {
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	slices.Reverse(jobs)
+	return jobs
+}
+
+func endDate(job *domain.Job) time.Time {
+	if job.EndDate == nil {
+		return time.Time{}
+	}
+	return *job.EndDate
+}
+
+func (repo *MockRepo) GetPurgeableJobs(ctx context.Context, filter domain.PurgeFilter, limit int) ([]uuid.UUID, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	jobs := repo.purgeableJobs(filter)
+	jobIDs := make([]uuid.UUID, 0, min(limit, len(jobs)))
+	for _, job := range jobs[:min(limit, len(jobs))] {
+		jobIDs = append(jobIDs, job.ID)
+	}
+	return jobIDs, nil
+}
+
+func (repo *MockRepo) CountPurgeableJobs(ctx context.Context, filter domain.PurgeFilter) (int64, int64, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	var jobs, taskRuns int64
+	for _, job := range repo.purgeableJobs(filter) {
+		jobs++
+		for _, taskRun := range repo.taskRuns {
+			if taskRun.JobID == job.ID {
+				taskRuns++
+			}
+		}
+	}
+	return jobs, taskRuns, nil
+}
+
+func (repo *MockRepo) DeleteJobs(ctx context.Context, jobIDs []uuid.UUID) (int64, int64, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var jobs, taskRuns int64
+	for _, jobID := range jobIDs {
+		if _, ok := repo.jobs[jobID]; !ok {
+			continue
+		}
+		delete(repo.jobs, jobID)
+		delete(repo.claims, jobID)
+		jobs++
+
+		for taskRunID, taskRun := range repo.taskRuns {
+			if taskRun.JobID == jobID {
+				delete(repo.taskRuns, taskRunID)
+				taskRuns++
+			}
+		}
+	}
+	return jobs, taskRuns, nil
 }
 
 func (repo *MockRepo) Close() error {
 	return nil
 }
+
+func (repo *MockRepo) SaveJobTemplate(ctx context.Context, template domain.JobTemplate) (*domain.JobTemplate, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, version := range repo.templates[template.ID] {
+		if version.Version == template.Version {
+			return nil, errors.New("job template version already exists")
+		}
+	}
+	repo.templates[template.ID] = append(repo.templates[template.ID], template)
+	return &template, nil
+}
+
+func (repo *MockRepo) GetJobTemplate(ctx context.Context, templateID uuid.UUID) (*domain.JobTemplate, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	versions := repo.templates[templateID]
+	if len(versions) == 0 {
+		return nil, nil
+	}
+	template := versions[len(versions)-1]
+	return &template, nil
+}
+
+func (repo *MockRepo) GetJobTemplateVersion(ctx context.Context, templateID uuid.UUID, version uuid.UUID) (*domain.JobTemplate, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	for _, template := range repo.templates[templateID] {
+		if template.Version == version {
+			return &template, nil
+		}
+	}
+	return nil, nil
+}
+
+func (repo *MockRepo) GetJobTemplateVersions(ctx context.Context, templateID uuid.UUID) ([]domain.JobTemplate, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	versions := slices.Clone(repo.templates[templateID])
+	slices.Reverse(versions)
+	return versions, nil
+}
+
+func (repo *MockRepo) GetAllJobTemplates(ctx context.Context, cursor *domain.CursorInput) (*domain.CursorOutput[domain.JobTemplate], error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	templates := make([]domain.JobTemplate, 0, len(repo.templates))
+	for _, versions := range repo.templates {
+		templates = append(templates, versions[len(versions)-1])
+	}
+
+	return &domain.CursorOutput[domain.JobTemplate]{
+		Limit: cursor.Limit,
+		Data:  templates,
+	}, nil
+}
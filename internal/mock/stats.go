@@ -0,0 +1,115 @@
+package mock
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/google/uuid"
+)
+
+func (repo *MockRepo) GetStats(ctx context.Context, statsRange domain.StatsRange) (*domain.Stats, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	stats := &domain.Stats{
+		StatsRange: statsRange,
+		JobStates:  make(map[domain.ExecutionState]int),
+		TaskStates: make(map[domain.ExecutionState]int),
+	}
+
+	throughput := make(map[time.Time]*domain.HourlyThroughput)
+	queueWait := make(map[string][]float64)
+	configDurations := make(map[uuid.UUID][]float64)
+	for _, job := range repo.jobs {
+		if inStatsRange(statsRange, &job.SubmitDate) {
+			stats.JobStates[job.State]++
+			for _, taskRun := range repo.jobTaskRuns(job.ID) {
+				stats.TaskStates[taskRun.State]++
+			}
+		}
+		if inStatsRange(statsRange, job.StartDate) {
+			queueWait[job.Queue] = append(queueWait[job.Queue], job.StartDate.Sub(job.SubmitDate).Seconds())
+		}
+		if !inStatsRange(statsRange, job.EndDate) || !slices.Contains(domain.EndedStates, job.State) {
+			continue
+		}
+
+		hour := job.EndDate.Truncate(time.Hour)
+		if throughput[hour] == nil {
+			throughput[hour] = &domain.HourlyThroughput{Hour: hour}
+		}
+		throughput[hour].Ended++
+		switch job.State {
+		case domain.StateFinished:
+			throughput[hour].Finished++
+		case domain.StateError:
+			throughput[hour].Failed++
+		}
+		if job.StartDate != nil {
+			configDurations[job.ConfigID] = append(configDurations[job.ConfigID], job.EndDate.Sub(*job.StartDate).Seconds())
+		}
+	}
+
+	taskDurations := make(map[string][]float64)
+	for _, taskRun := range repo.taskRuns {
+		if inStatsRange(statsRange, taskRun.EndDate) && taskRun.StartDate != nil {
+			taskDurations[taskRun.TaskName] = append(taskDurations[taskRun.TaskName], taskRun.EndDate.Sub(*taskRun.StartDate).Seconds())
+		}
+	}
+
+	for _, hourly := range throughput {
+		stats.Throughput = append(stats.Throughput, *hourly)
+	}
+	sort.Slice(stats.Throughput, func(i, j int) bool {
+		return stats.Throughput[i].Hour.Before(stats.Throughput[j].Hour)
+	})
+
+	for queue, seconds := range queueWait {
+		stats.QueueWait = append(stats.QueueWait, domain.QueueWaitStats{Queue: queue, DurationStats: durationStats(seconds)})
+	}
+	sort.Slice(stats.QueueWait, func(i, j int) bool { return stats.QueueWait[i].Queue < stats.QueueWait[j].Queue })
+
+	for taskName, seconds := range taskDurations {
+		stats.TaskDurations = append(stats.TaskDurations, domain.TaskDurationStats{TaskName: taskName, DurationStats: durationStats(seconds)})
+	}
+	sort.Slice(stats.TaskDurations, func(i, j int) bool { return stats.TaskDurations[i].TaskName < stats.TaskDurations[j].TaskName })
+
+	for configID, seconds := range configDurations {
+		stats.ConfigDurations = append(stats.ConfigDurations, domain.ConfigDurationStats{ConfigID: configID, DurationStats: durationStats(seconds)})
+	}
+	sort.Slice(stats.ConfigDurations, func(i, j int) bool {
+		return stats.ConfigDurations[i].ConfigID.String() < stats.ConfigDurations[j].ConfigID.String()
+	})
+
+	return stats, nil
+}
+
+func inStatsRange(statsRange domain.StatsRange, t *time.Time) bool {
+	return t != nil && !t.Before(statsRange.From) && t.Before(statsRange.To)
+}
+
+// durationStats summarizes durations with nearest rank percentiles, like the repositories' SQL
+func durationStats(seconds []float64) domain.DurationStats {
+	slices.Sort(seconds)
+
+	var sum float64
+	for _, s := range seconds {
+		sum += s
+	}
+	percentile := func(p int) float64 {
+		// The smallest rank whose share of the count is at least p percent
+		rank := (p*len(seconds) + 99) / 100
+		return seconds[max(rank, 1)-1]
+	}
+
+	return domain.DurationStats{
+		Count: len(seconds),
+		Avg:   sum / float64(len(seconds)),
+		P50:   percentile(50),
+		P95:   percentile(95),
+		P99:   percentile(99),
+	}
+}
@@ -21,6 +21,15 @@ type Config struct {
 	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time"`
 	SSLMode         string        `mapstructure:"ssl_mode"`
 	AutoMigrate     bool          `mapstructure:"auto_migrate"`
+	Leader          *LeaderConfig `mapstructure:"leader"`
+}
+
+// LeaderConfig controls leader election between processes sharing the database
+type LeaderConfig struct {
+	// Backend is "advisory" (postgres only) or "lease", defaulting to advisory on postgres
+	Backend       string        `mapstructure:"backend"`
+	LeaseTTL      time.Duration `mapstructure:"lease_ttl"`
+	RenewInterval time.Duration `mapstructure:"renew_interval"`
 }
 
 func SetConfigDefaults(v *viper.Viper) {
@@ -36,6 +45,10 @@ func SetConfigDefaults(v *viper.Viper) {
 	v.SetDefault("database.conn_max_idle_time", 0)
 	v.SetDefault("database.ssl_mode", "")
 	v.SetDefault("database.auto_migrate", true)
+	// --- Leader Election Defaults ---
+	v.SetDefault("database.leader.backend", "")
+	v.SetDefault("database.leader.lease_ttl", 30*time.Second)
+	v.SetDefault("database.leader.renew_interval", 10*time.Second)
 }
 
 func BindEnvironmentVariables(v *viper.Viper) {
@@ -50,6 +63,7 @@ func BindEnvironmentVariables(v *viper.Viper) {
 	v.BindEnv("database.conn_max_lifetime", "APP_DATABASE_CONN_MAX_LIFETIME")
 	v.BindEnv("database.ssl_mode", "APP_DATABASE_SSL_MODE")
 	v.BindEnv("database.auto_migrate", "APP_DATABASE_AUTO_MIGRATE")
+	v.BindEnv("database.leader.backend", "APP_DATABASE_LEADER_BACKEND")
 }
 
 // DSN builds the database connection string from config
@@ -97,5 +111,40 @@ func (config *Config) Validate() error {
 		return fmt.Errorf("max_idle_conns must be positive")
 	}
 
+	if err := config.Leader.Validate(config); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (config *LeaderConfig) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("backend", config.Backend),
+		slog.String("lease_ttl", config.LeaseTTL.String()),
+	)
+}
+
+func (config *LeaderConfig) Validate(dbConfig *Config) error {
+	switch config.Backend {
+	case "", LeaderBackendLease:
+	case LeaderBackendAdvisory:
+		if dbConfig.Driver != "postgres" {
+			return fmt.Errorf("advisory lock leader election requires postgres")
+		}
+	default:
+		return fmt.Errorf("invalid leader backend: %s", config.Backend)
+	}
+
+	// The advisory lock holds a connection for as long as it leads
+	if dbConfig.Driver == "postgres" && config.Backend != LeaderBackendLease && dbConfig.MaxOpenConns < 2 {
+		return fmt.Errorf("advisory lock leader election requires max_open_conns of at least 2")
+	}
+	if config.LeaseTTL <= 0 || config.RenewInterval <= 0 {
+		return fmt.Errorf("leader lease ttl and renew interval must be positive")
+	}
+	// Renew well before the lease expires, so a slow renewal does not lose leadership
+	if config.RenewInterval*2 > config.LeaseTTL {
+		return fmt.Errorf("leader renew interval must be at most half the lease ttl")
+	}
 	return nil
 }
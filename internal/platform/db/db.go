@@ -3,15 +3,20 @@ package db
 
 import (
 	"context"
+	"database/sql/driver"
 	"fmt"
 	"log/slog"
 	"time"
 
+	"github.com/XSAM/otelsql"
 	"github.com/abikandiah/task-worker/internal/util"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"           // PostgreSQL driver
 	_ "github.com/mattn/go-sqlite3" // SQLite driver
 	"github.com/pressly/goose/v3"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type DB struct {
@@ -39,11 +44,19 @@ func New(config *Config) (*DB, error) {
 		dsn = expandedPath
 	}
 
-	// Open connection
-	db, err := sqlx.Open(config.Driver, dsn)
+	// Open connection, queries are traced as children of the caller's span
+	sqlDB, err := otelsql.Open(config.Driver, dsn,
+		otelsql.WithAttributes(dbSystem(config.Driver)),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitConnPrepare:      true,
+			OmitRows:             true,
+			SpanFilter:           hasParentSpan,
+		}))
 	if err != nil {
 		return nil, fmt.Errorf("open database: %w", err)
 	}
+	db := sqlx.NewDb(sqlDB, config.Driver)
 
 	// Driver-specific configuration
 	switch config.Driver {
@@ -98,6 +111,19 @@ func New(config *Config) (*DB, error) {
 	return &DB{DB: db, driver: config.Driver, cfg: config}, nil
 }
 
+// dbSystem is the OpenTelemetry name of a driver's database
+func dbSystem(driver string) attribute.KeyValue {
+	if driver == "postgres" {
+		return semconv.DBSystemNamePostgreSQL
+	}
+	return semconv.DBSystemNameSQLite
+}
+
+// hasParentSpan skips spans of queries outside of a trace, such as the pollers', which would each start their own
+func hasParentSpan(ctx context.Context, method otelsql.Method, query string, args []driver.NamedValue) bool {
+	return trace.SpanContextFromContext(ctx).IsValid()
+}
+
 func (db *DB) Close() error {
 	return db.DB.Close()
 }
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/google/uuid"
+)
+
+// FilterType decides how the values of a filter are parsed and which operators it supports
+type FilterType int
+
+const (
+	// FilterText supports equality and contains
+	FilterText FilterType = iota
+	// FilterState supports equality, values are execution states
+	FilterState
+	// FilterUUID supports equality
+	FilterUUID
+	// FilterTime supports equality and comparisons, values are RFC 3339 times or dates
+	FilterTime
+)
+
+// FilterField is a column that list requests may filter on
+type FilterField struct {
+	Column string
+	Type   FilterType
+	// Condition wraps the comparison, in place of its %s, to filter on the rows of another table,
+	// e.g. "id IN (SELECT job_id FROM task_runs WHERE %s)". Column is then not qualified.
+	Condition string
+}
+
+var filterTypeOps = map[FilterType][]domain.FilterOp{
+	FilterText:  {domain.FilterEq, domain.FilterNe, domain.FilterContains},
+	FilterState: {domain.FilterEq, domain.FilterNe},
+	FilterUUID:  {domain.FilterEq, domain.FilterNe},
+	FilterTime:  {domain.FilterEq, domain.FilterNe, domain.FilterGt, domain.FilterGte, domain.FilterLt, domain.FilterLte},
+}
+
+// likeEscaper escapes the LIKE wildcards of a contains filter's value
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// buildFilterConditions translates filters into WHERE conditions with ? placeholders, only
+// fields in FilterFields may be filtered on
+func (pq *PaginationQuery) buildFilterConditions(filters []domain.Filter) ([]string, []any, error) {
+	var conditions []string
+	var args []any
+
+	for _, filter := range filters {
+		field, ok := pq.FilterFields[filter.Field]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: cannot filter on %s", domain.ErrInvalidQuery, filter.Field)
+		}
+		if !slices.Contains(filterTypeOps[field.Type], filter.Op) {
+			return nil, nil, fmt.Errorf("%w: %s does not support %s", domain.ErrInvalidQuery, filter.Field, filter.Op)
+		}
+
+		values := make([]any, len(filter.Values))
+		for i, value := range filter.Values {
+			parsed, err := parseFilterValue(field.Type, filter.Op, value)
+			if err != nil {
+				return nil, nil, err
+			}
+			values[i] = parsed
+		}
+
+		column := field.Column
+		if field.Condition == "" {
+			column = pq.qualifyField(column)
+		}
+		condition := filterComparison(column, filter.Op, len(values))
+		if field.Condition != "" {
+			condition = fmt.Sprintf(field.Condition, condition)
+		}
+
+		conditions = append(conditions, condition)
+		args = append(args, values...)
+	}
+	return conditions, args, nil
+}
+
+// filterComparison compares a column with count ? placeholders
+func filterComparison(column string, op domain.FilterOp, count int) string {
+	switch op {
+	case domain.FilterEq, domain.FilterNe:
+		if count == 1 {
+			if op == domain.FilterNe {
+				return column + " <> ?"
+			}
+			return column + " = ?"
+		}
+
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", count), ", ")
+		if op == domain.FilterNe {
+			return fmt.Sprintf("%s NOT IN (%s)", column, placeholders)
+		}
+		return fmt.Sprintf("%s IN (%s)", column, placeholders)
+	case domain.FilterContains:
+		return fmt.Sprintf(`LOWER(%s) LIKE ? ESCAPE '\'`, column)
+	}
+	return fmt.Sprintf("%s %s ?", column, op)
+}
+
+// parseFilterValue converts a filter value into the driver value it is compared as
+func parseFilterValue(filterType FilterType, op domain.FilterOp, value string) (any, error) {
+	switch filterType {
+	case FilterState:
+		state, err := domain.ParseFilterState(value)
+		return string(state), err
+	case FilterUUID:
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q is not a UUID", domain.ErrInvalidQuery, value)
+		}
+		return id, nil
+	case FilterTime:
+		// Dates are compared in the text form sqlite stores them in, postgres casts it to a timestamp
+		t, err := domain.ParseFilterTime(value)
+		return TextTime{Time: t}, err
+	}
+
+	if op == domain.FilterContains {
+		return "%" + likeEscaper.Replace(strings.ToLower(value)) + "%", nil
+	}
+	return value, nil
+}
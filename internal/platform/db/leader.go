@@ -0,0 +1,273 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	LeaderBackendAdvisory = "advisory"
+	LeaderBackendLease    = "lease"
+)
+
+// Lease rows are taken over once expired, or renewed by their current holder
+const acquireLeaseSQL = `
+	INSERT INTO leader_leases (name, holder, acquired_date, expires_date) VALUES (?, ?, ?, ?)
+	ON CONFLICT (name) DO UPDATE SET
+		holder = EXCLUDED.holder,
+		acquired_date = CASE WHEN leader_leases.holder = EXCLUDED.holder THEN leader_leases.acquired_date ELSE EXCLUDED.acquired_date END,
+		expires_date = EXCLUDED.expires_date
+	WHERE
+		leader_leases.holder = EXCLUDED.holder OR leader_leases.expires_date < ?
+`
+
+const releaseLeaseSQL = `DELETE FROM leader_leases WHERE name = ? AND holder = ?`
+
+// LeaderStatus is an instance's view of an election
+type LeaderStatus struct {
+	Name    string     `json:"name"`
+	Backend string     `json:"backend"`
+	Holder  string     `json:"holder"`
+	Leader  bool       `json:"leader"`
+	Since   *time.Time `json:"since,omitempty"`
+}
+
+// LeaderElector elects one holder among every process sharing the database. Postgres uses a
+// session advisory lock, held on a dedicated connection, that is released as soon as the
+// connection drops. The lease backend, the only one for sqlite3, renews a row in leader_leases
+// that others take over once it expires.
+type LeaderElector struct {
+	db      *DB
+	name    string
+	holder  string
+	backend string
+	config  *LeaderConfig
+
+	mu    sync.RWMutex
+	since *time.Time
+
+	// conn holds the advisory lock while leading
+	conn *sql.Conn
+}
+
+func (db *DB) NewLeaderElector(name string, holder string) *LeaderElector {
+	backend := db.cfg.Leader.Backend
+	if backend == "" {
+		backend = LeaderBackendLease
+		if db.driver == "postgres" {
+			backend = LeaderBackendAdvisory
+		}
+	}
+
+	return &LeaderElector{
+		db:      db,
+		name:    name,
+		holder:  holder,
+		backend: backend,
+		config:  db.cfg.Leader,
+	}
+}
+
+// Status reports whether this instance currently leads
+func (elector *LeaderElector) Status() LeaderStatus {
+	elector.mu.RLock()
+	defer elector.mu.RUnlock()
+
+	return LeaderStatus{
+		Name:    elector.name,
+		Backend: elector.backend,
+		Holder:  elector.holder,
+		Leader:  elector.since != nil,
+		Since:   elector.since,
+	}
+}
+
+func (elector *LeaderElector) IsLeader() bool {
+	return elector.Status().Leader
+}
+
+// Run campaigns until ctx is done. While leading, lead runs with a context that is cancelled
+// when leadership is lost; leadership is released only after lead returns, so duties never
+// overlap across instances. On shutdown leadership is released for another instance to take over.
+func (elector *LeaderElector) Run(ctx context.Context, lead func(ctx context.Context)) {
+	ticker := time.NewTicker(elector.config.RenewInterval)
+	defer ticker.Stop()
+
+	var term *leaderTerm
+	stepDown := func() {
+		if term == nil {
+			return
+		}
+		term.end()
+		term = nil
+
+		elector.setLeader(false)
+		elector.release()
+		slog.InfoContext(ctx, "leadership released", slog.String("election", elector.name))
+	}
+	defer stepDown()
+
+	for {
+		if term == nil {
+			acquired, err := elector.acquire(ctx)
+			if err != nil && ctx.Err() == nil {
+				slog.WarnContext(ctx, "failed to campaign for leadership", slog.String("election", elector.name), slog.Any("error", err))
+			}
+
+			if acquired {
+				elector.setLeader(true)
+				slog.InfoContext(ctx, "elected leader", slog.String("election", elector.name), slog.String("backend", elector.backend))
+				term = startLeaderTerm(ctx, lead)
+			}
+		} else if err := elector.renew(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			// Step down before the lease can expire, another instance may take over
+			slog.WarnContext(ctx, "lost leadership", slog.String("election", elector.name), slog.Any("error", err))
+			stepDown()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// leaderTerm is one period of leadership, running lead until it ends
+type leaderTerm struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func startLeaderTerm(ctx context.Context, lead func(ctx context.Context)) *leaderTerm {
+	leadCtx, cancel := context.WithCancel(ctx)
+	term := &leaderTerm{cancel: cancel, done: make(chan struct{})}
+
+	go func() {
+		defer close(term.done)
+		lead(leadCtx)
+	}()
+	return term
+}
+
+// end cancels lead and waits for it to return
+func (term *leaderTerm) end() {
+	term.cancel()
+	<-term.done
+}
+
+func (elector *LeaderElector) setLeader(leader bool) {
+	elector.mu.Lock()
+	defer elector.mu.Unlock()
+
+	if !leader {
+		elector.since = nil
+		return
+	}
+	now := time.Now().UTC()
+	elector.since = &now
+}
+
+func (elector *LeaderElector) acquire(ctx context.Context) (bool, error) {
+	if elector.backend == LeaderBackendAdvisory {
+		return elector.acquireAdvisoryLock(ctx)
+	}
+	return elector.acquireLease(ctx)
+}
+
+func (elector *LeaderElector) renew(ctx context.Context) error {
+	if elector.backend == LeaderBackendAdvisory {
+		// The lock lives as long as its connection
+		return elector.conn.PingContext(ctx)
+	}
+
+	renewed, err := elector.acquireLease(ctx)
+	if err != nil {
+		return err
+	}
+	if !renewed {
+		return fmt.Errorf("lease %s was taken over", elector.name)
+	}
+	return nil
+}
+
+// release gives up leadership, it runs during shutdown so it does not use the cancelled context
+func (elector *LeaderElector) release() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if elector.backend == LeaderBackendAdvisory {
+		if elector.conn == nil {
+			return
+		}
+		if _, err := elector.conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, elector.lockKey()); err != nil {
+			slog.WarnContext(ctx, "failed to release advisory lock", slog.Any("error", err))
+		}
+		// Closing the connection releases the lock regardless
+		elector.conn.Close()
+		elector.conn = nil
+		return
+	}
+
+	if _, err := elector.db.ExecContext(ctx, elector.db.Rebind(releaseLeaseSQL), elector.name, elector.holder); err != nil {
+		slog.WarnContext(ctx, "failed to release leader lease", slog.Any("error", err))
+	}
+}
+
+func (elector *LeaderElector) acquireLease(ctx context.Context) (bool, error) {
+	now := time.Now().UTC()
+	expires := now.Add(elector.config.LeaseTTL)
+
+	var args []any
+	if elector.db.driver == "sqlite3" {
+		args = []any{elector.name, elector.holder, TextTime{Time: now}, TextTime{Time: expires}, TextTime{Time: now}}
+	} else {
+		args = []any{elector.name, elector.holder, now, expires, now}
+	}
+
+	result, err := elector.db.ExecContext(ctx, elector.db.Rebind(acquireLeaseSQL), args...)
+	if err != nil {
+		return false, fmt.Errorf("acquire lease %s: %w", elector.name, err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("acquire lease %s: %w", elector.name, err)
+	}
+	return rows == 1, nil
+}
+
+func (elector *LeaderElector) acquireAdvisoryLock(ctx context.Context) (bool, error) {
+	conn, err := elector.db.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("get connection for advisory lock: %w", err)
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, elector.lockKey()).Scan(&acquired); err != nil {
+		conn.Close()
+		return false, fmt.Errorf("try advisory lock %s: %w", elector.name, err)
+	}
+	if !acquired {
+		conn.Close()
+		return false, nil
+	}
+
+	elector.conn = conn
+	return true, nil
+}
+
+// lockKey maps the election name onto the bigint key space of advisory locks
+func (elector *LeaderElector) lockKey() int64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(elector.name))
+	return int64(hash.Sum64())
+}
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestLeaseDBs opens n handles on one migrated sqlite3 database, standing in for processes
+// that share it
+func newTestLeaseDBs(t *testing.T, n int) []*DB {
+	t.Helper()
+
+	config := &Config{
+		Driver: "sqlite3",
+		DBName: filepath.Join(t.TempDir(), "test.db"),
+		Leader: &LeaderConfig{LeaseTTL: time.Second, RenewInterval: 10 * time.Millisecond},
+	}
+
+	dbs := make([]*DB, n)
+	for i := range dbs {
+		db, err := New(config)
+		if err != nil {
+			t.Fatalf("open database: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		dbs[i] = db
+	}
+
+	if err := dbs[0].RunMigrations("../../../migrations/sqlite3"); err != nil {
+		t.Fatalf("migrate database: %v", err)
+	}
+	return dbs
+}
+
+func waitForLeader(t *testing.T, elector *LeaderElector, leader bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for elector.IsLeader() != leader {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s leader = %t, want %t", elector.holder, !leader, leader)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// A stopping leader releases its lease and waits for its duties to return before another
+// instance takes over
+func TestLeaseHandover(t *testing.T) {
+	dbs := newTestLeaseDBs(t, 2)
+	first := dbs[0].NewLeaderElector("duties", "first")
+	second := dbs[1].NewLeaderElector("duties", "second")
+
+	leading := make(chan string, 2)
+	lead := func(holder string) func(ctx context.Context) {
+		return func(ctx context.Context) {
+			leading <- holder
+			<-ctx.Done()
+			leading <- holder + " stopped"
+		}
+	}
+
+	firstCtx, stopFirst := context.WithCancel(context.Background())
+	firstDone := make(chan struct{})
+	go func() {
+		defer close(firstDone)
+		first.Run(firstCtx, lead("first"))
+	}()
+	waitForLeader(t, first, true)
+
+	secondCtx, stopSecond := context.WithCancel(context.Background())
+	defer stopSecond()
+	secondDone := make(chan struct{})
+	go func() {
+		defer close(secondDone)
+		second.Run(secondCtx, lead("second"))
+	}()
+
+	// The second instance keeps campaigning while the lease is renewed
+	time.Sleep(100 * time.Millisecond)
+	if second.IsLeader() {
+		t.Fatal("second instance leads while the first holds the lease")
+	}
+
+	stopFirst()
+	<-firstDone
+	waitForLeader(t, second, true)
+
+	// The lease is released after the first term's duties return, well before it expires
+	for _, want := range []string{"first", "first stopped", "second"} {
+		if got := <-leading; got != want {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	}
+	if status := first.Status(); status.Leader || status.Since != nil {
+		t.Errorf("stopped instance status = %+v, want not leading", status)
+	}
+
+	stopSecond()
+	<-secondDone
+}
+
+// A lease that its holder stopped renewing is taken over once it expires
+func TestLeaseExpiry(t *testing.T) {
+	dbs := newTestLeaseDBs(t, 1)
+	ctx := context.Background()
+	crashed := dbs[0].NewLeaderElector("duties", "crashed")
+	other := dbs[0].NewLeaderElector("duties", "other")
+
+	if acquired, err := crashed.acquireLease(ctx); err != nil || !acquired {
+		t.Fatalf("acquireLease = %t, %v, want acquired", acquired, err)
+	}
+	if acquired, err := other.acquireLease(ctx); err != nil || acquired {
+		t.Fatalf("acquireLease of a held lease = %t, %v, want not acquired", acquired, err)
+	}
+	if err := crashed.renew(ctx); err != nil {
+		t.Fatalf("renew by the holder: %v", err)
+	}
+
+	time.Sleep(crashed.config.LeaseTTL + 100*time.Millisecond)
+	if acquired, err := other.acquireLease(ctx); err != nil || !acquired {
+		t.Fatalf("acquireLease of an expired lease = %t, %v, want acquired", acquired, err)
+	}
+	if err := crashed.renew(ctx); err == nil {
+		t.Error("former holder renewed a lease that was taken over")
+	}
+}
@@ -2,27 +2,42 @@ package db
 
 import (
 	"context"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"reflect"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/abikandiah/task-worker/internal/domain"
-	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
 )
 
-// The required CursorInput, CursorOutput, and SortDirection types are defined in db/job_repository.go
-// for centralized placeholder definition.
+// The CursorInput, CursorOutput, Cursor and SortDirection types are defined in domain/pagination.go.
 
-// PaginationQuery builds a cursor-based pagination query
+// PaginationQuery builds a keyset pagination query over any table. Cursors carry the sort values and
+// key of the row they point at, so the query never looks the cursor's row up again.
 type PaginationQuery struct {
 	BaseQuery     string   // The main SELECT query without ORDER BY or LIMIT
+	BaseArgs      []any    // Arguments of placeholders (?) in the base query
 	AllowedFields []string // Whitelist of sortable fields for security
 	TableAlias    string   // Optional table alias (e.g., "u" for "users u")
+	KeyField      string   // Unique column identifying rows, the last sort of every query, defaults to id
+	// Whitelist of fields the cursor's filters may use, by filter field name
+	FilterFields map[string]FilterField
 }
 
-// Paginate executes the pagination query and returns structured results
+// Paginate executes the pagination query and returns structured results. The sort fields and key of
+// T are read by their db tags to build the cursors.
 func Paginate[T any](ctx context.Context, db *sqlx.DB, pq *PaginationQuery, cursor *domain.CursorInput) (*domain.CursorOutput[T], error) {
-	query, args, err := pq.BuildQuery(cursor)
+	// Queries are built with ? placeholders and rebound to the driver's, e.g. $1 for postgres
+	query, args, err := pq.BuildQuery(cursor, sqlx.BindType(db.DriverName()))
+	if errors.Is(err, domain.ErrInvalidQuery) {
+		// The request's filters, sort or cursor, returned as is for the client
+		return nil, err
+	}
 	if err != nil {
 		return nil, fmt.Errorf("build pagination query: %w", err)
 	}
@@ -33,6 +48,13 @@ func Paginate[T any](ctx context.Context, db *sqlx.DB, pq *PaginationQuery, curs
 		return nil, fmt.Errorf("execute pagination query: %w", err)
 	}
 
+	// Determine if there are more pages
+	hasMore := len(results) > cursor.Limit
+	if hasMore {
+		// Remove the extra item we fetched
+		results = results[:cursor.Limit]
+	}
+
 	// If we got BEFORE cursor results, reverse them back to natural order
 	if cursor.HasBeforeCursor() {
 		reverseSlice(results)
@@ -43,64 +65,79 @@ func Paginate[T any](ctx context.Context, db *sqlx.DB, pq *PaginationQuery, curs
 		Limit: cursor.Limit,
 		Data:  results,
 	}
+	if len(results) == 0 {
+		return output, nil
+	}
 
-	// Determine if there are more pages
-	hasMore := len(results) > cursor.Limit
-	if hasMore {
-		// Remove the extra item we fetched
-		results = results[:cursor.Limit]
-		output.Data = results
+	sorts, err := pq.sortOrders(cursor)
+	if err != nil {
+		return nil, err
+	}
+	first, err := encodeRowCursor(db.Mapper, sorts, results[0])
+	if err != nil {
+		return nil, err
+	}
+	last, err := encodeRowCursor(db.Mapper, sorts, results[len(results)-1])
+	if err != nil {
+		return nil, err
 	}
 
 	// Set next/prev cursors
-	if len(results) > 0 {
-		// Extract IDs for cursors (assumes T has an ID field, enforced by the hasID interface)
-		firstID := extractID(results[0])
-		lastID := extractID(results[len(results)-1])
-
-		if cursor.HasAfterCursor() || (!cursor.HasAfterCursor() && !cursor.HasBeforeCursor()) {
-			// Forward pagination
-			if cursor.HasAfterCursor() {
-				output.PrevCursor = &cursor.AfterID
-			}
-			if hasMore {
-				output.NextCursor = &lastID
-			}
-		} else if cursor.HasBeforeCursor() {
-			// Backward pagination
-			output.NextCursor = &cursor.BeforeID
-			if hasMore {
-				output.PrevCursor = &firstID
-			}
+	if cursor.HasBeforeCursor() {
+		// Backward pagination, the BEFORE cursor's row is on the next page
+		output.NextCursor = &last
+		if hasMore {
+			output.PrevCursor = &first
+		}
+	} else {
+		// Forward pagination
+		if cursor.HasAfterCursor() {
+			output.PrevCursor = &first
+		}
+		if hasMore {
+			output.NextCursor = &last
 		}
 	}
 
 	return output, nil
 }
 
-// BuildQuery constructs the full keyset pagination SQL query
-func (pq *PaginationQuery) BuildQuery(cursor *domain.CursorInput) (string, []any, error) {
+// BuildQuery constructs the full keyset pagination SQL query with the placeholders of bindType
+func (pq *PaginationQuery) BuildQuery(cursor *domain.CursorInput, bindType int) (string, []any, error) {
 	cursor.SetDefaults()
 
-	// Validate sort field against whitelist
-	if !pq.isFieldAllowed(cursor.SortField) {
-		return "", nil, fmt.Errorf("invalid sort field: %s", cursor.SortField)
+	// Validate sort fields against whitelist
+	sorts, err := pq.sortOrders(cursor)
+	if err != nil {
+		return "", nil, err
 	}
 
-	var whereConditions []string
-	var args []any
+	args := append([]any{}, pq.BaseArgs...)
 
-	// Build cursor condition
-	if cursor.HasAfterCursor() {
-		// Forward pagination (AFTER)
-		condition := pq.buildCursorCondition(cursor.SortField, cursor.SortDir, ">", ">=")
-		whereConditions = append(whereConditions, condition)
-		args = append(args, cursor.AfterID, cursor.AfterID)
-	} else if cursor.HasBeforeCursor() {
-		// Backward pagination (BEFORE) - reverse the sort direction
-		condition := pq.buildCursorCondition(cursor.SortField, pq.reverseSortDir(cursor.SortDir), ">", ">=")
+	// Filters come before the cursor condition
+	whereConditions, filterArgs, err := pq.buildFilterConditions(cursor.Filters)
+	if err != nil {
+		return "", nil, err
+	}
+	args = append(args, filterArgs...)
+
+	// Build cursor condition, backward pagination (BEFORE) reverses the sort
+	backward := cursor.HasBeforeCursor()
+	if token := cursor.After; token != "" || backward {
+		if backward {
+			token = cursor.Before
+		}
+		position, err := domain.DecodeCursor(token)
+		if err != nil {
+			return "", nil, err
+		}
+		if !slices.Equal(position.Sort, sorts) {
+			return "", nil, fmt.Errorf("%w: cursor is of another sort", domain.ErrInvalidQuery)
+		}
+
+		condition, cursorArgs := pq.buildCursorCondition(sorts, position.Values, backward)
 		whereConditions = append(whereConditions, condition)
-		args = append(args, cursor.BeforeID, cursor.BeforeID)
+		args = append(args, cursorArgs...)
 	}
 
 	// Construct the query
@@ -117,42 +154,114 @@ func (pq *PaginationQuery) BuildQuery(cursor *domain.CursorInput) (string, []any
 	}
 
 	// Add ORDER BY
-	sortField := pq.qualifyField(cursor.SortField)
-	idField := pq.qualifyField("id")
+	query += " ORDER BY " + pq.buildOrderBy(sorts, backward)
 
-	sortDir := cursor.SortDir
-	if cursor.HasBeforeCursor() {
-		// Reverse sort direction for backward pagination, results will be reversed in Paginate
-		sortDir = pq.reverseSortDir(cursor.SortDir)
+	// Add LIMIT (request one extra to determine if there's a next/prev page)
+	query += fmt.Sprintf(" LIMIT %d", cursor.Limit+1)
+
+	return sqlx.Rebind(bindType, query), args, nil
+}
+
+// sortOrders validates the cursor's sort and ends it with the key field, which makes every row's
+// position unique. Sorts after the key never apply and are dropped.
+func (pq *PaginationQuery) sortOrders(cursor *domain.CursorInput) ([]domain.SortOrder, error) {
+	keyField := pq.keyField()
+
+	var sorts []domain.SortOrder
+	for _, sort := range cursor.Sort {
+		if !pq.isFieldAllowed(sort.Field) {
+			return nil, fmt.Errorf("%w: invalid sort field: %s", domain.ErrInvalidQuery, sort.Field)
+		}
+		sorts = append(sorts, sort)
+		if sort.Field == keyField {
+			return sorts, nil
+		}
+	}
+
+	// The key follows the direction of the last sort
+	keyDir := domain.SortASC
+	if len(sorts) > 0 {
+		keyDir = sorts[len(sorts)-1].Dir
 	}
+	return append(sorts, domain.SortOrder{Field: keyField, Dir: keyDir}), nil
+}
 
-	// Keysets are always sorted by (sortField, id)
-	query += fmt.Sprintf(" ORDER BY %s %s, %s %s", sortField, sortDir, idField, sortDir)
+// buildCursorCondition creates the WHERE condition for cursor pagination (keyset pagination). For
+// sorts (a, b, key) it matches rows past the cursor on a, or equal on a and past it on b, or equal on
+// a and b and past it on key. Nulls sort last, so they are past any value going forward.
+func (pq *PaginationQuery) buildCursorCondition(sorts []domain.SortOrder, values []any, backward bool) (string, []any) {
+	var terms []string
+	var args []any
 
-	// Add LIMIT (request one extra to determine if there's a next/prev page)
-	query += fmt.Sprintf(" LIMIT %d", cursor.Limit+1)
+	// Equality with the cursor on the sorts so far
+	var equal []string
+	var equalArgs []any
+
+	for i, sort := range sorts {
+		column := pq.qualifyField(sort.Field)
+		op := ">"
+		if pq.sortDir(sort.Dir, backward) == domain.SortDESC {
+			op = "<"
+		}
+
+		value := values[i]
+		var beyond string
+		var beyondArgs []any
+		switch {
+		case value == nil:
+			// Going backward every value comes before the cursor's null, forward none is after it
+			if backward {
+				beyond = column + " IS NOT NULL"
+			}
+		case backward || i == len(sorts)-1:
+			// The key is never null
+			beyond = fmt.Sprintf("%s %s ?", column, op)
+			beyondArgs = []any{value}
+		default:
+			beyond = fmt.Sprintf("(%s %s ? OR %s IS NULL)", column, op, column)
+			beyondArgs = []any{value}
+		}
+
+		if beyond != "" {
+			terms = append(terms, "("+strings.Join(append(slices.Clone(equal), beyond), " AND ")+")")
+			args = append(args, equalArgs...)
+			args = append(args, beyondArgs...)
+		}
 
-	return query, args, nil
+		if value == nil {
+			equal = append(equal, column+" IS NULL")
+		} else {
+			equal = append(equal, column+" = ?")
+			equalArgs = append(equalArgs, value)
+		}
+	}
+
+	return "(" + strings.Join(terms, " OR ") + ")", args
 }
 
-// buildCursorCondition creates the WHERE condition for cursor pagination (keyset pagination)
-func (pq *PaginationQuery) buildCursorCondition(sortField string, sortDir domain.SortDirection, gtOp, eqOp string) string {
-	qualifiedSortField := pq.qualifyField(sortField)
-	qualifiedIDField := pq.qualifyField("id")
+// buildOrderBy orders by each sort, with nulls last in either dialect, and reverses it going backward
+func (pq *PaginationQuery) buildOrderBy(sorts []domain.SortOrder, backward bool) string {
+	nullsDir := domain.SortASC
+	if backward {
+		nullsDir = domain.SortDESC
+	}
 
-	// For DESC sorting, reverse the operators
-	if sortDir == domain.SortDESC {
-		gtOp = "<"
-		eqOp = "<="
+	var orders []string
+	for i, sort := range sorts {
+		column := pq.qualifyField(sort.Field)
+		if i < len(sorts)-1 {
+			orders = append(orders, fmt.Sprintf("(%s IS NULL) %s", column, nullsDir))
+		}
+		orders = append(orders, fmt.Sprintf("%s %s", column, pq.sortDir(sort.Dir, backward)))
 	}
+	return strings.Join(orders, ", ")
+}
 
-	// Keyset pagination condition: (sortField > cursorValue) OR (sortField = cursorValue AND id > cursorID)
-	// The inner SELECTs are used to safely retrieve the cursor value from the database based on the cursor ID.
-	// NOTE: This complex query uses positional parameters (?) which are bound to cursorID and cursorValue.
-	return fmt.Sprintf(
-		"(%s %s (SELECT %s FROM jobs WHERE id = ?) OR (%s = (SELECT %s FROM jobs WHERE id = ?) AND %s %s ?))",
-		qualifiedSortField, gtOp, sortField, qualifiedSortField, sortField, qualifiedIDField, eqOp,
-	)
+func (pq *PaginationQuery) keyField() string {
+	if pq.KeyField == "" {
+		return "id"
+	}
+	return pq.KeyField
 }
 
 // qualifyField adds table alias to field name if alias exists
@@ -173,8 +282,11 @@ func (pq *PaginationQuery) isFieldAllowed(field string) bool {
 	return false
 }
 
-// reverseSortDir reverses the sort direction
-func (pq *PaginationQuery) reverseSortDir(dir domain.SortDirection) domain.SortDirection {
+// sortDir is the direction of a sort, reversed for backward pagination
+func (pq *PaginationQuery) sortDir(dir domain.SortDirection, backward bool) domain.SortDirection {
+	if !backward {
+		return dir
+	}
 	if dir == domain.SortASC {
 		return domain.SortDESC
 	}
@@ -183,18 +295,40 @@ func (pq *PaginationQuery) reverseSortDir(dir domain.SortDirection) domain.SortD
 
 // --- Helper Functions ---
 
-// hasID defines the interface required by Paginate to extract the ID for cursors.
-type hasID interface {
-	GetID() uuid.UUID
+// encodeRowCursor encodes the cursor of a row, reading its sort values by their db tags
+func encodeRowCursor(mapper *reflectx.Mapper, sorts []domain.SortOrder, row any) (string, error) {
+	value := reflect.Indirect(reflect.ValueOf(row))
+	fields := mapper.TypeMap(value.Type())
+
+	cursor := domain.Cursor{Sort: sorts}
+	for _, sort := range sorts {
+		field, ok := fields.Names[sort.Field]
+		if !ok {
+			return "", fmt.Errorf("sort field %s has no column in %s", sort.Field, value.Type())
+		}
+
+		sortValue, err := cursorValue(reflectx.FieldByIndexesReadOnly(value, field.Index).Interface())
+		if err != nil {
+			return "", fmt.Errorf("failed to read sort field %s: %w", sort.Field, err)
+		}
+		cursor.Values = append(cursor.Values, sortValue)
+	}
+	return domain.EncodeCursor(cursor)
 }
 
-// extractID uses type assertion to extract ID field
-func extractID(item interface{}) uuid.UUID {
-	if v, ok := item.(hasID); ok {
-		return v.GetID()
+// cursorValue converts a scanned column into the value it is compared as, which must survive the
+// cursor's JSON. Times become text that sqlite compares as stored and postgres casts back.
+func cursorValue(value any) (any, error) {
+	switch v := value.(type) {
+	case driver.Valuer:
+		return v.Value()
+	case *time.Time:
+		if v == nil {
+			return nil, nil
+		}
+		return *v, nil
 	}
-	// Should not happen if T is a DB struct with GetID() defined
-	return uuid.Nil
+	return value, nil
 }
 
 // reverseSlice reverses a slice in place
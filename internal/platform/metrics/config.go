@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Config of the Prometheus listener, which is separate from the API server so worker processes,
+// which run no API, are scraped too
+type Config struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Host    string `mapstructure:"host"`
+	Port    int    `mapstructure:"port"`
+	Path    string `mapstructure:"path"`
+}
+
+func SetConfigDefaults(v *viper.Viper) {
+	v.SetDefault("metrics.enabled", true)
+	v.SetDefault("metrics.host", "0.0.0.0")
+	v.SetDefault("metrics.port", 9090)
+	v.SetDefault("metrics.path", "/metrics")
+}
+
+func BindEnvironmentVariables(v *viper.Viper) {
+	v.BindEnv("metrics.enabled", "METRICS_ENABLED")
+	v.BindEnv("metrics.host", "METRICS_HOST")
+	v.BindEnv("metrics.port", "METRICS_PORT")
+	v.BindEnv("metrics.path", "METRICS_PATH")
+}
+
+func (config *Config) GetAddress() string {
+	return fmt.Sprintf("%s:%d", config.Host, config.Port)
+}
+
+func (config *Config) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Bool("enabled", config.Enabled),
+		slog.String("address", config.GetAddress()),
+		slog.String("path", config.Path),
+	)
+}
+
+func (config *Config) Validate() error {
+	if !config.Enabled {
+		return nil
+	}
+	if config.Port < 1 || config.Port > 65535 {
+		return fmt.Errorf("invalid metrics port: %d", config.Port)
+	}
+	if !strings.HasPrefix(config.Path, "/") {
+		return fmt.Errorf("metrics path must start with /: %s", config.Path)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/service"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "taskworker"
+
+// taskDurationBuckets span tasks of a fraction of a second to hours
+var taskDurationBuckets = []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600, 1800, 3600, 7200}
+
+// Metrics are the Prometheus collectors of the application, it implements service.Metrics
+type Metrics struct {
+	registry      *prometheus.Registry
+	jobsSubmitted *prometheus.CounterVec
+	jobsEnded     *prometheus.CounterVec
+	taskDurations *prometheus.HistogramVec
+	workers       *prometheus.GaugeVec
+	jobQueues     *jobQueueCollector
+	httpDurations *prometheus.HistogramVec
+	rateLimited   prometheus.Counter
+}
+
+var _ service.Metrics = (*Metrics)(nil)
+
+func New() *Metrics {
+	metrics := &Metrics{
+		registry: prometheus.NewRegistry(),
+		jobsSubmitted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "jobs_submitted_total",
+			Help:      "Jobs submitted, by config.",
+		}, []string{"config"}),
+		jobsEnded: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "jobs_ended_total",
+			Help:      "Jobs that finished or failed, by end state and config.",
+		}, []string{"state", "config"}),
+		taskDurations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "task_duration_seconds",
+			Help:      "How long task runs executed over all of their attempts, by task name and end state.",
+			Buckets:   taskDurationBuckets,
+		}, []string{"task_name", "state"}),
+		workers: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "workers",
+			Help:      "Job and task workers of a queue, by whether they are busy or idle.",
+		}, []string{"queue", "kind", "status"}),
+		jobQueues: &jobQueueCollector{
+			desc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "job_queue_depth"),
+				"Claimed jobs waiting for a job worker, by queue.", []string{"queue"}, nil),
+			depths: make(map[string]func() int),
+		},
+		httpDurations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of API requests, by method, route and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		rateLimited: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rate_limit_rejections_total",
+			Help:      "API requests rejected by the rate limiter.",
+		}),
+	}
+
+	metrics.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		metrics.jobsSubmitted,
+		metrics.jobsEnded,
+		metrics.taskDurations,
+		metrics.workers,
+		metrics.jobQueues,
+		metrics.httpDurations,
+		metrics.rateLimited,
+	)
+	return metrics
+}
+
+// RegisterDB exports the connection pool stats of a database, labelled with its name
+func (metrics *Metrics) RegisterDB(db *sql.DB, name string) {
+	metrics.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler serves the metrics in the Prometheus text format
+func (metrics *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(metrics.registry, promhttp.HandlerOpts{})
+}
+
+// NewServer creates the listener of the metrics, apart from the API server
+func (metrics *Metrics) NewServer(config *Config) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle(config.Path, metrics.Handler())
+
+	return &http.Server{
+		Addr:              config.GetAddress(),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+}
+
+// --- service.Metrics ---
+
+func (metrics *Metrics) JobSubmitted(job *domain.Job) {
+	metrics.jobsSubmitted.WithLabelValues(job.ConfigID.String()).Inc()
+}
+
+func (metrics *Metrics) JobEnded(job *domain.Job) {
+	metrics.jobsEnded.WithLabelValues(string(job.State), job.ConfigID.String()).Inc()
+}
+
+func (metrics *Metrics) TaskExecuted(taskRun *domain.TaskRun) {
+	if taskRun.StartDate == nil || taskRun.EndDate == nil {
+		return
+	}
+	duration := taskRun.EndDate.Sub(*taskRun.StartDate)
+	metrics.taskDurations.WithLabelValues(taskRun.TaskName, string(taskRun.State)).Observe(duration.Seconds())
+}
+
+func (metrics *Metrics) ObserveJobQueue(queue string, depth func() int) {
+	metrics.jobQueues.add(queue, depth)
+}
+
+func (metrics *Metrics) WorkerStarted(queue string, kind service.WorkerKind) {
+	metrics.workers.WithLabelValues(queue, string(kind), "idle").Inc()
+}
+
+func (metrics *Metrics) WorkerStopped(queue string, kind service.WorkerKind) {
+	metrics.workers.WithLabelValues(queue, string(kind), "idle").Dec()
+}
+
+func (metrics *Metrics) WorkerBusy(queue string, kind service.WorkerKind) {
+	metrics.workers.WithLabelValues(queue, string(kind), "idle").Dec()
+	metrics.workers.WithLabelValues(queue, string(kind), "busy").Inc()
+}
+
+func (metrics *Metrics) WorkerIdle(queue string, kind service.WorkerKind) {
+	metrics.workers.WithLabelValues(queue, string(kind), "busy").Dec()
+	metrics.workers.WithLabelValues(queue, string(kind), "idle").Inc()
+}
+
+// --- API server ---
+
+// ObserveRequest observes the latency of an API request, route is its chi route pattern
+func (metrics *Metrics) ObserveRequest(method string, route string, status int, duration time.Duration) {
+	metrics.httpDurations.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// RateLimited counts a request rejected by the rate limiter
+func (metrics *Metrics) RateLimited() {
+	metrics.rateLimited.Inc()
+}
+
+// jobQueueCollector reads the depth of each queue's jobCh when scraped
+type jobQueueCollector struct {
+	desc   *prometheus.Desc
+	mu     sync.RWMutex
+	depths map[string]func() int
+}
+
+func (collector *jobQueueCollector) add(queue string, depth func() int) {
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	collector.depths[queue] = depth
+}
+
+func (collector *jobQueueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.desc
+}
+
+func (collector *jobQueueCollector) Collect(ch chan<- prometheus.Metric) {
+	collector.mu.RLock()
+	defer collector.mu.RUnlock()
+
+	for queue, depth := range collector.depths {
+		ch <- prometheus.MustNewConstMetric(collector.desc, prometheus.GaugeValue, float64(depth()), queue)
+	}
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/service"
+	"github.com/google/uuid"
+)
+
+// scrape returns the metrics in the Prometheus text format
+func scrape(t *testing.T, metrics *Metrics) string {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("scrape = %d, want %d", recorder.Code, http.StatusOK)
+	}
+	body, _ := io.ReadAll(recorder.Body)
+	return string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	metrics := New()
+
+	configID := uuid.New()
+	job := &domain.Job{ConfigID: configID, Status: domain.Status{State: domain.StatePending}}
+	metrics.JobSubmitted(job)
+	metrics.JobSubmitted(job)
+	job.State = domain.StateError
+	metrics.JobEnded(job)
+
+	start := time.Now()
+	end := start.Add(3 * time.Second)
+	metrics.TaskExecuted(&domain.TaskRun{TaskName: "email", State: domain.StateFinished, StartDate: &start, EndDate: &end})
+	// A task that never started has no duration
+	metrics.TaskExecuted(&domain.TaskRun{TaskName: "report", State: domain.StateError})
+
+	for range 3 {
+		metrics.WorkerStarted("default", service.JobWorkerKind)
+	}
+	metrics.WorkerBusy("default", service.JobWorkerKind)
+	metrics.WorkerBusy("default", service.JobWorkerKind)
+	metrics.WorkerIdle("default", service.JobWorkerKind)
+	metrics.ObserveJobQueue("default", func() int { return 4 })
+
+	metrics.ObserveRequest(http.MethodGet, "/api/v1/jobs/{jobID}", http.StatusOK, 20*time.Millisecond)
+	metrics.RateLimited()
+
+	body := scrape(t, metrics)
+	for _, want := range []string{
+		`taskworker_jobs_submitted_total{config="` + configID.String() + `"} 2`,
+		`taskworker_jobs_ended_total{config="` + configID.String() + `",state="ERROR"} 1`,
+		`taskworker_task_duration_seconds_count{state="FINISHED",task_name="email"} 1`,
+		`taskworker_task_duration_seconds_bucket{state="FINISHED",task_name="email",le="5"} 1`,
+		`taskworker_task_duration_seconds_bucket{state="FINISHED",task_name="email",le="2.5"} 0`,
+		`taskworker_workers{kind="job",queue="default",status="idle"} 2`,
+		`taskworker_workers{kind="job",queue="default",status="busy"} 1`,
+		`taskworker_job_queue_depth{queue="default"} 4`,
+		`taskworker_http_request_duration_seconds_count{method="GET",route="/api/v1/jobs/{jobID}",status="200"} 1`,
+		`taskworker_rate_limit_rejections_total 1`,
+	} {
+		if !strings.Contains(body, want+"\n") {
+			t.Errorf("metrics are missing %s", want)
+		}
+	}
+	if strings.Contains(body, `task_name="report"`) {
+		t.Error("task without a duration was observed")
+	}
+}
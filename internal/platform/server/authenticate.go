@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/gorilla/websocket"
 )
 
 const userLogKey domain.LogKey = "user"
@@ -16,15 +17,12 @@ type UserInfo struct {
 
 func (server *Server) authenticateMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Get token from the Authorization header (e.g., Bearer <token>)
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" || len(authHeader) < 7 || authHeader[:6] != "Bearer" {
+		token, ok := requestToken(r)
+		if !ok {
 			http.Error(w, "Invalid authorization format", http.StatusUnauthorized)
 			return
 		}
 
-		token := authHeader[7:]
-
 		// Validate the token and retrieve user information
 		user, err := server.validateToken(token)
 		if err != nil {
@@ -39,6 +37,22 @@ func (server *Server) authenticateMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// requestToken gets the token from the Authorization header (e.g., Bearer <token>).
+// Browsers cannot set headers on WebSocket handshakes, so those may pass ?token= instead.
+func requestToken(r *http.Request) (string, bool) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader != "" && len(authHeader) >= 7 && authHeader[:6] == "Bearer" {
+		return authHeader[7:], true
+	}
+
+	if websocket.IsWebSocketUpgrade(r) {
+		if token := r.URL.Query().Get("token"); token != "" {
+			return token, true
+		}
+	}
+	return "", false
+}
+
 func (server *Server) validateToken(token string) (*UserInfo, error) {
 	_, ok := server.apiKeys[token]
 	if !ok {
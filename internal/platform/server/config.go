@@ -9,15 +9,18 @@ import (
 )
 
 type Config struct {
-	Host            string           `mapstructure:"host"`
-	Port            int              `mapstructure:"port"`
-	ReadTimeout     time.Duration    `mapstructure:"read_timeout"`
-	WriteTimeout    time.Duration    `mapstructure:"write_timeout"`
-	IdleTimeout     time.Duration    `mapstructure:"idle_timeout"`
-	Timeout         time.Duration    `mapstructure:"timeout"`
-	ShutdownTimeout time.Duration    `mapstructure:"shutdown_timeout"`
-	Cors            *CORSConfig      `mapstructure:"cors"`
-	RateLimit       *RateLimitConfig `mapstructure:"rate_limit"`
+	Host            string        `mapstructure:"host"`
+	Port            int           `mapstructure:"port"`
+	ReadTimeout     time.Duration `mapstructure:"read_timeout"`
+	WriteTimeout    time.Duration `mapstructure:"write_timeout"`
+	IdleTimeout     time.Duration `mapstructure:"idle_timeout"`
+	Timeout         time.Duration `mapstructure:"timeout"`
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+	EventHeartbeat  time.Duration `mapstructure:"event_heartbeat"`
+	// MaxBatchBytes limits the body of a batch submission
+	MaxBatchBytes int64            `mapstructure:"max_batch_bytes"`
+	Cors          *CORSConfig      `mapstructure:"cors"`
+	RateLimit     *RateLimitConfig `mapstructure:"rate_limit"`
 }
 
 type CORSConfig struct {
@@ -42,6 +45,8 @@ func SetConfigDefaults(v *viper.Viper) {
 	v.SetDefault("server.idle_timeout", 60*time.Second)
 	v.SetDefault("server.timeout", 60*time.Second)
 	v.SetDefault("server.shutdown_timeout", 15*time.Second)
+	v.SetDefault("server.event_heartbeat", 15*time.Second)
+	v.SetDefault("server.max_batch_bytes", 64<<20)
 	// --- CORS Configuration Defaults ---
 	v.SetDefault("server.cors.enabled", false)
 	v.SetDefault("server.cors.allowed_origins", []string{"*"})
@@ -62,6 +67,8 @@ func BindEnvironmentVariables(v *viper.Viper) {
 	v.BindEnv("server.idle_timeout", "SERVER_IDLE_TIMEOUT")
 	v.BindEnv("server.timeout", "SERVER_TIMEOUT")
 	v.BindEnv("server.shutdown_timeout", "SERVER_SHUTDOWN_TIMEOUT")
+	v.BindEnv("server.event_heartbeat", "SERVER_EVENT_HEARTBEAT")
+	v.BindEnv("server.max_batch_bytes", "SERVER_MAX_BATCH_BYTES")
 	// Rate Limit Config
 	v.BindEnv("server.rate_limit.requests_per_second", "RATE_LIMIT_REQUESTS_PER_SECOND")
 	v.BindEnv("server.rate_limit.burst", "RATE_LIMIT_BURST")
@@ -83,13 +90,16 @@ func (config *Config) LogValue() slog.Value {
 func (config *Config) Validate() error {
 	if config.ReadTimeout <= 0 || config.WriteTimeout <= 0 ||
 		config.IdleTimeout <= 0 || config.ShutdownTimeout <= 0 ||
-		config.Timeout <= 0 {
+		config.Timeout <= 0 || config.EventHeartbeat <= 0 {
 
 		return fmt.Errorf("server timeouts must be positive")
 	}
 	if config.Port < 1 || config.Port > 65535 {
 		return fmt.Errorf("invalid server port: %d", config.Port)
 	}
+	if config.MaxBatchBytes < 1 {
+		return fmt.Errorf("server max batch bytes must be greater than 0")
+	}
 
 	if err := config.RateLimit.Validate(); err != nil {
 		return err
@@ -0,0 +1,166 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// eventStreamBuffer is the per-client subscription buffer. A client that falls further behind
+// is disconnected and resumes from Last-Event-ID.
+const eventStreamBuffer = 256
+
+// Stream events for all jobs
+func (server *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	server.streamEvents(w, r, nil, nil)
+}
+
+// Stream events for a Job
+func (server *Server) handleJobEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	jobID := parseUUIDOrDefault(chi.URLParam(r, "id"))
+
+	if jobID == uuid.Nil {
+		server.respondError(w, http.StatusBadRequest, "job ID is required")
+		return
+	}
+
+	job, err := server.jobService.GetJob(ctx, jobID)
+	if err != nil || job == nil {
+		slog.ErrorContext(ctx, "failed to get job", slog.Any("error", err))
+		server.respondError(w, http.StatusNotFound, "job not found")
+		return
+	}
+
+	filter := func(event service.Event) bool {
+		return event.JobID == jobID
+	}
+	// The snapshot is read after subscribing, so no change can fall between the two
+	snapshot := func() (any, error) {
+		return server.jobService.GetJob(ctx, jobID)
+	}
+	server.streamEvents(w, r, filter, snapshot)
+}
+
+// streamEvents writes matching events as Server-Sent Events until the client disconnects,
+// falls behind or the server shuts down. With a Last-Event-ID header, retained events after
+// it are replayed first. Without one, or when the events are no longer retained, the snapshot
+// (if any) is sent as a "snapshot" event, otherwise a "resync" event tells the client to refetch.
+func (server *Server) streamEvents(w http.ResponseWriter, r *http.Request, filter service.EventFilter, snapshot func() (any, error)) {
+	ctx := r.Context()
+	bus := server.jobService.Events()
+
+	var sub *service.Subscription
+	var missed []service.Event
+	resuming := false
+	complete := true
+
+	if header := r.Header.Get("Last-Event-ID"); header != "" {
+		lastID, err := strconv.ParseUint(header, 10, 64)
+		if err != nil {
+			server.respondError(w, http.StatusBadRequest, "invalid Last-Event-ID")
+			return
+		}
+		resuming = true
+		sub, missed, complete = bus.Resume(eventStreamBuffer, filter, lastID)
+	} else {
+		sub = bus.Subscribe(eventStreamBuffer, filter)
+	}
+	defer sub.Close()
+
+	// The server WriteTimeout would otherwise end the stream
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		slog.WarnContext(ctx, "failed to clear write deadline for event stream", slog.Any("error", err))
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if !resuming || !complete {
+		if snapshot != nil {
+			data, err := snapshot()
+			if err != nil {
+				slog.ErrorContext(ctx, "failed to get event stream snapshot", slog.Any("error", err))
+				return
+			}
+			if err := writeEvent(w, "", "snapshot", data); err != nil {
+				return
+			}
+		} else if resuming {
+			if err := writeEvent(w, "", "resync", struct{}{}); err != nil {
+				return
+			}
+		}
+	}
+	for _, event := range missed {
+		if err := writeEvent(w, strconv.FormatUint(event.ID, 10), string(event.Type), event); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		slog.ErrorContext(ctx, "event stream does not support flushing", slog.Any("error", err))
+		return
+	}
+
+	heartbeat := time.NewTicker(server.serverConfig.EventHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+		select {
+		case <-ctx.Done():
+			return
+		case <-server.shutdownCh:
+			return
+
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(w, ": heartbeat\n\n")
+
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			err = writeEvent(w, strconv.FormatUint(event.ID, 10), string(event.Type), event)
+		}
+
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			slog.DebugContext(ctx, "event stream closed", slog.Any("error", err))
+			return
+		}
+
+		// Disconnect slow clients, they reconnect and replay from Last-Event-ID
+		if sub.Dropped() > 0 {
+			slog.WarnContext(ctx, "event stream client fell behind, disconnecting")
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, id string, eventType string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventType, payload)
+	return err
+}
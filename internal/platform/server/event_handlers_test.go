@@ -0,0 +1,144 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/mock"
+	"github.com/abikandiah/task-worker/internal/service"
+	"github.com/google/uuid"
+)
+
+// openEventStream requests an event stream, resuming after lastEventID when it is not empty
+func openEventStream(t *testing.T, url string, lastEventID string) *bufio.Reader {
+	t.Helper()
+
+	request := newTestRequest(t, http.MethodGet, url, "")
+	request.Header.Set("Accept", "text/event-stream")
+	if lastEventID != "" {
+		request.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	t.Cleanup(func() { response.Body.Close() })
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s = %d, want %d", url, response.StatusCode, http.StatusOK)
+	}
+	if contentType := response.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", contentType)
+	}
+	return bufio.NewReader(response.Body)
+}
+
+func saveTestJob(t *testing.T, repo *mock.MockRepo) *domain.Job {
+	t.Helper()
+
+	job, err := repo.SaveJob(context.Background(), domain.Job{Status: domain.Status{State: domain.StatePending}})
+	if err != nil {
+		t.Fatalf("SaveJob: %v", err)
+	}
+	return job
+}
+
+func TestJobEventStream(t *testing.T) {
+	repo := mock.NewMockRepo()
+	testServer, jobService := newTestServer(t, repo)
+	job := saveTestJob(t, repo)
+
+	stream := openEventStream(t, testServer.URL+"/api/v1/jobs/"+job.ID.String()+"/events", "")
+
+	snapshot := readSSEEvent(t, stream)
+	if snapshot.Event != "snapshot" || snapshot.ID != "" {
+		t.Fatalf("first event = %+v, want a snapshot without an ID", snapshot)
+	}
+	var snapshotJob domain.Job
+	if err := json.Unmarshal([]byte(snapshot.Data), &snapshotJob); err != nil || snapshotJob.ID != job.ID {
+		t.Fatalf("snapshot data = %s, want job %s", snapshot.Data, job.ID)
+	}
+
+	// The snapshot is written after subscribing, so events published now are streamed
+	jobService.Events().Publish(service.Event{Type: service.EventJobStarted, JobID: uuid.New()})
+	published := jobService.Events().Publish(service.Event{Type: service.EventJobStarted, JobID: job.ID})
+
+	event := readSSEEvent(t, stream)
+	if event.Event != string(service.EventJobStarted) || event.ID != "2" {
+		t.Fatalf("event = %+v, want %s with ID 2, events of other jobs are filtered", event, service.EventJobStarted)
+	}
+	var data service.Event
+	if err := json.Unmarshal([]byte(event.Data), &data); err != nil {
+		t.Fatalf("unmarshal event data: %v", err)
+	}
+	if data.ID != published.ID || data.JobID != job.ID {
+		t.Errorf("event data = %+v, want the published event", data)
+	}
+}
+
+func TestEventStreamResume(t *testing.T) {
+	repo := mock.NewMockRepo()
+	testServer, jobService := newTestServer(t, repo)
+	job := saveTestJob(t, repo)
+
+	for _, eventType := range []service.EventType{service.EventJobSubmitted, service.EventJobStarted, service.EventJobFinished} {
+		jobService.Events().Publish(service.Event{Type: eventType, JobID: job.ID})
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		lastEventID string
+		want        []sseEvent
+	}{
+		{
+			name:        "replays retained events",
+			path:        "/api/v1/jobs/" + job.ID.String() + "/events",
+			lastEventID: "1",
+			want:        []sseEvent{{ID: "2", Event: string(service.EventJobStarted)}, {ID: "3", Event: string(service.EventJobFinished)}},
+		},
+		{
+			name:        "snapshot when events are not retained",
+			path:        "/api/v1/jobs/" + job.ID.String() + "/events",
+			lastEventID: "100",
+			want:        []sseEvent{{Event: "snapshot"}},
+		},
+		{
+			name:        "resync without a snapshot",
+			path:        "/api/v1/events",
+			lastEventID: "100",
+			want:        []sseEvent{{Event: "resync"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stream := openEventStream(t, testServer.URL+test.path, test.lastEventID)
+			for _, want := range test.want {
+				event := readSSEEvent(t, stream)
+				if event.ID != want.ID || event.Event != want.Event {
+					t.Fatalf("event = %s %s, want %s %s", event.ID, event.Event, want.ID, want.Event)
+				}
+			}
+		})
+	}
+
+	t.Run("invalid Last-Event-ID", func(t *testing.T) {
+		request := newTestRequest(t, http.MethodGet, testServer.URL+"/api/v1/events", "")
+		request.Header.Set("Last-Event-ID", "latest")
+
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("GET events: %v", err)
+		}
+		defer response.Body.Close()
+		if response.StatusCode != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", response.StatusCode, http.StatusBadRequest)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/factory"
+	"github.com/abikandiah/task-worker/internal/mock"
+	"github.com/abikandiah/task-worker/internal/service"
+	"github.com/spf13/viper"
+)
+
+// testAPIKey authenticates requests to servers from newTestServer
+const testAPIKey = "secret"
+
+// newTestServer serves the API over a JobService backed by repo, with the default configs and
+// without workers. It is closed when the test ends.
+func newTestServer(t *testing.T, repo *mock.MockRepo) (*httptest.Server, *service.JobService) {
+	t.Helper()
+	t.Setenv("WORKER_SECRET", testAPIKey)
+
+	v := viper.New()
+	service.SetConfigDefaults(v)
+	SetConfigDefaults(v)
+
+	var serviceConfig service.Config
+	var serverConfig Config
+	if err := v.UnmarshalKey("worker", &serviceConfig); err != nil {
+		t.Fatalf("unmarshal worker config: %v", err)
+	}
+	if err := v.UnmarshalKey("server", &serverConfig); err != nil {
+		t.Fatalf("unmarshal server config: %v", err)
+	}
+
+	jobService := service.NewJobService(&service.JobServiceParams{
+		Config:      &serviceConfig,
+		Repository:  repo,
+		TaskFactory: factory.NewTaskFactory(),
+	})
+	httpServer := NewServer(&ServerParams{ServerConfig: &serverConfig, JobService: jobService})
+
+	testServer := httptest.NewServer(httpServer.Handler)
+	t.Cleanup(testServer.Close)
+	return testServer, jobService
+}
+
+// newTestRequest returns an authenticated request, cancelled when the test ends
+func newTestRequest(t *testing.T, method string, url string, body string) *http.Request {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	request, err := http.NewRequestWithContext(ctx, method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	request.Header.Set("Authorization", "Bearer "+testAPIKey)
+	if body != "" {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	return request
+}
+
+// sseEvent is an event read from a Server-Sent Events stream
+type sseEvent struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// readSSEEvent reads the next event from the stream, skipping comments such as heartbeats
+func readSSEEvent(t *testing.T, reader *bufio.Reader) sseEvent {
+	t.Helper()
+
+	var event sseEvent
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event stream: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "":
+			if event.Event != "" {
+				return event
+			}
+		case strings.HasPrefix(line, "id: "):
+			event.ID = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event.Event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			event.Data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
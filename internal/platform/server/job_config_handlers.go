@@ -1,41 +1,209 @@
 package server
 
 import (
+	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 
 	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/service"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
 
 func (server *Server) setupJobConfigRoutes() func(r chi.Router) {
 	return func(r chi.Router) {
+		r.Get("/", server.handleGetJobConfigs)
+		r.Post("/", server.handleCreateJobConfig)
+
 		r.Route("/{id}", func(r chi.Router) {
 			r.Use(server.updateRequestContextWithID("id", domain.LKeys.ConfigID))
 
 			r.Get("/", server.handleGetJobConfig)
+			r.Get("/versions", server.handleGetJobConfigVersions)
+			r.Post("/versions", server.handlePublishJobConfigVersion)
+			r.Delete("/versions/{version}", server.handleRetireJobConfigVersion)
+			r.Get("/diff", server.handleDiffJobConfigs)
+			r.Put("/default", server.handleSetDefaultJobConfig)
 		})
 	}
 }
 
-// Get JobConfig by ID
+// Get the latest version of every config
+func (server *Server) handleGetJobConfigs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	input, err := parseCursorInput(r)
+	if err != nil {
+		server.respondListError(w, r, err, "failed to get configs")
+		return
+	}
+
+	res, err := server.jobService.GetAllJobConfigs(ctx, input)
+	if err != nil {
+		server.respondListError(w, r, err, "failed to get configs")
+		return
+	}
+
+	server.respondJSON(w, http.StatusOK, res)
+}
+
+// Create a config
+func (server *Server) handleCreateJobConfig(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var submission domain.JobConfigSubmission
+	if err := json.NewDecoder(r.Body).Decode(&submission); err != nil {
+		slog.WarnContext(ctx, "failed to decode config request", slog.Any("error", err))
+		server.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	config, err := server.jobService.CreateJobConfig(ctx, &submission)
+	if err != nil {
+		server.respondJobConfigError(w, r, err)
+		return
+	}
+
+	slog.InfoContext(ctx, "config created", slog.Any("configId", config.ID))
+	server.respondJSON(w, http.StatusCreated, config)
+}
+
+// Get JobConfig by ID, the latest version unless ?version= is given
 func (server *Server) handleGetJobConfig(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	configID := parseUUIDOrDefault(chi.URLParam(r, "id"))
+	version := parseUUIDOrDefault(r.URL.Query().Get("version"))
 
-	if configID == uuid.Nil {
-		server.respondError(w, http.StatusBadRequest, "config ID is required")
+	config, err := server.jobService.GetJobConfig(ctx, configID, version)
+	if err != nil {
+		server.respondJobConfigError(w, r, err)
 		return
 	}
 
-	config, err := server.jobService.GetJobConfig(ctx, configID)
+	server.respondJSON(w, http.StatusOK, config)
+}
+
+// Get the versions of a config
+func (server *Server) handleGetJobConfigVersions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	configID := parseUUIDOrDefault(chi.URLParam(r, "id"))
+
+	input, err := parseCursorInput(r)
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to get config", slog.Any("error", err))
-		server.respondError(w, http.StatusNotFound, "config not found")
+		server.respondListError(w, r, err, "failed to get config versions")
+		return
+	}
+
+	res, err := server.jobService.GetJobConfigVersions(ctx, configID, input)
+	if err != nil {
+		server.respondJobConfigError(w, r, err)
+		return
+	}
+
+	server.respondJSON(w, http.StatusOK, res)
+}
+
+// Publish a new version of a config
+func (server *Server) handlePublishJobConfigVersion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	configID := parseUUIDOrDefault(chi.URLParam(r, "id"))
+
+	var submission domain.JobConfigSubmission
+	if err := json.NewDecoder(r.Body).Decode(&submission); err != nil {
+		slog.WarnContext(ctx, "failed to decode config request", slog.Any("error", err))
+		server.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	config, err := server.jobService.PublishJobConfigVersion(ctx, configID, &submission)
+	if err != nil {
+		server.respondJobConfigError(w, r, err)
 		return
 	}
 
+	slog.InfoContext(ctx, "config version published", slog.Any("version", config.Version))
+	server.respondJSON(w, http.StatusCreated, config)
+}
+
+// Retire a config version that is not the default and that no job references
+func (server *Server) handleRetireJobConfigVersion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	configID := parseUUIDOrDefault(chi.URLParam(r, "id"))
+	version, err := uuid.Parse(chi.URLParam(r, "version"))
+	if err != nil {
+		server.respondError(w, http.StatusBadRequest, "invalid version")
+		return
+	}
+
+	if err := server.jobService.RetireJobConfigVersion(ctx, configID, version); err != nil {
+		server.respondJobConfigError(w, r, err)
+		return
+	}
+
+	slog.InfoContext(ctx, "config version retired", slog.Any("version", version))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Diff two versions of a config, ?from= and ?to= default to the latest version
+func (server *Server) handleDiffJobConfigs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	configID := parseUUIDOrDefault(chi.URLParam(r, "id"))
+	from := parseUUIDOrDefault(r.URL.Query().Get("from"))
+	to := parseUUIDOrDefault(r.URL.Query().Get("to"))
+	if from == uuid.Nil && to == uuid.Nil {
+		server.respondError(w, http.StatusBadRequest, "from or to version is required")
+		return
+	}
+
+	diff, err := server.jobService.DiffJobConfigs(ctx, configID, from, to)
+	if err != nil {
+		server.respondJobConfigError(w, r, err)
+		return
+	}
+
+	server.respondJSON(w, http.StatusOK, diff)
+}
+
+// Make a config version the default, the latest unless ?version= is given
+func (server *Server) handleSetDefaultJobConfig(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	configID := parseUUIDOrDefault(chi.URLParam(r, "id"))
+	version := parseUUIDOrDefault(r.URL.Query().Get("version"))
+
+	config, err := server.jobService.SetDefaultJobConfig(ctx, configID, version)
+	if err != nil {
+		server.respondJobConfigError(w, r, err)
+		return
+	}
+
+	slog.InfoContext(ctx, "default config set", slog.Any("version", config.Version))
 	server.respondJSON(w, http.StatusOK, config)
 }
+
+// respondJobConfigError maps config service errors to responses
+func (server *Server) respondJobConfigError(w http.ResponseWriter, r *http.Request, err error) {
+	ctx := r.Context()
+
+	switch {
+	case errors.Is(err, service.ErrConfigNotFound):
+		server.respondError(w, http.StatusNotFound, "config not found")
+	case errors.Is(err, service.ErrConfigInUse):
+		slog.WarnContext(ctx, "config version in use", slog.Any("error", err))
+		server.respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrInvalidConfig), errors.Is(err, service.ErrInvalidSubmission),
+		errors.Is(err, domain.ErrInvalidQuery):
+		slog.WarnContext(ctx, "invalid config request", slog.Any("error", err))
+		server.respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		slog.ErrorContext(ctx, "failed to handle config request", slog.Any("error", err))
+		server.respondError(w, http.StatusInternalServerError, "failed to handle config request")
+	}
+}
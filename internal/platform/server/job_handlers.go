@@ -1,13 +1,17 @@
 package server
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
-	"strconv"
 
 	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/service"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
@@ -16,12 +20,17 @@ func (server *Server) setupJobRoutes() func(r chi.Router) {
 	return func(r chi.Router) {
 		r.Get("/", server.handleGetJobs)
 		r.Post("/", server.handleSubmitJob)
+		r.Route("/configs", server.setupJobConfigRoutes())
 
 		r.Route("/{id}", func(r chi.Router) {
 			r.Use(server.updateRequestContextWithID("id", domain.LKeys.JobID))
 
 			r.Get("/", server.handleGetJob)
 			r.Get("/status", server.handleGetJobStatus)
+			r.Get("/webhooks", server.handleGetJobWebhooks)
+			r.Get("/events", server.handleJobEvents)
+			r.Get("/tasks", server.handleGetJobTaskRuns)
+			r.Get("/tasks/{taskId}/logs", server.handleGetTaskLogs)
 		})
 	}
 }
@@ -52,6 +61,16 @@ func (server *Server) handleSubmitJob(w http.ResponseWriter, r *http.Request) {
 
 	// Submit job to service
 	job, err := server.jobService.SubmitJob(ctx, &submission)
+	if errors.Is(err, service.ErrInvalidSubmission) {
+		slog.WarnContext(ctx, "invalid job submission", slog.Any("error", err))
+		server.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrConcurrencyConflict) {
+		slog.WarnContext(ctx, "job submission rejected", slog.Any("error", err))
+		server.respondError(w, http.StatusConflict, err.Error())
+		return
+	}
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to submit job", slog.Any("error", err))
 		server.respondError(w, http.StatusInternalServerError, "failed to submit job")
@@ -64,6 +83,114 @@ func (server *Server) handleSubmitJob(w http.ResponseWriter, r *http.Request) {
 	server.respondJSON(w, http.StatusCreated, job)
 }
 
+// Submit a batch of jobs, as a JSON array or NDJSON stream of submissions
+func (server *Server) handleSubmitJobBatch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	maxSubmissions := server.jobService.MaxBatchSubmissions()
+	body := http.MaxBytesReader(w, r.Body, server.serverConfig.MaxBatchBytes)
+
+	submissions, err := decodeSubmissions(body, maxSubmissions)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		slog.WarnContext(ctx, "job batch request too large", slog.Any("error", err))
+		server.respondError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("batch body exceeds %d bytes", maxBytesErr.Limit))
+		return
+	}
+	if errors.Is(err, errTooManySubmissions) {
+		slog.WarnContext(ctx, "job batch has too many submissions", slog.Int("max", maxSubmissions))
+		server.respondError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("batch has more than %d submissions", maxSubmissions))
+		return
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "failed to decode job batch request", slog.Any("error", err))
+		server.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	mode := domain.BatchMode(r.URL.Query().Get("mode"))
+	report, err := server.jobService.SubmitJobBatch(ctx, submissions, mode)
+	if errors.Is(err, service.ErrInvalidSubmission) {
+		slog.WarnContext(ctx, "invalid job batch", slog.Any("error", err))
+		server.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to submit job batch", slog.Any("error", err))
+		server.respondError(w, http.StatusInternalServerError, "failed to submit job batch")
+		return
+	}
+
+	slog.InfoContext(ctx, "job batch submitted", slog.String("mode", string(report.Mode)),
+		slog.Int("submitted", report.Submitted), slog.Int("failed", report.Failed))
+
+	status := http.StatusCreated
+	if report.Submitted == 0 {
+		status = http.StatusBadRequest
+	} else if report.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	server.respondJSON(w, status, report)
+}
+
+// errTooManySubmissions is returned once a batch has more submissions than allowed
+var errTooManySubmissions = errors.New("too many submissions")
+
+// decodeSubmissions reads a JSON array of submissions, or submissions one after another as NDJSON.
+// Reading stops at the first submission past maxSubmissions.
+func decodeSubmissions(body io.Reader, maxSubmissions int) ([]domain.JobSubmission, error) {
+	reader := bufio.NewReader(body)
+	decoder := json.NewDecoder(reader)
+
+	first, err := peekNonSpace(reader)
+	if err != nil {
+		return nil, err
+	}
+	array := first == '['
+	if array {
+		// Consume the opening bracket to decode the elements one at a time
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+	}
+
+	var submissions []domain.JobSubmission
+	for !array || decoder.More() {
+		var submission domain.JobSubmission
+		if err := decoder.Decode(&submission); err != nil {
+			if !array && errors.Is(err, io.EOF) {
+				return submissions, nil
+			}
+			return nil, fmt.Errorf("submission %d: %w", len(submissions), err)
+		}
+		if len(submissions) == maxSubmissions {
+			return nil, errTooManySubmissions
+		}
+		submissions = append(submissions, submission)
+	}
+
+	// The closing bracket must end the array
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	return submissions, nil
+}
+
+func peekNonSpace(reader *bufio.Reader) (byte, error) {
+	for {
+		next, err := reader.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		switch next[0] {
+		case ' ', '\t', '\r', '\n':
+			reader.ReadByte()
+		default:
+			return next[0], nil
+		}
+	}
+}
+
 // Get Job by ID
 func (server *Server) handleGetJob(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -106,29 +233,39 @@ func (server *Server) handleGetJobStatus(w http.ResponseWriter, r *http.Request)
 	server.respondJSON(w, http.StatusOK, status)
 }
 
-func (server *Server) handleGetJobs(w http.ResponseWriter, r *http.Request) {
+// Get webhook delivery attempts for a Job
+func (server *Server) handleGetJobWebhooks(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	query := r.URL.Query()
 
-	afterID := parseUUIDOrDefault(query.Get("afterId"))
-	beforeID := parseUUIDOrDefault(query.Get("beforeId"))
-	limit, _ := strconv.Atoi(query.Get("limit"))
+	jobID := parseUUIDOrDefault(chi.URLParam(r, "id"))
+
+	if jobID == uuid.Nil {
+		server.respondError(w, http.StatusBadRequest, "job ID is required")
+		return
+	}
+
+	deliveries, err := server.jobService.GetWebhookDeliveries(ctx, jobID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get webhook deliveries", slog.Any("error", err))
+		server.respondError(w, http.StatusInternalServerError, "failed to get webhook deliveries")
+		return
+	}
 
-	sortField := query.Get("sortField")
-	sortDir := domain.SortDirection(query.Get("sortDir"))
+	server.respondJSON(w, http.StatusOK, deliveries)
+}
 
-	inputReq := &domain.CursorInput{
-		AfterID:   afterID,
-		BeforeID:  beforeID,
-		Limit:     limit,
-		SortField: sortField,
-		SortDir:   sortDir,
+func (server *Server) handleGetJobs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	input, err := parseCursorInput(r)
+	if err != nil {
+		server.respondListError(w, r, err, "failed to get jobs")
+		return
 	}
-	inputReq.SetDefaults()
 
-	res, err := server.jobService.GetAllJobs(ctx, inputReq)
+	res, err := server.jobService.GetAllJobs(ctx, input)
 	if err != nil {
-		http.Error(w, "Failed to retrieve jobs.", http.StatusInternalServerError)
+		server.respondListError(w, r, err, "failed to get jobs")
 		return
 	}
 
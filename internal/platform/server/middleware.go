@@ -4,13 +4,24 @@ import (
 	"context"
 	"mime"
 	"net/http"
+	"regexp"
 	"time"
 
 	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/gorilla/websocket"
 	"github.com/rs/cors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// tracer starts the API's spans from the global provider, which is a no-op unless tracing is set up
+var tracer = otel.Tracer("github.com/abikandiah/task-worker/internal/platform/server")
+
 func (server *Server) loggerMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Update request context with values
@@ -22,6 +33,67 @@ func (server *Server) loggerMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// metricsMiddleware observes the latency of requests by their chi route. Streams are left out,
+// they last as long as the client stays.
+func (server *Server) metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if server.metrics == nil || isStreamRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		// The pattern is complete once the request has been routed
+		route := chi.RouteContext(r.Context()).RoutePattern()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		server.metrics.ObserveRequest(r.Method, route, status, time.Since(start))
+	})
+}
+
+// tracingMiddleware starts a span per request, continuing the caller's trace from its traceparent
+// header. Like metrics, streams are left out.
+func tracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isStreamRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(r.Method),
+			semconv.URLPath(r.URL.Path),
+		))
+		defer span.End()
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		// Spans are named by route once the request has been routed
+		if route := chi.RouteContext(r.Context()).RoutePattern(); route != "" {
+			span.SetName(r.Method + " " + route)
+			span.SetAttributes(semconv.HTTPRoute(route))
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
+
 func (server *Server) contentTypeMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Use standard net/http constants for clarity
@@ -36,8 +108,8 @@ func (server *Server) contentTypeMiddleware(next http.Handler) http.Handler {
 
 			// The standard library provides a function for robust media type parsing
 			mediaType, _, err := mime.ParseMediaType(contentType)
-			if err != nil || mediaType != "application/json" {
-				server.respondError(w, http.StatusUnsupportedMediaType, "Content-Type must be application/json")
+			if err != nil || (mediaType != "application/json" && mediaType != "application/x-ndjson") {
+				server.respondError(w, http.StatusUnsupportedMediaType, "Content-Type must be application/json or application/x-ndjson")
 				return
 			}
 		}
@@ -45,6 +117,43 @@ func (server *Server) contentTypeMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// timeoutMiddleware applies chi's Timeout to regular requests. Event streams and WebSockets
+// are skipped, since the deadline would cancel them once it elapsed.
+func (server *Server) timeoutMiddleware(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		withTimeout := middleware.Timeout(timeout)(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isStreamRequest(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			withTimeout.ServeHTTP(w, r)
+		})
+	}
+}
+
+// taskLogsPathPattern matches the task logs route, which follows logs with follow=true. The
+// middleware runs before routing, so the route pattern is not known yet.
+var taskLogsPathPattern = regexp.MustCompile(`^/api/v1/jobs/[^/]+/tasks/[^/]+/logs/?$`)
+
+// isStreamRequest reports whether r opens a WebSocket or an event stream. Streams are requested
+// with an event-stream Accept header, or by following task logs with follow=true, which clients
+// like curl send without one.
+func isStreamRequest(r *http.Request) bool {
+	if websocket.IsWebSocketUpgrade(r) {
+		return true
+	}
+	if r.Method != http.MethodGet {
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Accept"))
+	if mediaType == "text/event-stream" {
+		return true
+	}
+	return r.URL.Query().Get("follow") == "true" && taskLogsPathPattern.MatchString(r.URL.Path)
+}
+
 func configureCORSMiddleware(cfg *CORSConfig) func(http.Handler) http.Handler {
 	if !cfg.Enabled {
 		return func(next http.Handler) http.Handler {
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abikandiah/task-worker/internal/mock"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// Request spans continue the caller's traceparent and are named by route
+func TestTracingMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+		provider.Shutdown(context.Background())
+	})
+
+	testServer, _ := newTestServer(t, mock.NewMockRepo())
+	request := newTestRequest(t, http.MethodGet, testServer.URL+"/api/v1/jobs/"+uuid.NewString(), "")
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("GET job: %v", err)
+	}
+	response.Body.Close()
+
+	var span sdktrace.ReadOnlySpan
+	for _, ended := range recorder.Ended() {
+		if ended.Name() == "GET /api/v1/jobs/{id}" {
+			span = ended
+		}
+	}
+	if span == nil {
+		t.Fatalf("no span of the job route among %d spans", len(recorder.Ended()))
+	}
+	if traceID := span.SpanContext().TraceID().String(); traceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace = %s, want the traceparent's", traceID)
+	}
+	if parentID := span.Parent().SpanID().String(); parentID != "00f067aa0ba902b7" {
+		t.Errorf("parent = %s, want the traceparent's", parentID)
+	}
+	for _, attr := range span.Attributes() {
+		if attr.Key == semconv.HTTPResponseStatusCodeKey && attr.Value.AsInt64() != int64(response.StatusCode) {
+			t.Errorf("status code attribute = %d, want %d", attr.Value.AsInt64(), response.StatusCode)
+		}
+	}
+}
+
+func TestIsStreamRequest(t *testing.T) {
+	logsPath := "/api/v1/jobs/" + uuid.NewString() + "/tasks/" + uuid.NewString() + "/logs"
+	tests := []struct {
+		name   string
+		method string
+		target string
+		accept string
+		want   bool
+	}{
+		{"event stream", http.MethodGet, "/api/v1/events", "text/event-stream", true},
+		{"json", http.MethodGet, "/api/v1/jobs", "application/json", false},
+		{"following task logs", http.MethodGet, logsPath + "?follow=true", "", true},
+		{"task logs page", http.MethodGet, logsPath, "", false},
+		// Other routes keep the timeout, whatever their query
+		{"follow on another route", http.MethodGet, "/api/v1/jobs?follow=true", "", false},
+		{"follow on a job", http.MethodGet, "/api/v1/jobs/" + uuid.NewString() + "?follow=true", "", false},
+		{"post", http.MethodPost, logsPath + "?follow=true", "text/event-stream", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(test.method, test.target, nil)
+			if test.accept != "" {
+				r.Header.Set("Accept", test.accept)
+			}
+			if got := isStreamRequest(r); got != test.want {
+				t.Errorf("isStreamRequest(%s %s) = %t, want %t", test.method, test.target, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// Get the depth of every queue and the live workers serving it
+func (server *Server) handleGetQueues(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	queues, err := server.jobService.GetQueues(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get queues", slog.Any("error", err))
+		server.respondError(w, http.StatusInternalServerError, "failed to get queues")
+		return
+	}
+
+	server.respondJSON(w, http.StatusOK, queues)
+}
@@ -48,6 +48,9 @@ func (server *Server) rateLimitMiddleware(next http.Handler) http.Handler {
 
 		limiter := server.limiter.getLimiter(ip)
 		if !limiter.Allow() {
+			if server.metrics != nil {
+				server.metrics.RateLimited()
+			}
 			server.respondError(w, http.StatusTooManyRequests, "Rate limit exceeded")
 			return
 		}
@@ -3,8 +3,14 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/abikandiah/task-worker/internal/domain"
 	"github.com/go-chi/chi/v5"
@@ -22,11 +28,14 @@ func (server *Server) updateRequestContextWithID(idKey string, logKey domain.Log
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			id := parseUUIDOrDefault(chi.URLParam(r, idKey))
 
-			if id != uuid.Nil {
-				// Store ID in context
-				ctx := context.WithValue(r.Context(), logKey, id)
-				next.ServeHTTP(w, r.WithContext(ctx))
+			if id == uuid.Nil {
+				server.respondError(w, http.StatusBadRequest, "invalid "+idKey)
+				return
 			}
+
+			// Store ID in context
+			ctx := context.WithValue(r.Context(), logKey, id)
+			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
@@ -62,3 +71,58 @@ func parseUUIDOrDefault(s string) uuid.UUID {
 	}
 	return id
 }
+
+// cursorParams are the query parameters of list requests that are not filters
+var cursorParams = []string{"after", "before", "limit", "sortField", "sortDir", "token"}
+
+// parseCursorInput reads the pagination query parameters of a list request, sortField and sortDir
+// take comma separated lists, e.g. sortField=state,submit_date&sortDir=ASC,DESC. Other parameters are
+// filters, e.g. state=RUNNING,ERROR, name~report or submitDate>=2026-01-01.
+func parseCursorInput(r *http.Request) (*domain.CursorInput, error) {
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	input := &domain.CursorInput{
+		After:  query.Get("after"),
+		Before: query.Get("before"),
+		Limit:  limit,
+		Sort:   domain.ParseSort(query.Get("sortField"), query.Get("sortDir")),
+	}
+	input.SetDefaults()
+
+	// Operators other than = are not key=value pairs, so filters are read from the raw query. They are
+	// unescaped once without form decoding, so the + of a time offset is not read as a space.
+	for _, param := range strings.Split(r.URL.RawQuery, "&") {
+		expr, err := url.PathUnescape(param)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q is not escaped correctly", domain.ErrInvalidQuery, param)
+		}
+		if expr == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(expr, "=")
+		if slices.Contains(cursorParams, name) {
+			continue
+		}
+
+		filter, err := domain.ParseFilter(expr)
+		if err != nil {
+			return nil, err
+		}
+		input.Filters = append(input.Filters, filter)
+	}
+	return input, nil
+}
+
+// respondListError responds to a failed list request, invalid filters and sorts are the client's
+func (server *Server) respondListError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	ctx := r.Context()
+
+	if errors.Is(err, domain.ErrInvalidQuery) {
+		slog.WarnContext(ctx, "invalid list request", slog.Any("error", err))
+		server.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	slog.ErrorContext(ctx, message, slog.Any("error", err))
+	server.respondError(w, http.StatusInternalServerError, message)
+}
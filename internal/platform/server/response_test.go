@@ -0,0 +1,44 @@
+package server
+
+import (
+	"errors"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+)
+
+func TestParseCursorInputFilters(t *testing.T) {
+	tests := []struct {
+		query      string
+		wantValues []string
+		wantErr    error
+	}{
+		{"submitDate>=2026-01-01T00:00:00+02:00", []string{"2026-01-01T00:00:00+02:00"}, nil},
+		{"submitDate>=2026-01-01T00:00:00%2B02:00", []string{"2026-01-01T00:00:00+02:00"}, nil},
+		{"name~daily%20report", []string{"daily report"}, nil},
+		{"name~100%25", []string{"100%"}, nil},
+		{"state=RUNNING,ERROR&limit=10&sortField=name", []string{"RUNNING", "ERROR"}, nil},
+		{"name~%zz", nil, domain.ErrInvalidQuery},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/v1/jobs?"+test.query, nil)
+			input, err := parseCursorInput(r)
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Fatalf("parseCursorInput error = %v, want %v", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseCursorInput: %v", err)
+			}
+			if len(input.Filters) != 1 || !slices.Equal(input.Filters[0].Values, test.wantValues) {
+				t.Errorf("filters = %+v, want one with values %q", input.Filters, test.wantValues)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// Get the ended jobs that retention would purge now, without deleting them
+func (server *Server) handleRetentionDryRun(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	report, err := server.jobService.PurgeJobs(ctx, true)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to evaluate retention", slog.Any("error", err))
+		server.respondError(w, http.StatusInternalServerError, "failed to evaluate retention")
+		return
+	}
+
+	server.respondJSON(w, http.StatusOK, report)
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/abikandiah/task-worker/internal/platform/metrics"
 	"github.com/abikandiah/task-worker/internal/service"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -18,16 +19,21 @@ type Server struct {
 	router  *chi.Mux
 	limiter *rateLimiter
 	apiKeys map[string]struct{}
+	// closed on shutdown so long-lived streams end instead of holding it open
+	shutdownCh chan struct{}
 }
 
 type serverDepedencies struct {
 	serverConfig *Config
 	jobService   *service.JobService
+	metrics      *metrics.Metrics
 }
 
 type ServerParams struct {
 	ServerConfig *Config
 	JobService   *service.JobService
+	// Metrics observes requests, nothing is observed when nil
+	Metrics *metrics.Metrics
 }
 
 func NewServer(deps *ServerParams) *http.Server {
@@ -35,10 +41,12 @@ func NewServer(deps *ServerParams) *http.Server {
 		serverDepedencies: &serverDepedencies{
 			serverConfig: deps.ServerConfig,
 			jobService:   deps.JobService,
+			metrics:      deps.Metrics,
 		},
-		router:  chi.NewRouter(),
-		limiter: newRateLimiter(deps.ServerConfig.RateLimit),
-		apiKeys: make(map[string]struct{}),
+		router:     chi.NewRouter(),
+		limiter:    newRateLimiter(deps.ServerConfig.RateLimit),
+		apiKeys:    make(map[string]struct{}),
+		shutdownCh: make(chan struct{}),
 	}
 
 	// Load API key with validation
@@ -70,6 +78,10 @@ func NewServer(deps *ServerParams) *http.Server {
 		ErrorLog:          errorLog,
 	}
 
+	httpServer.RegisterOnShutdown(func() {
+		close(server.shutdownCh)
+	})
+
 	slog.Info("server initialized", slog.Any("server_config", config))
 
 	server.printRoutes("")
@@ -87,6 +99,8 @@ func (server *Server) setupMiddleware() {
 	server.router.Use(middleware.RequestID)
 	server.router.Use(middleware.RealIP)
 	server.router.Use(middleware.StripSlashes)
+	server.router.Use(server.metricsMiddleware)
+	server.router.Use(tracingMiddleware)
 
 	// Custom middleware
 	server.router.Use(server.loggerMiddleware)
@@ -94,8 +108,8 @@ func (server *Server) setupMiddleware() {
 	server.router.Use(server.rateLimitMiddleware)
 	server.router.Use(server.contentTypeMiddleware)
 
-	// Timeout middleware
-	server.router.Use(middleware.Timeout(server.serverConfig.Timeout * time.Second))
+	// Timeout middleware, long-lived streams are exempt
+	server.router.Use(server.timeoutMiddleware(server.serverConfig.Timeout * time.Second))
 }
 
 // Set up API routes
@@ -106,8 +120,16 @@ func (server *Server) setupRoutes() {
 		// Authenticated routes
 		r.Use(server.authenticateMiddleware)
 
+		r.Get("/events", server.handleEvents)
+		r.Get("/ws", server.handleWebSocket)
+		r.Get("/workers", server.handleGetWorkers)
+		r.Get("/queues", server.handleGetQueues)
+		r.Get("/stats", server.handleGetStats)
+		r.Get("/retention/dry-run", server.handleRetentionDryRun)
+		r.Post("/jobs:batch", server.handleSubmitJobBatch)
 		r.Route("/jobs", server.setupJobRoutes())
-		r.Route("/jobs/configs/", server.setupJobConfigRoutes())
+		r.Route("/templates", server.setupTemplateRoutes())
+		r.Route("/tasks", server.setupTaskRunRoutes())
 	})
 }
 
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/service"
+)
+
+// Get the job and task statistics of ?from= to ?to=, RFC 3339 times or dates, by default the last day
+func (server *Server) handleGetStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	from, err := parseStatsTime(query.Get("from"))
+	if err != nil {
+		server.respondError(w, http.StatusBadRequest, "from must be an RFC 3339 time or a date")
+		return
+	}
+	to, err := parseStatsTime(query.Get("to"))
+	if err != nil {
+		server.respondError(w, http.StatusBadRequest, "to must be an RFC 3339 time or a date")
+		return
+	}
+
+	stats, err := server.jobService.GetStats(ctx, domain.StatsRange{From: from, To: to})
+	if errors.Is(err, service.ErrInvalidStatsRange) {
+		server.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get stats", slog.Any("error", err))
+		server.respondError(w, http.StatusInternalServerError, "failed to get stats")
+		return
+	}
+
+	server.respondJSON(w, http.StatusOK, stats)
+}
+
+// parseStatsTime parses a bound of the stats range, the zero time if not given
+func parseStatsTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return domain.ParseFilterTime(value)
+}
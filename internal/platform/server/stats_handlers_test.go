@@ -0,0 +1,33 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/mock"
+)
+
+func TestGetStats(t *testing.T) {
+	repo := mock.NewMockRepo()
+	testServer, _ := newTestServer(t, repo)
+	statsURL := testServer.URL + "/api/v1/stats"
+
+	// The range defaults to the last day
+	var stats domain.Stats
+	getTestJSON(t, statsURL, http.StatusOK, &stats)
+	if span := stats.To.Sub(stats.From); span != 24*time.Hour {
+		t.Errorf("default range spans %s, want 24h", span)
+	}
+
+	var dated domain.Stats
+	getTestJSON(t, statsURL+"?from=2026-01-01&to=2026-01-02", http.StatusOK, &dated)
+	if want := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC); !dated.From.Equal(want) {
+		t.Errorf("from = %s, want %s", dated.From, want)
+	}
+
+	for _, query := range []string{"?from=yesterday", "?to=2026-13-01", "?from=2026-01-02&to=2026-01-01"} {
+		getTestJSON(t, statsURL+query, http.StatusBadRequest, nil)
+	}
+}
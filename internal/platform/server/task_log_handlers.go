@@ -0,0 +1,154 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultTaskLogLimit = 100
+	maxTaskLogLimit     = 1000
+	// taskLogFollowInterval is how often follow mode polls for new logs
+	taskLogFollowInterval = 1 * time.Second
+)
+
+type TaskLogsResponse struct {
+	Data []domain.TaskLog `json:"data"`
+	// NextSeq is passed as afterSeq to get the following page
+	NextSeq int64 `json:"nextSeq"`
+}
+
+// Get logs of a TaskRun. With follow=true, logs are streamed as Server-Sent Events until
+// the TaskRun has ended and every log has been sent.
+func (server *Server) handleGetTaskLogs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	jobID := parseUUIDOrDefault(chi.URLParam(r, "id"))
+	taskRunID := parseUUIDOrDefault(chi.URLParam(r, "taskId"))
+
+	if jobID == uuid.Nil || taskRunID == uuid.Nil {
+		server.respondError(w, http.StatusBadRequest, "job ID and task ID are required")
+		return
+	}
+
+	afterSeq, _ := strconv.ParseInt(query.Get("afterSeq"), 10, 64)
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if limit <= 0 {
+		limit = defaultTaskLogLimit
+	}
+	limit = min(limit, maxTaskLogLimit)
+
+	taskRun, err := server.jobService.GetTaskRun(ctx, taskRunID)
+	if err != nil || taskRun == nil || taskRun.JobID != jobID {
+		slog.WarnContext(ctx, "failed to get task run", slog.Any("error", err))
+		server.respondError(w, http.StatusNotFound, "task not found")
+		return
+	}
+
+	if query.Get("follow") == "true" {
+		// EventSource reconnects with the last seq it received
+		if header := r.Header.Get("Last-Event-ID"); header != "" {
+			afterSeq, _ = strconv.ParseInt(header, 10, 64)
+		}
+		server.followTaskLogs(w, r, taskRunID, afterSeq, limit)
+		return
+	}
+
+	taskLogs, err := server.jobService.GetTaskLogs(ctx, taskRunID, afterSeq, limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get task logs", slog.Any("error", err))
+		server.respondError(w, http.StatusInternalServerError, "failed to get task logs")
+		return
+	}
+
+	nextSeq := afterSeq
+	if len(taskLogs) > 0 {
+		nextSeq = taskLogs[len(taskLogs)-1].Seq
+	}
+
+	server.respondJSON(w, http.StatusOK, TaskLogsResponse{
+		Data:    taskLogs,
+		NextSeq: nextSeq,
+	})
+}
+
+func (server *Server) followTaskLogs(w http.ResponseWriter, r *http.Request, taskRunID uuid.UUID, afterSeq int64, limit int) {
+	ctx := r.Context()
+
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		slog.WarnContext(ctx, "failed to clear write deadline for task log stream", slog.Any("error", err))
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	ticker := time.NewTicker(taskLogFollowInterval)
+	defer ticker.Stop()
+	lastWrite := time.Now()
+
+	for {
+		// Check the state before reading logs, so logs written before the end are not missed
+		taskRun, err := server.jobService.GetTaskRun(ctx, taskRunID)
+		if err != nil || taskRun == nil {
+			slog.ErrorContext(ctx, "failed to get task run", slog.Any("error", err))
+			return
+		}
+		ended := taskRun.State != domain.StatePending && taskRun.State != domain.StateRunning
+
+		for {
+			taskLogs, err := server.jobService.GetTaskLogs(ctx, taskRunID, afterSeq, limit)
+			if err != nil {
+				slog.ErrorContext(ctx, "failed to get task logs", slog.Any("error", err))
+				return
+			}
+			for _, taskLog := range taskLogs {
+				if err := writeEvent(w, strconv.FormatInt(taskLog.Seq, 10), "log", taskLog); err != nil {
+					return
+				}
+				afterSeq = taskLog.Seq
+				lastWrite = time.Now()
+			}
+			if len(taskLogs) < limit {
+				break
+			}
+		}
+
+		if ended {
+			// Logs are written asynchronously, allow one more flush before ending
+			if time.Since(lastWrite) > 2*taskLogFollowInterval {
+				writeEvent(w, "", "end", taskRun.Status())
+				rc.Flush()
+				return
+			}
+		} else if time.Since(lastWrite) >= server.serverConfig.EventHeartbeat {
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			lastWrite = time.Now()
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-server.shutdownCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
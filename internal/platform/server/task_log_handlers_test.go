@@ -0,0 +1,127 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/mock"
+	"github.com/google/uuid"
+)
+
+// saveTestTaskLogs saves a TaskRun in state with the given log messages
+func saveTestTaskLogs(t *testing.T, repo *mock.MockRepo, state domain.ExecutionState, messages ...string) *domain.TaskRun {
+	t.Helper()
+	ctx := context.Background()
+
+	taskRun, err := repo.SaveTaskRun(ctx, domain.TaskRun{JobID: uuid.New(), TaskName: "noop", State: state})
+	if err != nil {
+		t.Fatalf("SaveTaskRun: %v", err)
+	}
+
+	taskLogs := make([]domain.TaskLog, len(messages))
+	for i, message := range messages {
+		taskLogs[i] = domain.TaskLog{JobID: taskRun.JobID, TaskRunID: taskRun.ID, Level: "INFO", Message: message}
+	}
+	if err := repo.SaveTaskLogs(ctx, taskLogs); err != nil {
+		t.Fatalf("SaveTaskLogs: %v", err)
+	}
+	return taskRun
+}
+
+func taskLogsPath(taskRun *domain.TaskRun) string {
+	return "/api/v1/jobs/" + taskRun.JobID.String() + "/tasks/" + taskRun.ID.String() + "/logs"
+}
+
+func TestGetTaskLogs(t *testing.T) {
+	repo := mock.NewMockRepo()
+	testServer, _ := newTestServer(t, repo)
+	taskRun := saveTestTaskLogs(t, repo, domain.StateRunning, "one", "two", "three")
+
+	getPage := func(t *testing.T, query string) TaskLogsResponse {
+		t.Helper()
+
+		response, err := http.DefaultClient.Do(newTestRequest(t, http.MethodGet, testServer.URL+taskLogsPath(taskRun)+query, ""))
+		if err != nil {
+			t.Fatalf("GET logs: %v", err)
+		}
+		defer response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			t.Fatalf("GET logs%s = %d, want %d", query, response.StatusCode, http.StatusOK)
+		}
+
+		var page TaskLogsResponse
+		if err := json.NewDecoder(response.Body).Decode(&page); err != nil {
+			t.Fatalf("decode logs: %v", err)
+		}
+		return page
+	}
+
+	first := getPage(t, "?limit=2")
+	if len(first.Data) != 2 || first.Data[0].Message != "one" || first.NextSeq != first.Data[1].Seq {
+		t.Fatalf("first page = %+v, want logs one and two", first)
+	}
+
+	second := getPage(t, "?limit=2&afterSeq="+strconv.FormatInt(first.NextSeq, 10))
+	if len(second.Data) != 1 || second.Data[0].Message != "three" {
+		t.Fatalf("second page = %+v, want log three", second)
+	}
+
+	// An empty page keeps the cursor
+	last := getPage(t, "?afterSeq="+strconv.FormatInt(second.NextSeq, 10))
+	if len(last.Data) != 0 || last.NextSeq != second.NextSeq {
+		t.Errorf("last page = %+v, want no logs and nextSeq %d", last, second.NextSeq)
+	}
+
+	t.Run("task of another job", func(t *testing.T) {
+		path := "/api/v1/jobs/" + uuid.NewString() + "/tasks/" + taskRun.ID.String() + "/logs"
+		response, err := http.DefaultClient.Do(newTestRequest(t, http.MethodGet, testServer.URL+path, ""))
+		if err != nil {
+			t.Fatalf("GET logs: %v", err)
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusNotFound {
+			t.Errorf("status = %d, want %d", response.StatusCode, http.StatusNotFound)
+		}
+	})
+}
+
+// Following the logs of an ended TaskRun streams them, then ends with its status
+func TestFollowTaskLogs(t *testing.T) {
+	repo := mock.NewMockRepo()
+	testServer, _ := newTestServer(t, repo)
+	taskRun := saveTestTaskLogs(t, repo, domain.StateFinished, "one", "two", "three")
+
+	request := newTestRequest(t, http.MethodGet, testServer.URL+taskLogsPath(taskRun)+"?follow=true", "")
+	request.Header.Set("Last-Event-ID", "1")
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("GET logs: %v", err)
+	}
+	defer response.Body.Close()
+	stream := bufio.NewReader(response.Body)
+
+	for _, want := range []string{"two", "three"} {
+		event := readSSEEvent(t, stream)
+		var taskLog domain.TaskLog
+		if err := json.Unmarshal([]byte(event.Data), &taskLog); err != nil {
+			t.Fatalf("unmarshal log: %v", err)
+		}
+		if event.Event != "log" || event.ID != strconv.FormatInt(taskLog.Seq, 10) || taskLog.Message != want {
+			t.Fatalf("event = %+v, want log %s resuming after Last-Event-ID", event, want)
+		}
+	}
+
+	end := readSSEEvent(t, stream)
+	var status domain.Status
+	if err := json.Unmarshal([]byte(end.Data), &status); err != nil {
+		t.Fatalf("unmarshal status: %v", err)
+	}
+	if end.Event != "end" || status.State != domain.StateFinished {
+		t.Errorf("last event = %+v, want end with state %s", end, domain.StateFinished)
+	}
+}
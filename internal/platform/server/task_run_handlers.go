@@ -0,0 +1,89 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func (server *Server) setupTaskRunRoutes() func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Get("/", server.handleGetTaskRuns)
+
+		r.Route("/{taskId}", func(r chi.Router) {
+			r.Use(server.updateRequestContextWithID("taskId", domain.LKeys.TaskID))
+
+			r.Get("/", server.handleGetTaskRun)
+		})
+	}
+}
+
+// Get TaskRuns of every job, e.g. filtered by ?taskName= and ?state=
+func (server *Server) handleGetTaskRuns(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	input, err := parseCursorInput(r)
+	if err != nil {
+		server.respondListError(w, r, err, "failed to get tasks")
+		return
+	}
+
+	res, err := server.jobService.GetAllTaskRuns(ctx, input)
+	if err != nil {
+		server.respondListError(w, r, err, "failed to get tasks")
+		return
+	}
+
+	server.respondJSON(w, http.StatusOK, res)
+}
+
+// Get TaskRun by ID
+func (server *Server) handleGetTaskRun(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	taskRunID := parseUUIDOrDefault(chi.URLParam(r, "taskId"))
+
+	taskRun, err := server.jobService.GetTaskRun(ctx, taskRunID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get task run", slog.Any("error", err))
+		server.respondError(w, http.StatusInternalServerError, "failed to get task")
+		return
+	}
+	if taskRun == nil {
+		server.respondError(w, http.StatusNotFound, "task not found")
+		return
+	}
+
+	server.respondJSON(w, http.StatusOK, taskRun)
+}
+
+// Get the TaskRuns of a Job
+func (server *Server) handleGetJobTaskRuns(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	jobID := parseUUIDOrDefault(chi.URLParam(r, "id"))
+
+	if jobID == uuid.Nil {
+		server.respondError(w, http.StatusBadRequest, "job ID is required")
+		return
+	}
+
+	job, err := server.jobService.GetJob(ctx, jobID)
+	if err != nil || job == nil {
+		slog.WarnContext(ctx, "failed to get job", slog.Any("error", err))
+		server.respondError(w, http.StatusNotFound, "job not found")
+		return
+	}
+
+	taskRuns, err := server.jobService.GetTaskRuns(ctx, jobID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get job task runs", slog.Any("error", err))
+		server.respondError(w, http.StatusInternalServerError, "failed to get tasks")
+		return
+	}
+
+	server.respondJSON(w, http.StatusOK, taskRuns)
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/mock"
+	"github.com/google/uuid"
+)
+
+// getTestJSON GETs the URL, checks the status and decodes the body into v, when given
+func getTestJSON(t *testing.T, url string, wantStatus int, v any) {
+	t.Helper()
+
+	response, err := http.DefaultClient.Do(newTestRequest(t, http.MethodGet, url, ""))
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != wantStatus {
+		t.Fatalf("GET %s = %d, want %d", url, response.StatusCode, wantStatus)
+	}
+	if v != nil {
+		if err := json.NewDecoder(response.Body).Decode(v); err != nil {
+			t.Fatalf("decode %s: %v", url, err)
+		}
+	}
+}
+
+func TestTaskRunEndpoints(t *testing.T) {
+	repo := mock.NewMockRepo()
+	testServer, _ := newTestServer(t, repo)
+	ctx := context.Background()
+
+	job := saveTestJob(t, repo)
+	taskRuns, err := repo.SaveTaskRuns(ctx, []domain.TaskRun{
+		{JobID: job.ID, TaskName: "email", State: domain.StateFinished},
+		{JobID: job.ID, TaskName: "email", State: domain.StateError},
+		{JobID: uuid.New(), TaskName: "report", State: domain.StateFinished},
+	})
+	if err != nil {
+		t.Fatalf("SaveTaskRuns: %v", err)
+	}
+	tasksURL := testServer.URL + "/api/v1/tasks"
+
+	t.Run("filters", func(t *testing.T) {
+		tests := []struct {
+			query string
+			want  int
+		}{
+			{"", 3},
+			{"?taskName=email", 2},
+			{"?state=finished", 2},
+			{"?taskName=email&state=ERROR", 1},
+		}
+
+		for _, test := range tests {
+			var page domain.CursorOutput[domain.TaskRun]
+			getTestJSON(t, tasksURL+test.query, http.StatusOK, &page)
+			if len(page.Data) != test.want {
+				t.Errorf("GET /tasks%s = %d task runs, want %d", test.query, len(page.Data), test.want)
+			}
+		}
+
+		getTestJSON(t, tasksURL+"?state=done", http.StatusBadRequest, nil)
+	})
+
+	t.Run("task run", func(t *testing.T) {
+		var taskRun domain.TaskRun
+		getTestJSON(t, tasksURL+"/"+taskRuns[1].ID.String(), http.StatusOK, &taskRun)
+		if taskRun.ID != taskRuns[1].ID || taskRun.State != domain.StateError {
+			t.Errorf("task run = %+v, want %s", taskRun, taskRuns[1].ID)
+		}
+
+		getTestJSON(t, tasksURL+"/"+uuid.NewString(), http.StatusNotFound, nil)
+	})
+
+	t.Run("job task runs", func(t *testing.T) {
+		var jobTaskRuns []domain.TaskRun
+		getTestJSON(t, testServer.URL+"/api/v1/jobs/"+job.ID.String()+"/tasks", http.StatusOK, &jobTaskRuns)
+		if len(jobTaskRuns) != 2 {
+			t.Errorf("job task runs = %d, want 2", len(jobTaskRuns))
+		}
+
+		getTestJSON(t, testServer.URL+"/api/v1/jobs/"+uuid.NewString()+"/tasks", http.StatusNotFound, nil)
+	})
+}
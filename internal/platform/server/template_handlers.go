@@ -0,0 +1,166 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/service"
+	"github.com/go-chi/chi/v5"
+)
+
+func (server *Server) setupTemplateRoutes() func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Get("/", server.handleGetJobTemplates)
+		r.Post("/", server.handleCreateJobTemplate)
+
+		r.Route("/{id}", func(r chi.Router) {
+			r.Use(server.updateRequestContextWithID("id", domain.LKeys.TemplateID))
+
+			r.Get("/", server.handleGetJobTemplate)
+			r.Put("/", server.handleUpdateJobTemplate)
+			r.Get("/versions", server.handleGetJobTemplateVersions)
+			r.Post("/instantiate", server.handleInstantiateJobTemplate)
+		})
+	}
+}
+
+// Get the latest version of every template
+func (server *Server) handleGetJobTemplates(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	input, err := parseCursorInput(r)
+	if err != nil {
+		server.respondListError(w, r, err, "failed to get templates")
+		return
+	}
+
+	res, err := server.jobService.GetAllJobTemplates(ctx, input)
+	if err != nil {
+		server.respondListError(w, r, err, "failed to get templates")
+		return
+	}
+
+	server.respondJSON(w, http.StatusOK, res)
+}
+
+// Create a template
+func (server *Server) handleCreateJobTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var submission domain.JobTemplateSubmission
+	if err := json.NewDecoder(r.Body).Decode(&submission); err != nil {
+		slog.WarnContext(ctx, "failed to decode template request", slog.Any("error", err))
+		server.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	template, err := server.jobService.CreateJobTemplate(ctx, &submission)
+	if err != nil {
+		server.respondTemplateError(w, r, err)
+		return
+	}
+
+	slog.InfoContext(ctx, "template created", slog.Any("templateId", template.ID))
+	server.respondJSON(w, http.StatusCreated, template)
+}
+
+// Get a template by ID, the latest version unless ?version= is given
+func (server *Server) handleGetJobTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	templateID := parseUUIDOrDefault(chi.URLParam(r, "id"))
+	version := parseUUIDOrDefault(r.URL.Query().Get("version"))
+
+	template, err := server.jobService.GetJobTemplate(ctx, templateID, version)
+	if err != nil {
+		server.respondTemplateError(w, r, err)
+		return
+	}
+
+	server.respondJSON(w, http.StatusOK, template)
+}
+
+// Save a new version of a template
+func (server *Server) handleUpdateJobTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	templateID := parseUUIDOrDefault(chi.URLParam(r, "id"))
+
+	var submission domain.JobTemplateSubmission
+	if err := json.NewDecoder(r.Body).Decode(&submission); err != nil {
+		slog.WarnContext(ctx, "failed to decode template request", slog.Any("error", err))
+		server.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	template, err := server.jobService.UpdateJobTemplate(ctx, templateID, &submission)
+	if err != nil {
+		server.respondTemplateError(w, r, err)
+		return
+	}
+
+	slog.InfoContext(ctx, "template updated", slog.Any("version", template.Version))
+	server.respondJSON(w, http.StatusOK, template)
+}
+
+// Get every version of a template, newest first
+func (server *Server) handleGetJobTemplateVersions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	templateID := parseUUIDOrDefault(chi.URLParam(r, "id"))
+
+	versions, err := server.jobService.GetJobTemplateVersions(ctx, templateID)
+	if err != nil {
+		server.respondTemplateError(w, r, err)
+		return
+	}
+
+	server.respondJSON(w, http.StatusOK, versions)
+}
+
+// Submit a job from a template
+func (server *Server) handleInstantiateJobTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	templateID := parseUUIDOrDefault(chi.URLParam(r, "id"))
+
+	var instantiation domain.TemplateInstantiation
+	if err := json.NewDecoder(r.Body).Decode(&instantiation); err != nil {
+		slog.WarnContext(ctx, "failed to decode instantiate request", slog.Any("error", err))
+		server.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	job, err := server.jobService.InstantiateTemplate(ctx, templateID, &instantiation)
+	if errors.Is(err, service.ErrConcurrencyConflict) {
+		slog.WarnContext(ctx, "job submission rejected", slog.Any("error", err))
+		server.respondError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		server.respondTemplateError(w, r, err)
+		return
+	}
+
+	slog.InfoContext(ctx, "job instantiated from template", slog.Any("jobId", job.ID))
+	server.respondJSON(w, http.StatusCreated, job)
+}
+
+// respondTemplateError maps template service errors to responses
+func (server *Server) respondTemplateError(w http.ResponseWriter, r *http.Request, err error) {
+	ctx := r.Context()
+
+	switch {
+	case errors.Is(err, service.ErrTemplateNotFound):
+		server.respondError(w, http.StatusNotFound, "template not found")
+	case errors.Is(err, service.ErrInvalidTemplate), errors.Is(err, service.ErrInvalidSubmission):
+		slog.WarnContext(ctx, "invalid template request", slog.Any("error", err))
+		server.respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		slog.ErrorContext(ctx, "failed to handle template request", slog.Any("error", err))
+		server.respondError(w, http.StatusInternalServerError, "failed to handle template request")
+	}
+}
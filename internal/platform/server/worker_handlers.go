@@ -0,0 +1,20 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// Get all registered workers, live and dead
+func (server *Server) handleGetWorkers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	workers, err := server.jobService.GetWorkers(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get workers", slog.Any("error", err))
+		server.respondError(w, http.StatusInternalServerError, "failed to get workers")
+		return
+	}
+
+	server.respondJSON(w, http.StatusOK, workers)
+}
@@ -0,0 +1,278 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/service"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// wsEventBuffer is the per-connection subscription buffer. Events beyond it are dropped
+	// and the client is told how many it missed.
+	wsEventBuffer = 512
+	// wsWriteWait is how long a write may block before the connection is considered dead
+	wsWriteWait = 10 * time.Second
+	// wsPongWait is how long to wait for a pong before the connection is considered dead
+	wsPongWait   = 60 * time.Second
+	wsPingPeriod = wsPongWait * 9 / 10
+
+	wsMaxMessageSize   = 64 << 10
+	wsMaxSubscriptions = 100
+)
+
+// Client messages
+const (
+	wsSubscribe   = "subscribe"
+	wsUnsubscribe = "unsubscribe"
+)
+
+// Server messages
+const (
+	wsSubscribed   = "subscribed"
+	wsUnsubscribed = "unsubscribed"
+	wsEvent        = "event"
+	wsDropped      = "dropped"
+	wsError        = "error"
+)
+
+// wsClientMessage subscribes to or unsubscribes from events. A subscription matches events
+// whose job is in JobIDs, whose new state is in States and whose job has any of Tags; empty
+// lists match everything.
+type wsClientMessage struct {
+	Type   string                  `json:"type"`
+	ID     string                  `json:"id"`
+	JobIDs []uuid.UUID             `json:"jobIds,omitempty"`
+	States []domain.ExecutionState `json:"states,omitempty"`
+	Tags   []string                `json:"tags,omitempty"`
+}
+
+type wsServerMessage struct {
+	Type string `json:"type"`
+	ID   string `json:"id,omitempty"`
+	// Subscriptions lists the IDs of the subscriptions an event matched
+	Subscriptions []string       `json:"subscriptions,omitempty"`
+	Event         *service.Event `json:"event,omitempty"`
+	Dropped       uint64         `json:"dropped,omitempty"`
+	Message       string         `json:"message,omitempty"`
+}
+
+type wsSubscription struct {
+	jobIDs map[uuid.UUID]struct{}
+	states []domain.ExecutionState
+	tags   []string
+}
+
+func (sub *wsSubscription) matches(event service.Event) bool {
+	if len(sub.jobIDs) > 0 {
+		if _, ok := sub.jobIDs[event.JobID]; !ok {
+			return false
+		}
+	}
+	if len(sub.states) > 0 && !slices.Contains(sub.states, event.After.State) {
+		return false
+	}
+	if len(sub.tags) > 0 && !slices.ContainsFunc(sub.tags, func(tag string) bool {
+		return slices.Contains(event.Tags, tag)
+	}) {
+		return false
+	}
+	return true
+}
+
+// wsConnection holds the subscriptions of one WebSocket client
+type wsConnection struct {
+	mu            sync.RWMutex
+	subscriptions map[string]*wsSubscription
+}
+
+// matching returns the IDs of the subscriptions that match the event
+func (conn *wsConnection) matching(event service.Event) []string {
+	conn.mu.RLock()
+	defer conn.mu.RUnlock()
+
+	var ids []string
+	for id, sub := range conn.subscriptions {
+		if sub.matches(event) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func (conn *wsConnection) handle(message wsClientMessage) wsServerMessage {
+	if message.ID == "" {
+		return wsServerMessage{Type: wsError, Message: "subscription id is required"}
+	}
+
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	switch message.Type {
+	case wsSubscribe:
+		if _, exists := conn.subscriptions[message.ID]; !exists && len(conn.subscriptions) >= wsMaxSubscriptions {
+			return wsServerMessage{Type: wsError, ID: message.ID, Message: fmt.Sprintf("at most %d subscriptions are allowed", wsMaxSubscriptions)}
+		}
+
+		sub := &wsSubscription{
+			jobIDs: make(map[uuid.UUID]struct{}, len(message.JobIDs)),
+			states: message.States,
+			tags:   message.Tags,
+		}
+		for _, jobID := range message.JobIDs {
+			sub.jobIDs[jobID] = struct{}{}
+		}
+		// Subscribing again with the same ID replaces the filter
+		conn.subscriptions[message.ID] = sub
+		return wsServerMessage{Type: wsSubscribed, ID: message.ID}
+
+	case wsUnsubscribe:
+		delete(conn.subscriptions, message.ID)
+		return wsServerMessage{Type: wsUnsubscribed, ID: message.ID}
+
+	default:
+		return wsServerMessage{Type: wsError, ID: message.ID, Message: fmt.Sprintf("unknown message type %q", message.Type)}
+	}
+}
+
+func (server *Server) wsUpgrader() *websocket.Upgrader {
+	upgrader := &websocket.Upgrader{
+		ReadBufferSize:  4096,
+		WriteBufferSize: 4096,
+	}
+
+	// Follow the CORS config for cross-origin dashboards, otherwise only same-origin is allowed
+	if cors := server.serverConfig.Cors; cors != nil && cors.Enabled {
+		upgrader.CheckOrigin = func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			return origin == "" || slices.Contains(cors.AllowedOrigins, "*") || slices.Contains(cors.AllowedOrigins, origin)
+		}
+	}
+	return upgrader
+}
+
+// WebSocket for subscribing to job and task events
+func (server *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	ws, err := server.wsUpgrader().Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already responded to the client
+		slog.WarnContext(ctx, "failed to upgrade websocket", slog.Any("error", err))
+		return
+	}
+	defer ws.Close()
+
+	conn := &wsConnection{
+		subscriptions: make(map[string]*wsSubscription),
+	}
+
+	// Only events matching a subscription are buffered, so quiet subscriptions cost nothing
+	sub := server.jobService.Events().Subscribe(wsEventBuffer, func(event service.Event) bool {
+		return len(conn.matching(event)) > 0
+	})
+	defer sub.Close()
+
+	// Replies to client messages are handed to the writer, the only goroutine writing to ws
+	replies := make(chan wsServerMessage, 16)
+	readDone := make(chan struct{})
+	writeDone := make(chan struct{})
+	defer close(writeDone)
+	go func() {
+		defer close(readDone)
+		server.readWebSocket(ws, conn, replies, writeDone)
+	}()
+
+	pingTicker := time.NewTicker(wsPingPeriod)
+	defer pingTicker.Stop()
+
+	var reportedDropped uint64
+	for {
+		var message *wsServerMessage
+
+		select {
+		case <-readDone:
+			return
+		case <-server.shutdownCh:
+			ws.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"), time.Now().Add(wsWriteWait))
+			return
+
+		case <-pingTicker.C:
+			if err := ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				return
+			}
+			continue
+
+		case reply := <-replies:
+			message = &reply
+
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			// Subscriptions may have changed since the event was buffered
+			ids := conn.matching(event)
+			if len(ids) == 0 {
+				continue
+			}
+			message = &wsServerMessage{Type: wsEvent, Subscriptions: ids, Event: &event}
+		}
+
+		if err := writeWebSocket(ws, message); err != nil {
+			slog.DebugContext(ctx, "websocket closed", slog.Any("error", err))
+			return
+		}
+
+		// Tell the client it fell behind, so it can refetch the jobs it is watching
+		if dropped := sub.Dropped(); dropped > reportedDropped {
+			if err := writeWebSocket(ws, &wsServerMessage{Type: wsDropped, Dropped: dropped - reportedDropped}); err != nil {
+				return
+			}
+			reportedDropped = dropped
+		}
+	}
+}
+
+// readWebSocket handles client messages until the connection fails or the writer is done
+func (server *Server) readWebSocket(ws *websocket.Conn, conn *wsConnection, replies chan<- wsServerMessage, writeDone <-chan struct{}) {
+	ws.SetReadLimit(wsMaxMessageSize)
+	ws.SetReadDeadline(time.Now().Add(wsPongWait))
+	ws.SetPongHandler(func(string) error {
+		return ws.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var reply wsServerMessage
+		var message wsClientMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			reply = wsServerMessage{Type: wsError, Message: "invalid message"}
+		} else {
+			reply = conn.handle(message)
+		}
+
+		select {
+		case replies <- reply:
+		case <-writeDone:
+			return
+		}
+	}
+}
+
+func writeWebSocket(ws *websocket.Conn, message *wsServerMessage) error {
+	ws.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	return ws.WriteJSON(message)
+}
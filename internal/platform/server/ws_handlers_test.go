@@ -0,0 +1,141 @@
+package server
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/mock"
+	"github.com/abikandiah/task-worker/internal/service"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+func dialTestWebSocket(t *testing.T, url string) *websocket.Conn {
+	t.Helper()
+
+	header := http.Header{"Authorization": {"Bearer " + testAPIKey}}
+	ws, response, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(url, "http")+"/api/v1/ws", header)
+	if err != nil {
+		t.Fatalf("dial websocket: %v", err)
+	}
+	response.Body.Close()
+	t.Cleanup(func() { ws.Close() })
+	return ws
+}
+
+func sendWebSocket(t *testing.T, ws *websocket.Conn, message wsClientMessage) {
+	t.Helper()
+
+	if err := ws.WriteJSON(message); err != nil {
+		t.Fatalf("write %s: %v", message.Type, err)
+	}
+}
+
+func receiveWebSocket(t *testing.T, ws *websocket.Conn) wsServerMessage {
+	t.Helper()
+
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var message wsServerMessage
+	if err := ws.ReadJSON(&message); err != nil {
+		t.Fatalf("read message: %v", err)
+	}
+	return message
+}
+
+func TestWebSocketSubscriptions(t *testing.T) {
+	testServer, jobService := newTestServer(t, mock.NewMockRepo())
+	ws := dialTestWebSocket(t, testServer.URL)
+	bus := jobService.Events()
+
+	watchedJob, otherJob := uuid.New(), uuid.New()
+	finished := domain.Status{State: domain.StateFinished}
+	running := domain.Status{State: domain.StateRunning}
+
+	for _, message := range []wsClientMessage{
+		{Type: wsSubscribe, ID: "watched", JobIDs: []uuid.UUID{watchedJob}},
+		{Type: wsSubscribe, ID: "finished", States: []domain.ExecutionState{domain.StateFinished}},
+		{Type: wsSubscribe, ID: "nightly", Tags: []string{"nightly"}},
+	} {
+		sendWebSocket(t, ws, message)
+		if reply := receiveWebSocket(t, ws); reply.Type != wsSubscribed || reply.ID != message.ID {
+			t.Fatalf("reply = %+v, want %s %s", reply, wsSubscribed, message.ID)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		event service.Event
+		want  []string
+	}{
+		{"job ID", service.Event{Type: service.EventJobStarted, JobID: watchedJob, After: running}, []string{"watched"}},
+		{"state", service.Event{Type: service.EventJobFinished, JobID: otherJob, After: finished}, []string{"finished"}},
+		{"tag", service.Event{Type: service.EventJobStarted, JobID: otherJob, After: running, Tags: []string{"nightly"}}, []string{"nightly"}},
+		{"several", service.Event{Type: service.EventJobFinished, JobID: watchedJob, After: finished}, []string{"finished", "watched"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			published := bus.Publish(test.event)
+
+			message := receiveWebSocket(t, ws)
+			if message.Type != wsEvent || message.Event == nil || message.Event.ID != published.ID {
+				t.Fatalf("message = %+v, want event %d", message, published.ID)
+			}
+			slices.Sort(message.Subscriptions)
+			if !slices.Equal(message.Subscriptions, test.want) {
+				t.Errorf("subscriptions = %v, want %v", message.Subscriptions, test.want)
+			}
+		})
+	}
+
+	t.Run("unsubscribe", func(t *testing.T) {
+		sendWebSocket(t, ws, wsClientMessage{Type: wsUnsubscribe, ID: "watched"})
+		if reply := receiveWebSocket(t, ws); reply.Type != wsUnsubscribed || reply.ID != "watched" {
+			t.Fatalf("reply = %+v, want %s watched", reply, wsUnsubscribed)
+		}
+
+		// Only the second event matches a subscription left, so it is the next message
+		bus.Publish(service.Event{Type: service.EventJobStarted, JobID: watchedJob, After: running})
+		published := bus.Publish(service.Event{Type: service.EventJobFinished, JobID: otherJob, After: finished})
+
+		message := receiveWebSocket(t, ws)
+		if message.Type != wsEvent || message.Event == nil || message.Event.ID != published.ID {
+			t.Fatalf("message = %+v, want event %d of the finished subscription", message, published.ID)
+		}
+	})
+
+	t.Run("invalid messages", func(t *testing.T) {
+		for _, message := range []wsClientMessage{
+			{Type: wsSubscribe},
+			{Type: "watch", ID: "watched"},
+		} {
+			sendWebSocket(t, ws, message)
+			if reply := receiveWebSocket(t, ws); reply.Type != wsError || reply.Message == "" {
+				t.Errorf("reply to %+v = %+v, want an error", message, reply)
+			}
+		}
+
+		if err := ws.WriteMessage(websocket.TextMessage, []byte("{")); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		if reply := receiveWebSocket(t, ws); reply.Type != wsError {
+			t.Errorf("reply to malformed JSON = %+v, want an error", reply)
+		}
+	})
+}
+
+func TestWebSocketRequiresAuthentication(t *testing.T) {
+	testServer, _ := newTestServer(t, mock.NewMockRepo())
+
+	_, response, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(testServer.URL, "http")+"/api/v1/ws", nil)
+	if err == nil {
+		t.Fatal("dial without an API key succeeded")
+	}
+	if response == nil || response.StatusCode != http.StatusUnauthorized {
+		t.Errorf("response = %v, want %d", response, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,65 @@
+package tracing
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/spf13/viper"
+)
+
+// Config of the OpenTelemetry tracing, spans are sent to the exporter registered under Exporter
+type Config struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	Exporter string `mapstructure:"exporter"`
+	// File is where the file exporter appends spans, one JSON object per line
+	File string `mapstructure:"file"`
+	// Endpoint is the host:port of the OTLP collector, the OTEL_EXPORTER_OTLP_* variables apply when empty
+	Endpoint string `mapstructure:"endpoint"`
+	Insecure bool   `mapstructure:"insecure"`
+	// SampleRatio of the traces started here, traces continued from a caller follow its decision
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
+
+func SetConfigDefaults(v *viper.Viper) {
+	v.SetDefault("tracing.enabled", false)
+	v.SetDefault("tracing.exporter", StdoutExporter)
+	v.SetDefault("tracing.file", "~/.local/share/task-worker/traces.jsonl")
+	v.SetDefault("tracing.endpoint", "")
+	v.SetDefault("tracing.insecure", false)
+	v.SetDefault("tracing.sample_ratio", 1.0)
+}
+
+func BindEnvironmentVariables(v *viper.Viper) {
+	v.BindEnv("tracing.enabled", "TRACING_ENABLED")
+	v.BindEnv("tracing.exporter", "TRACING_EXPORTER")
+	v.BindEnv("tracing.file", "TRACING_FILE")
+	v.BindEnv("tracing.endpoint", "TRACING_ENDPOINT")
+	v.BindEnv("tracing.insecure", "TRACING_INSECURE")
+	v.BindEnv("tracing.sample_ratio", "TRACING_SAMPLE_RATIO")
+}
+
+func (config *Config) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Bool("enabled", config.Enabled),
+		slog.String("exporter", config.Exporter),
+		slog.String("file", config.File),
+		slog.String("endpoint", config.Endpoint),
+		slog.Float64("sample_ratio", config.SampleRatio),
+	)
+}
+
+func (config *Config) Validate() error {
+	if !config.Enabled {
+		return nil
+	}
+	if _, ok := lookupExporter(config.Exporter); !ok {
+		return fmt.Errorf("unknown tracing exporter: %s", config.Exporter)
+	}
+	if config.Exporter == FileExporter && config.File == "" {
+		return fmt.Errorf("tracing file is required by the %s exporter", FileExporter)
+	}
+	if config.SampleRatio < 0 || config.SampleRatio > 1 {
+		return fmt.Errorf("tracing sample ratio must be between 0 and 1: %v", config.SampleRatio)
+	}
+	return nil
+}
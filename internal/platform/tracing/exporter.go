@@ -0,0 +1,101 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/abikandiah/task-worker/internal/util"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Exporters registered by default
+const (
+	StdoutExporter = "stdout"
+	FileExporter   = "file"
+	OTLPExporter   = "otlp"
+)
+
+// ExporterFactory creates the span exporter configured by config
+type ExporterFactory func(ctx context.Context, config *Config) (sdktrace.SpanExporter, error)
+
+var (
+	exportersMu sync.RWMutex
+	exporters   = map[string]ExporterFactory{
+		StdoutExporter: newStdoutExporter,
+		FileExporter:   newFileExporter,
+		OTLPExporter:   newOTLPExporter,
+	}
+)
+
+// RegisterExporter makes an exporter selectable by name in the config, replacing one of the same name.
+// Register before the config is loaded, e.g. from an init function.
+func RegisterExporter(name string, factory ExporterFactory) {
+	exportersMu.Lock()
+	defer exportersMu.Unlock()
+
+	exporters[name] = factory
+}
+
+func lookupExporter(name string) (ExporterFactory, bool) {
+	exportersMu.RLock()
+	defer exportersMu.RUnlock()
+
+	factory, ok := exporters[name]
+	return factory, ok
+}
+
+// newStdoutExporter writes spans to stdout for environments without a collector
+func newStdoutExporter(ctx context.Context, config *Config) (sdktrace.SpanExporter, error) {
+	return stdouttrace.New()
+}
+
+// fileExporter writes spans to a file, which it closes on shutdown
+type fileExporter struct {
+	sdktrace.SpanExporter
+	file *os.File
+}
+
+func newFileExporter(ctx context.Context, config *Config) (sdktrace.SpanExporter, error) {
+	path, err := util.ExpandTilde(config.File)
+	if err != nil {
+		return nil, fmt.Errorf("error expanding path: %w", err)
+	}
+	if err := util.MakeDirs(path); err != nil {
+		return nil, fmt.Errorf("error creating trace file parent dirs: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open trace file: %w", err)
+	}
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &fileExporter{SpanExporter: exporter, file: file}, nil
+}
+
+func (exporter *fileExporter) Shutdown(ctx context.Context) error {
+	err := exporter.SpanExporter.Shutdown(ctx)
+	if closeErr := exporter.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// newOTLPExporter sends spans to an OpenTelemetry collector over HTTP
+func newOTLPExporter(ctx context.Context, config *Config) (sdktrace.SpanExporter, error) {
+	var options []otlptracehttp.Option
+	if config.Endpoint != "" {
+		options = append(options, otlptracehttp.WithEndpoint(config.Endpoint))
+	}
+	if config.Insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	return otlptracehttp.New(ctx, options...)
+}
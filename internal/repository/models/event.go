@@ -0,0 +1,28 @@
+package models
+
+import (
+	"encoding/json"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+)
+
+type CommonEventDB struct {
+	Seq     int64  `db:"seq"`
+	Origin  string `db:"origin"`
+	Payload string `db:"payload"`
+}
+
+func (eventDB *CommonEventDB) ToDomainEventBase() *domain.EventRecord {
+	return &domain.EventRecord{
+		Seq:     eventDB.Seq,
+		Origin:  eventDB.Origin,
+		Payload: json.RawMessage(eventDB.Payload),
+	}
+}
+
+func NewCommonEventDB(event domain.EventRecord) CommonEventDB {
+	return CommonEventDB{
+		Origin:  event.Origin,
+		Payload: string(event.Payload),
+	}
+}
@@ -2,19 +2,31 @@ package models
 
 import (
 	"database/sql"
+	"fmt"
 
 	"github.com/abikandiah/task-worker/internal/domain"
 	"github.com/google/uuid"
 )
 
 type CommonJobDB struct {
-	ID            uuid.UUID      `db:"id"`
-	Name          string         `db:"name"`
-	Description   sql.NullString `db:"description"`
-	ConfigID      uuid.UUID      `db:"config_id"`
-	ConfigVersion uuid.UUID      `db:"config_version"`
-	State         string         `db:"state"`
-	Progress      float32        `db:"progress"`
+	ID               uuid.UUID      `db:"id"`
+	Name             string         `db:"name"`
+	Description      sql.NullString `db:"description"`
+	ConfigID         uuid.UUID      `db:"config_id"`
+	ConfigVersion    uuid.UUID      `db:"config_version"`
+	State            string         `db:"state"`
+	Progress         float32        `db:"progress"`
+	VariablesJSON    sql.NullString `db:"variables"`
+	CallbacksJSON    sql.NullString `db:"callbacks"`
+	TagsJSON         sql.NullString `db:"tags"`
+	Queue            string         `db:"queue"`
+	CapabilitiesJSON sql.NullString `db:"capabilities"`
+	ConcurrencyKey   sql.NullString `db:"concurrency_key"`
+	ConcurrencyLimit int            `db:"concurrency_limit"`
+	TemplateID       uuid.NullUUID  `db:"template_id"`
+	TemplateVersion  uuid.NullUUID  `db:"template_version"`
+	WorkerID         sql.NullString `db:"worker_id"`
+	TraceContextJSON sql.NullString `db:"trace_context"`
 }
 
 // GetID implements the required method for cursor pagination.
@@ -22,7 +34,7 @@ func (jdb CommonJobDB) GetID() uuid.UUID {
 	return jdb.ID
 }
 
-func (jobDB *CommonJobDB) ToDomainJobBase() *domain.Job {
+func (jobDB *CommonJobDB) ToDomainJobBase() (*domain.Job, error) {
 	identity := domain.Identity{
 		ID: jobDB.ID,
 		IdentitySubmission: domain.IdentitySubmission{
@@ -31,7 +43,7 @@ func (jobDB *CommonJobDB) ToDomainJobBase() *domain.Job {
 		},
 	}
 
-	return &domain.Job{
+	job := &domain.Job{
 		Identity:      identity,
 		ConfigID:      jobDB.ConfigID,
 		ConfigVersion: jobDB.ConfigVersion,
@@ -39,23 +51,106 @@ func (jobDB *CommonJobDB) ToDomainJobBase() *domain.Job {
 			State:    domain.ExecutionState(jobDB.State),
 			Progress: jobDB.Progress,
 		},
+		Queue:            jobDB.Queue,
+		ConcurrencyKey:   jobDB.ConcurrencyKey.String,
+		ConcurrencyLimit: jobDB.ConcurrencyLimit,
+		WorkerID:         jobDB.WorkerID.String,
 	}
+	if jobDB.TemplateID.Valid {
+		job.TemplateID = &jobDB.TemplateID.UUID
+		job.TemplateVersion = &jobDB.TemplateVersion.UUID
+	}
+
+	// Unmarshal the JSON columns back into their domain types
+	if err := unmarshalNullJSON(jobDB.VariablesJSON, &job.Variables); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job variables JSON: %w", err)
+	}
+	if err := unmarshalNullJSON(jobDB.CallbacksJSON, &job.Callbacks); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job callbacks JSON: %w", err)
+	}
+	if err := unmarshalNullJSON(jobDB.TagsJSON, &job.Tags); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job tags JSON: %w", err)
+	}
+	if err := unmarshalNullJSON(jobDB.CapabilitiesJSON, &job.Capabilities); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job capabilities JSON: %w", err)
+	}
+	if err := unmarshalNullJSON(jobDB.TraceContextJSON, &job.TraceContext); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job trace context JSON: %w", err)
+	}
+
+	return job, nil
 }
 
-func NewCommonJobDB(job *domain.Job) CommonJobDB {
+func NewCommonJobDB(job *domain.Job) (CommonJobDB, error) {
 	isNew := job.ID == uuid.Nil
 	jobID := job.ID
 	if isNew {
 		jobID = uuid.New()
 	}
 
+	// Marshal the JSON columns, empty values are stored as NULL
+	variablesJSON, err := marshalNullJSON(job.Variables, len(job.Variables) == 0)
+	if err != nil {
+		return CommonJobDB{}, fmt.Errorf("failed to marshal job variables: %w", err)
+	}
+	callbacksJSON, err := marshalNullJSON(job.Callbacks, len(job.Callbacks) == 0)
+	if err != nil {
+		return CommonJobDB{}, fmt.Errorf("failed to marshal job callbacks: %w", err)
+	}
+	tagsJSON, err := marshalNullJSON(job.Tags, len(job.Tags) == 0)
+	if err != nil {
+		return CommonJobDB{}, fmt.Errorf("failed to marshal job tags: %w", err)
+	}
+	capabilitiesJSON, err := marshalNullJSON(job.Capabilities, len(job.Capabilities) == 0)
+	if err != nil {
+		return CommonJobDB{}, fmt.Errorf("failed to marshal job capabilities: %w", err)
+	}
+	traceContextJSON, err := marshalNullJSON(job.TraceContext, len(job.TraceContext) == 0)
+	if err != nil {
+		return CommonJobDB{}, fmt.Errorf("failed to marshal job trace context: %w", err)
+	}
+
 	return CommonJobDB{
-		ID:            jobID,
-		Name:          job.Name,
-		Description:   sql.NullString{String: job.Description, Valid: job.Description != ""},
-		ConfigID:      job.ConfigID,
-		ConfigVersion: job.ConfigVersion,
-		State:         string(job.State),
-		Progress:      job.Progress,
+		ID:               jobID,
+		Name:             job.Name,
+		Description:      sql.NullString{String: job.Description, Valid: job.Description != ""},
+		ConfigID:         job.ConfigID,
+		ConfigVersion:    job.ConfigVersion,
+		State:            string(job.State),
+		Progress:         job.Progress,
+		VariablesJSON:    variablesJSON,
+		CallbacksJSON:    callbacksJSON,
+		TagsJSON:         tagsJSON,
+		Queue:            job.Queue,
+		CapabilitiesJSON: capabilitiesJSON,
+		ConcurrencyKey:   sql.NullString{String: job.ConcurrencyKey, Valid: job.ConcurrencyKey != ""},
+		ConcurrencyLimit: max(job.ConcurrencyLimit, 1),
+		TemplateID:       nullUUID(job.TemplateID),
+		TemplateVersion:  nullUUID(job.TemplateVersion),
+		TraceContextJSON: traceContextJSON,
+	}, nil
+}
+
+// nullUUID converts an optional reference into a nullable UUID column
+func nullUUID(id *uuid.UUID) uuid.NullUUID {
+	if id == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: *id, Valid: true}
+}
+
+type QueueDepthDB struct {
+	Queue   string `db:"queue"`
+	Pending int    `db:"pending"`
+	Claimed int    `db:"claimed"`
+	Running int    `db:"running"`
+}
+
+func (depthDB QueueDepthDB) ToDomainQueue() domain.Queue {
+	return domain.Queue{
+		Name:    depthDB.Queue,
+		Pending: depthDB.Pending,
+		Claimed: depthDB.Claimed,
+		Running: depthDB.Running,
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/abikandiah/task-worker/internal/domain"
 	"github.com/google/uuid"
@@ -72,3 +73,11 @@ func NewCommonJobConfigDB(config domain.JobConfig) (CommonJobConfigDB, error) {
 		DetailsJSON: string(detailsBytes),
 	}, nil
 }
+
+// JobConfigCreateDate is when a config version was created, versions saved without a date are created now
+func JobConfigCreateDate(config domain.JobConfig) time.Time {
+	if config.CreateDate.IsZero() {
+		return time.Now().UTC()
+	}
+	return config.CreateDate.UTC()
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/google/uuid"
+)
+
+type CommonJobTemplateDB struct {
+	ID          uuid.UUID      `db:"id"`
+	Version     uuid.UUID      `db:"version"`
+	Name        string         `db:"name"`
+	Description sql.NullString `db:"description"`
+	DetailsJSON string         `db:"details"`
+}
+
+// GetID implements the required method for cursor pagination.
+func (templateDB CommonJobTemplateDB) GetID() uuid.UUID {
+	return templateDB.ID
+}
+
+func (templateDB *CommonJobTemplateDB) ToDomainJobTemplateBase() (*domain.JobTemplate, error) {
+	template := &domain.JobTemplate{
+		IdentityVersion: domain.IdentityVersion{
+			Identity: domain.Identity{
+				ID: templateDB.ID,
+				IdentitySubmission: domain.IdentitySubmission{
+					Name:        templateDB.Name,
+					Description: templateDB.Description.String,
+				},
+			},
+			Version: templateDB.Version,
+		},
+	}
+
+	if err := json.Unmarshal([]byte(templateDB.DetailsJSON), &template.JobTemplateDetails); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job template details JSON: %w", err)
+	}
+	return template, nil
+}
+
+func NewCommonJobTemplateDB(template domain.JobTemplate) (CommonJobTemplateDB, error) {
+	detailsBytes, err := json.Marshal(template.JobTemplateDetails)
+	if err != nil {
+		return CommonJobTemplateDB{}, fmt.Errorf("failed to marshal job template details: %w", err)
+	}
+
+	return CommonJobTemplateDB{
+		ID:          template.ID,
+		Version:     template.Version,
+		Name:        template.Name,
+		Description: sql.NullString{String: template.Description, Valid: template.Description != ""},
+		DetailsJSON: string(detailsBytes),
+	}, nil
+}
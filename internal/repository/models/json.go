@@ -0,0 +1,35 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+)
+
+// marshalNullJSON marshals a value into a nullable JSON column, storing NULL when empty is true
+func marshalNullJSON(value any, empty bool) (sql.NullString, error) {
+	if empty {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+// unmarshalNullJSON unmarshals a nullable JSON column, leaving target untouched when NULL
+func unmarshalNullJSON(column sql.NullString, target any) error {
+	if !column.Valid || column.String == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(column.String), target)
+}
+
+// StringArrayJSON marshals values into a JSON array parameter, nil is an empty array
+func StringArrayJSON(values []string) string {
+	if len(values) == 0 {
+		return "[]"
+	}
+	data, _ := json.Marshal(values)
+	return string(data)
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/google/uuid"
+)
+
+type StateCountDB struct {
+	State string `db:"state"`
+	Count int    `db:"count"`
+}
+
+// ToDomainStateCounts maps the counts by state
+func ToDomainStateCounts(countDBs []StateCountDB) map[domain.ExecutionState]int {
+	counts := make(map[domain.ExecutionState]int, len(countDBs))
+	for _, countDB := range countDBs {
+		counts[domain.ExecutionState(countDB.State)] = countDB.Count
+	}
+	return counts
+}
+
+type DurationStatsDB struct {
+	Key   string  `db:"stat_key"`
+	Count int     `db:"count"`
+	Avg   float64 `db:"avg"`
+	P50   float64 `db:"p50"`
+	P95   float64 `db:"p95"`
+	P99   float64 `db:"p99"`
+}
+
+func (statsDB DurationStatsDB) ToDomainDurationStats() domain.DurationStats {
+	return domain.DurationStats{
+		Count: statsDB.Count,
+		Avg:   statsDB.Avg,
+		P50:   statsDB.P50,
+		P95:   statsDB.P95,
+		P99:   statsDB.P99,
+	}
+}
+
+// StatsDB holds the rows of the statistics queries, besides throughput whose hour differs by driver
+type StatsDB struct {
+	JobStates       []StateCountDB
+	TaskStates      []StateCountDB
+	QueueWait       []DurationStatsDB
+	TaskDurations   []DurationStatsDB
+	ConfigDurations []DurationStatsDB
+}
+
+func (statsDB *StatsDB) ToDomainStats(statsRange domain.StatsRange, throughput []domain.HourlyThroughput) (*domain.Stats, error) {
+	stats := &domain.Stats{
+		StatsRange:      statsRange,
+		JobStates:       ToDomainStateCounts(statsDB.JobStates),
+		TaskStates:      ToDomainStateCounts(statsDB.TaskStates),
+		Throughput:      throughput,
+		QueueWait:       make([]domain.QueueWaitStats, len(statsDB.QueueWait)),
+		TaskDurations:   make([]domain.TaskDurationStats, len(statsDB.TaskDurations)),
+		ConfigDurations: make([]domain.ConfigDurationStats, len(statsDB.ConfigDurations)),
+	}
+
+	for i, statsDB := range statsDB.QueueWait {
+		stats.QueueWait[i] = domain.QueueWaitStats{Queue: statsDB.Key, DurationStats: statsDB.ToDomainDurationStats()}
+	}
+	for i, statsDB := range statsDB.TaskDurations {
+		stats.TaskDurations[i] = domain.TaskDurationStats{TaskName: statsDB.Key, DurationStats: statsDB.ToDomainDurationStats()}
+	}
+	for i, statsDB := range statsDB.ConfigDurations {
+		configID, err := uuid.Parse(statsDB.Key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid config ID %q: %w", statsDB.Key, err)
+		}
+		stats.ConfigDurations[i] = domain.ConfigDurationStats{ConfigID: configID, DurationStats: statsDB.ToDomainDurationStats()}
+	}
+	return stats, nil
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/google/uuid"
+)
+
+type CommonTaskLogDB struct {
+	Seq       int64          `db:"seq"`
+	JobID     uuid.UUID      `db:"job_id"`
+	TaskRunID uuid.UUID      `db:"task_run_id"`
+	Level     string         `db:"level"`
+	Message   string         `db:"message"`
+	AttrsJSON sql.NullString `db:"attrs"`
+}
+
+func (taskLogDB *CommonTaskLogDB) ToDomainTaskLogBase() (*domain.TaskLog, error) {
+	taskLog := &domain.TaskLog{
+		Seq:       taskLogDB.Seq,
+		JobID:     taskLogDB.JobID,
+		TaskRunID: taskLogDB.TaskRunID,
+		Level:     taskLogDB.Level,
+		Message:   taskLogDB.Message,
+	}
+
+	if err := unmarshalNullJSON(taskLogDB.AttrsJSON, &taskLog.Attrs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal task log attrs JSON: %w", err)
+	}
+
+	return taskLog, nil
+}
+
+func NewCommonTaskLogDB(taskLog domain.TaskLog) (CommonTaskLogDB, error) {
+	attrsJSON, err := marshalNullJSON(taskLog.Attrs, len(taskLog.Attrs) == 0)
+	if err != nil {
+		return CommonTaskLogDB{}, fmt.Errorf("failed to marshal task log attrs: %w", err)
+	}
+
+	return CommonTaskLogDB{
+		JobID:     taskLog.JobID,
+		TaskRunID: taskLog.TaskRunID,
+		Level:     taskLog.Level,
+		Message:   taskLog.Message,
+		AttrsJSON: attrsJSON,
+	}, nil
+}
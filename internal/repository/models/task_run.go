@@ -19,6 +19,11 @@ type CommonTaskRunDB struct {
 	DetailsJSON string         `db:"details"`
 }
 
+// GetID implements the required method for cursor pagination.
+func (taskRunDB CommonTaskRunDB) GetID() uuid.UUID {
+	return taskRunDB.ID
+}
+
 func (taskRunDB *CommonTaskRunDB) ToDomainTaskRunBase() (*domain.TaskRun, error) {
 	identity := domain.Identity{
 		ID: taskRunDB.ID,
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/google/uuid"
+)
+
+type CommonWebhookDeliveryDB struct {
+	ID             uuid.UUID      `db:"id"`
+	JobID          uuid.UUID      `db:"job_id"`
+	URL            string         `db:"url"`
+	EventType      string         `db:"event_type"`
+	PayloadJSON    string         `db:"payload"`
+	State          string         `db:"state"`
+	Attempts       int            `db:"attempts"`
+	LastStatusCode sql.NullInt64  `db:"last_status_code"`
+	LastError      sql.NullString `db:"last_error"`
+}
+
+func (deliveryDB *CommonWebhookDeliveryDB) ToDomainWebhookDeliveryBase() *domain.WebhookDelivery {
+	return &domain.WebhookDelivery{
+		ID:             deliveryDB.ID,
+		JobID:          deliveryDB.JobID,
+		URL:            deliveryDB.URL,
+		EventType:      deliveryDB.EventType,
+		Payload:        json.RawMessage(deliveryDB.PayloadJSON),
+		State:          domain.ExecutionState(deliveryDB.State),
+		Attempts:       deliveryDB.Attempts,
+		LastStatusCode: int(deliveryDB.LastStatusCode.Int64),
+		LastError:      deliveryDB.LastError.String,
+	}
+}
+
+func NewCommonWebhookDeliveryDB(delivery domain.WebhookDelivery) CommonWebhookDeliveryDB {
+	// Set ID if new delivery
+	deliveryID := delivery.ID
+	if delivery.ID == uuid.Nil {
+		deliveryID = uuid.New()
+	}
+
+	return CommonWebhookDeliveryDB{
+		ID:             deliveryID,
+		JobID:          delivery.JobID,
+		URL:            delivery.URL,
+		EventType:      delivery.EventType,
+		PayloadJSON:    string(delivery.Payload),
+		State:          string(delivery.State),
+		Attempts:       delivery.Attempts,
+		LastStatusCode: sql.NullInt64{Int64: int64(delivery.LastStatusCode), Valid: delivery.LastStatusCode != 0},
+		LastError:      sql.NullString{String: delivery.LastError, Valid: delivery.LastError != ""},
+	}
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+)
+
+type CommonWorkerDB struct {
+	ID               string         `db:"id"`
+	Hostname         string         `db:"hostname"`
+	Version          sql.NullString `db:"version"`
+	JobWorkerCount   int            `db:"job_worker_count"`
+	TaskWorkerCount  int            `db:"task_worker_count"`
+	TaskNamesJSON    sql.NullString `db:"task_names"`
+	QueuesJSON       sql.NullString `db:"queues"`
+	CapabilitiesJSON sql.NullString `db:"capabilities"`
+	Leader           bool           `db:"leader"`
+}
+
+func (workerDB *CommonWorkerDB) ToDomainWorkerBase() (*domain.Worker, error) {
+	worker := &domain.Worker{
+		ID:              workerDB.ID,
+		Hostname:        workerDB.Hostname,
+		Version:         workerDB.Version.String,
+		JobWorkerCount:  workerDB.JobWorkerCount,
+		TaskWorkerCount: workerDB.TaskWorkerCount,
+		Leader:          workerDB.Leader,
+	}
+
+	if err := unmarshalNullJSON(workerDB.TaskNamesJSON, &worker.TaskNames); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal worker task names JSON: %w", err)
+	}
+	if err := unmarshalNullJSON(workerDB.QueuesJSON, &worker.Queues); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal worker queues JSON: %w", err)
+	}
+	if err := unmarshalNullJSON(workerDB.CapabilitiesJSON, &worker.Capabilities); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal worker capabilities JSON: %w", err)
+	}
+	return worker, nil
+}
+
+func NewCommonWorkerDB(worker domain.Worker) (CommonWorkerDB, error) {
+	taskNamesJSON, err := marshalNullJSON(worker.TaskNames, len(worker.TaskNames) == 0)
+	if err != nil {
+		return CommonWorkerDB{}, fmt.Errorf("failed to marshal worker task names: %w", err)
+	}
+	queuesJSON, err := marshalNullJSON(worker.Queues, len(worker.Queues) == 0)
+	if err != nil {
+		return CommonWorkerDB{}, fmt.Errorf("failed to marshal worker queues: %w", err)
+	}
+	capabilitiesJSON, err := marshalNullJSON(worker.Capabilities, len(worker.Capabilities) == 0)
+	if err != nil {
+		return CommonWorkerDB{}, fmt.Errorf("failed to marshal worker capabilities: %w", err)
+	}
+
+	return CommonWorkerDB{
+		ID:               worker.ID,
+		Hostname:         worker.Hostname,
+		Version:          sql.NullString{String: worker.Version, Valid: worker.Version != ""},
+		JobWorkerCount:   worker.JobWorkerCount,
+		TaskWorkerCount:  worker.TaskWorkerCount,
+		TaskNamesJSON:    taskNamesJSON,
+		QueuesJSON:       queuesJSON,
+		CapabilitiesJSON: capabilitiesJSON,
+		Leader:           worker.Leader,
+	}, nil
+}
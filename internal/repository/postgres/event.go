@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/repository/models"
+	"github.com/abikandiah/task-worker/internal/repository/queries"
+)
+
+// --- SQL Constants for events table ---
+const insertEventSQL = `
+    INSERT INTO events (
+        ` + queries.InsertEventFields + `
+    ) VALUES (
+		$1, $2, $3
+    )
+`
+
+const selectEventsSQL = queries.SelectEventsBaseSQL + `$1` + queries.SelectEventsOrderSQL + `$2`
+
+const deleteEventsSQL = queries.DeleteEventsBaseSQL + `$1`
+
+type EventDB struct {
+	models.CommonEventDB
+	CreatedDate time.Time `db:"created_date"`
+}
+
+func (eventDB *EventDB) ToDomainEvent() *domain.EventRecord {
+	event := eventDB.ToDomainEventBase()
+	event.CreatedDate = eventDB.CreatedDate
+	return event
+}
+
+func FromDomainEvent(event domain.EventRecord) *EventDB {
+	return &EventDB{
+		CommonEventDB: models.NewCommonEventDB(event),
+		CreatedDate:   event.CreatedDate.UTC(),
+	}
+}
+
+func (repo *PostgresServiceRepository) SaveEvents(ctx context.Context, events []domain.EventRecord) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	// Begin Transaction
+	tx, err := repo.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for event save: %w", err)
+	}
+
+	for _, event := range events {
+		eventDB := FromDomainEvent(event)
+		_, execErr := tx.ExecContext(ctx, insertEventSQL,
+			eventDB.Origin,
+			eventDB.Payload,
+			eventDB.CreatedDate,
+		)
+		if execErr != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to insert event: %w", execErr)
+		}
+	}
+
+	// Commit Transaction
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction for event save: %w", err)
+	}
+	return nil
+}
+
+func (repo *PostgresServiceRepository) GetEvents(ctx context.Context, afterSeq int64, limit int) ([]domain.EventRecord, error) {
+	var eventDBs []EventDB
+	if err := repo.DB.SelectContext(ctx, &eventDBs, selectEventsSQL, afterSeq, limit); err != nil {
+		return nil, fmt.Errorf("failed to get events after %d: %w", afterSeq, err)
+	}
+
+	events := make([]domain.EventRecord, len(eventDBs))
+	for i, eventDB := range eventDBs {
+		events[i] = *eventDB.ToDomainEvent()
+	}
+	return events, nil
+}
+
+func (repo *PostgresServiceRepository) GetLastEventSeq(ctx context.Context) (int64, error) {
+	var seq int64
+	if err := repo.DB.GetContext(ctx, &seq, queries.SelectLastEventSeqSQL); err != nil {
+		return 0, fmt.Errorf("failed to get last event seq: %w", err)
+	}
+	return seq, nil
+}
+
+func (repo *PostgresServiceRepository) DeleteEvents(ctx context.Context, before time.Time) (int64, error) {
+	result, err := repo.DB.ExecContext(ctx, deleteEventsSQL, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete events: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/abikandiah/task-worker/internal/repository/models"
 	"github.com/abikandiah/task-worker/internal/repository/queries"
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 )
 
 // --- SQL Constants for jobs table ---
@@ -26,33 +27,84 @@ const selectJobByIDSQL = `
 
 const insertJobSQL = `
     INSERT INTO jobs (
-        ` + queries.SelectJobFields + `
+        ` + queries.InsertJobFields + `
     ) VALUES (
-        $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+        $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21
     )
 `
 
 const upsertJobSQL = insertJobSQL + queries.UpsertJobConflictClause
 
+// SKIP LOCKED lets concurrent workers claim disjoint jobs without waiting on each other
+const claimJobsSQL = queries.ClaimJobsBaseSQL + `$1, claim_date = $2
+    WHERE 
+        id IN (` + queries.SelectClaimableJobsSQL + `$3
+            AND COALESCE(capabilities, '[]')::jsonb <@ $4::jsonb` + queries.ClaimableConcurrencyCondition + queries.SelectClaimableJobsOrderSQL + `$5 FOR UPDATE SKIP LOCKED)
+    RETURNING 
+        id
+`
+
+const selectUnclaimedCapabilityJobsSQL = queries.SelectUnclaimedCapabilityJobsSQL + `$1
+    ORDER BY 
+        submit_date ASC
+    LIMIT $2
+`
+
+const failUnclaimedJobSQL = queries.FailUnclaimedJobBaseSQL + `$1
+    WHERE 
+        id = $2 AND state = 'PENDING' AND worker_id IS NULL
+`
+
+// Concurrency limits count claims made by other transactions, so claims take turns
+const lockJobClaimsSQL = `SELECT pg_advisory_xact_lock($1)`
+
+// jobClaimsLockKey is the advisory lock key serializing job claims
+const jobClaimsLockKey = 7_356_001
+
+const countActiveJobsSQL = queries.CountActiveJobsBaseSQL + `$1`
+
+const stopPendingJobsSQL = queries.StopPendingJobsBaseSQL + `$1
+    WHERE 
+        state = 'PENDING' AND worker_id IS NULL AND concurrency_key = $2
+    RETURNING 
+        id
+`
+
+const stopPendingTaskRunsSQL = queries.StopPendingTaskRunsBaseSQL + `$1 WHERE state = 'PENDING' AND job_id = $2`
+
+const expireJobsSQL = queries.ExpireJobsBaseSQL + `$1` + queries.ExpireJobsCondition + `$2
+    RETURNING 
+        id
+`
+
+const expireTaskRunsSQL = queries.ExpireTaskRunsBaseSQL + `$1 WHERE state = 'PENDING' AND job_id = $2`
+
+const releaseJobClaimsSQL = queries.ReleaseJobClaimsBaseSQL + `$1`
+
 type JobDB struct {
 	models.CommonJobDB
 	SubmitDate time.Time  `db:"submit_date"`
 	StartDate  *time.Time `db:"start_date"`
 	EndDate    *time.Time `db:"end_date"`
+	ExpiresAt  *time.Time `db:"expires_at"`
 }
 
-func (jobDB *JobDB) ToDomainJob() *domain.Job {
-	job := jobDB.ToDomainJobBase()
+func (jobDB *JobDB) ToDomainJob() (*domain.Job, error) {
+	job, err := jobDB.ToDomainJobBase()
+	if err != nil {
+		return nil, err
+	}
 
 	// Use native time.Time types directly
 	job.SubmitDate = jobDB.SubmitDate
 	job.StartDate = jobDB.StartDate
 	job.EndDate = jobDB.EndDate
+	job.ExpiresAt = jobDB.ExpiresAt
 
-	return job
+	return job, nil
 }
 
-func FromDomainJob(job *domain.Job) *JobDB {
+func FromDomainJob(job *domain.Job) (*JobDB, error) {
 	isNew := job.ID == uuid.Nil
 	submitDate := job.SubmitDate
 
@@ -60,21 +112,23 @@ func FromDomainJob(job *domain.Job) *JobDB {
 		submitDate = time.Now().UTC()
 	}
 
-	commonJobDb := models.NewCommonJobDB(job)
+	commonJobDb, err := models.NewCommonJobDB(job)
+	if err != nil {
+		return nil, err
+	}
 
 	return &JobDB{
 		CommonJobDB: commonJobDb,
 		SubmitDate:  submitDate,
 		StartDate:   job.StartDate,
 		EndDate:     job.EndDate,
-	}
+		ExpiresAt:   job.ExpiresAt,
+	}, nil
 }
 
-func (repo *PostgresServiceRepository) SaveJob(ctx context.Context, job domain.Job) (*domain.Job, error) {
-	jobDB := FromDomainJob(&job)
-
-	// Execute the query using positional parameters
-	_, err := repo.DB.ExecContext(ctx, upsertJobSQL,
+// upsertArgs are the positional parameters of upsertJobSQL
+func (jobDB *JobDB) upsertArgs() []any {
+	return []any{
 		jobDB.ID,
 		jobDB.Name,
 		jobDB.Description,
@@ -85,12 +139,76 @@ func (repo *PostgresServiceRepository) SaveJob(ctx context.Context, job domain.J
 		jobDB.SubmitDate,
 		jobDB.StartDate,
 		jobDB.EndDate,
-	)
+		jobDB.VariablesJSON,
+		jobDB.CallbacksJSON,
+		jobDB.TagsJSON,
+		jobDB.Queue,
+		jobDB.CapabilitiesJSON,
+		jobDB.ConcurrencyKey,
+		jobDB.ConcurrencyLimit,
+		jobDB.ExpiresAt,
+		jobDB.TemplateID,
+		jobDB.TemplateVersion,
+		jobDB.TraceContextJSON,
+	}
+}
+
+func (repo *PostgresServiceRepository) SaveJob(ctx context.Context, job domain.Job) (*domain.Job, error) {
+	jobDB, err := FromDomainJob(&job)
+	if err != nil {
+		return nil, err
+	}
+
+	// Execute the query using positional parameters
+	_, err = repo.DB.ExecContext(ctx, upsertJobSQL, jobDB.upsertArgs()...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to upsert job %s: %w", jobDB.ID, err)
 	}
 
-	return jobDB.ToDomainJob(), nil
+	return jobDB.ToDomainJob()
+}
+
+func (repo *PostgresServiceRepository) SaveJobsWithTaskRuns(ctx context.Context, jobs []domain.JobWithTaskRuns) ([]uuid.UUID, error) {
+	tx, err := repo.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction for job batch save: %w", err)
+	}
+	defer tx.Rollback()
+
+	var replaced []uuid.UUID
+	for _, job := range jobs {
+		// Replacing before the insert also stops earlier jobs of the key in the same batch
+		if job.ReplacePending {
+			jobIDs, err := stopPendingJobs(ctx, tx, job.Job.ConcurrencyKey, job.Job.SubmitDate)
+			if err != nil {
+				return nil, err
+			}
+			replaced = append(replaced, jobIDs...)
+		}
+
+		jobDB, err := FromDomainJob(&job.Job)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.ExecContext(ctx, upsertJobSQL, jobDB.upsertArgs()...); err != nil {
+			return nil, fmt.Errorf("failed to upsert job %s in transaction: %w", jobDB.ID, err)
+		}
+
+		for _, taskRun := range job.TaskRuns {
+			taskRunDB, err := FromDomainTaskRun(taskRun)
+			if err != nil {
+				return nil, fmt.Errorf("conversion failed for task run %s: %w", taskRun.ID, err)
+			}
+			if _, err := tx.ExecContext(ctx, upsertTaskRunSQL, taskRunDB.upsertArgs()...); err != nil {
+				return nil, fmt.Errorf("failed to upsert task run %s in transaction: %w", taskRunDB.ID, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction for job batch save: %w", err)
+	}
+	return replaced, nil
 }
 
 func (repo *PostgresServiceRepository) GetJob(ctx context.Context, jobID uuid.UUID) (*domain.Job, error) {
@@ -104,13 +222,14 @@ func (repo *PostgresServiceRepository) GetJob(ctx context.Context, jobID uuid.UU
 		return nil, fmt.Errorf("failed to get job with ID %s: %w", jobID, err)
 	}
 
-	return jobDB.ToDomainJob(), nil
+	return jobDB.ToDomainJob()
 }
 
 func (repo *PostgresServiceRepository) GetAllJobs(ctx context.Context, cursor *domain.CursorInput) (*domain.CursorOutput[domain.Job], error) {
 	pq := &db.PaginationQuery{
 		BaseQuery:     queries.SelectPaginationJobSQL,
 		AllowedFields: queries.JobPaginationAllowedFields,
+		FilterFields:  queries.JobFilterFields,
 	}
 
 	// Paginate with DB struct for correct sqlx scanning
@@ -122,7 +241,11 @@ func (repo *PostgresServiceRepository) GetAllJobs(ctx context.Context, cursor *d
 	// Convert JobDB slice to Job slice
 	domainJobs := make([]domain.Job, len(dbOutput.Data))
 	for i, jobDB := range dbOutput.Data {
-		domainJobs[i] = *jobDB.ToDomainJob()
+		domainJob, err := jobDB.ToDomainJob()
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert job DB model to domain model: %w", err)
+		}
+		domainJobs[i] = *domainJob
 	}
 
 	domainOutput := &domain.CursorOutput[domain.Job]{
@@ -134,3 +257,123 @@ func (repo *PostgresServiceRepository) GetAllJobs(ctx context.Context, cursor *d
 
 	return domainOutput, nil
 }
+
+func (repo *PostgresServiceRepository) ClaimJobs(ctx context.Context, workerID string, queue string, capabilities []string, limit int) ([]uuid.UUID, error) {
+	tx, err := repo.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, lockJobClaimsSQL, jobClaimsLockKey); err != nil {
+		return nil, fmt.Errorf("failed to lock job claims: %w", err)
+	}
+
+	var jobIDs []uuid.UUID
+	err = tx.SelectContext(ctx, &jobIDs, claimJobsSQL, workerID, time.Now().UTC(), queue, models.StringArrayJSON(capabilities), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim jobs of queue %s for worker %s: %w", queue, workerID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return jobIDs, nil
+}
+
+func (repo *PostgresServiceRepository) GetUnclaimedCapabilityJobs(ctx context.Context, submittedBefore time.Time, limit int) ([]domain.Job, error) {
+	var jobDBs []JobDB
+	if err := repo.DB.SelectContext(ctx, &jobDBs, selectUnclaimedCapabilityJobsSQL, submittedBefore.UTC(), limit); err != nil {
+		return nil, fmt.Errorf("failed to get unclaimed jobs requiring capabilities: %w", err)
+	}
+
+	jobs := make([]domain.Job, len(jobDBs))
+	for i, jobDB := range jobDBs {
+		job, err := jobDB.ToDomainJob()
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert job DB model to domain model: %w", err)
+		}
+		jobs[i] = *job
+	}
+	return jobs, nil
+}
+
+func (repo *PostgresServiceRepository) FailUnclaimedJob(ctx context.Context, jobID uuid.UUID, endDate time.Time) (bool, error) {
+	result, err := repo.DB.ExecContext(ctx, failUnclaimedJobSQL, endDate.UTC(), jobID)
+	if err != nil {
+		return false, fmt.Errorf("failed to fail unclaimed job %s: %w", jobID, err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to fail unclaimed job %s: %w", jobID, err)
+	}
+	return rows == 1, nil
+}
+
+func (repo *PostgresServiceRepository) CountActiveJobs(ctx context.Context, concurrencyKey string) (int, error) {
+	var count int
+	if err := repo.DB.GetContext(ctx, &count, countActiveJobsSQL, concurrencyKey); err != nil {
+		return 0, fmt.Errorf("failed to count active jobs of concurrency key %s: %w", concurrencyKey, err)
+	}
+	return count, nil
+}
+
+// stopPendingJobs stops the unclaimed pending jobs of a concurrency key and their task runs in tx
+func stopPendingJobs(ctx context.Context, tx *sqlx.Tx, concurrencyKey string, endDate time.Time) ([]uuid.UUID, error) {
+	var jobIDs []uuid.UUID
+	if err := tx.SelectContext(ctx, &jobIDs, stopPendingJobsSQL, endDate.UTC(), concurrencyKey); err != nil {
+		return nil, fmt.Errorf("failed to stop pending jobs of concurrency key %s: %w", concurrencyKey, err)
+	}
+
+	for _, jobID := range jobIDs {
+		if _, err := tx.ExecContext(ctx, stopPendingTaskRunsSQL, endDate.UTC(), jobID); err != nil {
+			return nil, fmt.Errorf("failed to stop task runs of job %s: %w", jobID, err)
+		}
+	}
+	return jobIDs, nil
+}
+
+func (repo *PostgresServiceRepository) ExpireJobs(ctx context.Context, now time.Time) ([]uuid.UUID, error) {
+	tx, err := repo.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var jobIDs []uuid.UUID
+	if err := tx.SelectContext(ctx, &jobIDs, expireJobsSQL, now.UTC(), now.UTC()); err != nil {
+		return nil, fmt.Errorf("failed to expire jobs: %w", err)
+	}
+
+	for _, jobID := range jobIDs {
+		if _, err := tx.ExecContext(ctx, expireTaskRunsSQL, now.UTC(), jobID); err != nil {
+			return nil, fmt.Errorf("failed to expire task runs of job %s: %w", jobID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return jobIDs, nil
+}
+
+func (repo *PostgresServiceRepository) ReleaseJobClaims(ctx context.Context, workerID string) error {
+	if _, err := repo.DB.ExecContext(ctx, releaseJobClaimsSQL, workerID); err != nil {
+		return fmt.Errorf("failed to release job claims of worker %s: %w", workerID, err)
+	}
+	return nil
+}
+
+func (repo *PostgresServiceRepository) GetQueueDepths(ctx context.Context) ([]domain.Queue, error) {
+	var depthDBs []models.QueueDepthDB
+	if err := repo.DB.SelectContext(ctx, &depthDBs, queries.SelectQueueDepthsSQL); err != nil {
+		return nil, fmt.Errorf("failed to get queue depths: %w", err)
+	}
+
+	queues := make([]domain.Queue, len(depthDBs))
+	for i, depthDB := range depthDBs {
+		queues[i] = depthDB.ToDomainQueue()
+	}
+	return queues, nil
+}
@@ -14,20 +14,27 @@ import (
 )
 
 // --- SQL Constants for job_configs table ---
-const selectJobConfigByIDSQL = `
-    SELECT 
-        ` + queries.SelectConfigFields + `
-    FROM 
-        job_configs
-    WHERE 
-        id = $1
+const selectJobConfigVersionsSQL = queries.SelectJobConfigVersionsBaseSQL + `$1` + queries.SelectJobConfigVersionsOrderSQL
+
+const selectJobConfigByIDSQL = selectJobConfigVersionsSQL + `LIMIT 1`
+
+const selectJobConfigVersionSQL = queries.SelectJobConfigVersionsBaseSQL + `$1 AND version = $2`
+
+const setDefaultJobConfigSQL = `
+    UPDATE job_configs SET is_default = TRUE WHERE id = $1 AND version = $2
+`
+
+const deleteJobConfigVersionSQL = `
+    DELETE FROM job_configs
+    WHERE id = $1 AND version = $2 AND is_default = FALSE
+        AND NOT EXISTS (SELECT 1 FROM jobs WHERE config_id = $1 AND config_version = $2)
 `
 
 const insertJobConfigSQL = `
     INSERT INTO job_configs (
         ` + queries.SelectConfigFields + `
     ) VALUES (
-        $1, $2, $3, $4, $5, $6
+        $1, $2, $3, $4, $5, $6, $7
     )
 `
 
@@ -35,16 +42,24 @@ const upsertJobConfigSQL = insertJobConfigSQL + queries.UpsertJobConfigConflictC
 
 type JobConfigDB struct {
 	models.CommonJobConfigDB
+	CreateDate time.Time `db:"create_date"`
 }
 
 func (configDB *JobConfigDB) ToDomainJobConfig() (*domain.JobConfig, error) {
-	return configDB.ToDomainJobConfigBase()
+	config, err := configDB.ToDomainJobConfigBase()
+	if err != nil {
+		return nil, err
+	}
+
+	config.CreateDate = configDB.CreateDate
+	return config, nil
 }
 
 func FromDomainJobConfig(config domain.JobConfig) (JobConfigDB, error) {
 	commonConfig, err := models.NewCommonJobConfigDB(config)
 	return JobConfigDB{
 		CommonJobConfigDB: commonConfig,
+		CreateDate:        models.JobConfigCreateDate(config),
 	}, err
 }
 
@@ -122,6 +137,7 @@ func (repo *PostgresServiceRepository) SaveJobConfig(ctx context.Context, config
 		configDB.IsDefault,
 		configDB.Version,
 		configDB.DetailsJSON,
+		configDB.CreateDate,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to upsert job config %s: %w", configDB.ID, err)
@@ -144,6 +160,19 @@ func (repo *PostgresServiceRepository) GetJobConfig(ctx context.Context, configI
 	return configDB.ToDomainJobConfig()
 }
 
+func (repo *PostgresServiceRepository) GetJobConfigVersion(ctx context.Context, configID uuid.UUID, version uuid.UUID) (*domain.JobConfig, error) {
+	var configDB JobConfigDB
+	err := repo.DB.GetContext(ctx, &configDB, selectJobConfigVersionSQL, configID, version)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get job config %s version %s: %w", configID, version, err)
+	}
+
+	return configDB.ToDomainJobConfig()
+}
+
 func (repo *PostgresServiceRepository) GetAllJobConfigs(ctx context.Context, cursor *domain.CursorInput) (*domain.CursorOutput[domain.JobConfig], error) {
 	pq := &db.PaginationQuery{
 		BaseQuery:     queries.SelectPaginationConfigSQL,
@@ -176,3 +205,75 @@ func (repo *PostgresServiceRepository) GetAllJobConfigs(ctx context.Context, cur
 
 	return domainOutput, nil
 }
+
+func (repo *PostgresServiceRepository) GetJobConfigVersions(ctx context.Context, configID uuid.UUID, cursor *domain.CursorInput) (*domain.CursorOutput[domain.JobConfig], error) {
+	pq := &db.PaginationQuery{
+		BaseQuery:     queries.SelectPaginationConfigVersionsSQL,
+		BaseArgs:      []any{configID},
+		AllowedFields: queries.JobConfigVersionPaginationAllowedFields,
+		KeyField:      "version",
+	}
+
+	dbOutput, err := db.Paginate[JobConfigDB](ctx, repo.DB, pq, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	domainConfigs := make([]domain.JobConfig, len(dbOutput.Data))
+	for i, configDB := range dbOutput.Data {
+		domainConfig, err := configDB.ToDomainJobConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert job config DB model to domain model: %w", err)
+		}
+		domainConfigs[i] = *domainConfig
+	}
+
+	return &domain.CursorOutput[domain.JobConfig]{
+		Limit:      dbOutput.Limit,
+		Data:       domainConfigs,
+		NextCursor: dbOutput.NextCursor,
+		PrevCursor: dbOutput.PrevCursor,
+	}, nil
+}
+
+func (repo *PostgresServiceRepository) SetDefaultJobConfig(ctx context.Context, configID uuid.UUID, version uuid.UUID) (bool, error) {
+	tx, err := repo.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Only one config may be the default, clear it before setting the new one
+	if _, err := tx.ExecContext(ctx, queries.ClearDefaultJobConfigSQL); err != nil {
+		return false, fmt.Errorf("failed to clear default job config: %w", err)
+	}
+	result, err := tx.ExecContext(ctx, setDefaultJobConfigSQL, configID, version)
+	if err != nil {
+		return false, fmt.Errorf("failed to set default job config %s version %s: %w", configID, version, err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to set default job config %s version %s: %w", configID, version, err)
+	}
+	if rows == 0 {
+		return false, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit default job config: %w", err)
+	}
+	return true, nil
+}
+
+func (repo *PostgresServiceRepository) DeleteJobConfigVersion(ctx context.Context, configID uuid.UUID, version uuid.UUID) (bool, error) {
+	result, err := repo.DB.ExecContext(ctx, deleteJobConfigVersionSQL, configID, version)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete job config %s version %s: %w", configID, version, err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete job config %s version %s: %w", configID, version, err)
+	}
+	return rows == 1, nil
+}
@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/repository/queries"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+func purgeableJobsCondition(filter domain.PurgeFilter) (string, []any) {
+	var endedBefore any
+	if filter.EndedBefore != nil {
+		endedBefore = filter.EndedBefore.UTC()
+	}
+	return queries.PurgeableJobsCondition(filter.ConfigID, filter.ConfigVersion, filter.State, endedBefore, filter.KeepNewest)
+}
+
+func (repo *PostgresServiceRepository) GetEndedJobConfigVersions(ctx context.Context) ([]domain.ConfigVersionRef, error) {
+	var rows []struct {
+		ConfigID      uuid.UUID `db:"config_id"`
+		ConfigVersion uuid.UUID `db:"config_version"`
+	}
+	if err := repo.DB.SelectContext(ctx, &rows, queries.SelectEndedJobConfigVersionsSQL); err != nil {
+		return nil, fmt.Errorf("failed to get config versions of ended jobs: %w", err)
+	}
+
+	refs := make([]domain.ConfigVersionRef, 0, len(rows))
+	for _, row := range rows {
+		refs = append(refs, domain.ConfigVersionRef{ConfigID: row.ConfigID, Version: row.ConfigVersion})
+	}
+	return refs, nil
+}
+
+func (repo *PostgresServiceRepository) GetPurgeableJobs(ctx context.Context, filter domain.PurgeFilter, limit int) ([]uuid.UUID, error) {
+	condition, args := purgeableJobsCondition(filter)
+	query := repo.DB.Rebind(queries.SelectPurgeableJobsBaseSQL + condition + queries.SelectPurgeableJobsOrderSQL)
+
+	var jobIDs []uuid.UUID
+	if err := repo.DB.SelectContext(ctx, &jobIDs, query, append(args, limit)...); err != nil {
+		return nil, fmt.Errorf("failed to get purgeable jobs of config %s: %w", filter.ConfigID, err)
+	}
+	return jobIDs, nil
+}
+
+func (repo *PostgresServiceRepository) CountPurgeableJobs(ctx context.Context, filter domain.PurgeFilter) (int64, int64, error) {
+	condition, args := purgeableJobsCondition(filter)
+	query := repo.DB.Rebind(queries.CountPurgeableJobsBaseSQL + condition)
+
+	var counts struct {
+		Jobs     int64 `db:"jobs"`
+		TaskRuns int64 `db:"task_runs"`
+	}
+	if err := repo.DB.GetContext(ctx, &counts, query, args...); err != nil {
+		return 0, 0, fmt.Errorf("failed to count purgeable jobs of config %s: %w", filter.ConfigID, err)
+	}
+	return counts.Jobs, counts.TaskRuns, nil
+}
+
+func (repo *PostgresServiceRepository) DeleteJobs(ctx context.Context, jobIDs []uuid.UUID) (int64, int64, error) {
+	if len(jobIDs) == 0 {
+		return 0, 0, nil
+	}
+
+	countQuery, countArgs, err := sqlx.In(queries.CountTaskRunsOfJobsBaseSQL, jobIDs)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to build task run count query: %w", err)
+	}
+	deleteQuery, deleteArgs, err := sqlx.In(queries.DeleteJobsBaseSQL, jobIDs)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to build job delete query: %w", err)
+	}
+
+	tx, err := repo.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var taskRuns int64
+	if err := tx.GetContext(ctx, &taskRuns, tx.Rebind(countQuery), countArgs...); err != nil {
+		return 0, 0, fmt.Errorf("failed to count task runs of purged jobs: %w", err)
+	}
+
+	// task_runs, task_logs and webhook_deliveries rows cascade
+	result, err := tx.ExecContext(ctx, tx.Rebind(deleteQuery), deleteArgs...)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to delete jobs: %w", err)
+	}
+	jobs, err := result.RowsAffected()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to delete jobs: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return jobs, taskRuns, nil
+}
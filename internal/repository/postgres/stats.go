@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/repository/models"
+	"github.com/abikandiah/task-worker/internal/repository/queries"
+)
+
+var statsSQL = queries.NewStatsSQL(
+	func(from string, to string) string {
+		return fmt.Sprintf("EXTRACT(EPOCH FROM (%s - %s))", to, from)
+	},
+	func(column string) string {
+		return fmt.Sprintf("date_trunc('hour', %s)", column)
+	},
+)
+
+type hourlyThroughputDB struct {
+	Hour     time.Time `db:"hour"`
+	Ended    int       `db:"ended"`
+	Finished int       `db:"finished"`
+	Failed   int       `db:"failed"`
+}
+
+func (repo *PostgresServiceRepository) GetStats(ctx context.Context, statsRange domain.StatsRange) (*domain.Stats, error) {
+	args := []any{statsRange.From.UTC(), statsRange.To.UTC()}
+
+	var statsDB models.StatsDB
+	selects := []struct {
+		dest  any
+		query string
+		name  string
+	}{
+		{&statsDB.JobStates, statsSQL.JobStateCounts, "job states"},
+		{&statsDB.TaskStates, statsSQL.TaskRunStateCounts, "task run states"},
+		{&statsDB.QueueWait, statsSQL.QueueWait, "queue wait"},
+		{&statsDB.TaskDurations, statsSQL.TaskDurations, "task durations"},
+		{&statsDB.ConfigDurations, statsSQL.ConfigDurations, "config durations"},
+	}
+	for _, s := range selects {
+		if err := repo.DB.SelectContext(ctx, s.dest, repo.DB.Rebind(s.query), args...); err != nil {
+			return nil, fmt.Errorf("failed to get %s stats: %w", s.name, err)
+		}
+	}
+
+	var throughputDBs []hourlyThroughputDB
+	if err := repo.DB.SelectContext(ctx, &throughputDBs, repo.DB.Rebind(statsSQL.Throughput), args...); err != nil {
+		return nil, fmt.Errorf("failed to get throughput stats: %w", err)
+	}
+
+	throughput := make([]domain.HourlyThroughput, len(throughputDBs))
+	for i, throughputDB := range throughputDBs {
+		throughput[i] = domain.HourlyThroughput{
+			Hour:     throughputDB.Hour,
+			Ended:    throughputDB.Ended,
+			Finished: throughputDB.Finished,
+			Failed:   throughputDB.Failed,
+		}
+	}
+	return statsDB.ToDomainStats(statsRange, throughput)
+}
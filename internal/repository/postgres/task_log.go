@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/repository/models"
+	"github.com/abikandiah/task-worker/internal/repository/queries"
+	"github.com/google/uuid"
+)
+
+// --- SQL Constants for task_logs table ---
+const insertTaskLogSQL = `
+    INSERT INTO task_logs (
+        ` + queries.InsertTaskLogFields + `
+    ) VALUES (
+		$1, $2, $3, $4, $5, $6
+    )
+`
+
+const selectTaskLogsSQL = queries.SelectTaskLogsBaseSQL + `task_run_id = $1 AND seq > $2` + queries.SelectTaskLogsOrderSQL + `$3`
+
+type TaskLogDB struct {
+	models.CommonTaskLogDB
+	Time time.Time `db:"time"`
+}
+
+func (taskLogDB *TaskLogDB) ToDomainTaskLog() (*domain.TaskLog, error) {
+	taskLog, err := taskLogDB.ToDomainTaskLogBase()
+	if err != nil {
+		return nil, err
+	}
+
+	taskLog.Time = taskLogDB.Time
+	return taskLog, nil
+}
+
+func FromDomainTaskLog(taskLog domain.TaskLog) (*TaskLogDB, error) {
+	commonTaskLogDB, err := models.NewCommonTaskLogDB(taskLog)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TaskLogDB{
+		CommonTaskLogDB: commonTaskLogDB,
+		Time:            taskLog.Time.UTC(),
+	}, nil
+}
+
+func (repo *PostgresServiceRepository) SaveTaskLogs(ctx context.Context, taskLogs []domain.TaskLog) error {
+	if len(taskLogs) == 0 {
+		return nil
+	}
+
+	// Begin Transaction
+	tx, err := repo.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for task log save: %w", err)
+	}
+
+	for _, taskLog := range taskLogs {
+		taskLogDB, convErr := FromDomainTaskLog(taskLog)
+		if convErr != nil {
+			tx.Rollback()
+			return fmt.Errorf("conversion failed for task log of task run %s: %w", taskLog.TaskRunID, convErr)
+		}
+
+		_, execErr := tx.ExecContext(ctx, insertTaskLogSQL,
+			taskLogDB.JobID,
+			taskLogDB.TaskRunID,
+			taskLogDB.Time,
+			taskLogDB.Level,
+			taskLogDB.Message,
+			taskLogDB.AttrsJSON,
+		)
+		if execErr != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to insert task log for task run %s: %w", taskLog.TaskRunID, execErr)
+		}
+	}
+
+	// Commit Transaction
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction for task log save: %w", err)
+	}
+	return nil
+}
+
+func (repo *PostgresServiceRepository) GetTaskLogs(ctx context.Context, taskRunID uuid.UUID, afterSeq int64, limit int) ([]domain.TaskLog, error) {
+	var taskLogDBs []TaskLogDB
+	err := repo.DB.SelectContext(ctx, &taskLogDBs, selectTaskLogsSQL, taskRunID, afterSeq, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task logs for task run %s: %w", taskRunID, err)
+	}
+
+	taskLogs := make([]domain.TaskLog, len(taskLogDBs))
+	for i, taskLogDB := range taskLogDBs {
+		taskLog, convErr := taskLogDB.ToDomainTaskLog()
+		if convErr != nil {
+			return nil, fmt.Errorf("failed to convert task log DB model to domain model for seq %d: %w", taskLogDB.Seq, convErr)
+		}
+		taskLogs[i] = *taskLog
+	}
+
+	return taskLogs, nil
+}
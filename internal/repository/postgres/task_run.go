@@ -67,15 +67,9 @@ func FromDomainTaskRun(taskRun domain.TaskRun) (*TaskRunDB, error) {
 	}, nil
 }
 
-func (repo *PostgresServiceRepository) SaveTaskRun(ctx context.Context, taskRun domain.TaskRun) (*domain.TaskRun, error) {
-	// Convert domain model to database model, handling JSON marshaling errors
-	taskRunDB, err := FromDomainTaskRun(taskRun)
-	if err != nil {
-		return nil, err
-	}
-
-	// Execute the query using positional parameters
-	_, err = repo.DB.ExecContext(ctx, upsertTaskRunSQL,
+// upsertArgs are the positional parameters of upsertTaskRunSQL
+func (taskRunDB *TaskRunDB) upsertArgs() []any {
+	return []any{
 		taskRunDB.ID,
 		taskRunDB.JobID,
 		taskRunDB.Name,
@@ -85,7 +79,18 @@ func (repo *PostgresServiceRepository) SaveTaskRun(ctx context.Context, taskRun
 		taskRunDB.StartDate,
 		taskRunDB.EndDate,
 		taskRunDB.DetailsJSON,
-	)
+	}
+}
+
+func (repo *PostgresServiceRepository) SaveTaskRun(ctx context.Context, taskRun domain.TaskRun) (*domain.TaskRun, error) {
+	// Convert domain model to database model, handling JSON marshaling errors
+	taskRunDB, err := FromDomainTaskRun(taskRun)
+	if err != nil {
+		return nil, err
+	}
+
+	// Execute the query using positional parameters
+	_, err = repo.DB.ExecContext(ctx, upsertTaskRunSQL, taskRunDB.upsertArgs()...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to upsert task run %s: %w", taskRunDB.ID, err)
 	}
@@ -115,17 +120,7 @@ func (repo *PostgresServiceRepository) SaveTaskRuns(ctx context.Context, taskRun
 			return nil, fmt.Errorf("conversion failed for task run %s: %w", taskRun.ID, convErr)
 		}
 
-		_, execErr := tx.ExecContext(ctx, upsertTaskRunSQL,
-			taskRunDB.ID,
-			taskRunDB.JobID,
-			taskRunDB.Name,
-			taskRunDB.Description,
-			taskRunDB.TaskName,
-			taskRunDB.State,
-			taskRunDB.StartDate,
-			taskRunDB.EndDate,
-			taskRunDB.DetailsJSON,
-		)
+		_, execErr := tx.ExecContext(ctx, upsertTaskRunSQL, taskRunDB.upsertArgs()...)
 		if execErr != nil {
 			tx.Rollback()
 			return nil, fmt.Errorf("failed to upsert task run %s in transaction: %w", taskRunDB.ID, execErr)
@@ -186,6 +181,7 @@ func (repo *PostgresServiceRepository) GetAllTaskRuns(ctx context.Context, curso
 	pq := &db.PaginationQuery{
 		BaseQuery:     queries.SelectPaginationTaskRunSQL,
 		AllowedFields: queries.TaskRunPaginationAllowedFields,
+		FilterFields:  queries.TaskRunFilterFields,
 	}
 
 	dbOutput, err := db.Paginate[TaskRunDB](ctx, repo.DB, pq, cursor)
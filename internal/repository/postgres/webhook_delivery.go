@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/repository/models"
+	"github.com/abikandiah/task-worker/internal/repository/queries"
+	"github.com/google/uuid"
+)
+
+// --- SQL Constants for webhook_deliveries table ---
+const insertWebhookDeliverySQL = `
+    INSERT INTO webhook_deliveries (
+        ` + queries.SelectWebhookDeliveryFields + `
+    ) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+    )
+`
+
+const upsertWebhookDeliverySQL = insertWebhookDeliverySQL + queries.UpsertWebhookDeliveryConflictClause
+
+const selectWebhookDeliveriesByJobSQL = queries.SelectWebhookDeliveriesByJobBaseSQL + `$1` + queries.SelectWebhookDeliveriesByJobOrderSQL
+
+const selectDueWebhookDeliveriesSQL = queries.SelectDueWebhookDeliveriesBaseSQL + `$1` + queries.SelectDueWebhookDeliveriesOrderSQL + `$2`
+
+const claimWebhookDeliverySQL = queries.ClaimWebhookDeliveryBaseSQL + `$1
+	WHERE 
+		id = $2 AND state = 'PENDING' AND next_attempt_date <= $3
+`
+
+type WebhookDeliveryDB struct {
+	models.CommonWebhookDeliveryDB
+	NextAttemptDate time.Time  `db:"next_attempt_date"`
+	CreatedDate     time.Time  `db:"created_date"`
+	DeliveredDate   *time.Time `db:"delivered_date"`
+}
+
+func (deliveryDB *WebhookDeliveryDB) ToDomainWebhookDelivery() *domain.WebhookDelivery {
+	delivery := deliveryDB.ToDomainWebhookDeliveryBase()
+
+	// Use native time.Time types directly
+	delivery.NextAttemptDate = deliveryDB.NextAttemptDate
+	delivery.CreatedDate = deliveryDB.CreatedDate
+	delivery.DeliveredDate = deliveryDB.DeliveredDate
+
+	return delivery
+}
+
+func FromDomainWebhookDelivery(delivery domain.WebhookDelivery) *WebhookDeliveryDB {
+	createdDate := delivery.CreatedDate
+	if createdDate.IsZero() {
+		createdDate = time.Now().UTC()
+	}
+
+	return &WebhookDeliveryDB{
+		CommonWebhookDeliveryDB: models.NewCommonWebhookDeliveryDB(delivery),
+		NextAttemptDate:         delivery.NextAttemptDate.UTC(),
+		CreatedDate:             createdDate.UTC(),
+		DeliveredDate:           delivery.DeliveredDate,
+	}
+}
+
+func (repo *PostgresServiceRepository) SaveWebhookDelivery(ctx context.Context, delivery domain.WebhookDelivery) (*domain.WebhookDelivery, error) {
+	deliveryDB := FromDomainWebhookDelivery(delivery)
+
+	_, err := repo.DB.ExecContext(ctx, upsertWebhookDeliverySQL,
+		deliveryDB.ID,
+		deliveryDB.JobID,
+		deliveryDB.URL,
+		deliveryDB.EventType,
+		deliveryDB.PayloadJSON,
+		deliveryDB.State,
+		deliveryDB.Attempts,
+		deliveryDB.LastStatusCode,
+		deliveryDB.LastError,
+		deliveryDB.NextAttemptDate,
+		deliveryDB.CreatedDate,
+		deliveryDB.DeliveredDate,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert webhook delivery %s: %w", deliveryDB.ID, err)
+	}
+
+	return deliveryDB.ToDomainWebhookDelivery(), nil
+}
+
+func (repo *PostgresServiceRepository) GetWebhookDeliveries(ctx context.Context, jobID uuid.UUID) ([]domain.WebhookDelivery, error) {
+	var deliveryDBs []WebhookDeliveryDB
+	err := repo.DB.SelectContext(ctx, &deliveryDBs, selectWebhookDeliveriesByJobSQL, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries for job %s: %w", jobID, err)
+	}
+
+	return toDomainWebhookDeliveries(deliveryDBs), nil
+}
+
+func (repo *PostgresServiceRepository) GetDueWebhookDeliveries(ctx context.Context, before time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	var deliveryDBs []WebhookDeliveryDB
+	err := repo.DB.SelectContext(ctx, &deliveryDBs, selectDueWebhookDeliveriesSQL, before.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due webhook deliveries: %w", err)
+	}
+
+	return toDomainWebhookDeliveries(deliveryDBs), nil
+}
+
+func (repo *PostgresServiceRepository) ClaimWebhookDelivery(ctx context.Context, deliveryID uuid.UUID, now time.Time, leaseUntil time.Time) (bool, error) {
+	result, err := repo.DB.ExecContext(ctx, claimWebhookDeliverySQL, leaseUntil.UTC(), deliveryID, now.UTC())
+	if err != nil {
+		return false, fmt.Errorf("failed to claim webhook delivery %s: %w", deliveryID, err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim webhook delivery %s: %w", deliveryID, err)
+	}
+	return rows == 1, nil
+}
+
+func toDomainWebhookDeliveries(deliveryDBs []WebhookDeliveryDB) []domain.WebhookDelivery {
+	deliveries := make([]domain.WebhookDelivery, len(deliveryDBs))
+	for i, deliveryDB := range deliveryDBs {
+		deliveries[i] = *deliveryDB.ToDomainWebhookDelivery()
+	}
+	return deliveries
+}
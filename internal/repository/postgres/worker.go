@@ -0,0 +1,154 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/repository/models"
+	"github.com/abikandiah/task-worker/internal/repository/queries"
+	"github.com/google/uuid"
+)
+
+// --- SQL Constants for workers table ---
+const insertWorkerSQL = `
+	INSERT INTO workers (
+		` + queries.SelectWorkerFields + `
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+	)
+`
+
+const upsertWorkerSQL = insertWorkerSQL + queries.UpsertWorkerConflictClause
+
+const updateWorkerHeartbeatSQL = queries.UpdateWorkerHeartbeatBaseSQL + `$1, leader = $2 WHERE id = $3`
+
+const stopWorkerSQL = queries.StopWorkerBaseSQL + `$1 WHERE id = $2`
+
+const recoverJobsSQL = queries.RecoverJobsBaseSQL + queries.DeadJobsCondition + `$1)
+	RETURNING 
+		id
+`
+
+const resetRecoveredTaskRunsSQL = queries.ResetRecoveredTaskRunsBaseSQL + `$1`
+
+type WorkerDB struct {
+	models.CommonWorkerDB
+	StartedDate   time.Time  `db:"started_date"`
+	HeartbeatDate time.Time  `db:"heartbeat_date"`
+	StoppedDate   *time.Time `db:"stopped_date"`
+}
+
+func (workerDB *WorkerDB) ToDomainWorker() (*domain.Worker, error) {
+	worker, err := workerDB.ToDomainWorkerBase()
+	if err != nil {
+		return nil, err
+	}
+
+	worker.StartedDate = workerDB.StartedDate
+	worker.HeartbeatDate = workerDB.HeartbeatDate
+	worker.StoppedDate = workerDB.StoppedDate
+	return worker, nil
+}
+
+func FromDomainWorker(worker domain.Worker) (*WorkerDB, error) {
+	commonWorkerDB, err := models.NewCommonWorkerDB(worker)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WorkerDB{
+		CommonWorkerDB: commonWorkerDB,
+		StartedDate:    worker.StartedDate.UTC(),
+		HeartbeatDate:  worker.HeartbeatDate.UTC(),
+		StoppedDate:    worker.StoppedDate,
+	}, nil
+}
+
+func (repo *PostgresServiceRepository) SaveWorker(ctx context.Context, worker domain.Worker) (*domain.Worker, error) {
+	workerDB, err := FromDomainWorker(worker)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = repo.DB.ExecContext(ctx, upsertWorkerSQL,
+		workerDB.ID,
+		workerDB.Hostname,
+		workerDB.Version,
+		workerDB.JobWorkerCount,
+		workerDB.TaskWorkerCount,
+		workerDB.TaskNamesJSON,
+		workerDB.StartedDate,
+		workerDB.HeartbeatDate,
+		workerDB.StoppedDate,
+		workerDB.Leader,
+		workerDB.QueuesJSON,
+		workerDB.CapabilitiesJSON,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert worker %s: %w", worker.ID, err)
+	}
+	return workerDB.ToDomainWorker()
+}
+
+func (repo *PostgresServiceRepository) UpdateWorkerHeartbeat(ctx context.Context, workerID string, heartbeat time.Time, leader bool) (bool, error) {
+	result, err := repo.DB.ExecContext(ctx, updateWorkerHeartbeatSQL, heartbeat.UTC(), leader, workerID)
+	if err != nil {
+		return false, fmt.Errorf("failed to update heartbeat of worker %s: %w", workerID, err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update heartbeat of worker %s: %w", workerID, err)
+	}
+	return rows == 1, nil
+}
+
+func (repo *PostgresServiceRepository) StopWorker(ctx context.Context, workerID string, stopped time.Time) error {
+	if _, err := repo.DB.ExecContext(ctx, stopWorkerSQL, stopped.UTC(), workerID); err != nil {
+		return fmt.Errorf("failed to stop worker %s: %w", workerID, err)
+	}
+	return nil
+}
+
+func (repo *PostgresServiceRepository) GetWorkers(ctx context.Context) ([]domain.Worker, error) {
+	var workerDBs []WorkerDB
+	if err := repo.DB.SelectContext(ctx, &workerDBs, queries.SelectWorkersSQL); err != nil {
+		return nil, fmt.Errorf("failed to get workers: %w", err)
+	}
+
+	workers := make([]domain.Worker, len(workerDBs))
+	for i, workerDB := range workerDBs {
+		worker, err := workerDB.ToDomainWorker()
+		if err != nil {
+			return nil, err
+		}
+		workers[i] = *worker
+	}
+	return workers, nil
+}
+
+func (repo *PostgresServiceRepository) RecoverJobs(ctx context.Context, aliveSince time.Time) ([]uuid.UUID, error) {
+	tx, err := repo.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var jobIDs []uuid.UUID
+	if err := tx.SelectContext(ctx, &jobIDs, recoverJobsSQL, aliveSince.UTC()); err != nil {
+		return nil, fmt.Errorf("failed to recover jobs: %w", err)
+	}
+
+	for _, jobID := range jobIDs {
+		if _, err := tx.ExecContext(ctx, resetRecoveredTaskRunsSQL, jobID); err != nil {
+			return nil, fmt.Errorf("failed to reset task runs of recovered job %s: %w", jobID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return jobIDs, nil
+}
@@ -0,0 +1,29 @@
+package queries
+
+// SelectEventFields contains all column names for the events table
+const SelectEventFields = "seq, origin, payload, created_date"
+
+// InsertEventFields contains the columns written on insert, seq is generated
+const InsertEventFields = "origin, payload, created_date"
+
+// SelectEventsBaseSQL retrieves events after a sequence number
+// Database-specific implementations add the parameter placeholders
+const SelectEventsBaseSQL = `
+	SELECT 
+		` + SelectEventFields + `
+	FROM 
+		events
+	WHERE 
+		seq > `
+
+// SelectEventsOrderSQL is the ORDER BY clause for events
+const SelectEventsOrderSQL = `
+	ORDER BY 
+		seq ASC
+	LIMIT `
+
+// SelectLastEventSeqSQL returns the sequence number of the latest event, 0 when there are none
+const SelectLastEventSeqSQL = `SELECT COALESCE(MAX(seq), 0) FROM events`
+
+// DeleteEventsBaseSQL deletes events created before a time
+const DeleteEventsBaseSQL = `DELETE FROM events WHERE created_date < `
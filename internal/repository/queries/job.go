@@ -1,7 +1,13 @@
 package queries
 
-// SelectJobFields contains all column names for the jobs table
-const SelectJobFields = "id, name, description, config_id, config_version, state, progress, submit_date, start_date, end_date"
+import "github.com/abikandiah/task-worker/internal/platform/db"
+
+// InsertJobFields contains the column names written when saving a job
+const InsertJobFields = "id, name, description, config_id, config_version, state, progress, submit_date, start_date, end_date, variables, callbacks, tags, queue, capabilities, concurrency_key, concurrency_limit, expires_at, template_id, template_version, trace_context"
+
+// SelectJobFields contains all column names for the jobs table. worker_id is only written by
+// claims, so saving a job never overwrites which worker holds it.
+const SelectJobFields = InsertJobFields + ", worker_id"
 
 // SelectPaginationJobSQL is the base query for paginated job retrieval
 const SelectPaginationJobSQL = `
@@ -14,8 +20,26 @@ const SelectPaginationJobSQL = `
 // JobPaginationAllowedFields defines which fields can be used for sorting/filtering
 var JobPaginationAllowedFields = []string{"id", "state", "submit_date", "start_date", "end_date"}
 
-// UpsertJobConflictClause contains the common ON CONFLICT UPDATE logic
-// Database-specific implementations prepend their INSERT statement
+// JobFilterFields defines which fields jobs can be filtered on. taskName matches jobs with a task run of the task.
+var JobFilterFields = map[string]db.FilterField{
+	"name":            {Column: "name", Type: db.FilterText},
+	"state":           {Column: "state", Type: db.FilterState},
+	"queue":           {Column: "queue", Type: db.FilterText},
+	"concurrencyKey":  {Column: "concurrency_key", Type: db.FilterText},
+	"workerId":        {Column: "worker_id", Type: db.FilterText},
+	"configId":        {Column: "config_id", Type: db.FilterUUID},
+	"configVersion":   {Column: "config_version", Type: db.FilterUUID},
+	"templateId":      {Column: "template_id", Type: db.FilterUUID},
+	"templateVersion": {Column: "template_version", Type: db.FilterUUID},
+	"submitDate":      {Column: "submit_date", Type: db.FilterTime},
+	"startDate":       {Column: "start_date", Type: db.FilterTime},
+	"endDate":         {Column: "end_date", Type: db.FilterTime},
+	"expiresAt":       {Column: "expires_at", Type: db.FilterTime},
+	"taskName":        {Column: "task_name", Type: db.FilterText, Condition: "id IN (SELECT job_id FROM task_runs WHERE %s)"},
+}
+
+// UpsertJobConflictClause contains the common ON CONFLICT UPDATE logic, trace_context is kept from
+// the submission. Database-specific implementations prepend their INSERT statement
 const UpsertJobConflictClause = `
     ON CONFLICT (id) DO UPDATE SET
         name = EXCLUDED.name,
@@ -25,5 +49,149 @@ const UpsertJobConflictClause = `
         state = EXCLUDED.state,
         progress = EXCLUDED.progress,
         start_date = EXCLUDED.start_date,
-        end_date = EXCLUDED.end_date
+        end_date = EXCLUDED.end_date,
+        variables = EXCLUDED.variables,
+        callbacks = EXCLUDED.callbacks,
+        tags = EXCLUDED.tags,
+        queue = EXCLUDED.queue,
+        capabilities = EXCLUDED.capabilities,
+        concurrency_key = EXCLUDED.concurrency_key,
+        concurrency_limit = EXCLUDED.concurrency_limit,
+        expires_at = EXCLUDED.expires_at,
+        template_id = EXCLUDED.template_id,
+        template_version = EXCLUDED.template_version
+`
+
+// ClaimJobsBaseSQL assigns unclaimed pending jobs to a worker, oldest first.
+// Database-specific implementations add the placeholders and the row selection.
+const ClaimJobsBaseSQL = `
+    UPDATE 
+        jobs
+    SET 
+        worker_id = `
+
+// SelectClaimableJobsSQL selects the IDs of pending jobs in a queue that no worker has claimed
+// Database-specific implementations add the queue placeholder, a condition matching the job's
+// capabilities against the worker's, then SelectClaimableJobsOrderSQL
+const SelectClaimableJobsSQL = `
+        SELECT 
+            id
+        FROM 
+            jobs
+        WHERE 
+            state = 'PENDING' AND worker_id IS NULL AND queue = `
+
+// ClaimableConcurrencyCondition holds back jobs whose concurrency key is at its limit. Jobs of the
+// key that are claimed or running, or pending ahead of the job, count towards the limit, so a batch
+// never claims more than the limit allows and jobs of a key start in submission order.
+const ClaimableConcurrencyCondition = `
+            AND (concurrency_key IS NULL OR (
+                SELECT 
+                    COUNT(*)
+                FROM 
+                    jobs other
+                WHERE 
+                    other.concurrency_key = jobs.concurrency_key AND other.id <> jobs.id AND (
+                        other.state = 'RUNNING' OR (other.state = 'PENDING' AND (
+                            other.worker_id IS NOT NULL OR other.submit_date < jobs.submit_date OR
+                            (other.submit_date = jobs.submit_date AND other.id < jobs.id)
+                        ))
+                    )
+            ) < concurrency_limit)`
+
+// SelectClaimableJobsOrderSQL claims the oldest jobs first
+const SelectClaimableJobsOrderSQL = `
+        ORDER BY 
+            submit_date ASC
+        LIMIT `
+
+// SelectUnclaimedCapabilityJobsSQL selects pending jobs requiring capabilities that no worker claimed
+// Database-specific implementations add the submit date and limit placeholders
+const SelectUnclaimedCapabilityJobsSQL = `
+    SELECT 
+        ` + SelectJobFields + `
+    FROM 
+        jobs
+    WHERE 
+        state = 'PENDING' AND worker_id IS NULL AND capabilities IS NOT NULL AND submit_date < `
+
+// FailUnclaimedJobBaseSQL ends a pending job in error, unless a worker claimed it meanwhile
+// Database-specific implementations add the end date and ID placeholders
+const FailUnclaimedJobBaseSQL = `
+    UPDATE 
+        jobs
+    SET 
+        state = 'ERROR', end_date = `
+
+// CountActiveJobsBaseSQL counts the unfinished jobs of a concurrency key
+// Database-specific implementations add the key placeholder
+const CountActiveJobsBaseSQL = `
+    SELECT 
+        COUNT(*)
+    FROM 
+        jobs
+    WHERE 
+        state IN ('PENDING', 'RUNNING') AND concurrency_key = `
+
+// StopPendingJobsBaseSQL stops the unclaimed pending jobs of a concurrency key
+// Database-specific implementations add the end date and key placeholders
+const StopPendingJobsBaseSQL = `
+    UPDATE 
+        jobs
+    SET 
+        state = 'STOPPED', end_date = `
+
+// StopPendingTaskRunsBaseSQL stops the pending task runs of a stopped job
+// Database-specific implementations add the end date and job ID placeholders
+const StopPendingTaskRunsBaseSQL = `
+    UPDATE 
+        task_runs
+    SET 
+        state = 'STOPPED', end_date = `
+
+// ExpireJobsBaseSQL expires unclaimed pending jobs that never started before their deadline
+// Database-specific implementations add the end date and deadline placeholders
+const ExpireJobsBaseSQL = `
+    UPDATE 
+        jobs
+    SET 
+        state = 'EXPIRED', end_date = `
+
+// ExpireJobsCondition matches the jobs ExpireJobsBaseSQL expires
+const ExpireJobsCondition = `
+    WHERE 
+        state = 'PENDING' AND worker_id IS NULL AND start_date IS NULL AND expires_at <= `
+
+// ExpireTaskRunsBaseSQL expires the pending task runs of an expired job
+// Database-specific implementations add the end date and job ID placeholders
+const ExpireTaskRunsBaseSQL = `
+    UPDATE 
+        task_runs
+    SET 
+        state = 'EXPIRED', end_date = `
+
+// ReleaseJobClaimsBaseSQL hands a worker's jobs that have not started back to other workers
+const ReleaseJobClaimsBaseSQL = `
+    UPDATE 
+        jobs
+    SET 
+        worker_id = NULL, claim_date = NULL
+    WHERE 
+        state = 'PENDING' AND worker_id = `
+
+// SelectQueueDepthsSQL counts unfinished jobs per queue
+const SelectQueueDepthsSQL = `
+    SELECT 
+        queue,
+        SUM(CASE WHEN state = 'PENDING' AND worker_id IS NULL THEN 1 ELSE 0 END) AS pending,
+        SUM(CASE WHEN state = 'PENDING' AND worker_id IS NOT NULL THEN 1 ELSE 0 END) AS claimed,
+        SUM(CASE WHEN state = 'RUNNING' THEN 1 ELSE 0 END) AS running
+    FROM 
+        jobs
+    WHERE 
+        state IN ('PENDING', 'RUNNING')
+    GROUP BY 
+        queue
+    ORDER BY 
+        queue
 `
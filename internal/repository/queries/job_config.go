@@ -1,7 +1,7 @@
 package queries
 
 // SelectConfigFields contains all column names for the job_configs table
-const SelectConfigFields = "id, name, description, is_default, version, details"
+const SelectConfigFields = "id, name, description, is_default, version, details, create_date"
 
 // SelectDefaultJobConfigSQL retrieves the default job configuration
 const SelectDefaultJobConfigSQL = `
@@ -13,16 +13,54 @@ const SelectDefaultJobConfigSQL = `
         is_default = TRUE
 `
 
-// SelectPaginationConfigSQL is the base query for paginated job config retrieval
+// SelectJobConfigVersionsBaseSQL retrieves the versions of a config
+// Database-specific implementations add the ID placeholder
+const SelectJobConfigVersionsBaseSQL = `
+    SELECT 
+        ` + SelectConfigFields + `
+    FROM 
+        job_configs
+    WHERE id = `
+
+// SelectJobConfigVersionsOrderSQL orders config versions newest first
+const SelectJobConfigVersionsOrderSQL = `
+    ORDER BY 
+        create_date DESC, version DESC
+`
+
+// SelectPaginationConfigSQL is the base query for paginated retrieval of the latest version of each config.
+// Versions created at the same time are ordered by version.
 const SelectPaginationConfigSQL = `
     SELECT 
         ` + SelectConfigFields + `
     FROM 
         job_configs
+    WHERE NOT EXISTS (
+        SELECT 1 FROM job_configs newer
+        WHERE newer.id = job_configs.id AND (newer.create_date > job_configs.create_date
+            OR (newer.create_date = job_configs.create_date AND newer.version > job_configs.version))
+    )
+`
+
+// SelectPaginationConfigVersionsSQL is the base query for paginated retrieval of the versions of a config
+const SelectPaginationConfigVersionsSQL = `
+    SELECT 
+        ` + SelectConfigFields + `
+    FROM 
+        job_configs
+    WHERE id = ?
 `
 
 // JobConfigPaginationAllowedFields defines which fields can be used for sorting/filtering
-var JobConfigPaginationAllowedFields = []string{"id", "name", "version"}
+var JobConfigPaginationAllowedFields = []string{"id", "name", "version", "create_date"}
+
+// JobConfigVersionPaginationAllowedFields defines which fields versions of a config can be sorted by
+var JobConfigVersionPaginationAllowedFields = []string{"version", "name", "create_date"}
+
+// ClearDefaultJobConfigSQL unsets the default config, so another can be set within the same transaction
+const ClearDefaultJobConfigSQL = `
+    UPDATE job_configs SET is_default = FALSE WHERE is_default = TRUE
+`
 
 // UpsertJobConfigConflictClause contains the common ON CONFLICT UPDATE logic
 // Database-specific implementations prepend their INSERT statement
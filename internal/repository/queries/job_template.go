@@ -0,0 +1,42 @@
+package queries
+
+// SelectJobTemplateFields contains all column names for the job_templates table
+const SelectJobTemplateFields = "id, version, name, description, details, create_date"
+
+// InsertJobTemplateBaseSQL inserts a template version, versions are never updated
+// Database-specific implementations add the placeholders
+const InsertJobTemplateBaseSQL = `
+    INSERT INTO job_templates (
+        ` + SelectJobTemplateFields + `
+    ) VALUES (`
+
+// SelectJobTemplateVersionsBaseSQL retrieves the versions of a template, newest first
+// Database-specific implementations add the ID placeholder
+const SelectJobTemplateVersionsBaseSQL = `
+    SELECT 
+        ` + SelectJobTemplateFields + `
+    FROM 
+        job_templates
+    WHERE 
+        id = `
+
+// SelectJobTemplateVersionsOrderSQL orders template versions newest first
+const SelectJobTemplateVersionsOrderSQL = `
+    ORDER BY 
+        create_date DESC
+`
+
+// SelectPaginationJobTemplateSQL is the base query for paginated retrieval of the latest version of each template
+const SelectPaginationJobTemplateSQL = `
+    SELECT 
+        ` + SelectJobTemplateFields + `
+    FROM 
+        job_templates
+    WHERE NOT EXISTS (
+        SELECT 1 FROM job_templates newer
+        WHERE newer.id = job_templates.id AND newer.create_date > job_templates.create_date
+    )
+`
+
+// JobTemplatePaginationAllowedFields defines which fields can be used for sorting/filtering
+var JobTemplatePaginationAllowedFields = []string{"id", "name", "create_date"}
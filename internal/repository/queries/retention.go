@@ -0,0 +1,93 @@
+package queries
+
+import (
+	"strings"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/google/uuid"
+)
+
+// EndedStatesList is domain.EndedStates as a SQL list
+var EndedStatesList = func() string {
+	states := make([]string, len(domain.EndedStates))
+	for i, state := range domain.EndedStates {
+		states[i] = "'" + string(state) + "'"
+	}
+	return "(" + strings.Join(states, ", ") + ")"
+}()
+
+// SelectEndedJobConfigVersionsSQL selects the config versions that have ended jobs
+var SelectEndedJobConfigVersionsSQL = `
+    SELECT DISTINCT 
+        config_id, config_version
+    FROM 
+        jobs
+    WHERE 
+        state IN ` + EndedStatesList
+
+// PurgeableJobsCondition builds the WHERE clause selecting the jobs of a PurgeFilter, with ?
+// placeholders. endedBefore is the driver's representation of the filter's time, or nil. The
+// newest jobs kept are those of the whole config, whatever the version.
+func PurgeableJobsCondition(configID uuid.UUID, configVersion uuid.UUID, state domain.ExecutionState, endedBefore any, keepNewest int) (string, []any) {
+	var condition strings.Builder
+	args := []any{configID}
+
+	condition.WriteString(`
+    WHERE 
+        config_id = ?`)
+
+	if configVersion != uuid.Nil {
+		condition.WriteString(` AND config_version = ?`)
+		args = append(args, configVersion)
+	}
+
+	if state != "" {
+		condition.WriteString(` AND state = ?`)
+		args = append(args, string(state))
+	} else {
+		condition.WriteString(` AND state IN ` + EndedStatesList)
+	}
+
+	if endedBefore != nil {
+		condition.WriteString(` AND end_date < ?`)
+		args = append(args, endedBefore)
+	}
+
+	if keepNewest > 0 {
+		condition.WriteString(` AND id NOT IN (
+            SELECT id FROM jobs WHERE config_id = ? AND state IN ` + EndedStatesList + `
+            ORDER BY end_date DESC, id DESC LIMIT ?
+        )`)
+		args = append(args, configID, keepNewest)
+	}
+	return condition.String(), args
+}
+
+// SelectPurgeableJobsBaseSQL selects the IDs of purgeable jobs, the oldest first
+// Database-specific implementations add PurgeableJobsCondition and SelectPurgeableJobsOrderSQL
+const SelectPurgeableJobsBaseSQL = `
+    SELECT 
+        id
+    FROM 
+        jobs`
+
+const SelectPurgeableJobsOrderSQL = `
+    ORDER BY 
+        end_date ASC
+    LIMIT ?`
+
+// CountPurgeableJobsBaseSQL counts purgeable jobs and their task runs
+// Database-specific implementations add PurgeableJobsCondition
+const CountPurgeableJobsBaseSQL = `
+    SELECT 
+        COUNT(*) AS jobs,
+        COALESCE(SUM((SELECT COUNT(*) FROM task_runs WHERE task_runs.job_id = jobs.id)), 0) AS task_runs
+    FROM 
+        jobs`
+
+// CountTaskRunsOfJobsBaseSQL counts the task runs of jobs, sqlx.In expands the IDs
+const CountTaskRunsOfJobsBaseSQL = `SELECT COUNT(*) FROM task_runs WHERE job_id IN (?)`
+
+// DeleteJobsBaseSQL deletes jobs, their task runs, logs and webhook deliveries cascade.
+// sqlx.In expands the IDs.
+const DeleteJobsBaseSQL = `DELETE FROM jobs WHERE id IN (?)`
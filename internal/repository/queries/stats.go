@@ -0,0 +1,93 @@
+package queries
+
+import "fmt"
+
+// StatsSQL holds the statistics queries of a driver, each takes the range's from and to as ? placeholders
+type StatsSQL struct {
+	JobStateCounts     string
+	TaskRunStateCounts string
+	Throughput         string
+	QueueWait          string
+	TaskDurations      string
+	ConfigDurations    string
+}
+
+// NewStatsSQL builds the statistics queries from the expressions that differ between drivers,
+// secondsBetween is the seconds from one date column to another and hour truncates a date column
+func NewStatsSQL(secondsBetween func(from string, to string) string, hour func(column string) string) StatsSQL {
+	return StatsSQL{
+		JobStateCounts:     selectJobStateCountsSQL,
+		TaskRunStateCounts: selectTaskRunStateCountsSQL,
+		Throughput:         selectThroughputSQL(hour("end_date")),
+		QueueWait:          selectDurationStatsSQL("jobs", "queue", secondsBetween("submit_date", "start_date"), "start_date"),
+		TaskDurations:      selectDurationStatsSQL("task_runs", "task_name", secondsBetween("start_date", "end_date"), "end_date"),
+		ConfigDurations:    selectDurationStatsSQL("jobs", "config_id", secondsBetween("start_date", "end_date"), "end_date"),
+	}
+}
+
+const selectJobStateCountsSQL = `
+    SELECT
+        state, COUNT(*) AS count
+    FROM
+        jobs
+    WHERE
+        submit_date >= ? AND submit_date < ?
+    GROUP BY
+        state
+`
+
+const selectTaskRunStateCountsSQL = `
+    SELECT
+        state, COUNT(*) AS count
+    FROM
+        task_runs
+    WHERE
+        job_id IN (SELECT id FROM jobs WHERE submit_date >= ? AND submit_date < ?)
+    GROUP BY
+        state
+`
+
+func selectThroughputSQL(hour string) string {
+	return `
+    SELECT
+        ` + hour + ` AS hour,
+        COUNT(*) AS ended,
+        SUM(CASE WHEN state = 'FINISHED' THEN 1 ELSE 0 END) AS finished,
+        SUM(CASE WHEN state = 'ERROR' THEN 1 ELSE 0 END) AS failed
+    FROM
+        jobs
+    WHERE
+        end_date >= ? AND end_date < ? AND state IN ` + EndedStatesList + `
+    GROUP BY
+        hour
+    ORDER BY
+        hour
+`
+}
+
+// selectDurationStatsSQL summarizes seconds per key over the rows of table whose rangeColumn is in the
+// range. Percentiles are nearest rank, the smallest value whose rank is at least the percentile of the
+// key's count, which the window functions sqlite and postgres share can compute.
+func selectDurationStatsSQL(table string, key string, seconds string, rangeColumn string) string {
+	return fmt.Sprintf(`
+    SELECT
+        stat_key, COUNT(*) AS count, AVG(seconds) AS avg,
+        MIN(CASE WHEN row_num * 100 >= 50 * samples THEN seconds END) AS p50,
+        MIN(CASE WHEN row_num * 100 >= 95 * samples THEN seconds END) AS p95,
+        MIN(CASE WHEN row_num * 100 >= 99 * samples THEN seconds END) AS p99
+    FROM (
+        SELECT
+            %[2]s AS stat_key, %[3]s AS seconds,
+            ROW_NUMBER() OVER (PARTITION BY %[2]s ORDER BY %[3]s) AS row_num,
+            COUNT(*) OVER (PARTITION BY %[2]s) AS samples
+        FROM
+            %[1]s
+        WHERE
+            %[4]s >= ? AND %[4]s < ? AND %[3]s IS NOT NULL
+    ) durations
+    GROUP BY
+        stat_key
+    ORDER BY
+        stat_key
+`, table, key, seconds, rangeColumn)
+}
@@ -0,0 +1,23 @@
+package queries
+
+// SelectTaskLogFields contains all column names for the task_logs table
+const SelectTaskLogFields = "seq, job_id, task_run_id, time, level, message, attrs"
+
+// InsertTaskLogFields contains the columns written on insert, seq is generated
+const InsertTaskLogFields = "job_id, task_run_id, time, level, message, attrs"
+
+// SelectTaskLogsBaseSQL retrieves a TaskRun's logs after a sequence number
+// Database-specific implementations add the parameter placeholders
+const SelectTaskLogsBaseSQL = `
+	SELECT 
+		` + SelectTaskLogFields + `
+	FROM 
+		task_logs
+	WHERE 
+`
+
+// SelectTaskLogsOrderSQL is the ORDER BY clause for a TaskRun's logs
+const SelectTaskLogsOrderSQL = `
+	ORDER BY 
+		seq ASC
+	LIMIT `
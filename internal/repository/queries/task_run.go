@@ -1,5 +1,7 @@
 package queries
 
+import "github.com/abikandiah/task-worker/internal/platform/db"
+
 // SelectTaskRunFields contains all column names for the task_runs table
 const SelectTaskRunFields = "id, job_id, name, description, task_name, state, start_date, end_date, details"
 
@@ -28,6 +30,16 @@ const SelectPaginationTaskRunSQL = `
         task_runs
 `
 
+// TaskRunFilterFields defines which fields task runs can be filtered on
+var TaskRunFilterFields = map[string]db.FilterField{
+	"name":      {Column: "name", Type: db.FilterText},
+	"taskName":  {Column: "task_name", Type: db.FilterText},
+	"state":     {Column: "state", Type: db.FilterState},
+	"jobId":     {Column: "job_id", Type: db.FilterUUID},
+	"startDate": {Column: "start_date", Type: db.FilterTime},
+	"endDate":   {Column: "end_date", Type: db.FilterTime},
+}
+
 // TaskRunPaginationAllowedFields defines which fields can be used for sorting/filtering
 var TaskRunPaginationAllowedFields = []string{"id", "job_id", "task_name", "state", "start_date", "end_date"}
 
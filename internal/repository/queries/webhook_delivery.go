@@ -0,0 +1,56 @@
+package queries
+
+// SelectWebhookDeliveryFields contains all column names for the webhook_deliveries table
+const SelectWebhookDeliveryFields = "id, job_id, url, event_type, payload, state, attempts, last_status_code, last_error, next_attempt_date, created_date, delivered_date"
+
+// SelectWebhookDeliveriesByJobBaseSQL retrieves all deliveries for a job
+// Database-specific implementations add the appropriate parameter placeholder
+const SelectWebhookDeliveriesByJobBaseSQL = `
+	SELECT 
+		` + SelectWebhookDeliveryFields + `
+	FROM 
+		webhook_deliveries
+	WHERE 
+		job_id = `
+
+// SelectWebhookDeliveriesByJobOrderSQL is the ORDER BY clause for a job's deliveries
+const SelectWebhookDeliveriesByJobOrderSQL = `
+	ORDER BY 
+		created_date ASC, id ASC
+`
+
+// UpsertWebhookDeliveryConflictClause contains the common ON CONFLICT UPDATE logic
+// Database-specific implementations prepend their INSERT statement
+const UpsertWebhookDeliveryConflictClause = `
+	ON CONFLICT (id) DO UPDATE SET
+		state = EXCLUDED.state,
+		attempts = EXCLUDED.attempts,
+		last_status_code = EXCLUDED.last_status_code,
+		last_error = EXCLUDED.last_error,
+		next_attempt_date = EXCLUDED.next_attempt_date,
+		delivered_date = EXCLUDED.delivered_date
+`
+
+// SelectDueWebhookDeliveriesBaseSQL retrieves pending deliveries whose next attempt is due
+// Database-specific implementations add the due date and limit placeholders
+const SelectDueWebhookDeliveriesBaseSQL = `
+	SELECT 
+		` + SelectWebhookDeliveryFields + `
+	FROM 
+		webhook_deliveries
+	WHERE 
+		state = 'PENDING' AND next_attempt_date <= `
+
+// SelectDueWebhookDeliveriesOrderSQL is the ORDER BY clause for due deliveries
+const SelectDueWebhookDeliveriesOrderSQL = `
+	ORDER BY 
+		next_attempt_date ASC
+	LIMIT `
+
+// ClaimWebhookDeliveryBaseSQL leases a due delivery by pushing its next attempt date forward.
+// Only one process can win the lease, since the WHERE clause no longer matches once updated.
+const ClaimWebhookDeliveryBaseSQL = `
+	UPDATE 
+		webhook_deliveries
+	SET 
+		next_attempt_date = `
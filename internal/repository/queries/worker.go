@@ -0,0 +1,70 @@
+package queries
+
+// SelectWorkerFields contains all column names for the workers table
+const SelectWorkerFields = "id, hostname, version, job_worker_count, task_worker_count, task_names, started_date, heartbeat_date, stopped_date, leader, queues, capabilities"
+
+// SelectWorkersSQL retrieves every registered worker, most recently started first
+const SelectWorkersSQL = `
+	SELECT 
+		` + SelectWorkerFields + `
+	FROM 
+		workers
+	ORDER BY 
+		started_date DESC, id ASC
+`
+
+// UpsertWorkerConflictClause contains the common ON CONFLICT UPDATE logic
+// Database-specific implementations prepend their INSERT statement
+const UpsertWorkerConflictClause = `
+	ON CONFLICT (id) DO UPDATE SET
+		hostname = EXCLUDED.hostname,
+		version = EXCLUDED.version,
+		job_worker_count = EXCLUDED.job_worker_count,
+		task_worker_count = EXCLUDED.task_worker_count,
+		task_names = EXCLUDED.task_names,
+		queues = EXCLUDED.queues,
+		capabilities = EXCLUDED.capabilities,
+		heartbeat_date = EXCLUDED.heartbeat_date,
+		stopped_date = EXCLUDED.stopped_date,
+		leader = EXCLUDED.leader
+`
+
+// UpdateWorkerHeartbeatBaseSQL refreshes a worker's heartbeat and leadership
+// Database-specific implementations add the heartbeat, leader and ID placeholders
+const UpdateWorkerHeartbeatBaseSQL = `
+	UPDATE 
+		workers
+	SET 
+		heartbeat_date = `
+
+// StopWorkerBaseSQL marks a worker as gracefully stopped, it has released any leadership
+const StopWorkerBaseSQL = `
+	UPDATE 
+		workers
+	SET 
+		leader = FALSE, stopped_date = `
+
+// DeadJobsCondition matches unfinished jobs claimed by a worker that is not alive. Workers that
+// stopped, missed heartbeats or never registered all count as not alive.
+// Database-specific implementations add the heartbeat threshold placeholder and closing parenthesis.
+const DeadJobsCondition = `
+		state IN ('PENDING', 'RUNNING') AND worker_id IS NOT NULL AND worker_id NOT IN (
+			SELECT id FROM workers WHERE stopped_date IS NULL AND heartbeat_date >= `
+
+// RecoverJobsBaseSQL returns jobs of dead workers to the pending queue, unclaimed
+const RecoverJobsBaseSQL = `
+	UPDATE 
+		jobs
+	SET 
+		state = 'PENDING', worker_id = NULL, claim_date = NULL
+	WHERE 
+`
+
+// ResetRecoveredTaskRunsBaseSQL returns task runs interrupted by a dead worker to pending
+const ResetRecoveredTaskRunsBaseSQL = `
+	UPDATE 
+		task_runs
+	SET 
+		state = 'PENDING'
+	WHERE 
+		state = 'RUNNING' AND job_id = `
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/abikandiah/task-worker/internal/domain"
 	"github.com/google/uuid"
@@ -10,6 +11,12 @@ import (
 type ServiceRepository interface {
 	JobRepository
 	TaskRunRepository
+	TaskLogRepository
+	EventRepository
+	WebhookRepository
+	WorkerRepository
+	RetentionRepository
+	TemplateRepository
 	Close() error
 }
 
@@ -17,13 +24,48 @@ type JobRepository interface {
 	SaveJob(ctx context.Context, job domain.Job) (*domain.Job, error)
 	GetJob(ctx context.Context, jobID uuid.UUID) (*domain.Job, error)
 	GetAllJobs(ctx context.Context, cursor *domain.CursorInput) (*domain.CursorOutput[domain.Job], error)
+	// SaveJobsWithTaskRuns saves the jobs and their task runs in one transaction, none are saved if any fails.
+	// Jobs that replace pending jobs stop them in the same transaction, their IDs are returned.
+	SaveJobsWithTaskRuns(ctx context.Context, jobs []domain.JobWithTaskRuns) ([]uuid.UUID, error)
+
+	// ClaimJobs assigns up to limit unclaimed pending jobs of the queue, whose required capabilities
+	// are all provided by the worker, to the worker and returns their IDs.
+	// A job is claimed by at most one worker, even across processes, and jobs sharing a concurrency
+	// key are claimed in submission order without exceeding the key's limit.
+	ClaimJobs(ctx context.Context, workerID string, queue string, capabilities []string, limit int) ([]uuid.UUID, error)
+	// ReleaseJobClaims unassigns the worker's jobs that have not started
+	ReleaseJobClaims(ctx context.Context, workerID string) error
+	// CountActiveJobs counts the pending and running jobs of a concurrency key
+	CountActiveJobs(ctx context.Context, concurrencyKey string) (int, error)
+	// ExpireJobs expires unclaimed pending jobs, and their pending task runs, whose deadline
+	// to start has passed and returns their IDs
+	ExpireJobs(ctx context.Context, now time.Time) ([]uuid.UUID, error)
+	// GetUnclaimedCapabilityJobs returns up to limit unclaimed pending jobs that require capabilities
+	// and were submitted before submittedBefore, oldest first
+	GetUnclaimedCapabilityJobs(ctx context.Context, submittedBefore time.Time, limit int) ([]domain.Job, error)
+	// FailUnclaimedJob ends a pending job in error, reporting false if a worker claimed it meanwhile
+	FailUnclaimedJob(ctx context.Context, jobID uuid.UUID, endDate time.Time) (bool, error)
+	// GetQueueDepths counts pending, claimed and running jobs of every queue that has any
+	GetQueueDepths(ctx context.Context) ([]domain.Queue, error)
+	// GetStats aggregates the jobs and task runs of a time range
+	GetStats(ctx context.Context, statsRange domain.StatsRange) (*domain.Stats, error)
 
 	GetDefaultJobConfig(ctx context.Context) (*domain.JobConfig, error)
 	GetOrCreateDefaultJobConfig(ctx context.Context) (*domain.JobConfig, error)
 
 	SaveJobConfig(ctx context.Context, config domain.JobConfig) (*domain.JobConfig, error)
+	// GetJobConfig returns the latest version of a config
 	GetJobConfig(ctx context.Context, configID uuid.UUID) (*domain.JobConfig, error)
+	GetJobConfigVersion(ctx context.Context, configID uuid.UUID, version uuid.UUID) (*domain.JobConfig, error)
+	// GetAllJobConfigs returns the latest version of every config
 	GetAllJobConfigs(ctx context.Context, cursor *domain.CursorInput) (*domain.CursorOutput[domain.JobConfig], error)
+	GetJobConfigVersions(ctx context.Context, configID uuid.UUID, cursor *domain.CursorInput) (*domain.CursorOutput[domain.JobConfig], error)
+	// SetDefaultJobConfig makes a config version the default in place of the current one,
+	// reporting false if the version does not exist
+	SetDefaultJobConfig(ctx context.Context, configID uuid.UUID, version uuid.UUID) (bool, error)
+	// DeleteJobConfigVersion deletes a config version that is not the default and no job references,
+	// reporting false if it was not deleted
+	DeleteJobConfigVersion(ctx context.Context, configID uuid.UUID, version uuid.UUID) (bool, error)
 }
 
 type TaskRunRepository interface {
@@ -34,3 +76,64 @@ type TaskRunRepository interface {
 	GetTaskRuns(ctx context.Context, jobID uuid.UUID) ([]domain.TaskRun, error)
 	GetAllTaskRuns(ctx context.Context, cursor *domain.CursorInput) (*domain.CursorOutput[domain.TaskRun], error)
 }
+
+type TaskLogRepository interface {
+	SaveTaskLogs(ctx context.Context, taskLogs []domain.TaskLog) error
+	// GetTaskLogs returns up to limit logs of a TaskRun with a sequence number after afterSeq
+	GetTaskLogs(ctx context.Context, taskRunID uuid.UUID, afterSeq int64, limit int) ([]domain.TaskLog, error)
+}
+
+type EventRepository interface {
+	SaveEvents(ctx context.Context, events []domain.EventRecord) error
+	// GetEvents returns up to limit events with a sequence number after afterSeq, in order
+	GetEvents(ctx context.Context, afterSeq int64, limit int) ([]domain.EventRecord, error)
+	// GetLastEventSeq returns the sequence number of the latest event, 0 when there are none
+	GetLastEventSeq(ctx context.Context) (int64, error)
+	// DeleteEvents deletes events created before the given time and reports how many were removed
+	DeleteEvents(ctx context.Context, before time.Time) (int64, error)
+}
+
+type WebhookRepository interface {
+	SaveWebhookDelivery(ctx context.Context, delivery domain.WebhookDelivery) (*domain.WebhookDelivery, error)
+	GetWebhookDeliveries(ctx context.Context, jobID uuid.UUID) ([]domain.WebhookDelivery, error)
+
+	// GetDueWebhookDeliveries returns pending deliveries whose next attempt is at or before the given time
+	GetDueWebhookDeliveries(ctx context.Context, before time.Time, limit int) ([]domain.WebhookDelivery, error)
+	// ClaimWebhookDelivery leases a due delivery until leaseUntil, reporting false if another process won it
+	ClaimWebhookDelivery(ctx context.Context, deliveryID uuid.UUID, now time.Time, leaseUntil time.Time) (bool, error)
+}
+
+type WorkerRepository interface {
+	SaveWorker(ctx context.Context, worker domain.Worker) (*domain.Worker, error)
+	// UpdateWorkerHeartbeat reports false if the worker is not registered
+	UpdateWorkerHeartbeat(ctx context.Context, workerID string, heartbeat time.Time, leader bool) (bool, error)
+	StopWorker(ctx context.Context, workerID string, stopped time.Time) error
+	GetWorkers(ctx context.Context) ([]domain.Worker, error)
+
+	// RecoverJobs returns unfinished jobs claimed by workers without a heartbeat since aliveSince
+	// to the pending queue, along with their interrupted task runs, and returns the job IDs
+	RecoverJobs(ctx context.Context, aliveSince time.Time) ([]uuid.UUID, error)
+}
+
+type RetentionRepository interface {
+	// GetEndedJobConfigVersions returns the config versions that have ended jobs
+	GetEndedJobConfigVersions(ctx context.Context) ([]domain.ConfigVersionRef, error)
+	// GetPurgeableJobs returns the IDs of up to limit jobs matching the filter, those that ended first
+	GetPurgeableJobs(ctx context.Context, filter domain.PurgeFilter, limit int) ([]uuid.UUID, error)
+	// CountPurgeableJobs counts the jobs matching the filter and their task runs
+	CountPurgeableJobs(ctx context.Context, filter domain.PurgeFilter) (jobs int64, taskRuns int64, err error)
+	// DeleteJobs deletes the jobs along with their task runs and reports how many rows of each were removed
+	DeleteJobs(ctx context.Context, jobIDs []uuid.UUID) (jobs int64, taskRuns int64, err error)
+}
+
+type TemplateRepository interface {
+	// SaveJobTemplate inserts a version of a template, versions are never updated
+	SaveJobTemplate(ctx context.Context, template domain.JobTemplate) (*domain.JobTemplate, error)
+	// GetJobTemplate returns the latest version of a template
+	GetJobTemplate(ctx context.Context, templateID uuid.UUID) (*domain.JobTemplate, error)
+	GetJobTemplateVersion(ctx context.Context, templateID uuid.UUID, version uuid.UUID) (*domain.JobTemplate, error)
+	// GetJobTemplateVersions returns every version of a template, newest first
+	GetJobTemplateVersions(ctx context.Context, templateID uuid.UUID) ([]domain.JobTemplate, error)
+	// GetAllJobTemplates pages through the latest version of each template
+	GetAllJobTemplates(ctx context.Context, cursor *domain.CursorInput) (*domain.CursorOutput[domain.JobTemplate], error)
+}
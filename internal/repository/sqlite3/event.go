@@ -0,0 +1,97 @@
+package sqlite3
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/platform/db"
+	"github.com/abikandiah/task-worker/internal/repository/models"
+	"github.com/abikandiah/task-worker/internal/repository/queries"
+)
+
+// --- SQL Constants for events table ---
+const insertEventSQL = `
+    INSERT INTO events (
+        ` + queries.InsertEventFields + `
+    ) VALUES (
+		:origin, :payload, :created_date
+    )
+`
+
+const selectEventsSQL = queries.SelectEventsBaseSQL + `?` + queries.SelectEventsOrderSQL + `?`
+
+const deleteEventsSQL = queries.DeleteEventsBaseSQL + `?`
+
+type EventDB struct {
+	models.CommonEventDB
+	CreatedDate db.TextTime `db:"created_date"`
+}
+
+func (eventDB *EventDB) ToDomainEvent() *domain.EventRecord {
+	event := eventDB.ToDomainEventBase()
+	event.CreatedDate = eventDB.CreatedDate.Time
+	return event
+}
+
+func FromDomainEvent(event domain.EventRecord) *EventDB {
+	return &EventDB{
+		CommonEventDB: models.NewCommonEventDB(event),
+		CreatedDate:   db.TextTime{Time: event.CreatedDate.UTC()},
+	}
+}
+
+func (repo *SQLiteServiceRepository) SaveEvents(ctx context.Context, events []domain.EventRecord) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	// Begin Transaction
+	tx, err := repo.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for event save: %w", err)
+	}
+
+	for _, event := range events {
+		if _, execErr := tx.NamedExecContext(ctx, insertEventSQL, FromDomainEvent(event)); execErr != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to insert event: %w", execErr)
+		}
+	}
+
+	// Commit Transaction
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction for event save: %w", err)
+	}
+	return nil
+}
+
+func (repo *SQLiteServiceRepository) GetEvents(ctx context.Context, afterSeq int64, limit int) ([]domain.EventRecord, error) {
+	var eventDBs []EventDB
+	if err := repo.DB.SelectContext(ctx, &eventDBs, selectEventsSQL, afterSeq, limit); err != nil {
+		return nil, fmt.Errorf("failed to get events after %d: %w", afterSeq, err)
+	}
+
+	events := make([]domain.EventRecord, len(eventDBs))
+	for i, eventDB := range eventDBs {
+		events[i] = *eventDB.ToDomainEvent()
+	}
+	return events, nil
+}
+
+func (repo *SQLiteServiceRepository) GetLastEventSeq(ctx context.Context) (int64, error) {
+	var seq int64
+	if err := repo.DB.GetContext(ctx, &seq, queries.SelectLastEventSeqSQL); err != nil {
+		return 0, fmt.Errorf("failed to get last event seq: %w", err)
+	}
+	return seq, nil
+}
+
+func (repo *SQLiteServiceRepository) DeleteEvents(ctx context.Context, before time.Time) (int64, error) {
+	result, err := repo.DB.ExecContext(ctx, deleteEventsSQL, db.TextTime{Time: before.UTC()})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete events: %w", err)
+	}
+	return result.RowsAffected()
+}
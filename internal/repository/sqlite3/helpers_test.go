@@ -0,0 +1,69 @@
+package sqlite3
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/platform/db"
+	"github.com/google/uuid"
+)
+
+// newTestRepository returns a repository over a migrated database in a temporary directory
+func newTestRepository(t *testing.T) *SQLiteServiceRepository {
+	t.Helper()
+
+	database, err := db.New(&db.Config{Driver: "sqlite3", DBName: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	if err := database.RunMigrations("../../../migrations/sqlite3"); err != nil {
+		t.Fatalf("migrate database: %v", err)
+	}
+	return NewSQLiteServiceRepository(database.DB)
+}
+
+// saveTestJob saves a pending job of the default config on the default queue, submitted at submitDate
+func saveTestJob(t *testing.T, repo *SQLiteServiceRepository, submitDate time.Time) *domain.Job {
+	t.Helper()
+	ctx := context.Background()
+
+	config, err := repo.GetOrCreateDefaultJobConfig(ctx)
+	if err != nil {
+		t.Fatalf("GetOrCreateDefaultJobConfig: %v", err)
+	}
+
+	// A job saved without an ID is stamped with the current time instead
+	job, err := repo.SaveJob(ctx, domain.Job{
+		Identity:      domain.Identity{ID: uuid.New()},
+		ConfigID:      config.ID,
+		ConfigVersion: config.Version,
+		Status:        domain.Status{State: domain.StatePending},
+		SubmitDate:    submitDate.UTC(),
+		Queue:         domain.DefaultQueue,
+	})
+	if err != nil {
+		t.Fatalf("SaveJob: %v", err)
+	}
+	return job
+}
+
+// claimTestJob claims the oldest claimable job of the default queue for the worker and returns it
+func claimTestJob(t *testing.T, repo *SQLiteServiceRepository, workerID string) domain.Job {
+	t.Helper()
+	ctx := context.Background()
+
+	jobIDs, err := repo.ClaimJobs(ctx, workerID, domain.DefaultQueue, nil, 1)
+	if err != nil || len(jobIDs) != 1 {
+		t.Fatalf("ClaimJobs(%s) = %v, %v, want a job", workerID, jobIDs, err)
+	}
+	job, err := repo.GetJob(ctx, jobIDs[0])
+	if err != nil {
+		t.Fatalf("GetJob: %v", err)
+	}
+	return *job
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/abikandiah/task-worker/internal/repository/models"
 	"github.com/abikandiah/task-worker/internal/repository/queries"
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 )
 
 // --- SQL Constants for jobs table ---
@@ -26,23 +27,69 @@ const selectJobByIDSQL = `
 
 const insertJobSQL = `
     INSERT INTO jobs (
-        ` + queries.SelectJobFields + `
+        ` + queries.InsertJobFields + `
     ) VALUES (
-		:id, :name, :description, :config_id, :config_version, :state, :progress, :submit_date, :start_date, :end_date
+		:id, :name, :description, :config_id, :config_version, :state, :progress, :submit_date, :start_date, :end_date, :variables, :callbacks, :tags, :queue, :capabilities, :concurrency_key, :concurrency_limit, :expires_at, :template_id, :template_version, :trace_context
     )
 `
 
 const upsertJobSQL = insertJobSQL + queries.UpsertJobConflictClause
 
+// SQLite serializes writers, so the subquery and update cannot interleave with another claim
+const claimJobsSQL = queries.ClaimJobsBaseSQL + `?, claim_date = ?
+    WHERE 
+        id IN (` + queries.SelectClaimableJobsSQL + `?
+            AND NOT EXISTS (
+                SELECT 1 FROM json_each(capabilities) WHERE value NOT IN (SELECT value FROM json_each(?))
+            )` + queries.ClaimableConcurrencyCondition + queries.SelectClaimableJobsOrderSQL + `?)
+    RETURNING 
+        id
+`
+
+const selectUnclaimedCapabilityJobsSQL = queries.SelectUnclaimedCapabilityJobsSQL + `?
+    ORDER BY 
+        submit_date ASC
+    LIMIT ?
+`
+
+const failUnclaimedJobSQL = queries.FailUnclaimedJobBaseSQL + `?
+    WHERE 
+        id = ? AND state = 'PENDING' AND worker_id IS NULL
+`
+
+const countActiveJobsSQL = queries.CountActiveJobsBaseSQL + `?`
+
+const stopPendingJobsSQL = queries.StopPendingJobsBaseSQL + `?
+    WHERE 
+        state = 'PENDING' AND worker_id IS NULL AND concurrency_key = ?
+    RETURNING 
+        id
+`
+
+const stopPendingTaskRunsSQL = queries.StopPendingTaskRunsBaseSQL + `? WHERE state = 'PENDING' AND job_id = ?`
+
+const expireJobsSQL = queries.ExpireJobsBaseSQL + `?` + queries.ExpireJobsCondition + `?
+    RETURNING 
+        id
+`
+
+const expireTaskRunsSQL = queries.ExpireTaskRunsBaseSQL + `? WHERE state = 'PENDING' AND job_id = ?`
+
+const releaseJobClaimsSQL = queries.ReleaseJobClaimsBaseSQL + `?`
+
 type JobDB struct {
 	models.CommonJobDB
 	SubmitDate db.TextTime     `db:"submit_date"`
 	StartDate  db.NullTextTime `db:"start_date"`
 	EndDate    db.NullTextTime `db:"end_date"`
+	ExpiresAt  db.NullTextTime `db:"expires_at"`
 }
 
-func (jobDB *JobDB) ToDomainJob() *domain.Job {
-	job := jobDB.ToDomainJobBase()
+func (jobDB *JobDB) ToDomainJob() (*domain.Job, error) {
+	job, err := jobDB.ToDomainJobBase()
+	if err != nil {
+		return nil, err
+	}
 
 	// Extract time.Time from TextTime
 	job.SubmitDate = jobDB.SubmitDate.Time
@@ -52,36 +99,89 @@ func (jobDB *JobDB) ToDomainJob() *domain.Job {
 	if jobDB.EndDate.Valid {
 		job.EndDate = &jobDB.EndDate.Time
 	}
+	if jobDB.ExpiresAt.Valid {
+		job.ExpiresAt = &jobDB.ExpiresAt.Time
+	}
 
-	return job
+	return job, nil
 }
 
-func FromDomainJob(job *domain.Job) *JobDB {
+func FromDomainJob(job *domain.Job) (*JobDB, error) {
 	isNew := job.ID == uuid.Nil
 	submitDate := job.SubmitDate
 	if isNew {
 		submitDate = time.Now().UTC()
 	}
 
-	commonJobDb := models.NewCommonJobDB(job)
+	commonJobDb, err := models.NewCommonJobDB(job)
+	if err != nil {
+		return nil, err
+	}
 
 	return &JobDB{
 		CommonJobDB: commonJobDb,
 		SubmitDate:  db.TextTime{Time: submitDate},
 		StartDate:   db.NewNullTextTime(job.StartDate),
 		EndDate:     db.NewNullTextTime(job.EndDate),
-	}
+		ExpiresAt:   db.NewNullTextTime(job.ExpiresAt),
+	}, nil
 }
 
 func (repo *SQLiteServiceRepository) SaveJob(ctx context.Context, job domain.Job) (*domain.Job, error) {
-	jobDB := FromDomainJob(&job)
+	jobDB, err := FromDomainJob(&job)
+	if err != nil {
+		return nil, err
+	}
 	// Execute the query using NamedExecContext
-	_, err := repo.DB.NamedExecContext(ctx, upsertJobSQL, jobDB)
+	_, err = repo.DB.NamedExecContext(ctx, upsertJobSQL, jobDB)
 	if err != nil {
 		return nil, fmt.Errorf("failed to upsert job %s: %w", jobDB.ID, err)
 	}
 
-	return jobDB.ToDomainJob(), nil
+	return jobDB.ToDomainJob()
+}
+
+func (repo *SQLiteServiceRepository) SaveJobsWithTaskRuns(ctx context.Context, jobs []domain.JobWithTaskRuns) ([]uuid.UUID, error) {
+	tx, err := repo.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction for job batch save: %w", err)
+	}
+	defer tx.Rollback()
+
+	var replaced []uuid.UUID
+	for _, job := range jobs {
+		// Replacing before the insert also stops earlier jobs of the key in the same batch
+		if job.ReplacePending {
+			jobIDs, err := stopPendingJobs(ctx, tx, job.Job.ConcurrencyKey, job.Job.SubmitDate)
+			if err != nil {
+				return nil, err
+			}
+			replaced = append(replaced, jobIDs...)
+		}
+
+		jobDB, err := FromDomainJob(&job.Job)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.NamedExecContext(ctx, upsertJobSQL, jobDB); err != nil {
+			return nil, fmt.Errorf("failed to upsert job %s in transaction: %w", jobDB.ID, err)
+		}
+
+		for _, taskRun := range job.TaskRuns {
+			taskRunDB, err := FromDomainTaskRun(taskRun)
+			if err != nil {
+				return nil, fmt.Errorf("conversion failed for task run %s: %w", taskRun.ID, err)
+			}
+			if _, err := tx.NamedExecContext(ctx, upsertTaskRunSQL, taskRunDB); err != nil {
+				return nil, fmt.Errorf("failed to upsert task run %s in transaction: %w", taskRunDB.ID, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction for job batch save: %w", err)
+	}
+	return replaced, nil
 }
 
 func (repo *SQLiteServiceRepository) GetJob(ctx context.Context, jobID uuid.UUID) (*domain.Job, error) {
@@ -95,13 +195,14 @@ func (repo *SQLiteServiceRepository) GetJob(ctx context.Context, jobID uuid.UUID
 		return nil, fmt.Errorf("failed to get job with ID %s: %w", jobID, err)
 	}
 
-	return jobDB.ToDomainJob(), nil
+	return jobDB.ToDomainJob()
 }
 
 func (repo *SQLiteServiceRepository) GetAllJobs(ctx context.Context, cursor *domain.CursorInput) (*domain.CursorOutput[domain.Job], error) {
 	pq := &db.PaginationQuery{
 		BaseQuery:     queries.SelectPaginationJobSQL,
 		AllowedFields: queries.JobPaginationAllowedFields,
+		FilterFields:  queries.JobFilterFields,
 	}
 
 	// Paginate with DB struct for correct sqlx scanning
@@ -113,7 +214,11 @@ func (repo *SQLiteServiceRepository) GetAllJobs(ctx context.Context, cursor *dom
 	// Convert JobDB slice to Job slice
 	domainJobs := make([]domain.Job, len(dbOutput.Data))
 	for i, jobDB := range dbOutput.Data {
-		domainJobs[i] = *jobDB.ToDomainJob()
+		domainJob, err := jobDB.ToDomainJob()
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert job DB model to domain model: %w", err)
+		}
+		domainJobs[i] = *domainJob
 	}
 
 	domainOutput := &domain.CursorOutput[domain.Job]{
@@ -124,3 +229,109 @@ func (repo *SQLiteServiceRepository) GetAllJobs(ctx context.Context, cursor *dom
 	}
 	return domainOutput, nil
 }
+
+func (repo *SQLiteServiceRepository) ClaimJobs(ctx context.Context, workerID string, queue string, capabilities []string, limit int) ([]uuid.UUID, error) {
+	var jobIDs []uuid.UUID
+	err := repo.DB.SelectContext(ctx, &jobIDs, claimJobsSQL, workerID, db.TextTime{Time: time.Now().UTC()}, queue, models.StringArrayJSON(capabilities), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim jobs of queue %s for worker %s: %w", queue, workerID, err)
+	}
+	return jobIDs, nil
+}
+
+func (repo *SQLiteServiceRepository) GetUnclaimedCapabilityJobs(ctx context.Context, submittedBefore time.Time, limit int) ([]domain.Job, error) {
+	var jobDBs []JobDB
+	if err := repo.DB.SelectContext(ctx, &jobDBs, selectUnclaimedCapabilityJobsSQL, db.TextTime{Time: submittedBefore.UTC()}, limit); err != nil {
+		return nil, fmt.Errorf("failed to get unclaimed jobs requiring capabilities: %w", err)
+	}
+
+	jobs := make([]domain.Job, len(jobDBs))
+	for i, jobDB := range jobDBs {
+		job, err := jobDB.ToDomainJob()
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert job DB model to domain model: %w", err)
+		}
+		jobs[i] = *job
+	}
+	return jobs, nil
+}
+
+func (repo *SQLiteServiceRepository) FailUnclaimedJob(ctx context.Context, jobID uuid.UUID, endDate time.Time) (bool, error) {
+	result, err := repo.DB.ExecContext(ctx, failUnclaimedJobSQL, db.TextTime{Time: endDate.UTC()}, jobID)
+	if err != nil {
+		return false, fmt.Errorf("failed to fail unclaimed job %s: %w", jobID, err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to fail unclaimed job %s: %w", jobID, err)
+	}
+	return rows == 1, nil
+}
+
+func (repo *SQLiteServiceRepository) CountActiveJobs(ctx context.Context, concurrencyKey string) (int, error) {
+	var count int
+	if err := repo.DB.GetContext(ctx, &count, countActiveJobsSQL, concurrencyKey); err != nil {
+		return 0, fmt.Errorf("failed to count active jobs of concurrency key %s: %w", concurrencyKey, err)
+	}
+	return count, nil
+}
+
+// stopPendingJobs stops the unclaimed pending jobs of a concurrency key and their task runs in tx
+func stopPendingJobs(ctx context.Context, tx *sqlx.Tx, concurrencyKey string, endDate time.Time) ([]uuid.UUID, error) {
+	var jobIDs []uuid.UUID
+	if err := tx.SelectContext(ctx, &jobIDs, stopPendingJobsSQL, db.TextTime{Time: endDate.UTC()}, concurrencyKey); err != nil {
+		return nil, fmt.Errorf("failed to stop pending jobs of concurrency key %s: %w", concurrencyKey, err)
+	}
+
+	for _, jobID := range jobIDs {
+		if _, err := tx.ExecContext(ctx, stopPendingTaskRunsSQL, db.TextTime{Time: endDate.UTC()}, jobID); err != nil {
+			return nil, fmt.Errorf("failed to stop task runs of job %s: %w", jobID, err)
+		}
+	}
+	return jobIDs, nil
+}
+
+func (repo *SQLiteServiceRepository) ExpireJobs(ctx context.Context, now time.Time) ([]uuid.UUID, error) {
+	tx, err := repo.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var jobIDs []uuid.UUID
+	if err := tx.SelectContext(ctx, &jobIDs, expireJobsSQL, db.TextTime{Time: now.UTC()}, db.TextTime{Time: now.UTC()}); err != nil {
+		return nil, fmt.Errorf("failed to expire jobs: %w", err)
+	}
+
+	for _, jobID := range jobIDs {
+		if _, err := tx.ExecContext(ctx, expireTaskRunsSQL, db.TextTime{Time: now.UTC()}, jobID); err != nil {
+			return nil, fmt.Errorf("failed to expire task runs of job %s: %w", jobID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return jobIDs, nil
+}
+
+func (repo *SQLiteServiceRepository) ReleaseJobClaims(ctx context.Context, workerID string) error {
+	if _, err := repo.DB.ExecContext(ctx, releaseJobClaimsSQL, workerID); err != nil {
+		return fmt.Errorf("failed to release job claims of worker %s: %w", workerID, err)
+	}
+	return nil
+}
+
+func (repo *SQLiteServiceRepository) GetQueueDepths(ctx context.Context) ([]domain.Queue, error) {
+	var depthDBs []models.QueueDepthDB
+	if err := repo.DB.SelectContext(ctx, &depthDBs, queries.SelectQueueDepthsSQL); err != nil {
+		return nil, fmt.Errorf("failed to get queue depths: %w", err)
+	}
+
+	queues := make([]domain.Queue, len(depthDBs))
+	for i, depthDB := range depthDBs {
+		queues[i] = depthDB.ToDomainQueue()
+	}
+	return queues, nil
+}
@@ -0,0 +1,136 @@
+package sqlite3
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/platform/db"
+	"github.com/abikandiah/task-worker/internal/repository/models"
+	"github.com/abikandiah/task-worker/internal/repository/queries"
+	"github.com/google/uuid"
+)
+
+// --- SQL Constants for job_templates table ---
+const insertJobTemplateSQL = queries.InsertJobTemplateBaseSQL + `
+        :id, :version, :name, :description, :details, :create_date
+    )
+`
+
+const selectJobTemplateVersionsSQL = queries.SelectJobTemplateVersionsBaseSQL + `?` + queries.SelectJobTemplateVersionsOrderSQL
+
+const selectLatestJobTemplateSQL = selectJobTemplateVersionsSQL + `LIMIT 1`
+
+const selectJobTemplateVersionSQL = queries.SelectJobTemplateVersionsBaseSQL + `? AND version = ?`
+
+type JobTemplateDB struct {
+	models.CommonJobTemplateDB
+	CreateDate db.TextTime `db:"create_date"`
+}
+
+func (templateDB *JobTemplateDB) ToDomainJobTemplate() (*domain.JobTemplate, error) {
+	template, err := templateDB.ToDomainJobTemplateBase()
+	if err != nil {
+		return nil, err
+	}
+
+	template.CreateDate = templateDB.CreateDate.Time
+	return template, nil
+}
+
+func FromDomainJobTemplate(template domain.JobTemplate) (*JobTemplateDB, error) {
+	commonTemplateDB, err := models.NewCommonJobTemplateDB(template)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JobTemplateDB{
+		CommonJobTemplateDB: commonTemplateDB,
+		CreateDate:          db.TextTime{Time: template.CreateDate.UTC()},
+	}, nil
+}
+
+func (repo *SQLiteServiceRepository) SaveJobTemplate(ctx context.Context, template domain.JobTemplate) (*domain.JobTemplate, error) {
+	templateDB, err := FromDomainJobTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := repo.DB.NamedExecContext(ctx, insertJobTemplateSQL, templateDB); err != nil {
+		return nil, fmt.Errorf("failed to insert job template %s: %w", template.ID, err)
+	}
+	return templateDB.ToDomainJobTemplate()
+}
+
+func (repo *SQLiteServiceRepository) GetJobTemplate(ctx context.Context, templateID uuid.UUID) (*domain.JobTemplate, error) {
+	var templateDB JobTemplateDB
+	err := repo.DB.GetContext(ctx, &templateDB, selectLatestJobTemplateSQL, templateID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get job template with ID %s: %w", templateID, err)
+	}
+
+	return templateDB.ToDomainJobTemplate()
+}
+
+func (repo *SQLiteServiceRepository) GetJobTemplateVersion(ctx context.Context, templateID uuid.UUID, version uuid.UUID) (*domain.JobTemplate, error) {
+	var templateDB JobTemplateDB
+	err := repo.DB.GetContext(ctx, &templateDB, selectJobTemplateVersionSQL, templateID, version)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get job template %s version %s: %w", templateID, version, err)
+	}
+
+	return templateDB.ToDomainJobTemplate()
+}
+
+func (repo *SQLiteServiceRepository) GetJobTemplateVersions(ctx context.Context, templateID uuid.UUID) ([]domain.JobTemplate, error) {
+	var templateDBs []JobTemplateDB
+	if err := repo.DB.SelectContext(ctx, &templateDBs, selectJobTemplateVersionsSQL, templateID); err != nil {
+		return nil, fmt.Errorf("failed to get versions of job template %s: %w", templateID, err)
+	}
+
+	templates := make([]domain.JobTemplate, len(templateDBs))
+	for i, templateDB := range templateDBs {
+		template, err := templateDB.ToDomainJobTemplate()
+		if err != nil {
+			return nil, err
+		}
+		templates[i] = *template
+	}
+	return templates, nil
+}
+
+func (repo *SQLiteServiceRepository) GetAllJobTemplates(ctx context.Context, cursor *domain.CursorInput) (*domain.CursorOutput[domain.JobTemplate], error) {
+	pq := &db.PaginationQuery{
+		BaseQuery:     queries.SelectPaginationJobTemplateSQL,
+		AllowedFields: queries.JobTemplatePaginationAllowedFields,
+	}
+
+	// Paginate with DB struct for correct sqlx scanning
+	dbOutput, err := db.Paginate[JobTemplateDB](ctx, repo.DB, pq, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make([]domain.JobTemplate, len(dbOutput.Data))
+	for i, templateDB := range dbOutput.Data {
+		template, err := templateDB.ToDomainJobTemplate()
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert job template DB model to domain model: %w", err)
+		}
+		templates[i] = *template
+	}
+
+	return &domain.CursorOutput[domain.JobTemplate]{
+		Limit:      dbOutput.Limit,
+		Data:       templates,
+		NextCursor: dbOutput.NextCursor,
+		PrevCursor: dbOutput.PrevCursor,
+	}, nil
+}
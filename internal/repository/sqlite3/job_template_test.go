@@ -0,0 +1,59 @@
+package sqlite3
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/google/uuid"
+)
+
+// Every version of a template is kept, the latest is the one created last
+func TestJobTemplateVersions(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+
+	templateID := uuid.New()
+	created := time.Now().UTC().Add(-time.Hour).Truncate(time.Millisecond)
+	var versions []*domain.JobTemplate
+	for i, days := range []int{7, 30} {
+		template, err := repo.SaveJobTemplate(ctx, domain.JobTemplate{
+			IdentityVersion: domain.IdentityVersion{
+				Identity: domain.Identity{ID: templateID, IdentitySubmission: domain.IdentitySubmission{Name: "report"}},
+				Version:  uuid.New(),
+			},
+			CreateDate: created.Add(time.Duration(i) * time.Minute),
+			JobTemplateDetails: domain.JobTemplateDetails{
+				Parameters: []domain.TemplateParameter{{Name: "days", Type: domain.ParameterInteger, Default: float64(days)}},
+				TaskRuns:   []domain.TaskRun{{TaskName: "noop", TaskRunDetails: domain.TaskRunDetails{Params: json.RawMessage(`{"days":"{{ params.days }}"}`)}}},
+			},
+		})
+		if err != nil {
+			t.Fatalf("SaveJobTemplate: %v", err)
+		}
+		versions = append(versions, template)
+	}
+
+	latest, err := repo.GetJobTemplate(ctx, templateID)
+	if err != nil || latest == nil || latest.Version != versions[1].Version {
+		t.Fatalf("GetJobTemplate = %+v, %v, want version %s", latest, err, versions[1].Version)
+	}
+	if latest.Parameters[0].Default != float64(30) || string(latest.TaskRuns[0].Params) != `{"days":"{{ params.days }}"}` {
+		t.Errorf("latest details = %+v, want the saved parameters and task runs", latest.JobTemplateDetails)
+	}
+
+	first, err := repo.GetJobTemplateVersion(ctx, templateID, versions[0].Version)
+	if err != nil || first == nil || first.Parameters[0].Default != float64(7) {
+		t.Errorf("GetJobTemplateVersion = %+v, %v, want the first version", first, err)
+	}
+	if missing, err := repo.GetJobTemplateVersion(ctx, templateID, uuid.New()); err != nil || missing != nil {
+		t.Errorf("GetJobTemplateVersion of an unknown version = %+v, %v, want none", missing, err)
+	}
+
+	all, err := repo.GetJobTemplateVersions(ctx, templateID)
+	if err != nil || len(all) != 2 {
+		t.Errorf("GetJobTemplateVersions = %d versions, %v, want 2", len(all), err)
+	}
+}
@@ -0,0 +1,339 @@
+package sqlite3
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/util"
+	"github.com/google/uuid"
+)
+
+// Claims take the oldest unclaimed jobs of the queue and never jobs of another queue
+func TestClaimJobsByQueue(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	newest := saveTestJob(t, repo, now)
+	oldest := saveTestJob(t, repo, now.Add(-time.Minute))
+	reports := saveTestJob(t, repo, now.Add(-time.Hour))
+	reports.Queue = "reports"
+	if _, err := repo.SaveJob(ctx, *reports); err != nil {
+		t.Fatalf("SaveJob: %v", err)
+	}
+
+	if job := claimTestJob(t, repo, "w1"); job.ID != oldest.ID || job.WorkerID != "w1" {
+		t.Fatalf("claimed %s by %q, want the oldest job %s by w1", job.ID, job.WorkerID, oldest.ID)
+	}
+	if job := claimTestJob(t, repo, "w2"); job.ID != newest.ID {
+		t.Fatalf("claimed %s, want the unclaimed job %s", job.ID, newest.ID)
+	}
+	if jobIDs, err := repo.ClaimJobs(ctx, "w1", domain.DefaultQueue, nil, 10); err != nil || len(jobIDs) != 0 {
+		t.Fatalf("ClaimJobs of a drained queue = %v, %v, want none", jobIDs, err)
+	}
+	if jobIDs, err := repo.ClaimJobs(ctx, "w1", "reports", nil, 10); err != nil || len(jobIDs) != 1 || jobIDs[0] != reports.ID {
+		t.Fatalf("ClaimJobs(reports) = %v, %v, want %s", jobIDs, err, reports.ID)
+	}
+
+	depths, err := repo.GetQueueDepths(ctx)
+	if err != nil {
+		t.Fatalf("GetQueueDepths: %v", err)
+	}
+	if len(depths) != 2 || depths[0].Name != domain.DefaultQueue || depths[0].Claimed != 2 || depths[1].Claimed != 1 {
+		t.Errorf("depths = %+v, want 2 claimed on default and 1 on reports", depths)
+	}
+
+	// Releasing returns only the worker's own claims to the queue
+	if err := repo.ReleaseJobClaims(ctx, "w1"); err != nil {
+		t.Fatalf("ReleaseJobClaims: %v", err)
+	}
+	if job := claimTestJob(t, repo, "w3"); job.ID != oldest.ID {
+		t.Errorf("claimed %s after release, want %s", job.ID, oldest.ID)
+	}
+	if job, _ := repo.GetJob(ctx, newest.ID); job.WorkerID != "w2" {
+		t.Errorf("job of w2 is held by %q after w1 released its claims", job.WorkerID)
+	}
+}
+
+// Workers claiming at once each get their own jobs, and every job is claimed exactly once
+func TestConcurrentClaimJobs(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+
+	const jobCount, workerCount = 50, 5
+	now := time.Now().UTC()
+	for i := range jobCount {
+		saveTestJob(t, repo, now.Add(time.Duration(i)*time.Millisecond))
+	}
+
+	var mu sync.Mutex
+	claimedBy := make(map[uuid.UUID]string)
+	errs := make(chan error, workerCount)
+
+	var wg sync.WaitGroup
+	for w := range workerCount {
+		workerID := fmt.Sprintf("w%d", w)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				jobIDs, err := repo.ClaimJobs(ctx, workerID, domain.DefaultQueue, nil, 3)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if len(jobIDs) == 0 {
+					return
+				}
+
+				mu.Lock()
+				for _, jobID := range jobIDs {
+					if other, claimed := claimedBy[jobID]; claimed {
+						errs <- fmt.Errorf("job %s claimed by %s and %s", jobID, other, workerID)
+					}
+					claimedBy[jobID] = workerID
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+	if len(claimedBy) != jobCount {
+		t.Errorf("claimed %d jobs, want %d", len(claimedBy), jobCount)
+	}
+}
+
+// A worker claims only jobs whose required capabilities it all provides
+func TestClaimJobsByCapabilities(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	saveCapabilityJob := func(submitDate time.Time, capabilities ...string) *domain.Job {
+		job := saveTestJob(t, repo, submitDate)
+		job.Capabilities = capabilities
+		if _, err := repo.SaveJob(ctx, *job); err != nil {
+			t.Fatalf("SaveJob: %v", err)
+		}
+		return job
+	}
+	gpu := saveCapabilityJob(now.Add(-3*time.Minute), "gpu")
+	gpuAndLicense := saveCapabilityJob(now.Add(-2*time.Minute), "gpu", "license")
+	plain := saveCapabilityJob(now.Add(-time.Minute))
+
+	jobIDs, err := repo.ClaimJobs(ctx, "cpu", domain.DefaultQueue, nil, 10)
+	if err != nil || len(jobIDs) != 1 || jobIDs[0] != plain.ID {
+		t.Fatalf("ClaimJobs without capabilities = %v, %v, want only %s", jobIDs, err, plain.ID)
+	}
+	jobIDs, err = repo.ClaimJobs(ctx, "gpu", domain.DefaultQueue, []string{"gpu"}, 10)
+	if err != nil || len(jobIDs) != 1 || jobIDs[0] != gpu.ID {
+		t.Fatalf("ClaimJobs(gpu) = %v, %v, want only %s", jobIDs, err, gpu.ID)
+	}
+
+	unclaimed, err := repo.GetUnclaimedCapabilityJobs(ctx, now, 10)
+	if err != nil || len(unclaimed) != 1 || unclaimed[0].ID != gpuAndLicense.ID {
+		t.Fatalf("GetUnclaimedCapabilityJobs = %v, %v, want %s", unclaimed, err, gpuAndLicense.ID)
+	}
+	if failed, err := repo.FailUnclaimedJob(ctx, gpu.ID, now); err != nil || failed {
+		t.Errorf("FailUnclaimedJob of a claimed job = %t, %v, want not failed", failed, err)
+	}
+	if failed, err := repo.FailUnclaimedJob(ctx, gpuAndLicense.ID, now); err != nil || !failed {
+		t.Errorf("FailUnclaimedJob = %t, %v, want failed", failed, err)
+	}
+	if job, _ := repo.GetJob(ctx, gpuAndLicense.ID); job.State != domain.StateError || job.EndDate == nil {
+		t.Errorf("failed job = %s ended at %v, want %s with an end date", job.State, job.EndDate, domain.StateError)
+	}
+}
+
+// Jobs sharing a concurrency key are claimed in submission order, never more than the limit at once
+func TestClaimJobsByConcurrencyKey(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	saveKeyedJob := func(submitDate time.Time, limit int) *domain.Job {
+		job := saveTestJob(t, repo, submitDate)
+		job.ConcurrencyKey, job.ConcurrencyLimit = "account-1", limit
+		if _, err := repo.SaveJob(ctx, *job); err != nil {
+			t.Fatalf("SaveJob: %v", err)
+		}
+		return job
+	}
+	first := saveKeyedJob(now.Add(-3*time.Minute), 2)
+	second := saveKeyedJob(now.Add(-2*time.Minute), 2)
+	third := saveKeyedJob(now.Add(-time.Minute), 2)
+	unkeyed := saveTestJob(t, repo, now)
+
+	// One batch claims up to the limit, other jobs are unaffected
+	jobIDs, err := repo.ClaimJobs(ctx, "w1", domain.DefaultQueue, nil, 10)
+	if err != nil || len(jobIDs) != 3 || !slices.Contains(jobIDs, first.ID) || !slices.Contains(jobIDs, second.ID) || !slices.Contains(jobIDs, unkeyed.ID) {
+		t.Fatalf("ClaimJobs = %v, %v, want the first two keyed jobs and the unkeyed job", jobIDs, err)
+	}
+	if active, err := repo.CountActiveJobs(ctx, "account-1"); err != nil || active != 3 {
+		t.Errorf("CountActiveJobs = %d, %v, want 3", active, err)
+	}
+
+	first.State = domain.StateRunning
+	if _, err := repo.SaveJob(ctx, *first); err != nil {
+		t.Fatalf("SaveJob: %v", err)
+	}
+	if jobIDs, err := repo.ClaimJobs(ctx, "w2", domain.DefaultQueue, nil, 10); err != nil || len(jobIDs) != 0 {
+		t.Fatalf("ClaimJobs at the limit = %v, %v, want none", jobIDs, err)
+	}
+
+	first.State = domain.StateFinished
+	if _, err := repo.SaveJob(ctx, *first); err != nil {
+		t.Fatalf("SaveJob: %v", err)
+	}
+	if jobIDs, err := repo.ClaimJobs(ctx, "w2", domain.DefaultQueue, nil, 10); err != nil || len(jobIDs) != 1 || jobIDs[0] != third.ID {
+		t.Fatalf("ClaimJobs after a job finished = %v, %v, want %s", jobIDs, err, third.ID)
+	}
+}
+
+// A job saved replacing pending jobs stops the unclaimed pending jobs of its key, along with their
+// pending TaskRuns, in the same transaction
+func TestSaveJobsReplacingPending(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	var jobs []*domain.Job
+	for i := range 2 {
+		job := saveTestJob(t, repo, now.Add(time.Duration(i)*time.Second))
+		job.ConcurrencyKey, job.ConcurrencyLimit = "account-1", 1
+		if _, err := repo.SaveJob(ctx, *job); err != nil {
+			t.Fatalf("SaveJob: %v", err)
+		}
+		jobs = append(jobs, job)
+	}
+	claimed, waiting := jobs[0], jobs[1]
+	if job := claimTestJob(t, repo, "w1"); job.ID != claimed.ID {
+		t.Fatalf("claimed %s, want %s", job.ID, claimed.ID)
+	}
+
+	taskRuns, err := repo.SaveTaskRuns(ctx, []domain.TaskRun{
+		{JobID: waiting.ID, TaskName: "noop", State: domain.StatePending},
+		{JobID: claimed.ID, TaskName: "noop", State: domain.StatePending},
+	})
+	if err != nil {
+		t.Fatalf("SaveTaskRuns: %v", err)
+	}
+
+	replacing := *waiting
+	replacing.ID = uuid.New()
+	jobIDs, err := repo.SaveJobsWithTaskRuns(ctx, []domain.JobWithTaskRuns{{
+		Job:            replacing,
+		TaskRuns:       []domain.TaskRun{{JobID: replacing.ID, TaskName: "noop", State: domain.StatePending}},
+		ReplacePending: true,
+	}})
+	if err != nil || len(jobIDs) != 1 || jobIDs[0] != waiting.ID {
+		t.Fatalf("SaveJobsWithTaskRuns = %v, %v, want only the unclaimed job %s replaced", jobIDs, err, waiting.ID)
+	}
+	if job, _ := repo.GetJob(ctx, replacing.ID); job == nil || job.State != domain.StatePending {
+		t.Errorf("replacing job = %+v, want it saved pending", job)
+	}
+	if job, _ := repo.GetJob(ctx, waiting.ID); job.State != domain.StateStopped || job.EndDate == nil {
+		t.Errorf("replaced job = %s ended at %v, want %s", job.State, job.EndDate, domain.StateStopped)
+	}
+	for i, want := range []domain.ExecutionState{domain.StateStopped, domain.StatePending} {
+		if taskRun, _ := repo.GetTaskRun(ctx, taskRuns[i].ID); taskRun.State != want {
+			t.Errorf("task run %d = %s, want %s", i, taskRun.State, want)
+		}
+	}
+}
+
+// Unclaimed pending jobs past their deadline expire with their pending TaskRuns
+func TestExpireJobs(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	saveExpiringJob := func(expiresAt *time.Time) *domain.Job {
+		job := saveTestJob(t, repo, now.Add(-time.Hour))
+		job.ExpiresAt = expiresAt
+		if _, err := repo.SaveJob(ctx, *job); err != nil {
+			t.Fatalf("SaveJob: %v", err)
+		}
+		return job
+	}
+	claimed := saveExpiringJob(util.TimePtr(now.Add(-time.Minute)))
+	if job := claimTestJob(t, repo, "w1"); job.ID != claimed.ID {
+		t.Fatalf("claimed %s, want %s", job.ID, claimed.ID)
+	}
+	expired := saveExpiringJob(util.TimePtr(now.Add(-time.Minute)))
+	future := saveExpiringJob(util.TimePtr(now.Add(time.Hour)))
+	noDeadline := saveExpiringJob(nil)
+
+	taskRuns, err := repo.SaveTaskRuns(ctx, []domain.TaskRun{
+		{JobID: expired.ID, TaskName: "noop", State: domain.StatePending},
+		{JobID: future.ID, TaskName: "noop", State: domain.StatePending},
+	})
+	if err != nil {
+		t.Fatalf("SaveTaskRuns: %v", err)
+	}
+
+	jobIDs, err := repo.ExpireJobs(ctx, now)
+	if err != nil || len(jobIDs) != 1 || jobIDs[0] != expired.ID {
+		t.Fatalf("ExpireJobs = %v, %v, want only %s", jobIDs, err, expired.ID)
+	}
+
+	for _, want := range []struct {
+		job   *domain.Job
+		state domain.ExecutionState
+	}{
+		{expired, domain.StateExpired},
+		{claimed, domain.StatePending},
+		{future, domain.StatePending},
+		{noDeadline, domain.StatePending},
+	} {
+		if job, _ := repo.GetJob(ctx, want.job.ID); job.State != want.state {
+			t.Errorf("job expiring at %v = %s, want %s", want.job.ExpiresAt, job.State, want.state)
+		}
+	}
+	for i, want := range []domain.ExecutionState{domain.StateExpired, domain.StatePending} {
+		if taskRun, _ := repo.GetTaskRun(ctx, taskRuns[i].ID); taskRun.State != want {
+			t.Errorf("task run %d = %s, want %s", i, taskRun.State, want)
+		}
+	}
+}
+
+// The trace context of the submission is kept when the job is saved again
+func TestSaveJobTraceContext(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+
+	config, err := repo.GetOrCreateDefaultJobConfig(ctx)
+	if err != nil {
+		t.Fatalf("GetOrCreateDefaultJobConfig: %v", err)
+	}
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	job, err := repo.SaveJob(ctx, domain.Job{
+		ConfigID:      config.ID,
+		ConfigVersion: config.Version,
+		Status:        domain.Status{State: domain.StatePending},
+		Queue:         domain.DefaultQueue,
+		TraceContext:  map[string]string{"traceparent": traceparent},
+	})
+	if err != nil {
+		t.Fatalf("SaveJob: %v", err)
+	}
+
+	job.State = domain.StateRunning
+	job.TraceContext = nil
+	if _, err := repo.SaveJob(ctx, *job); err != nil {
+		t.Fatalf("SaveJob: %v", err)
+	}
+	if saved, err := repo.GetJob(ctx, job.ID); err != nil || saved.State != domain.StateRunning || saved.TraceContext["traceparent"] != traceparent {
+		t.Errorf("GetJob = %+v, %v, want running with the submission's traceparent", saved, err)
+	}
+}
@@ -0,0 +1,88 @@
+package sqlite3
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/util"
+	"github.com/google/uuid"
+)
+
+func TestPurgeableJobs(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	saveEndedJob := func(state domain.ExecutionState, endDate time.Time) *domain.Job {
+		job := saveTestJob(t, repo, endDate.Add(-time.Minute))
+		job.State = state
+		job.EndDate = util.TimePtr(endDate)
+		if _, err := repo.SaveJob(ctx, *job); err != nil {
+			t.Fatalf("SaveJob: %v", err)
+		}
+		return job
+	}
+	oldFinished := saveEndedJob(domain.StateFinished, now.Add(-3*time.Hour))
+	oldError := saveEndedJob(domain.StateError, now.Add(-2*time.Hour))
+	recentFinished := saveEndedJob(domain.StateFinished, now.Add(-time.Minute))
+	pending := saveTestJob(t, repo, now.Add(-4*time.Hour))
+
+	if _, err := repo.SaveTaskRuns(ctx, []domain.TaskRun{
+		{JobID: oldFinished.ID, TaskName: "noop", State: domain.StateFinished},
+		{JobID: oldFinished.ID, TaskName: "noop", State: domain.StateFinished},
+		{JobID: pending.ID, TaskName: "noop", State: domain.StatePending},
+	}); err != nil {
+		t.Fatalf("SaveTaskRuns: %v", err)
+	}
+
+	refs, err := repo.GetEndedJobConfigVersions(ctx)
+	if err != nil || len(refs) != 1 || refs[0].ConfigID != oldFinished.ConfigID || refs[0].Version != oldFinished.ConfigVersion {
+		t.Fatalf("GetEndedJobConfigVersions = %v, %v, want the default config's version", refs, err)
+	}
+
+	base := domain.PurgeFilter{ConfigID: oldFinished.ConfigID}
+	tests := []struct {
+		name   string
+		modify func(filter *domain.PurgeFilter)
+		want   []uuid.UUID
+	}{
+		{"every ended job", func(filter *domain.PurgeFilter) {}, []uuid.UUID{oldFinished.ID, oldError.ID, recentFinished.ID}},
+		{"ended before", func(filter *domain.PurgeFilter) { filter.EndedBefore = util.TimePtr(now.Add(-time.Hour)) }, []uuid.UUID{oldFinished.ID, oldError.ID}},
+		{"state", func(filter *domain.PurgeFilter) { filter.State = domain.StateFinished }, []uuid.UUID{oldFinished.ID, recentFinished.ID}},
+		{"keep newest", func(filter *domain.PurgeFilter) { filter.KeepNewest = 2 }, []uuid.UUID{oldFinished.ID}},
+		{"another version", func(filter *domain.PurgeFilter) { filter.ConfigVersion = uuid.New() }, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filter := base
+			test.modify(&filter)
+
+			jobIDs, err := repo.GetPurgeableJobs(ctx, filter, 10)
+			if err != nil {
+				t.Fatalf("GetPurgeableJobs: %v", err)
+			}
+			// Jobs that ended first are purged first
+			if !slices.Equal(jobIDs, test.want) {
+				t.Errorf("GetPurgeableJobs = %v, want %v", jobIDs, test.want)
+			}
+			if jobs, _, err := repo.CountPurgeableJobs(ctx, filter); err != nil || jobs != int64(len(test.want)) {
+				t.Errorf("CountPurgeableJobs = %d, %v, want %d", jobs, err, len(test.want))
+			}
+		})
+	}
+
+	jobs, taskRuns, err := repo.DeleteJobs(ctx, []uuid.UUID{oldFinished.ID, oldError.ID})
+	if err != nil || jobs != 2 || taskRuns != 2 {
+		t.Fatalf("DeleteJobs = %d jobs and %d task runs, %v, want 2 and 2", jobs, taskRuns, err)
+	}
+	if job, _ := repo.GetJob(ctx, oldFinished.ID); job != nil {
+		t.Error("deleted job is still saved")
+	}
+	if taskRuns, _ := repo.GetTaskRuns(ctx, pending.ID); len(taskRuns) != 1 {
+		t.Errorf("task runs of a kept job = %d, want 1", len(taskRuns))
+	}
+}
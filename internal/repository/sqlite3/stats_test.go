@@ -0,0 +1,80 @@
+package sqlite3
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+)
+
+// Durations of 1 to 20 seconds have nearest rank percentiles that are each one of the samples
+func TestGetStats(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+
+	hour := time.Now().UTC().Truncate(time.Hour).Add(-2 * time.Hour)
+	var taskRuns []domain.TaskRun
+	for i := 1; i <= 20; i++ {
+		job := saveTestJob(t, repo, hour)
+		start := hour.Add(2 * time.Second)
+		end := start.Add(time.Duration(i) * time.Second)
+		job.State = domain.StateFinished
+		if i == 20 {
+			job.State = domain.StateError
+		}
+		job.StartDate, job.EndDate = &start, &end
+		if _, err := repo.SaveJob(ctx, *job); err != nil {
+			t.Fatalf("SaveJob: %v", err)
+		}
+		taskRuns = append(taskRuns, domain.TaskRun{JobID: job.ID, TaskName: "noop", State: job.State, StartDate: &start, EndDate: &end})
+	}
+	if _, err := repo.SaveTaskRuns(ctx, taskRuns); err != nil {
+		t.Fatalf("SaveTaskRuns: %v", err)
+	}
+	// Jobs submitted outside of the range are not counted
+	saveTestJob(t, repo, hour.Add(-2*time.Hour))
+
+	stats, err := repo.GetStats(ctx, domain.StatsRange{From: hour.Add(-time.Hour), To: hour.Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("GetStats: %v", err)
+	}
+
+	if stats.JobStates[domain.StateFinished] != 19 || stats.JobStates[domain.StateError] != 1 || stats.JobStates[domain.StatePending] != 0 {
+		t.Errorf("job states = %v, want 19 finished and 1 error", stats.JobStates)
+	}
+	if stats.TaskStates[domain.StateFinished] != 19 || stats.TaskStates[domain.StateError] != 1 {
+		t.Errorf("task states = %v, want 19 finished and 1 error", stats.TaskStates)
+	}
+	if len(stats.Throughput) != 1 || !stats.Throughput[0].Hour.Equal(hour) ||
+		stats.Throughput[0].Ended != 20 || stats.Throughput[0].Finished != 19 || stats.Throughput[0].Failed != 1 {
+		t.Errorf("throughput = %+v, want 20 ended at %s", stats.Throughput, hour)
+	}
+
+	checkDurations := func(name string, got domain.DurationStats, want domain.DurationStats) {
+		t.Helper()
+
+		// julianday arithmetic is not exact to the second
+		near := func(a, b float64) bool { return math.Abs(a-b) < 0.01 }
+		if got.Count != want.Count || !near(got.Avg, want.Avg) || !near(got.P50, want.P50) || !near(got.P95, want.P95) || !near(got.P99, want.P99) {
+			t.Errorf("%s = %+v, want %+v", name, got, want)
+		}
+	}
+	durations := domain.DurationStats{Count: 20, Avg: 10.5, P50: 10, P95: 19, P99: 20}
+
+	if len(stats.QueueWait) != 1 || stats.QueueWait[0].Queue != domain.DefaultQueue {
+		t.Fatalf("queue wait = %+v, want the default queue", stats.QueueWait)
+	}
+	checkDurations("queue wait", stats.QueueWait[0].DurationStats, domain.DurationStats{Count: 20, Avg: 2, P50: 2, P95: 2, P99: 2})
+
+	if len(stats.TaskDurations) != 1 || stats.TaskDurations[0].TaskName != "noop" {
+		t.Fatalf("task durations = %+v, want noop", stats.TaskDurations)
+	}
+	checkDurations("task durations", stats.TaskDurations[0].DurationStats, durations)
+
+	if len(stats.ConfigDurations) != 1 {
+		t.Fatalf("config durations = %+v, want the default config", stats.ConfigDurations)
+	}
+	checkDurations("config durations", stats.ConfigDurations[0].DurationStats, durations)
+}
@@ -169,6 +169,7 @@ func (repo *SQLiteServiceRepository) GetAllTaskRuns(ctx context.Context, cursor
 	pq := &db.PaginationQuery{
 		BaseQuery:     queries.SelectPaginationTaskRunSQL,
 		AllowedFields: queries.TaskRunPaginationAllowedFields,
+		FilterFields:  queries.TaskRunFilterFields,
 	}
 
 	dbOutput, err := db.Paginate[TaskRunDB](ctx, repo.DB, pq, cursor)
@@ -0,0 +1,55 @@
+package sqlite3
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+)
+
+// Filters combine with the cursor of later pages
+func TestGetAllTaskRunsFiltered(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+
+	job := saveTestJob(t, repo, time.Now())
+	var taskRuns []domain.TaskRun
+	for _, taskName := range []string{"email", "email", "email", "report"} {
+		taskRuns = append(taskRuns, domain.TaskRun{JobID: job.ID, TaskName: taskName, State: domain.StateFinished})
+	}
+	taskRuns[0].State = domain.StateError
+	if _, err := repo.SaveTaskRuns(ctx, taskRuns); err != nil {
+		t.Fatalf("SaveTaskRuns: %v", err)
+	}
+
+	getAll := func(cursor domain.CursorInput) *domain.CursorOutput[domain.TaskRun] {
+		t.Helper()
+
+		cursor.SetDefaults()
+		page, err := repo.GetAllTaskRuns(ctx, &cursor)
+		if err != nil {
+			t.Fatalf("GetAllTaskRuns(%+v): %v", cursor.Filters, err)
+		}
+		return page
+	}
+
+	if page := getAll(domain.CursorInput{}); len(page.Data) != 4 {
+		t.Errorf("unfiltered = %d task runs, want 4", len(page.Data))
+	}
+	emails := []domain.Filter{{Field: "taskName", Op: domain.FilterEq, Values: []string{"email"}}}
+	finishedEmails := append(slices.Clone(emails), domain.Filter{Field: "state", Op: domain.FilterEq, Values: []string{string(domain.StateFinished)}})
+	if page := getAll(domain.CursorInput{Filters: finishedEmails}); len(page.Data) != 2 {
+		t.Errorf("finished email = %d task runs, want 2", len(page.Data))
+	}
+
+	first := getAll(domain.CursorInput{Limit: 2, Filters: emails})
+	if len(first.Data) != 2 || first.NextCursor == nil || *first.NextCursor == "" {
+		t.Fatalf("first page = %d task runs with next cursor %v, want 2 and a cursor", len(first.Data), first.NextCursor)
+	}
+	second := getAll(domain.CursorInput{Limit: 2, After: *first.NextCursor, Filters: emails})
+	if len(second.Data) != 1 || second.Data[0].TaskName != "email" {
+		t.Errorf("second page = %+v, want the last email task run", second.Data)
+	}
+}
@@ -0,0 +1,123 @@
+package sqlite3
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/platform/db"
+	"github.com/abikandiah/task-worker/internal/repository/models"
+	"github.com/abikandiah/task-worker/internal/repository/queries"
+	"github.com/google/uuid"
+)
+
+// --- SQL Constants for webhook_deliveries table ---
+const insertWebhookDeliverySQL = `
+    INSERT INTO webhook_deliveries (
+        ` + queries.SelectWebhookDeliveryFields + `
+    ) VALUES (
+		:id, :job_id, :url, :event_type, :payload, :state, :attempts, :last_status_code, :last_error, :next_attempt_date, :created_date, :delivered_date
+    )
+`
+
+const upsertWebhookDeliverySQL = insertWebhookDeliverySQL + queries.UpsertWebhookDeliveryConflictClause
+
+const selectWebhookDeliveriesByJobSQL = queries.SelectWebhookDeliveriesByJobBaseSQL + `?` + queries.SelectWebhookDeliveriesByJobOrderSQL
+
+const selectDueWebhookDeliveriesSQL = queries.SelectDueWebhookDeliveriesBaseSQL + `?` + queries.SelectDueWebhookDeliveriesOrderSQL + `?`
+
+const claimWebhookDeliverySQL = queries.ClaimWebhookDeliveryBaseSQL + `?
+	WHERE 
+		id = ? AND state = 'PENDING' AND next_attempt_date <= ?
+`
+
+type WebhookDeliveryDB struct {
+	models.CommonWebhookDeliveryDB
+	NextAttemptDate db.TextTime     `db:"next_attempt_date"`
+	CreatedDate     db.TextTime     `db:"created_date"`
+	DeliveredDate   db.NullTextTime `db:"delivered_date"`
+}
+
+func (deliveryDB *WebhookDeliveryDB) ToDomainWebhookDelivery() *domain.WebhookDelivery {
+	delivery := deliveryDB.ToDomainWebhookDeliveryBase()
+
+	// Extract time.Time from TextTime
+	delivery.NextAttemptDate = deliveryDB.NextAttemptDate.Time
+	delivery.CreatedDate = deliveryDB.CreatedDate.Time
+	if deliveryDB.DeliveredDate.Valid {
+		delivery.DeliveredDate = &deliveryDB.DeliveredDate.Time
+	}
+
+	return delivery
+}
+
+func FromDomainWebhookDelivery(delivery domain.WebhookDelivery) *WebhookDeliveryDB {
+	createdDate := delivery.CreatedDate
+	if createdDate.IsZero() {
+		createdDate = time.Now().UTC()
+	}
+
+	return &WebhookDeliveryDB{
+		CommonWebhookDeliveryDB: models.NewCommonWebhookDeliveryDB(delivery),
+		NextAttemptDate:         db.TextTime{Time: delivery.NextAttemptDate.UTC()},
+		CreatedDate:             db.TextTime{Time: createdDate.UTC()},
+		DeliveredDate:           db.NewNullTextTime(delivery.DeliveredDate),
+	}
+}
+
+func (repo *SQLiteServiceRepository) SaveWebhookDelivery(ctx context.Context, delivery domain.WebhookDelivery) (*domain.WebhookDelivery, error) {
+	deliveryDB := FromDomainWebhookDelivery(delivery)
+
+	_, err := repo.DB.NamedExecContext(ctx, upsertWebhookDeliverySQL, deliveryDB)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert webhook delivery %s: %w", deliveryDB.ID, err)
+	}
+
+	return deliveryDB.ToDomainWebhookDelivery(), nil
+}
+
+func (repo *SQLiteServiceRepository) GetWebhookDeliveries(ctx context.Context, jobID uuid.UUID) ([]domain.WebhookDelivery, error) {
+	var deliveryDBs []WebhookDeliveryDB
+	err := repo.DB.SelectContext(ctx, &deliveryDBs, selectWebhookDeliveriesByJobSQL, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries for job %s: %w", jobID, err)
+	}
+
+	return toDomainWebhookDeliveries(deliveryDBs), nil
+}
+
+func (repo *SQLiteServiceRepository) GetDueWebhookDeliveries(ctx context.Context, before time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	var deliveryDBs []WebhookDeliveryDB
+	err := repo.DB.SelectContext(ctx, &deliveryDBs, selectDueWebhookDeliveriesSQL, db.TextTime{Time: before.UTC()}, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due webhook deliveries: %w", err)
+	}
+
+	return toDomainWebhookDeliveries(deliveryDBs), nil
+}
+
+func (repo *SQLiteServiceRepository) ClaimWebhookDelivery(ctx context.Context, deliveryID uuid.UUID, now time.Time, leaseUntil time.Time) (bool, error) {
+	result, err := repo.DB.ExecContext(ctx, claimWebhookDeliverySQL,
+		db.TextTime{Time: leaseUntil.UTC()},
+		deliveryID,
+		db.TextTime{Time: now.UTC()},
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim webhook delivery %s: %w", deliveryID, err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim webhook delivery %s: %w", deliveryID, err)
+	}
+	return rows == 1, nil
+}
+
+func toDomainWebhookDeliveries(deliveryDBs []WebhookDeliveryDB) []domain.WebhookDelivery {
+	deliveries := make([]domain.WebhookDelivery, len(deliveryDBs))
+	for i, deliveryDB := range deliveryDBs {
+		deliveries[i] = *deliveryDB.ToDomainWebhookDelivery()
+	}
+	return deliveries
+}
@@ -0,0 +1,144 @@
+package sqlite3
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/platform/db"
+	"github.com/abikandiah/task-worker/internal/repository/models"
+	"github.com/abikandiah/task-worker/internal/repository/queries"
+	"github.com/google/uuid"
+)
+
+// --- SQL Constants for workers table ---
+const insertWorkerSQL = `
+	INSERT INTO workers (
+		` + queries.SelectWorkerFields + `
+	) VALUES (
+		:id, :hostname, :version, :job_worker_count, :task_worker_count, :task_names, :started_date, :heartbeat_date, :stopped_date, :leader, :queues, :capabilities
+	)
+`
+
+const upsertWorkerSQL = insertWorkerSQL + queries.UpsertWorkerConflictClause
+
+const updateWorkerHeartbeatSQL = queries.UpdateWorkerHeartbeatBaseSQL + `?, leader = ? WHERE id = ?`
+
+const stopWorkerSQL = queries.StopWorkerBaseSQL + `? WHERE id = ?`
+
+const recoverJobsSQL = queries.RecoverJobsBaseSQL + queries.DeadJobsCondition + `?)
+	RETURNING 
+		id
+`
+
+const resetRecoveredTaskRunsSQL = queries.ResetRecoveredTaskRunsBaseSQL + `?`
+
+type WorkerDB struct {
+	models.CommonWorkerDB
+	StartedDate   db.TextTime     `db:"started_date"`
+	HeartbeatDate db.TextTime     `db:"heartbeat_date"`
+	StoppedDate   db.NullTextTime `db:"stopped_date"`
+}
+
+func (workerDB *WorkerDB) ToDomainWorker() (*domain.Worker, error) {
+	worker, err := workerDB.ToDomainWorkerBase()
+	if err != nil {
+		return nil, err
+	}
+
+	// Extract time.Time from TextTime
+	worker.StartedDate = workerDB.StartedDate.Time
+	worker.HeartbeatDate = workerDB.HeartbeatDate.Time
+	if workerDB.StoppedDate.Valid {
+		worker.StoppedDate = &workerDB.StoppedDate.Time
+	}
+	return worker, nil
+}
+
+func FromDomainWorker(worker domain.Worker) (*WorkerDB, error) {
+	commonWorkerDB, err := models.NewCommonWorkerDB(worker)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WorkerDB{
+		CommonWorkerDB: commonWorkerDB,
+		StartedDate:    db.TextTime{Time: worker.StartedDate.UTC()},
+		HeartbeatDate:  db.TextTime{Time: worker.HeartbeatDate.UTC()},
+		StoppedDate:    db.NewNullTextTime(worker.StoppedDate),
+	}, nil
+}
+
+func (repo *SQLiteServiceRepository) SaveWorker(ctx context.Context, worker domain.Worker) (*domain.Worker, error) {
+	workerDB, err := FromDomainWorker(worker)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := repo.DB.NamedExecContext(ctx, upsertWorkerSQL, workerDB); err != nil {
+		return nil, fmt.Errorf("failed to upsert worker %s: %w", worker.ID, err)
+	}
+	return workerDB.ToDomainWorker()
+}
+
+func (repo *SQLiteServiceRepository) UpdateWorkerHeartbeat(ctx context.Context, workerID string, heartbeat time.Time, leader bool) (bool, error) {
+	result, err := repo.DB.ExecContext(ctx, updateWorkerHeartbeatSQL, db.TextTime{Time: heartbeat.UTC()}, leader, workerID)
+	if err != nil {
+		return false, fmt.Errorf("failed to update heartbeat of worker %s: %w", workerID, err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update heartbeat of worker %s: %w", workerID, err)
+	}
+	return rows == 1, nil
+}
+
+func (repo *SQLiteServiceRepository) StopWorker(ctx context.Context, workerID string, stopped time.Time) error {
+	if _, err := repo.DB.ExecContext(ctx, stopWorkerSQL, db.TextTime{Time: stopped.UTC()}, workerID); err != nil {
+		return fmt.Errorf("failed to stop worker %s: %w", workerID, err)
+	}
+	return nil
+}
+
+func (repo *SQLiteServiceRepository) GetWorkers(ctx context.Context) ([]domain.Worker, error) {
+	var workerDBs []WorkerDB
+	if err := repo.DB.SelectContext(ctx, &workerDBs, queries.SelectWorkersSQL); err != nil {
+		return nil, fmt.Errorf("failed to get workers: %w", err)
+	}
+
+	workers := make([]domain.Worker, len(workerDBs))
+	for i, workerDB := range workerDBs {
+		worker, err := workerDB.ToDomainWorker()
+		if err != nil {
+			return nil, err
+		}
+		workers[i] = *worker
+	}
+	return workers, nil
+}
+
+func (repo *SQLiteServiceRepository) RecoverJobs(ctx context.Context, aliveSince time.Time) ([]uuid.UUID, error) {
+	tx, err := repo.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var jobIDs []uuid.UUID
+	if err := tx.SelectContext(ctx, &jobIDs, recoverJobsSQL, db.TextTime{Time: aliveSince.UTC()}); err != nil {
+		return nil, fmt.Errorf("failed to recover jobs: %w", err)
+	}
+
+	for _, jobID := range jobIDs {
+		if _, err := tx.ExecContext(ctx, resetRecoveredTaskRunsSQL, jobID); err != nil {
+			return nil, fmt.Errorf("failed to reset task runs of recovered job %s: %w", jobID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return jobIDs, nil
+}
@@ -0,0 +1,149 @@
+package sqlite3
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/google/uuid"
+)
+
+func saveTestWorker(t *testing.T, repo *SQLiteServiceRepository, workerID string, heartbeat time.Time, stopped *time.Time) {
+	t.Helper()
+
+	_, err := repo.SaveWorker(context.Background(), domain.Worker{
+		ID:            workerID,
+		Hostname:      "host",
+		TaskNames:     []string{},
+		StartedDate:   heartbeat,
+		HeartbeatDate: heartbeat,
+		StoppedDate:   stopped,
+	})
+	if err != nil {
+		t.Fatalf("SaveWorker(%s): %v", workerID, err)
+	}
+}
+
+// Unfinished jobs of workers that stopped, missed heartbeats or never registered return to the
+// pending queue unclaimed, with their running TaskRuns reset
+func TestRecoverJobsOfDeadWorkers(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	saveTestWorker(t, repo, "alive", now, nil)
+	saveTestWorker(t, repo, "dead", now.Add(-time.Hour), nil)
+	saveTestWorker(t, repo, "stopped", now, &now)
+
+	claim := func(workerID string, state domain.ExecutionState) domain.Job {
+		saveTestJob(t, repo, now)
+		job := claimTestJob(t, repo, workerID)
+		job.State = state
+		if _, err := repo.SaveJob(ctx, job); err != nil {
+			t.Fatalf("SaveJob: %v", err)
+		}
+		return job
+	}
+	deadRunning := claim("dead", domain.StateRunning)
+	deadFinished := claim("dead", domain.StateFinished)
+	stoppedPending := claim("stopped", domain.StatePending)
+	unregistered := claim("unregistered", domain.StateRunning)
+	aliveRunning := claim("alive", domain.StateRunning)
+
+	taskRuns, err := repo.SaveTaskRuns(ctx, []domain.TaskRun{
+		{JobID: deadRunning.ID, TaskName: "noop", State: domain.StateFinished},
+		{JobID: deadRunning.ID, TaskName: "noop", State: domain.StateRunning},
+		{JobID: aliveRunning.ID, TaskName: "noop", State: domain.StateRunning},
+	})
+	if err != nil {
+		t.Fatalf("SaveTaskRuns: %v", err)
+	}
+
+	recovered, err := repo.RecoverJobs(ctx, now.Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("RecoverJobs: %v", err)
+	}
+	wantRecovered := []uuid.UUID{deadRunning.ID, stoppedPending.ID, unregistered.ID}
+	sortIDs := func(ids []uuid.UUID) {
+		slices.SortFunc(ids, func(a, b uuid.UUID) int { return slices.Compare(a[:], b[:]) })
+	}
+	sortIDs(recovered)
+	sortIDs(wantRecovered)
+	if !slices.Equal(recovered, wantRecovered) {
+		t.Fatalf("recovered %v, want %v", recovered, wantRecovered)
+	}
+
+	for _, want := range []struct {
+		job      domain.Job
+		state    domain.ExecutionState
+		workerID string
+	}{
+		{deadRunning, domain.StatePending, ""},
+		{stoppedPending, domain.StatePending, ""},
+		{unregistered, domain.StatePending, ""},
+		{deadFinished, domain.StateFinished, "dead"},
+		{aliveRunning, domain.StateRunning, "alive"},
+	} {
+		job, err := repo.GetJob(ctx, want.job.ID)
+		if err != nil {
+			t.Fatalf("GetJob: %v", err)
+		}
+		if job.State != want.state || job.WorkerID != want.workerID {
+			t.Errorf("job of %s = %s claimed by %q, want %s claimed by %q", want.job.WorkerID, job.State, job.WorkerID, want.state, want.workerID)
+		}
+	}
+
+	wantTaskStates := []domain.ExecutionState{domain.StateFinished, domain.StatePending, domain.StateRunning}
+	for i, taskRun := range taskRuns {
+		saved, err := repo.GetTaskRun(ctx, taskRun.ID)
+		if err != nil {
+			t.Fatalf("GetTaskRun: %v", err)
+		}
+		if saved.State != wantTaskStates[i] {
+			t.Errorf("task run %d = %s, want %s", i, saved.State, wantTaskStates[i])
+		}
+	}
+
+	// A recovered job can be claimed by a live worker
+	if job := claimTestJob(t, repo, "alive"); job.WorkerID != "alive" {
+		t.Errorf("reclaimed job is held by %q, want alive", job.WorkerID)
+	}
+}
+
+func TestWorkerHeartbeatAndStop(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+
+	started := time.Now().UTC().Add(-time.Minute).Truncate(time.Millisecond)
+	saveTestWorker(t, repo, "w1", started, nil)
+
+	heartbeat := started.Add(30 * time.Second)
+	if found, err := repo.UpdateWorkerHeartbeat(ctx, "w1", heartbeat, true); err != nil || !found {
+		t.Fatalf("UpdateWorkerHeartbeat = %v, %v, want found", found, err)
+	}
+	if found, err := repo.UpdateWorkerHeartbeat(ctx, "missing", heartbeat, false); err != nil || found {
+		t.Fatalf("UpdateWorkerHeartbeat of an unregistered worker = %v, %v, want not found", found, err)
+	}
+	if workers, err := repo.GetWorkers(ctx); err != nil || len(workers) != 1 || !workers[0].Leader {
+		t.Fatalf("GetWorkers = %v, %v, want w1 leading", workers, err)
+	}
+
+	stopped := heartbeat.Add(time.Second)
+	if err := repo.StopWorker(ctx, "w1", stopped); err != nil {
+		t.Fatalf("StopWorker: %v", err)
+	}
+
+	workers, err := repo.GetWorkers(ctx)
+	if err != nil || len(workers) != 1 {
+		t.Fatalf("GetWorkers = %v, %v, want w1", workers, err)
+	}
+	worker := workers[0]
+	if !worker.StartedDate.Equal(started) || !worker.HeartbeatDate.Equal(heartbeat) || worker.StoppedDate == nil || !worker.StoppedDate.Equal(stopped) {
+		t.Errorf("worker = %+v, want started %s, heartbeat %s and stopped %s", worker, started, heartbeat, stopped)
+	}
+	if worker.Leader {
+		t.Error("stopped worker is still reported as the leader")
+	}
+}
@@ -0,0 +1,363 @@
+package service
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/google/uuid"
+)
+
+const (
+	// archiveManifest lists every archive file of a directory, one JSON entry per line
+	archiveManifest  = "manifest.jsonl"
+	archiveExtension = ".jsonl.gz"
+)
+
+// jobArchiver writes purged jobs to gzip compressed JSONL files, partitioned by the date they ended
+type jobArchiver struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func newJobArchiver(dir string) *jobArchiver {
+	return &jobArchiver{dir: dir}
+}
+
+// Write archives the jobs, a file per date they ended, and records the files in the manifest.
+// Nothing is left behind when it fails, so the jobs can be archived again by the next pass.
+func (archiver *jobArchiver) Write(jobs []domain.ArchivedJob, now time.Time) ([]domain.ArchiveFile, error) {
+	archiver.mu.Lock()
+	defer archiver.mu.Unlock()
+
+	var dates []string
+	byDate := make(map[string][]domain.ArchivedJob)
+	for _, job := range jobs {
+		ended := now
+		if job.Job.EndDate != nil {
+			ended = *job.Job.EndDate
+		}
+		date := ended.UTC().Format(time.DateOnly)
+		if _, ok := byDate[date]; !ok {
+			dates = append(dates, date)
+		}
+		byDate[date] = append(byDate[date], job)
+	}
+
+	files := make([]domain.ArchiveFile, 0, len(dates))
+	for _, date := range dates {
+		file, err := archiver.writeFile(date, byDate[date], now)
+		if err == nil {
+			files = append(files, *file)
+			continue
+		}
+		archiver.remove(files)
+		return nil, err
+	}
+
+	if err := archiver.appendManifest(files); err != nil {
+		archiver.remove(files)
+		return nil, err
+	}
+	return files, nil
+}
+
+// writeFile writes the jobs to a new file of the date's partition, e.g. 2025/01/31
+func (archiver *jobArchiver) writeFile(date string, jobs []domain.ArchivedJob, now time.Time) (*domain.ArchiveFile, error) {
+	name := fmt.Sprintf("jobs-%s-%s%s", now.UTC().Format("20060102T150405Z"), uuid.NewString()[:8], archiveExtension)
+	relPath := filepath.Join(filepath.Join(strings.Split(date, "-")...), name)
+	path := filepath.Join(archiver.dir, relPath)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create archive partition %s: %w", date, err)
+	}
+
+	// Write to a temporary file first, so a partial archive never has the final name
+	tmp, err := os.CreateTemp(filepath.Dir(path), name+".tmp*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	gz := gzip.NewWriter(io.MultiWriter(tmp, hash))
+	encoder := json.NewEncoder(gz)
+
+	file := &domain.ArchiveFile{
+		Path:      filepath.ToSlash(relPath),
+		Date:      date,
+		CreatedAt: now,
+	}
+	for _, job := range jobs {
+		if err := encoder.Encode(job); err != nil {
+			return nil, fmt.Errorf("failed to archive job %s: %w", job.Job.ID, err)
+		}
+		file.Jobs++
+		file.TaskRuns += len(job.TaskRuns)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress archive file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return nil, fmt.Errorf("failed to sync archive file: %w", err)
+	}
+	info, err := tmp.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat archive file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close archive file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, fmt.Errorf("failed to name archive file %s: %w", file.Path, err)
+	}
+
+	file.Bytes = info.Size()
+	file.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	return file, nil
+}
+
+func (archiver *jobArchiver) appendManifest(files []domain.ArchiveFile) error {
+	manifest, err := os.OpenFile(filepath.Join(archiver.dir, archiveManifest), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open archive manifest: %w", err)
+	}
+	defer manifest.Close()
+
+	encoder := json.NewEncoder(manifest)
+	for _, file := range files {
+		if err := encoder.Encode(file); err != nil {
+			return fmt.Errorf("failed to write archive manifest: %w", err)
+		}
+	}
+	if err := manifest.Sync(); err != nil {
+		return fmt.Errorf("failed to sync archive manifest: %w", err)
+	}
+	return manifest.Close()
+}
+
+func (archiver *jobArchiver) remove(files []domain.ArchiveFile) {
+	for _, file := range files {
+		os.Remove(filepath.Join(archiver.dir, filepath.FromSlash(file.Path)))
+	}
+}
+
+// archiveJobs writes the jobs, with their task runs and config, to the archive before they are purged
+func (service *JobService) archiveJobs(ctx context.Context, jobIDs []uuid.UUID) error {
+	// Config versions are keyed by version, which is unique across configs
+	configs := make(map[uuid.UUID]*domain.JobConfig)
+	jobs := make([]domain.ArchivedJob, 0, len(jobIDs))
+	for _, jobID := range jobIDs {
+		job, err := service.repository.GetJob(ctx, jobID)
+		if err != nil {
+			return fmt.Errorf("failed to get job %s to archive: %w", jobID, err)
+		}
+		if job == nil {
+			continue
+		}
+		taskRuns, err := service.repository.GetTaskRuns(ctx, jobID)
+		if err != nil {
+			return fmt.Errorf("failed to get task runs of job %s to archive: %w", jobID, err)
+		}
+
+		// Only the version the job ran with can satisfy the job's reference when restored
+		jobConfig, ok := configs[job.ConfigVersion]
+		if !ok {
+			jobConfig, err = service.repository.GetJobConfigVersion(ctx, job.ConfigID, job.ConfigVersion)
+			if err != nil {
+				return fmt.Errorf("failed to get config %s version %s to archive: %w", job.ConfigID, job.ConfigVersion, err)
+			}
+			configs[job.ConfigVersion] = jobConfig
+		}
+
+		archived := domain.ArchivedJob{Job: *job, TaskRuns: taskRuns, Config: jobConfig}
+		jobs = append(jobs, archived)
+	}
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	files, err := service.archiver.Write(jobs, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		slog.InfoContext(ctx, "archived jobs", slog.String("path", file.Path), slog.Int("jobs", file.Jobs))
+	}
+	return nil
+}
+
+// RestoreArchive saves the jobs of an archive file, or of every archive file under a directory,
+// back into the repository. Files listed in a directory's manifest are verified against their
+// checksum first. Restoring is idempotent, jobs and task runs keep their IDs.
+func (service *JobService) RestoreArchive(ctx context.Context, path string) (*domain.RestoreReport, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive %s: %w", path, err)
+	}
+
+	files := []string{path}
+	checksums := make(map[string]string)
+	if info.IsDir() {
+		if files, err = findArchiveFiles(path); err != nil {
+			return nil, err
+		}
+		if checksums, err = readArchiveManifest(path); err != nil {
+			return nil, err
+		}
+	}
+
+	report := &domain.RestoreReport{}
+	for _, file := range files {
+		if expected, ok := checksums[file]; ok {
+			if err := verifyArchiveFile(file, expected); err != nil {
+				return report, err
+			}
+		}
+		if err := service.restoreArchiveFile(ctx, file, report); err != nil {
+			return report, err
+		}
+		report.Files++
+	}
+	return report, nil
+}
+
+func (service *JobService) restoreArchiveFile(ctx context.Context, path string, report *domain.RestoreReport) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open archive file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(bufio.NewReader(file))
+	if err != nil {
+		return fmt.Errorf("failed to decompress archive file %s: %w", path, err)
+	}
+	defer gz.Close()
+
+	restoredConfigs := make(map[uuid.UUID]bool)
+	decoder := json.NewDecoder(gz)
+	for {
+		var archived domain.ArchivedJob
+		if err := decoder.Decode(&archived); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to read archive file %s: %w", path, err)
+		}
+
+		if archived.Config != nil && !restoredConfigs[archived.Config.Version] {
+			restored, err := service.restoreJobConfig(ctx, *archived.Config)
+			if err != nil {
+				return err
+			}
+			restoredConfigs[archived.Config.Version] = true
+			if restored {
+				report.Configs++
+			}
+		}
+
+		if _, err := service.repository.SaveJob(ctx, archived.Job); err != nil {
+			return fmt.Errorf("failed to restore job %s: %w", archived.Job.ID, err)
+		}
+		if _, err := service.repository.SaveTaskRuns(ctx, archived.TaskRuns); err != nil {
+			return fmt.Errorf("failed to restore task runs of job %s: %w", archived.Job.ID, err)
+		}
+		report.Jobs++
+		report.TaskRuns += len(archived.TaskRuns)
+	}
+}
+
+// restoreJobConfig saves an archived config unless it is in the repository, reporting whether it was saved
+func (service *JobService) restoreJobConfig(ctx context.Context, jobConfig domain.JobConfig) (bool, error) {
+	existing, err := service.repository.GetJobConfigVersion(ctx, jobConfig.ID, jobConfig.Version)
+	if err != nil {
+		return false, fmt.Errorf("failed to get config %s version %s: %w", jobConfig.ID, jobConfig.Version, err)
+	}
+	if existing != nil {
+		return false, nil
+	}
+
+	// The repository's own default config stays the default
+	jobConfig.IsDefault = false
+	if _, err := service.repository.SaveJobConfig(ctx, jobConfig); err != nil {
+		return false, fmt.Errorf("failed to restore config %s: %w", jobConfig.ID, err)
+	}
+	return true, nil
+}
+
+// findArchiveFiles returns the archive files under dir, in date order
+func findArchiveFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && strings.HasSuffix(path, archiveExtension) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archive files in %s: %w", dir, err)
+	}
+	return files, nil
+}
+
+// readArchiveManifest returns the checksums of the files listed in dir's manifest by path,
+// a directory without a manifest has none
+func readArchiveManifest(dir string) (map[string]string, error) {
+	checksums := make(map[string]string)
+	manifest, err := os.Open(filepath.Join(dir, archiveManifest))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return checksums, nil
+		}
+		return nil, fmt.Errorf("failed to open archive manifest: %w", err)
+	}
+	defer manifest.Close()
+
+	decoder := json.NewDecoder(manifest)
+	for {
+		var file domain.ArchiveFile
+		if err := decoder.Decode(&file); err != nil {
+			if errors.Is(err, io.EOF) {
+				return checksums, nil
+			}
+			return nil, fmt.Errorf("failed to read archive manifest: %w", err)
+		}
+		checksums[filepath.Join(dir, filepath.FromSlash(file.Path))] = file.SHA256
+	}
+}
+
+func verifyArchiveFile(path string, expected string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open archive file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return fmt.Errorf("failed to read archive file %s: %w", path, err)
+	}
+	if actual := hex.EncodeToString(hash.Sum(nil)); actual != expected {
+		return fmt.Errorf("archive file %s does not match its manifest checksum", path)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/mock"
+	"github.com/google/uuid"
+)
+
+// Purged jobs are archived with their task runs and config, and restore into an empty repository
+func TestArchiveRestoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	repo := mock.NewMockRepo()
+	service := &JobService{jobServiceDependencies: newTestDeps(t, repo), archiver: newJobArchiver(dir)}
+	service.config.Retention = &RetentionConfig{
+		BatchSize: 10,
+		MaxAge:    map[string]time.Duration{"finished": time.Minute},
+	}
+
+	jobConfig, err := repo.SaveJobConfig(ctx, domain.JobConfig{
+		IdentityVersion: domain.IdentityVersion{Identity: domain.Identity{ID: uuid.New()}, Version: uuid.New()},
+	})
+	if err != nil {
+		t.Fatalf("SaveJobConfig: %v", err)
+	}
+
+	// Jobs that ended on different days are archived to different partitions
+	now := time.Now().UTC()
+	var jobIDs []uuid.UUID
+	for _, endDate := range []time.Time{now.Add(-time.Hour), now.Add(-48 * time.Hour)} {
+		job, err := repo.SaveJob(ctx, domain.Job{
+			ConfigID:      jobConfig.ID,
+			ConfigVersion: jobConfig.Version,
+			Status:        domain.Status{State: domain.StateFinished},
+			EndDate:       &endDate,
+		})
+		if err != nil {
+			t.Fatalf("SaveJob: %v", err)
+		}
+		if _, err := repo.SaveTaskRun(ctx, domain.TaskRun{JobID: job.ID, TaskName: "noop", State: domain.StateFinished}); err != nil {
+			t.Fatalf("SaveTaskRun: %v", err)
+		}
+		jobIDs = append(jobIDs, job.ID)
+	}
+
+	if report, err := service.PurgeJobs(ctx, false); err != nil || report.Jobs != 2 {
+		t.Fatalf("PurgeJobs = %+v, %v, want 2 jobs purged", report, err)
+	}
+	files, err := findArchiveFiles(dir)
+	if err != nil || len(files) != 2 {
+		t.Fatalf("archive files = %v, %v, want one per date", files, err)
+	}
+	if checksums, err := readArchiveManifest(dir); err != nil || len(checksums) != 2 {
+		t.Fatalf("manifest = %v, %v, want both files", checksums, err)
+	}
+
+	restoreRepo := mock.NewMockRepo()
+	restorer := &JobService{jobServiceDependencies: newTestDeps(t, restoreRepo)}
+	report, err := restorer.RestoreArchive(ctx, dir)
+	if err != nil {
+		t.Fatalf("RestoreArchive: %v", err)
+	}
+	want := domain.RestoreReport{Files: 2, Jobs: 2, TaskRuns: 2, Configs: 1}
+	if *report != want {
+		t.Errorf("report = %+v, want %+v", *report, want)
+	}
+	for _, jobID := range jobIDs {
+		if job, _ := restoreRepo.GetJob(ctx, jobID); job == nil || job.State != domain.StateFinished {
+			t.Errorf("job %s was not restored", jobID)
+		}
+		if taskRuns, _ := restoreRepo.GetTaskRuns(ctx, jobID); len(taskRuns) != 1 {
+			t.Errorf("job %s restored %d task runs, want 1", jobID, len(taskRuns))
+		}
+	}
+	if restored, _ := restoreRepo.GetJobConfigVersion(ctx, jobConfig.ID, jobConfig.Version); restored == nil {
+		t.Error("config version the jobs ran with was not restored")
+	}
+
+	// Restoring again saves the same jobs and finds the config already there
+	report, err = restorer.RestoreArchive(ctx, files[0])
+	if err != nil || report.Jobs != 1 || report.Configs != 0 {
+		t.Errorf("restoring a file again = %+v, %v, want 1 job and no configs", report, err)
+	}
+}
+
+func TestRestoreArchiveVerifiesChecksums(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	files, err := newJobArchiver(dir).Write([]domain.ArchivedJob{{Job: domain.Job{Identity: domain.Identity{ID: uuid.New()}}}}, time.Now().UTC())
+	if err != nil || len(files) != 1 {
+		t.Fatalf("Write = %v, %v, want a file", files, err)
+	}
+
+	path := filepath.Join(dir, filepath.FromSlash(files[0].Path))
+	if err := os.WriteFile(path, []byte("tampered"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	service := &JobService{jobServiceDependencies: newTestDeps(t, mock.NewMockRepo())}
+	_, err = service.RestoreArchive(ctx, dir)
+	if err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Errorf("RestoreArchive of a tampered file = %v, want a checksum error", err)
+	}
+}
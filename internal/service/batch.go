@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// batchChunkSize is how many jobs a best effort batch saves per transaction
+const batchChunkSize = 500
+
+// preparedJob is a batch submission that passed validation, by its index in the batch
+type preparedJob struct {
+	index int
+	domain.JobWithTaskRuns
+}
+
+// SubmitJobBatch submits many jobs at once. An all or nothing batch is saved in one transaction
+// only when every submission is valid, a best effort batch saves the valid submissions in chunks.
+// Pending jobs are replaced in the transaction saving the submission that replaces them, so they
+// are only stopped once the batch validated and is saved.
+func (service *JobService) SubmitJobBatch(ctx context.Context, submissions []domain.JobSubmission, mode domain.BatchMode) (report *domain.BatchSubmissionReport, err error) {
+	ctx, span := tracer.Start(ctx, "JobService.SubmitJobBatch", trace.WithAttributes(
+		attribute.Int("batch.size", len(submissions)), attribute.String("batch.mode", string(mode))))
+	defer func() { endSpan(span, err) }()
+
+	if mode == "" {
+		mode = domain.BatchAllOrNothing
+	}
+	if mode != domain.BatchAllOrNothing && mode != domain.BatchBestEffort {
+		return nil, fmt.Errorf("%w: unknown batch mode %q", ErrInvalidSubmission, mode)
+	}
+	if len(submissions) == 0 {
+		return nil, fmt.Errorf("%w: batch has no submissions", ErrInvalidSubmission)
+	}
+	if len(submissions) > service.config.MaxBatchSubmissions {
+		return nil, fmt.Errorf("%w: batch has %d submissions, at most %d are allowed",
+			ErrInvalidSubmission, len(submissions), service.config.MaxBatchSubmissions)
+	}
+
+	var replaced []uuid.UUID
+	report = &domain.BatchSubmissionReport{
+		Mode:    mode,
+		Results: make([]domain.BatchSubmissionResult, len(submissions)),
+	}
+
+	lookups := newBatchLookups()
+	prepared := make([]preparedJob, 0, len(submissions))
+	for i := range submissions {
+		submission := &submissions[i]
+		report.Results[i].Index = i
+
+		job, err := service.prepareBatchJob(ctx, submission, lookups)
+		if err != nil {
+			failBatchItem(report, i, err)
+			continue
+		}
+		prepared = append(prepared, preparedJob{
+			index:           i,
+			JobWithTaskRuns: newJobWithTaskRuns(job, submission),
+		})
+	}
+
+	if mode == domain.BatchAllOrNothing {
+		if report.Failed > 0 {
+			for _, job := range prepared {
+				report.Results[job.index].Status = domain.BatchItemSkipped
+			}
+			return report, nil
+		}
+		jobIDs, err := service.saveBatchJobs(ctx, prepared)
+		if err != nil {
+			return nil, err
+		}
+		replaced = jobIDs
+		submitBatchItems(report, prepared)
+	} else {
+		for start := 0; start < len(prepared); start += batchChunkSize {
+			chunk := prepared[start:min(start+batchChunkSize, len(prepared))]
+			jobIDs, err := service.saveBatchJobs(ctx, chunk)
+			if err != nil {
+				for _, job := range chunk {
+					failBatchItem(report, job.index, err)
+				}
+				continue
+			}
+			replaced = append(replaced, jobIDs...)
+			submitBatchItems(report, chunk)
+		}
+	}
+
+	for _, job := range prepared {
+		if report.Results[job.index].Status == domain.BatchItemSubmitted {
+			service.jobSubmitted(ctx, &job.Job)
+		}
+	}
+	// Replaced jobs may have been submitted earlier in the batch
+	service.jobsReplaced(ctx, replaced)
+	return report, nil
+}
+
+// prepareBatchJob validates a submission of a batch, assigning its job ID up front so its task
+// runs can be saved with it. The job counts towards its concurrency key's limit for the rest of the batch.
+func (service *JobService) prepareBatchJob(ctx context.Context, submission *domain.JobSubmission, lookups *batchLookups) (*domain.Job, error) {
+	if err := validateRequiredFields(submission); err != nil {
+		return nil, err
+	}
+	job, err := service.prepareJob(ctx, submission, lookups)
+	if err != nil {
+		return nil, err
+	}
+	job.ID = uuid.New()
+	if job.ConcurrencyKey != "" {
+		lookups.queued[job.ConcurrencyKey]++
+	}
+	return job, nil
+}
+
+// saveBatchJobs saves the jobs in one transaction and returns the IDs of the jobs they replaced
+func (service *JobService) saveBatchJobs(ctx context.Context, jobs []preparedJob) ([]uuid.UUID, error) {
+	batch := make([]domain.JobWithTaskRuns, len(jobs))
+	for i, job := range jobs {
+		batch[i] = job.JobWithTaskRuns
+	}
+	replaced, err := service.repository.SaveJobsWithTaskRuns(ctx, batch)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to save job batch", slog.Int("jobs", len(batch)), slog.Any("error", err))
+		return nil, fmt.Errorf("failed to save job batch: %w", err)
+	}
+	return replaced, nil
+}
+
+// failBatchItem reports a submission that was not saved, errors that are not the submitter's are hidden
+func failBatchItem(report *domain.BatchSubmissionReport, index int, err error) {
+	message := err.Error()
+	if !errors.Is(err, ErrInvalidSubmission) && !errors.Is(err, ErrConcurrencyConflict) {
+		message = "failed to submit job"
+	}
+	report.Results[index].Status = domain.BatchItemFailed
+	report.Results[index].Error = message
+	report.Failed++
+}
+
+func submitBatchItems(report *domain.BatchSubmissionReport, jobs []preparedJob) {
+	for _, job := range jobs {
+		report.Results[job.index].Status = domain.BatchItemSubmitted
+		report.Results[job.index].JobID = &job.Job.ID
+		report.Submitted++
+	}
+}
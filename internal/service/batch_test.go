@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/mock"
+	"github.com/google/uuid"
+)
+
+func concurrencySubmission(policy domain.ConcurrencyPolicy) domain.JobSubmission {
+	return domain.JobSubmission{
+		IdentitySubmission: domain.IdentitySubmission{Name: "sync"},
+		TaskRuns:           []domain.TaskRun{{TaskName: "noop"}},
+		ConcurrencyKey:     "account-1",
+		ConcurrencyLimit:   1,
+		ConcurrencyPolicy:  policy,
+	}
+}
+
+// savePendingJob saves a pending job of the concurrency key, as if submitted earlier
+func savePendingJob(t *testing.T, service *JobService) *domain.Job {
+	t.Helper()
+
+	submission := concurrencySubmission(domain.ConcurrencyQueue)
+	job, err := service.SubmitJob(context.Background(), &submission)
+	if err != nil {
+		t.Fatalf("SubmitJob: %v", err)
+	}
+	return job
+}
+
+func jobState(t *testing.T, repo *mock.MockRepo, jobID uuid.UUID) domain.ExecutionState {
+	t.Helper()
+
+	job, err := repo.GetJob(context.Background(), jobID)
+	if err != nil || job == nil {
+		t.Fatalf("GetJob(%s): %v", jobID, err)
+	}
+	return job.State
+}
+
+// A replacing submission must not stop pending jobs unless its batch is saved
+func TestSubmitJobBatchReplacesOnlyWhenSaved(t *testing.T) {
+	invalid := domain.JobSubmission{IdentitySubmission: domain.IdentitySubmission{Name: "missing task runs"}}
+
+	tests := []struct {
+		name        string
+		submissions []domain.JobSubmission
+		wantState   domain.ExecutionState
+	}{
+		{"valid batch replaces", []domain.JobSubmission{concurrencySubmission(domain.ConcurrencyReplace)}, domain.StateStopped},
+		{"invalid batch keeps pending jobs", []domain.JobSubmission{concurrencySubmission(domain.ConcurrencyReplace), invalid}, domain.StatePending},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := mock.NewMockRepo()
+			service := &JobService{jobServiceDependencies: newTestDeps(t, repo)}
+			pending := savePendingJob(t, service)
+
+			if _, err := service.SubmitJobBatch(context.Background(), test.submissions, domain.BatchAllOrNothing); err != nil {
+				t.Fatalf("SubmitJobBatch: %v", err)
+			}
+			if state := jobState(t, repo, pending.ID); state != test.wantState {
+				t.Errorf("pending job state = %s, want %s", state, test.wantState)
+			}
+		})
+	}
+}
+
+// Later replacing submissions of a batch replace the earlier ones
+func TestSubmitJobBatchReplacesEarlierJobsOfBatch(t *testing.T) {
+	repo := mock.NewMockRepo()
+	service := &JobService{jobServiceDependencies: newTestDeps(t, repo)}
+
+	submissions := []domain.JobSubmission{
+		concurrencySubmission(domain.ConcurrencyReplace),
+		concurrencySubmission(domain.ConcurrencyReplace),
+	}
+	report, err := service.SubmitJobBatch(context.Background(), submissions, domain.BatchAllOrNothing)
+	if err != nil || report.Submitted != 2 {
+		t.Fatalf("SubmitJobBatch = %+v, %v, want 2 submitted", report, err)
+	}
+	if state := jobState(t, repo, *report.Results[0].JobID); state != domain.StateStopped {
+		t.Errorf("first job state = %s, want %s", state, domain.StateStopped)
+	}
+	if state := jobState(t, repo, *report.Results[1].JobID); state != domain.StatePending {
+		t.Errorf("second job state = %s, want %s", state, domain.StatePending)
+	}
+}
+
+// Rejecting submissions count the jobs of their key earlier in the batch
+func TestSubmitJobBatchRejectCountsBatchDuplicates(t *testing.T) {
+	repo := mock.NewMockRepo()
+	service := &JobService{jobServiceDependencies: newTestDeps(t, repo)}
+
+	submissions := []domain.JobSubmission{
+		concurrencySubmission(domain.ConcurrencyReject),
+		concurrencySubmission(domain.ConcurrencyReject),
+	}
+	report, err := service.SubmitJobBatch(context.Background(), submissions, domain.BatchBestEffort)
+	if err != nil {
+		t.Fatalf("SubmitJobBatch: %v", err)
+	}
+	if report.Submitted != 1 || report.Failed != 1 {
+		t.Fatalf("report = %d submitted, %d failed, want 1 and 1", report.Submitted, report.Failed)
+	}
+	if result := report.Results[1]; result.Status != domain.BatchItemFailed {
+		t.Errorf("second submission = %s, want %s", result.Status, domain.BatchItemFailed)
+	}
+
+	// The same holds against the saved job
+	submission := concurrencySubmission(domain.ConcurrencyReject)
+	if _, err := service.SubmitJob(context.Background(), &submission); !errors.Is(err, ErrConcurrencyConflict) {
+		t.Errorf("SubmitJob error = %v, want ErrConcurrencyConflict", err)
+	}
+}
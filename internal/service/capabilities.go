@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/factory"
+	"github.com/abikandiah/task-worker/internal/util"
+)
+
+// unschedulableBatchSize bounds the jobs checked per pass for a worker that can run them
+const unschedulableBatchSize = 100
+
+// requiredCapabilities returns the sorted union of the capabilities required by the taskRuns' tasks
+func requiredCapabilities(taskFactory *factory.TaskFactory, taskRuns []domain.TaskRun) []string {
+	var capabilities []string
+	for _, taskRun := range taskRuns {
+		capabilities = append(capabilities, taskFactory.RequiredCapabilities(taskRun.TaskName)...)
+	}
+	slices.Sort(capabilities)
+	return slices.Compact(capabilities)
+}
+
+// missingCapabilities returns the required capabilities that are not provided
+func missingCapabilities(required []string, provided []string) []string {
+	var missing []string
+	for _, capability := range required {
+		if !slices.Contains(provided, capability) {
+			missing = append(missing, capability)
+		}
+	}
+	return missing
+}
+
+// failUnschedulableJobs is a leader duty, failing jobs that no live worker can run until ctx is done
+func (service *JobService) failUnschedulableJobs(ctx context.Context) {
+	ticker := time.NewTicker(service.config.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			service.checkUnschedulableJobs(ctx)
+		}
+	}
+}
+
+// checkUnschedulableJobs fails jobs that waited past the threshold while their queue is served,
+// but by no live worker providing their capabilities. Jobs of queues without any live worker keep
+// waiting like every other job.
+func (service *JobService) checkUnschedulableJobs(ctx context.Context) {
+	now := time.Now().UTC()
+
+	jobs, err := service.repository.GetUnclaimedCapabilityJobs(ctx, now.Add(-service.config.UnschedulableAfter), unschedulableBatchSize)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get jobs requiring capabilities", slog.Any("error", err))
+		return
+	}
+	if len(jobs) == 0 {
+		return
+	}
+
+	workers, err := service.GetWorkers(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get workers", slog.Any("error", err))
+		return
+	}
+
+	for i := range jobs {
+		job := &jobs[i]
+
+		served, schedulable := false, false
+		for _, worker := range workers {
+			if worker.State != domain.WorkerAlive || !slices.ContainsFunc(worker.Queues, func(queue domain.WorkerQueue) bool {
+				return queue.Name == job.Queue
+			}) {
+				continue
+			}
+			served = true
+			schedulable = schedulable || len(missingCapabilities(job.Capabilities, worker.Capabilities)) == 0
+		}
+		if !served || schedulable {
+			continue
+		}
+
+		reason := fmt.Sprintf("unschedulable: no worker of queue %s provides capabilities %v", job.Queue, job.Capabilities)
+		service.failUnschedulableJob(ctx, job, reason, now)
+	}
+}
+
+func (service *JobService) failUnschedulableJob(ctx context.Context, job *domain.Job, reason string, now time.Time) {
+	ctx = context.WithValue(ctx, domain.LKeys.JobID, job.ID)
+
+	failed, err := service.repository.FailUnclaimedJob(ctx, job.ID, now)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to fail unschedulable job", slog.Any("error", err))
+		return
+	}
+	if !failed {
+		// A worker claimed it meanwhile
+		return
+	}
+	slog.WarnContext(ctx, "job is unschedulable", slog.Any("capabilities", job.Capabilities), slog.String("queue", job.Queue))
+
+	taskRuns, err := service.repository.GetTaskRuns(ctx, job.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to fetch taskRuns", slog.Any("error", err))
+	}
+	for i := range taskRuns {
+		taskRun := &taskRuns[i]
+		if taskRun.State != domain.StatePending {
+			continue
+		}
+
+		before := taskRun.Status()
+		taskRun.State = domain.StateError
+		taskRun.EndDate = util.TimePtr(now)
+		taskRun.Error = reason
+		if _, err := service.repository.SaveTaskRun(ctx, *taskRun); err != nil {
+			slog.ErrorContext(ctx, "failed to save taskRun", slog.Any("error", err))
+		}
+		service.events.Publish(newTaskEvent(EventTaskFinished, before, taskRun, job.Tags))
+	}
+
+	before := job.Status
+	job.State = domain.StateError
+	job.EndDate = util.TimePtr(now)
+	service.events.Publish(newJobEvent(before, job))
+}
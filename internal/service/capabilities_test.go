@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/mock"
+)
+
+func TestMissingCapabilities(t *testing.T) {
+	tests := []struct {
+		required []string
+		provided []string
+		want     []string
+	}{
+		{nil, nil, nil},
+		{[]string{"gpu"}, []string{"gpu", "license"}, nil},
+		{[]string{"gpu", "license"}, []string{"gpu"}, []string{"license"}},
+		{[]string{"gpu"}, nil, []string{"gpu"}},
+	}
+
+	for _, test := range tests {
+		if got := missingCapabilities(test.required, test.provided); !slices.Equal(got, test.want) {
+			t.Errorf("missingCapabilities(%v, %v) = %v, want %v", test.required, test.provided, got, test.want)
+		}
+	}
+}
+
+// Jobs waiting past the threshold on a served queue, without a live worker providing their
+// capabilities, fail along with their pending TaskRuns
+func TestCheckUnschedulableJobs(t *testing.T) {
+	repo := mock.NewMockRepo()
+	deps := newTestDeps(t, repo)
+	deps.config.DeadAfter = time.Minute
+	deps.config.UnschedulableAfter = time.Minute
+	service := &JobService{jobServiceDependencies: deps}
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	saveWorker := func(workerID string, heartbeat time.Time, queue string, capabilities ...string) {
+		_, err := repo.SaveWorker(ctx, domain.Worker{
+			ID:            workerID,
+			Queues:        []domain.WorkerQueue{{Name: queue}},
+			Capabilities:  capabilities,
+			HeartbeatDate: heartbeat,
+		})
+		if err != nil {
+			t.Fatalf("SaveWorker: %v", err)
+		}
+	}
+	saveWorker("cpu", now, domain.DefaultQueue)
+	saveWorker("gpu", now, "gpu", "gpu")
+	// A dead worker providing the capability does not make a job schedulable
+	saveWorker("dead", now.Add(-time.Hour), domain.DefaultQueue, "license")
+
+	saveJob := func(queue string, submitDate time.Time, capabilities ...string) *domain.Job {
+		job, err := repo.SaveJob(ctx, domain.Job{
+			Status:       domain.Status{State: domain.StatePending},
+			Queue:        queue,
+			Capabilities: capabilities,
+			SubmitDate:   submitDate,
+		})
+		if err != nil {
+			t.Fatalf("SaveJob: %v", err)
+		}
+		return job
+	}
+	unschedulable := saveJob(domain.DefaultQueue, now.Add(-time.Hour), "license")
+	recent := saveJob(domain.DefaultQueue, now, "license")
+	schedulable := saveJob("gpu", now.Add(-time.Hour), "gpu")
+	unserved := saveJob("reports", now.Add(-time.Hour), "license")
+
+	taskRun, err := repo.SaveTaskRun(ctx, domain.TaskRun{JobID: unschedulable.ID, TaskName: "render", State: domain.StatePending})
+	if err != nil {
+		t.Fatalf("SaveTaskRun: %v", err)
+	}
+
+	sub := deps.events.Subscribe(16, nil)
+	defer sub.Close()
+
+	service.checkUnschedulableJobs(ctx)
+
+	for _, want := range []struct {
+		job   *domain.Job
+		state domain.ExecutionState
+	}{
+		{unschedulable, domain.StateError},
+		{recent, domain.StatePending},
+		{schedulable, domain.StatePending},
+		{unserved, domain.StatePending},
+	} {
+		job, _ := repo.GetJob(ctx, want.job.ID)
+		if job.State != want.state {
+			t.Errorf("job of queue %s submitted %s = %s, want %s", job.Queue, job.SubmitDate, job.State, want.state)
+		}
+	}
+
+	failedTaskRun, _ := repo.GetTaskRun(ctx, taskRun.ID)
+	if failedTaskRun.State != domain.StateError || failedTaskRun.Error == "" {
+		t.Errorf("task run = %s with error %q, want %s with the reason", failedTaskRun.State, failedTaskRun.Error, domain.StateError)
+	}
+
+	if types := receiveEventTypes(t, sub, 2); !slices.Equal(types, []EventType{EventTaskFinished, jobEventType(domain.StateError)}) {
+		t.Errorf("events = %v, want the task then the job ending", types)
+	}
+}
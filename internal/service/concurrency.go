@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/google/uuid"
+)
+
+// checkConcurrencyPolicy refuses a job under the reject policy when its key's unfinished jobs,
+// along with the queued jobs of the key that are saved with it, reach the limit. Claims never run
+// more jobs of a key than its limit, so the policies only decide what happens to the waiting jobs.
+// The check is not atomic with saving the job, concurrent duplicates still queue. The replace
+// policy is applied when the job is saved, see newJobWithTaskRuns.
+func (service *JobService) checkConcurrencyPolicy(ctx context.Context, policy domain.ConcurrencyPolicy, job *domain.Job, queued int) error {
+	if job.ConcurrencyKey == "" || policy != domain.ConcurrencyReject {
+		return nil
+	}
+
+	active, err := service.repository.CountActiveJobs(ctx, job.ConcurrencyKey)
+	if err != nil {
+		return fmt.Errorf("failed to count active jobs: %w", err)
+	}
+	if active+queued >= job.ConcurrencyLimit {
+		return fmt.Errorf("%w: %s has %d unfinished jobs", ErrConcurrencyConflict, job.ConcurrencyKey, active+queued)
+	}
+	return nil
+}
+
+// jobsReplaced announces the pending jobs that were stopped by jobs saved with the replace policy
+func (service *JobService) jobsReplaced(ctx context.Context, jobIDs []uuid.UUID) {
+	for _, jobID := range jobIDs {
+		replaced, err := service.repository.GetJob(ctx, jobID)
+		if err != nil || replaced == nil {
+			slog.WarnContext(ctx, "failed to get replaced job", slog.Any("jobId", jobID), slog.Any("error", err))
+			continue
+		}
+		service.events.Publish(newJobEvent(domain.Status{State: domain.StatePending}, replaced))
+	}
+	if len(jobIDs) > 0 {
+		slog.InfoContext(ctx, "replaced pending jobs", slog.Int("count", len(jobIDs)))
+	}
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/mock"
+)
+
+func TestValidateConcurrency(t *testing.T) {
+	tests := []struct {
+		name       string
+		submission domain.JobSubmission
+		wantErr    bool
+	}{
+		{"no key", domain.JobSubmission{}, false},
+		{"key", domain.JobSubmission{ConcurrencyKey: "account-1", ConcurrencyLimit: 2, ConcurrencyPolicy: domain.ConcurrencyReplace}, false},
+		{"limit without key", domain.JobSubmission{ConcurrencyLimit: 2}, true},
+		{"policy without key", domain.JobSubmission{ConcurrencyPolicy: domain.ConcurrencyReject}, true},
+		{"negative limit", domain.JobSubmission{ConcurrencyKey: "account-1", ConcurrencyLimit: -1}, true},
+		{"unknown policy", domain.JobSubmission{ConcurrencyKey: "account-1", ConcurrencyPolicy: "drop"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateConcurrency(&test.submission)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("validateConcurrency() = %v, want error %t", err, test.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidSubmission) {
+				t.Errorf("error %v is not ErrInvalidSubmission", err)
+			}
+		})
+	}
+}
+
+func TestCheckConcurrencyPolicy(t *testing.T) {
+	repo := mock.NewMockRepo()
+	service := &JobService{jobServiceDependencies: newTestDeps(t, repo)}
+	ctx := context.Background()
+
+	if _, err := repo.SaveJob(ctx, domain.Job{
+		Status:           domain.Status{State: domain.StateRunning},
+		Queue:            domain.DefaultQueue,
+		ConcurrencyKey:   "account-1",
+		ConcurrencyLimit: 1,
+		SubmitDate:       time.Now().UTC(),
+	}); err != nil {
+		t.Fatalf("SaveJob: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		policy  domain.ConcurrencyPolicy
+		limit   int
+		queued  int
+		wantErr bool
+	}{
+		{"queue", domain.ConcurrencyQueue, 1, 0, false},
+		{"replace", domain.ConcurrencyReplace, 1, 0, false},
+		{"reject at the limit", domain.ConcurrencyReject, 1, 0, true},
+		{"reject under the limit", domain.ConcurrencyReject, 3, 0, false},
+		{"reject with the batch's queued jobs", domain.ConcurrencyReject, 3, 2, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			job := &domain.Job{ConcurrencyKey: "account-1", ConcurrencyLimit: test.limit}
+			err := service.checkConcurrencyPolicy(ctx, test.policy, job, test.queued)
+			if test.wantErr != errors.Is(err, ErrConcurrencyConflict) {
+				t.Errorf("checkConcurrencyPolicy() = %v, want conflict %t", err, test.wantErr)
+			}
+		})
+	}
+}
+
+// A replacing submission stops the pending jobs of its key and announces them
+func TestSubmitJobReplacesPendingJobs(t *testing.T) {
+	repo := mock.NewMockRepo()
+	deps := newTestDeps(t, repo)
+	service := &JobService{jobServiceDependencies: deps}
+	pending := savePendingJob(t, service)
+
+	sub := deps.events.Subscribe(16, nil)
+	defer sub.Close()
+
+	submission := concurrencySubmission(domain.ConcurrencyReplace)
+	job, err := service.SubmitJob(context.Background(), &submission)
+	if err != nil {
+		t.Fatalf("SubmitJob: %v", err)
+	}
+	if state := jobState(t, repo, pending.ID); state != domain.StateStopped {
+		t.Errorf("pending job = %s, want %s", state, domain.StateStopped)
+	}
+	if state := jobState(t, repo, job.ID); state != domain.StatePending {
+		t.Errorf("replacing job = %s, want %s", state, domain.StatePending)
+	}
+
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case event := <-sub.Events():
+			if event.JobID == pending.ID && event.After.State == domain.StateStopped {
+				return
+			}
+		case <-deadline:
+			t.Fatal("no event for the replaced job")
+		}
+	}
+}
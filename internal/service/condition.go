@@ -0,0 +1,127 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/expr"
+)
+
+// validateTaskConditions checks that every condition references an earlier TaskRun and
+// that its expression parses, so bad submissions are rejected instead of failing mid-job.
+func validateTaskConditions(taskRuns []domain.TaskRun) error {
+	for i, taskRun := range taskRuns {
+		if taskRun.When == nil {
+			continue
+		}
+		when := taskRun.When
+
+		if when.Task == "" && when.Expression == "" {
+			return fmt.Errorf("%w: taskRun %d has an empty condition", ErrInvalidSubmission, i)
+		}
+		if when.Task != "" {
+			if !slices.ContainsFunc(taskRuns[:i], func(prior domain.TaskRun) bool {
+				return taskRunRef(prior) == when.Task
+			}) {
+				return fmt.Errorf("%w: taskRun %d condition references unknown earlier task %q", ErrInvalidSubmission, i, when.Task)
+			}
+			if len(when.States) == 0 {
+				return fmt.Errorf("%w: taskRun %d condition on task %q requires states", ErrInvalidSubmission, i, when.Task)
+			}
+		}
+		if when.Expression != "" {
+			if _, err := expr.Parse(when.Expression); err != nil {
+				return fmt.Errorf("%w: taskRun %d condition expression: %v", ErrInvalidSubmission, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// evaluateCondition reports whether taskRuns[index] should run given the outcomes of the
+// TaskRuns dispatched before it. Callers must ensure those TaskRuns have completed.
+func evaluateCondition(job *domain.Job, taskRuns []domain.TaskRun, index int) (bool, error) {
+	when := taskRuns[index].When
+	if when == nil {
+		return true, nil
+	}
+	prior := taskRuns[:index]
+
+	if when.Task != "" {
+		// Use the latest TaskRun with the referenced name
+		var ref *domain.TaskRun
+		for i := len(prior) - 1; i >= 0; i-- {
+			if taskRunRef(prior[i]) == when.Task {
+				ref = &prior[i]
+				break
+			}
+		}
+		if ref == nil {
+			return false, fmt.Errorf("condition references unknown task %q", when.Task)
+		}
+		if !slices.Contains(when.States, ref.State) {
+			return false, nil
+		}
+	}
+
+	if when.Expression != "" {
+		env, err := conditionEnv(job, prior)
+		if err != nil {
+			return false, err
+		}
+		return expr.EvalBool(when.Expression, env)
+	}
+
+	return true, nil
+}
+
+// conditionEnv exposes job variables as vars.* and earlier outcomes as tasks.<name>.{state,result}.
+func conditionEnv(job *domain.Job, prior []domain.TaskRun) (map[string]any, error) {
+	vars, err := toJSONValue(job.Variables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert job variables: %w", err)
+	}
+
+	tasks := make(map[string]any, len(prior))
+	for _, taskRun := range prior {
+		result, err := toJSONValue(taskRun.Result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert result of task %q: %w", taskRunRef(taskRun), err)
+		}
+		tasks[taskRunRef(taskRun)] = map[string]any{
+			"state":  string(taskRun.State),
+			"result": result,
+		}
+	}
+
+	return map[string]any{
+		"vars":  vars,
+		"tasks": tasks,
+	}, nil
+}
+
+// taskRunRef is the name conditions use to refer to a TaskRun.
+func taskRunRef(taskRun domain.TaskRun) string {
+	if taskRun.Name != "" {
+		return taskRun.Name
+	}
+	return taskRun.TaskName
+}
+
+// toJSONValue round-trips a value through JSON so expressions see plain maps, slices and float64s.
+func toJSONValue(val any) (any, error) {
+	if val == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+)
+
+// conditionTaskRuns are a job's fetch task, which failed, followed by the task under test
+func conditionTaskRuns(when *domain.TaskCondition) []domain.TaskRun {
+	return []domain.TaskRun{
+		{
+			TaskName: "fetch",
+			State:    domain.StateError,
+			TaskRunDetails: domain.TaskRunDetails{
+				Result: map[string]any{"count": 4},
+			},
+		},
+		{
+			TaskName:       "report",
+			State:          domain.StatePending,
+			TaskRunDetails: domain.TaskRunDetails{When: when},
+		},
+	}
+}
+
+func TestEvaluateCondition(t *testing.T) {
+	job := &domain.Job{Variables: domain.Variables{"region": "eu-west", "threshold": 3}}
+
+	tests := []struct {
+		name string
+		when *domain.TaskCondition
+		want bool
+	}{
+		{"no condition runs", nil, true},
+		{"state matches", &domain.TaskCondition{Task: "fetch", States: []domain.ExecutionState{domain.StateError}}, true},
+		{"state does not match skips", &domain.TaskCondition{Task: "fetch", States: []domain.ExecutionState{domain.StateFinished}}, false},
+		{"expression over variables", &domain.TaskCondition{Expression: "vars.region == 'eu-west'"}, true},
+		{"false expression skips", &domain.TaskCondition{Expression: "vars.region == 'us-east'"}, false},
+		{"expression over earlier results", &domain.TaskCondition{Expression: "tasks.fetch.result.count > vars.threshold"}, true},
+		{"expression over earlier states", &domain.TaskCondition{Expression: "tasks.fetch.state == 'FINISHED'"}, false},
+		{"both parts must hold", &domain.TaskCondition{
+			Task: "fetch", States: []domain.ExecutionState{domain.StateError}, Expression: "vars.missing",
+		}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := evaluateCondition(job, conditionTaskRuns(test.when), 1)
+			if err != nil {
+				t.Fatalf("evaluateCondition: %v", err)
+			}
+			if got != test.want {
+				t.Errorf("evaluateCondition = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateConditionErrors(t *testing.T) {
+	job := &domain.Job{Variables: domain.Variables{"region": "eu-west"}}
+
+	tests := []struct {
+		name    string
+		when    *domain.TaskCondition
+		wantErr string
+	}{
+		{"unknown task", &domain.TaskCondition{Task: "missing", States: []domain.ExecutionState{domain.StateError}}, `unknown task "missing"`},
+		{"unknown identifier", &domain.TaskCondition{Expression: "region == 'eu-west'"}, `unknown identifier "region"`},
+		{"invalid expression", &domain.TaskCondition{Expression: "vars.region == 'eu"}, "unterminated string"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := evaluateCondition(job, conditionTaskRuns(test.when), 1)
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("evaluateCondition error = %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateTaskConditions(t *testing.T) {
+	tests := []struct {
+		name    string
+		when    *domain.TaskCondition
+		wantErr bool
+	}{
+		{"valid task condition", &domain.TaskCondition{Task: "fetch", States: []domain.ExecutionState{domain.StateError}}, false},
+		{"valid expression", &domain.TaskCondition{Expression: "vars.region == 'eu-west'"}, false},
+		{"empty condition", &domain.TaskCondition{}, true},
+		{"unknown earlier task", &domain.TaskCondition{Task: "missing", States: []domain.ExecutionState{domain.StateError}}, true},
+		{"task without states", &domain.TaskCondition{Task: "fetch"}, true},
+		{"expression that does not parse", &domain.TaskCondition{Expression: "vars.region =="}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateTaskConditions(conditionTaskRuns(test.when))
+			if test.wantErr != (err != nil) {
+				t.Fatalf("validateTaskConditions error = %v, want error %v", err, test.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidSubmission) {
+				t.Errorf("validateTaskConditions error = %v, want ErrInvalidSubmission", err)
+			}
+		})
+	}
+}
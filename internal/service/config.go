@@ -2,26 +2,191 @@ package service
 
 import (
 	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	JobBufferCapacity int `mapstructure:"job_buffer_capacity"`
-	JobWorkerCount    int `mapstructure:"job_worker_count"`
-	TaskWorkerCount   int `mapstructure:"task_worker_count"`
+	// Enabled runs job and task workers in this process, disable it for an API-only tier
+	Enabled bool `mapstructure:"enabled"`
+	// ID identifies this process when claiming jobs, generated when empty
+	ID                string        `mapstructure:"id"`
+	PollInterval      time.Duration `mapstructure:"poll_interval"`
+	HeartbeatInterval time.Duration `mapstructure:"heartbeat_interval"`
+	// DeadAfter is how long without a heartbeat before a worker's jobs are recovered
+	DeadAfter         time.Duration `mapstructure:"dead_after"`
+	JobBufferCapacity int           `mapstructure:"job_buffer_capacity"`
+	JobWorkerCount    int           `mapstructure:"job_worker_count"`
+	TaskWorkerCount   int           `mapstructure:"task_worker_count"`
+	// QueueNames subscribes to queues served with the global worker counts
+	QueueNames []string `mapstructure:"queue_names"`
+	// Queues subscribes to queues with their own worker counts, it takes precedence over QueueNames
+	Queues []QueueConfig `mapstructure:"queues"`
+	// Capabilities this worker provides, tasks requiring others run elsewhere
+	Capabilities []string `mapstructure:"capabilities"`
+	// UnschedulableAfter is how long a job waits for a worker providing its capabilities before it fails
+	UnschedulableAfter time.Duration `mapstructure:"unschedulable_after"`
+	// MaxBatchSubmissions limits how many jobs a batch submission may contain
+	MaxBatchSubmissions int              `mapstructure:"max_batch_submissions"`
+	Webhook             *WebhookConfig   `mapstructure:"webhook"`
+	TaskLogs            *TaskLogConfig   `mapstructure:"task_logs"`
+	Events              *EventsConfig    `mapstructure:"events"`
+	Retention           *RetentionConfig `mapstructure:"retention"`
+}
+
+// RetentionConfig is the global policy for purging ended jobs, JobConfigs can override it
+type RetentionConfig struct {
+	Enabled   bool          `mapstructure:"enabled"`
+	Interval  time.Duration `mapstructure:"interval"`
+	BatchSize int           `mapstructure:"batch_size"`
+	// MaxAge is how long jobs are kept after ending, by state, e.g. finished: 720h. States without an age are kept.
+	MaxAge map[string]time.Duration `mapstructure:"max_age"`
+	// MaxJobsPerConfig keeps at most this many ended jobs of each config, 0 keeps all
+	MaxJobsPerConfig int `mapstructure:"max_jobs_per_config"`
+	// Archive writes purged jobs to compressed files before they are deleted
+	Archive *ArchiveConfig `mapstructure:"archive"`
+}
+
+// ArchiveConfig controls archiving purged jobs to gzip compressed JSONL files, partitioned by the
+// date jobs ended, in a local directory
+type ArchiveConfig struct {
+	Enabled   bool   `mapstructure:"enabled"`
+	Directory string `mapstructure:"directory"`
+}
+
+// QueueConfig sizes the worker pool serving a queue, counts fall back to the global counts when 0
+type QueueConfig struct {
+	Name            string `mapstructure:"name"`
+	JobWorkerCount  int    `mapstructure:"job_worker_count"`
+	TaskWorkerCount int    `mapstructure:"task_worker_count"`
+}
+
+var queueNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,64}$`)
+
+type WebhookConfig struct {
+	// Secret used to sign payloads with HMAC-SHA256, required unless AllowUnsigned
+	Secret string `mapstructure:"secret"`
+	// AllowUnsigned delivers callbacks without a signature when there is no secret
+	AllowUnsigned bool          `mapstructure:"allow_unsigned"`
+	Timeout       time.Duration `mapstructure:"timeout"`
+	MaxAttempts   int           `mapstructure:"max_attempts"`
+	Backoff       time.Duration `mapstructure:"backoff"`
+	MaxBackoff    time.Duration `mapstructure:"max_backoff"`
+	PollInterval  time.Duration `mapstructure:"poll_interval"`
+	BatchSize     int           `mapstructure:"batch_size"`
+	// AllowedHosts callbacks may reach on loopback, private and link-local addresses, which are
+	// refused for every other host
+	AllowedHosts []string `mapstructure:"allowed_hosts"`
+}
+
+// TaskLogConfig controls capturing logs emitted during task execution into the repository
+type TaskLogConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Minimum level captured, independent of the logger level
+	Level           string        `mapstructure:"level"`
+	MaxMessageBytes int           `mapstructure:"max_message_bytes"`
+	MaxLogsPerTask  int           `mapstructure:"max_logs_per_task"`
+	BufferSize      int           `mapstructure:"buffer_size"`
+	BatchSize       int           `mapstructure:"batch_size"`
+	FlushInterval   time.Duration `mapstructure:"flush_interval"`
+}
+
+// EventsConfig controls relaying lifecycle events between processes sharing the repository.
+// Every process stores the events it publishes and republishes those of the others, so event
+// streams served by API instances without workers see jobs running on worker processes.
+type EventsConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	BufferSize   int           `mapstructure:"buffer_size"`
+	BatchSize    int           `mapstructure:"batch_size"`
+	// Retention is how long stored events are kept for processes to relay them
+	Retention time.Duration `mapstructure:"retention"`
 }
 
 func SetConfigDefaults(v *viper.Viper) {
+	v.SetDefault("worker.enabled", true)
+	v.SetDefault("worker.id", "")
+	v.SetDefault("worker.poll_interval", 2*time.Second)
+	v.SetDefault("worker.heartbeat_interval", 10*time.Second)
+	v.SetDefault("worker.dead_after", 60*time.Second)
 	v.SetDefault("worker.job_buffer_capacity", 128)
 	v.SetDefault("worker.job_worker_count", 2)
 	v.SetDefault("worker.task_worker_count", 4)
+	v.SetDefault("worker.queue_names", []string{domain.DefaultQueue})
+	v.SetDefault("worker.capabilities", []string{})
+	v.SetDefault("worker.unschedulable_after", 5*time.Minute)
+	v.SetDefault("worker.max_batch_submissions", 10000)
+	// --- Webhook Configuration Defaults ---
+	v.SetDefault("worker.webhook.secret", "")
+	v.SetDefault("worker.webhook.allow_unsigned", false)
+	v.SetDefault("worker.webhook.timeout", 10*time.Second)
+	v.SetDefault("worker.webhook.max_attempts", 8)
+	v.SetDefault("worker.webhook.backoff", 5*time.Second)
+	v.SetDefault("worker.webhook.max_backoff", 30*time.Minute)
+	v.SetDefault("worker.webhook.poll_interval", 5*time.Second)
+	v.SetDefault("worker.webhook.batch_size", 50)
+	v.SetDefault("worker.webhook.allowed_hosts", []string{})
+	// --- Task Log Configuration Defaults ---
+	v.SetDefault("worker.task_logs.enabled", true)
+	v.SetDefault("worker.task_logs.level", "INFO")
+	v.SetDefault("worker.task_logs.max_message_bytes", 4096)
+	v.SetDefault("worker.task_logs.max_logs_per_task", 1000)
+	v.SetDefault("worker.task_logs.buffer_size", 1024)
+	v.SetDefault("worker.task_logs.batch_size", 100)
+	v.SetDefault("worker.task_logs.flush_interval", 1*time.Second)
+	// --- Events Configuration Defaults ---
+	v.SetDefault("worker.events.enabled", true)
+	v.SetDefault("worker.events.poll_interval", 500*time.Millisecond)
+	v.SetDefault("worker.events.buffer_size", 4096)
+	v.SetDefault("worker.events.batch_size", 200)
+	v.SetDefault("worker.events.retention", 1*time.Hour)
+	// --- Retention Configuration Defaults ---
+	v.SetDefault("worker.retention.enabled", true)
+	v.SetDefault("worker.retention.interval", 1*time.Hour)
+	v.SetDefault("worker.retention.batch_size", 500)
+	v.SetDefault("worker.retention.max_age", map[string]time.Duration{})
+	v.SetDefault("worker.retention.max_jobs_per_config", 0)
+	v.SetDefault("worker.retention.archive.enabled", false)
+	v.SetDefault("worker.retention.archive.directory", "./archive")
 }
 
 func BindEnvironmentVariables(v *viper.Viper) {
+	v.BindEnv("worker.enabled", "WORKER_ENABLED")
+	v.BindEnv("worker.id", "WORKER_ID")
+	v.BindEnv("worker.poll_interval", "WORKER_POLL_INTERVAL")
+	v.BindEnv("worker.heartbeat_interval", "WORKER_HEARTBEAT_INTERVAL")
+	v.BindEnv("worker.dead_after", "WORKER_DEAD_AFTER")
 	v.BindEnv("worker.job_buffer_capacity", "JOB_BUFFER_CAPACITY")
 	v.BindEnv("worker.job_worker_count", "JOB_WORKER_COUNT")
 	v.BindEnv("worker.task_worker_count", "TASK_WORKER_COUNT")
+	v.BindEnv("worker.queue_names", "WORKER_QUEUES")
+	v.BindEnv("worker.capabilities", "WORKER_CAPABILITIES")
+	v.BindEnv("worker.unschedulable_after", "WORKER_UNSCHEDULABLE_AFTER")
+	v.BindEnv("worker.max_batch_submissions", "MAX_BATCH_SUBMISSIONS")
+	// Webhook Config
+	v.BindEnv("worker.webhook.secret", "WEBHOOK_SECRET")
+	v.BindEnv("worker.webhook.allow_unsigned", "WEBHOOK_ALLOW_UNSIGNED")
+	v.BindEnv("worker.webhook.timeout", "WEBHOOK_TIMEOUT")
+	v.BindEnv("worker.webhook.max_attempts", "WEBHOOK_MAX_ATTEMPTS")
+	v.BindEnv("worker.webhook.allowed_hosts", "WEBHOOK_ALLOWED_HOSTS")
+	// Task Log Config
+	v.BindEnv("worker.task_logs.enabled", "TASK_LOGS_ENABLED")
+	v.BindEnv("worker.task_logs.level", "TASK_LOGS_LEVEL")
+	// Events Config
+	v.BindEnv("worker.events.enabled", "EVENTS_ENABLED")
+	v.BindEnv("worker.events.poll_interval", "EVENTS_POLL_INTERVAL")
+	// Retention Config
+	v.BindEnv("worker.retention.enabled", "RETENTION_ENABLED")
+	v.BindEnv("worker.retention.max_jobs_per_config", "RETENTION_MAX_JOBS_PER_CONFIG")
+	v.BindEnv("worker.retention.archive.enabled", "RETENTION_ARCHIVE_ENABLED")
+	v.BindEnv("worker.retention.archive.directory", "RETENTION_ARCHIVE_DIRECTORY")
 }
 
 func (config *Config) Validate() error {
@@ -34,5 +199,214 @@ func (config *Config) Validate() error {
 	if config.TaskWorkerCount < 1 {
 		return fmt.Errorf("task worker count must be at least 1")
 	}
+	if config.PollInterval <= 0 || config.HeartbeatInterval <= 0 {
+		return fmt.Errorf("worker poll and heartbeat intervals must be positive")
+	}
+	// Allow a missed heartbeat before a worker is declared dead
+	if config.DeadAfter < 2*config.HeartbeatInterval {
+		return fmt.Errorf("worker dead after must be at least twice the heartbeat interval")
+	}
+	if config.UnschedulableAfter <= 0 {
+		return fmt.Errorf("worker unschedulable after must be positive")
+	}
+	if config.MaxBatchSubmissions < 1 {
+		return fmt.Errorf("max batch submissions must be at least 1")
+	}
+	for _, capability := range config.Capabilities {
+		if capability == "" {
+			return fmt.Errorf("worker capabilities cannot be empty")
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, queue := range config.SubscribedQueues() {
+		if err := validateQueueName(queue.Name); err != nil {
+			return err
+		}
+		if seen[queue.Name] {
+			return fmt.Errorf("queue %s is configured more than once", queue.Name)
+		}
+		seen[queue.Name] = true
+
+		if queue.JobWorkerCount < 1 || queue.TaskWorkerCount < 1 {
+			return fmt.Errorf("queue %s worker counts must be at least 1", queue.Name)
+		}
+	}
+
+	if err := config.Webhook.Validate(); err != nil {
+		return err
+	}
+	if err := config.TaskLogs.Validate(); err != nil {
+		return err
+	}
+	if err := config.Events.Validate(); err != nil {
+		return err
+	}
+	if err := config.Retention.Validate(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SubscribedQueues returns the queues this process serves, with worker counts resolved
+func (config *Config) SubscribedQueues() []QueueConfig {
+	var queues []QueueConfig
+	if len(config.Queues) > 0 {
+		queues = append(queues, config.Queues...)
+	} else {
+		for _, name := range config.QueueNames {
+			queues = append(queues, QueueConfig{Name: name})
+		}
+	}
+	if len(queues) == 0 {
+		queues = append(queues, QueueConfig{Name: domain.DefaultQueue})
+	}
+
+	for i := range queues {
+		if queues[i].JobWorkerCount == 0 {
+			queues[i].JobWorkerCount = config.JobWorkerCount
+		}
+		if queues[i].TaskWorkerCount == 0 {
+			queues[i].TaskWorkerCount = config.TaskWorkerCount
+		}
+	}
+	return queues
+}
+
+func validateQueueName(name string) error {
+	if !queueNamePattern.MatchString(name) {
+		return fmt.Errorf("queue name %q must be 1 to 64 characters of a-z, 0-9, _ and -", name)
+	}
+	return nil
+}
+
+func (config *WebhookConfig) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Bool("signed", config.Secret != ""),
+		slog.String("timeout", config.Timeout.String()),
+		slog.Int("max_attempts", config.MaxAttempts),
+		slog.Any("allowed_hosts", config.AllowedHosts),
+	)
+}
+
+func (config *WebhookConfig) Validate() error {
+	if config.Secret == "" && !config.AllowUnsigned {
+		return fmt.Errorf("webhook secret is required, set WEBHOOK_SECRET or allow unsigned callbacks with allow_unsigned")
+	}
+	if config.Timeout <= 0 || config.Backoff <= 0 ||
+		config.MaxBackoff <= 0 || config.PollInterval <= 0 {
+
+		return fmt.Errorf("webhook timeouts and intervals must be positive")
+	}
+	if config.MaxAttempts < 1 {
+		return fmt.Errorf("webhook max attempts must be at least 1")
+	}
+	if config.BatchSize < 1 {
+		return fmt.Errorf("webhook batch size must be at least 1")
+	}
+	return nil
+}
+
+func (config *TaskLogConfig) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Bool("enabled", config.Enabled),
+		slog.String("level", config.Level),
+		slog.Int("max_logs_per_task", config.MaxLogsPerTask),
+	)
+}
+
+// SlogLevel returns the parsed minimum level, Validate ensures it parses
+func (config *TaskLogConfig) SlogLevel() slog.Level {
+	var level slog.Level
+	level.UnmarshalText([]byte(config.Level))
+	return level
+}
+
+func (config *TaskLogConfig) Validate() error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(config.Level)); err != nil {
+		return fmt.Errorf("invalid task log level: %s", config.Level)
+	}
+	if config.MaxMessageBytes < 1 || config.MaxLogsPerTask < 1 {
+		return fmt.Errorf("task log limits must be greater than 0")
+	}
+	if config.BufferSize < 1 || config.BatchSize < 1 {
+		return fmt.Errorf("task log buffer and batch sizes must be greater than 0")
+	}
+	if config.FlushInterval <= 0 {
+		return fmt.Errorf("task log flush interval must be positive")
+	}
+	return nil
+}
+
+func (config *EventsConfig) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Bool("enabled", config.Enabled),
+		slog.String("poll_interval", config.PollInterval.String()),
+		slog.String("retention", config.Retention.String()),
+	)
+}
+
+func (config *EventsConfig) Validate() error {
+	if config.PollInterval <= 0 || config.Retention <= 0 {
+		return fmt.Errorf("events poll interval and retention must be positive")
+	}
+	if config.BufferSize < 1 || config.BatchSize < 1 {
+		return fmt.Errorf("events buffer and batch sizes must be greater than 0")
+	}
+	return nil
+}
+
+func (config *RetentionConfig) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Bool("enabled", config.Enabled),
+		slog.Any("max_age", config.MaxAge),
+		slog.Int("max_jobs_per_config", config.MaxJobsPerConfig),
+		slog.Any("archive", config.Archive),
+	)
+}
+
+func (config *ArchiveConfig) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Bool("enabled", config.Enabled),
+		slog.String("directory", config.Directory),
+	)
+}
+
+// ArchiveEnabled reports whether purged jobs are archived before deletion
+func (config *RetentionConfig) ArchiveEnabled() bool {
+	return config.Archive != nil && config.Archive.Enabled
+}
+
+// MaxAgeByState returns MaxAge keyed by state, config keys are lower cased when loaded
+func (config *RetentionConfig) MaxAgeByState() map[domain.ExecutionState]time.Duration {
+	maxAge := make(map[domain.ExecutionState]time.Duration, len(config.MaxAge))
+	for state, age := range config.MaxAge {
+		maxAge[domain.ExecutionState(strings.ToUpper(state))] = age
+	}
+	return maxAge
+}
+
+func (config *RetentionConfig) Validate() error {
+	if config.Interval <= 0 {
+		return fmt.Errorf("retention interval must be positive")
+	}
+	if config.BatchSize < 1 {
+		return fmt.Errorf("retention batch size must be at least 1")
+	}
+	if config.MaxJobsPerConfig < 0 {
+		return fmt.Errorf("retention max jobs per config cannot be negative")
+	}
+	for state, age := range config.MaxAgeByState() {
+		if !slices.Contains(domain.EndedStates, state) {
+			return fmt.Errorf("retention max age has unknown ended state %s", state)
+		}
+		if age <= 0 {
+			return fmt.Errorf("retention max age of %s must be positive", state)
+		}
+	}
+	if config.ArchiveEnabled() && config.Archive.Directory == "" {
+		return fmt.Errorf("retention archive directory is required when archiving is enabled")
+	}
 	return nil
 }
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/spf13/viper"
+)
+
+// newTestConfig returns the default worker config, which tests break one field at a time
+func newTestConfig(t *testing.T) *Config {
+	t.Helper()
+
+	v := viper.New()
+	SetConfigDefaults(v)
+
+	var config Config
+	if err := v.UnmarshalKey("worker", &config); err != nil {
+		t.Fatalf("unmarshal worker config: %v", err)
+	}
+	// The webhook secret has no default, it is set via WEBHOOK_SECRET
+	config.Webhook.Secret = "secret"
+	if err := config.Validate(); err != nil {
+		t.Fatalf("default config is invalid: %v", err)
+	}
+	return &config
+}
+
+func TestSubscribedQueues(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   []QueueConfig
+	}{
+		{
+			name:   "default queue",
+			config: Config{JobWorkerCount: 2, TaskWorkerCount: 4},
+			want:   []QueueConfig{{Name: domain.DefaultQueue, JobWorkerCount: 2, TaskWorkerCount: 4}},
+		},
+		{
+			name:   "queue names use the global counts",
+			config: Config{JobWorkerCount: 2, TaskWorkerCount: 4, QueueNames: []string{"default", "reports"}},
+			want: []QueueConfig{
+				{Name: "default", JobWorkerCount: 2, TaskWorkerCount: 4},
+				{Name: "reports", JobWorkerCount: 2, TaskWorkerCount: 4},
+			},
+		},
+		{
+			name: "queues take precedence and fall back to the global counts",
+			config: Config{
+				JobWorkerCount:  2,
+				TaskWorkerCount: 4,
+				QueueNames:      []string{"default"},
+				Queues:          []QueueConfig{{Name: "reports", JobWorkerCount: 1}, {Name: "emails", TaskWorkerCount: 8}},
+			},
+			want: []QueueConfig{
+				{Name: "reports", JobWorkerCount: 1, TaskWorkerCount: 4},
+				{Name: "emails", JobWorkerCount: 2, TaskWorkerCount: 8},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.config.SubscribedQueues(); !slices.Equal(got, test.want) {
+				t.Errorf("SubscribedQueues() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestValidateQueues(t *testing.T) {
+	tests := []struct {
+		name    string
+		queues  []QueueConfig
+		wantErr bool
+	}{
+		{"valid", []QueueConfig{{Name: "default"}, {Name: "reports_v2-eu"}}, false},
+		{"uppercase name", []QueueConfig{{Name: "Reports"}}, true},
+		{"empty name", []QueueConfig{{Name: ""}}, true},
+		{"duplicate", []QueueConfig{{Name: "reports"}, {Name: "reports"}}, true},
+		{"negative count", []QueueConfig{{Name: "reports", JobWorkerCount: -1}}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := newTestConfig(t)
+			config.Queues = test.queues
+
+			if err := config.Validate(); (err != nil) != test.wantErr {
+				t.Errorf("Validate() = %v, want error %t", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateWebhookSecret(t *testing.T) {
+	tests := []struct {
+		name          string
+		secret        string
+		allowUnsigned bool
+		wantErr       bool
+	}{
+		{"secret", "secret", false, false},
+		{"no secret", "", false, true},
+		{"unsigned allowed", "", true, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := newTestConfig(t)
+			config.Webhook.Secret = test.secret
+			config.Webhook.AllowUnsigned = test.allowUnsigned
+
+			if err := config.Validate(); (err != nil) != test.wantErr {
+				t.Errorf("Validate() = %v, want error %t", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetentionConfigMaxAgeByState(t *testing.T) {
+	config := RetentionConfig{MaxAge: map[string]time.Duration{"finished": time.Hour, "error": 2 * time.Hour}}
+
+	want := map[domain.ExecutionState]time.Duration{domain.StateFinished: time.Hour, domain.StateError: 2 * time.Hour}
+	if got := config.MaxAgeByState(); !maps.Equal(got, want) {
+		t.Errorf("MaxAgeByState() = %v, want %v", got, want)
+	}
+}
+
+func TestValidateRetention(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(config *RetentionConfig)
+		wantErr bool
+	}{
+		{"default", func(config *RetentionConfig) {}, false},
+		{"max age", func(config *RetentionConfig) { config.MaxAge = map[string]time.Duration{"expired": time.Hour} }, false},
+		{"state that does not end", func(config *RetentionConfig) { config.MaxAge = map[string]time.Duration{"running": time.Hour} }, true},
+		{"zero age", func(config *RetentionConfig) { config.MaxAge = map[string]time.Duration{"finished": 0} }, true},
+		{"zero batch size", func(config *RetentionConfig) { config.BatchSize = 0 }, true},
+		{"negative max jobs", func(config *RetentionConfig) { config.MaxJobsPerConfig = -1 }, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := newTestConfig(t)
+			test.modify(config.Retention)
+
+			if err := config.Validate(); (err != nil) != test.wantErr {
+				t.Errorf("Validate() = %v, want error %t", err, test.wantErr)
+			}
+		})
+	}
+}
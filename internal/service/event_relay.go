@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+)
+
+// eventGapTimeout is how long the relay waits for a missing sequence number, which may belong
+// to a transaction that has not committed yet, before skipping it
+const eventGapTimeout = 5 * time.Second
+
+// eventPruneInterval is how often the leader deletes events past their retention
+const eventPruneInterval = time.Minute
+
+// eventRelay bridges the EventBus of processes sharing the repository. Events published by this
+// process are stored in batches, and those stored by other processes are republished locally, so
+// streams see every job whichever process runs it. Events are dropped rather than blocking
+// workers when the buffer is full.
+type eventRelay struct {
+	*jobServiceDependencies
+	// origin identifies this process's events, which are not republished
+	origin  string
+	ch      chan Event
+	dropped atomic.Uint64
+}
+
+func newEventRelay(deps *jobServiceDependencies, origin string) *eventRelay {
+	relay := &eventRelay{
+		jobServiceDependencies: deps,
+		origin:                 origin,
+		ch:                     make(chan Event, deps.config.Events.BufferSize),
+	}
+	deps.events.Observe(relay.enqueue)
+	return relay
+}
+
+func (relay *eventRelay) enqueue(event Event) {
+	select {
+	case relay.ch <- event:
+	default:
+		relay.dropped.Add(1)
+	}
+}
+
+// Run stores and republishes events until ctx is done, then stores what is buffered
+func (relay *eventRelay) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		relay.republishLoop(ctx)
+	}()
+
+	relay.storeLoop(ctx)
+	wg.Wait()
+}
+
+func (relay *eventRelay) storeLoop(ctx context.Context) {
+	config := relay.config.Events
+	ticker := time.NewTicker(config.PollInterval)
+	defer ticker.Stop()
+
+	batch := make([]domain.EventRecord, 0, config.BatchSize)
+	add := func(event Event) {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to marshal event", slog.String("type", string(event.Type)), slog.Any("error", err))
+			return
+		}
+		batch = append(batch, domain.EventRecord{Origin: relay.origin, Payload: payload, CreatedDate: event.Time})
+	}
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+		if err := relay.repository.SaveEvents(ctx, batch); err != nil {
+			slog.ErrorContext(ctx, "failed to save events", slog.Int("count", len(batch)), slog.Any("error", err))
+		}
+		batch = batch[:0]
+
+		if dropped := relay.dropped.Swap(0); dropped > 0 {
+			slog.WarnContext(ctx, "events not relayed, buffer full", slog.Uint64("dropped", dropped))
+		}
+	}
+
+	for {
+		select {
+		case event := <-relay.ch:
+			add(event)
+			if len(batch) >= config.BatchSize {
+				flush(ctx)
+			}
+
+		case <-ticker.C:
+			flush(ctx)
+
+		case <-ctx.Done():
+			// Store what was published while shutting down
+			flushCtx := context.WithoutCancel(ctx)
+			for {
+				select {
+				case event := <-relay.ch:
+					add(event)
+					if len(batch) >= config.BatchSize {
+						flush(flushCtx)
+					}
+				default:
+					flush(flushCtx)
+					return
+				}
+			}
+		}
+	}
+}
+
+// republishLoop publishes the events other processes stored after this one started. Sequence
+// numbers are followed without gaps, so an event committed out of order is not skipped, unless
+// its number stays missing for eventGapTimeout as it does after a rollback.
+func (relay *eventRelay) republishLoop(ctx context.Context) {
+	config := relay.config.Events
+	ticker := time.NewTicker(config.PollInterval)
+	defer ticker.Stop()
+
+	// Streams resynchronize from the repository when they connect, earlier events are not replayed
+	afterSeq, err := relay.repository.GetLastEventSeq(ctx)
+	for err != nil {
+		slog.ErrorContext(ctx, "failed to get last event seq", slog.Any("error", err))
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		afterSeq, err = relay.repository.GetLastEventSeq(ctx)
+	}
+
+	var gapSince time.Time
+	for {
+		var caughtUp bool
+		afterSeq, gapSince, caughtUp = relay.republish(ctx, afterSeq, gapSince)
+		if caughtUp {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		} else if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// republish publishes the next batch of stored events after afterSeq and returns the sequence
+// number to continue from, when the gap it is waiting on appeared, and whether it caught up
+func (relay *eventRelay) republish(ctx context.Context, afterSeq int64, gapSince time.Time) (int64, time.Time, bool) {
+	limit := relay.config.Events.BatchSize
+	records, err := relay.repository.GetEvents(ctx, afterSeq, limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get events", slog.Any("error", err))
+		return afterSeq, gapSince, true
+	}
+
+	now := time.Now()
+	for _, record := range records {
+		if record.Seq != afterSeq+1 {
+			if gapSince.IsZero() {
+				gapSince = now
+			}
+			if now.Sub(gapSince) < eventGapTimeout {
+				return afterSeq, gapSince, true
+			}
+		}
+		afterSeq = record.Seq
+		gapSince = time.Time{}
+
+		if record.Origin == relay.origin {
+			continue
+		}
+
+		var event Event
+		if err := json.Unmarshal(record.Payload, &event); err != nil {
+			slog.ErrorContext(ctx, "failed to unmarshal event", slog.Int64("seq", record.Seq), slog.Any("error", err))
+			continue
+		}
+		// The local bus numbers events for its own subscribers
+		event.ID = 0
+		event.relayed = true
+		relay.events.Publish(event)
+	}
+	return afterSeq, gapSince, len(records) < limit
+}
+
+// pruneEvents is a leader duty, deleting stored events past their retention until ctx is done
+func (relay *eventRelay) pruneEvents(ctx context.Context) {
+	ticker := time.NewTicker(eventPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			before := time.Now().UTC().Add(-relay.config.Events.Retention)
+			if _, err := relay.repository.DeleteEvents(ctx, before); err != nil {
+				slog.ErrorContext(ctx, "failed to prune events", slog.Any("error", err))
+			}
+		}
+	}
+}
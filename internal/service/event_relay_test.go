@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/mock"
+	"github.com/google/uuid"
+)
+
+func receiveEvent(t *testing.T, sub *Subscription) Event {
+	t.Helper()
+
+	select {
+	case event := <-sub.Events():
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event received")
+		return Event{}
+	}
+}
+
+// Events published by a worker process reach the streams of an API process without workers
+func TestEventRelayRepublishesOtherProcessEvents(t *testing.T) {
+	repo := mock.NewMockRepo()
+	worker := newEventRelay(newTestDeps(t, repo), "worker")
+	api := newEventRelay(newTestDeps(t, repo), "api")
+
+	workerSub := worker.events.Subscribe(16, nil)
+	apiSub := api.events.Subscribe(16, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go worker.Run(ctx)
+	go api.Run(ctx)
+
+	// Let the relays find the last stored event before publishing
+	time.Sleep(50 * time.Millisecond)
+
+	job := &domain.Job{Identity: domain.Identity{ID: uuid.New()}, Status: domain.Status{State: domain.StateFinished, Progress: 1}}
+	published := worker.events.Publish(newJobEvent(domain.Status{State: domain.StateRunning}, job))
+	receiveEvent(t, workerSub)
+
+	relayed := receiveEvent(t, apiSub)
+	if relayed.Type != EventJobFinished || relayed.JobID != job.ID || !relayed.relayed {
+		t.Errorf("relayed event = %s of job %s, relayed %v, want %s of job %s", relayed.Type, relayed.JobID, relayed.relayed, EventJobFinished, job.ID)
+	}
+	if relayed.After != job.Status || !relayed.Time.Equal(published.Time) {
+		t.Errorf("relayed event status %+v at %s, want %+v at %s", relayed.After, relayed.Time, job.Status, published.Time)
+	}
+
+	// Neither process republishes its own events, nor stores those it relayed
+	select {
+	case event := <-workerSub.Events():
+		t.Errorf("worker received its own event again: %s", event.Type)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if seq, _ := repo.GetLastEventSeq(context.Background()); seq != 1 {
+		t.Errorf("last event seq = %d, want 1", seq)
+	}
+}
+
+// A missing sequence number may still be committing, the relay waits for it before skipping it
+func TestEventRelayWaitsForGaps(t *testing.T) {
+	repo := mock.NewMockRepo()
+	relay := newEventRelay(newTestDeps(t, repo), "api")
+	sub := relay.events.Subscribe(16, nil)
+
+	now := time.Now().UTC()
+	for i, createdDate := range []time.Time{now, now, now.Add(-2 * time.Hour), now} {
+		payload, _ := json.Marshal(Event{Type: EventJobStarted, JobID: uuid.New(), Time: now.Add(time.Duration(i))})
+		repo.SaveEvents(context.Background(), []domain.EventRecord{{Origin: "worker", Payload: payload, CreatedDate: createdDate}})
+	}
+	// Leave seq 3 missing
+	repo.DeleteEvents(context.Background(), now.Add(-time.Hour))
+
+	ctx := context.Background()
+	afterSeq, gapSince, caughtUp := relay.republish(ctx, 0, time.Time{})
+	if afterSeq != 2 || gapSince.IsZero() || !caughtUp {
+		t.Fatalf("republish = seq %d, gap since %v, caught up %v, want seq 2 waiting on the gap", afterSeq, gapSince, caughtUp)
+	}
+	receiveEvent(t, sub)
+	receiveEvent(t, sub)
+
+	afterSeq, gapSince, _ = relay.republish(ctx, afterSeq, gapSince)
+	if afterSeq != 2 {
+		t.Fatalf("republish within the gap timeout = seq %d, want 2", afterSeq)
+	}
+
+	afterSeq, gapSince, _ = relay.republish(ctx, afterSeq, gapSince.Add(-eventGapTimeout))
+	if afterSeq != 4 || !gapSince.IsZero() {
+		t.Fatalf("republish after the gap timeout = seq %d, gap since %v, want seq 4", afterSeq, gapSince)
+	}
+	receiveEvent(t, sub)
+}
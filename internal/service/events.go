@@ -0,0 +1,276 @@
+package service
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/google/uuid"
+)
+
+type EventType string
+
+const (
+	EventJobSubmitted EventType = "job.submitted"
+	EventJobStarted   EventType = "job.started"
+	EventJobFinished  EventType = "job.finished"
+	EventJobFailed    EventType = "job.failed"
+	EventJobCancelled EventType = "job.cancelled"
+	EventJobExpired   EventType = "job.expired"
+	EventTaskStarted  EventType = "task.started"
+	EventTaskProgress EventType = "task.progress"
+	EventTaskFinished EventType = "task.finished"
+)
+
+// Event describes a job or task lifecycle change, with the status before and after it.
+type Event struct {
+	ID        uint64          `json:"id"`
+	Type      EventType       `json:"type"`
+	Time      time.Time       `json:"time"`
+	JobID     uuid.UUID       `json:"jobId"`
+	TaskRunID *uuid.UUID      `json:"taskRunId,omitempty"`
+	Tags      []string        `json:"tags,omitempty"`
+	Before    domain.Status   `json:"before"`
+	After     domain.Status   `json:"after"`
+	Job       *domain.Job     `json:"job,omitempty"`
+	TaskRun   *domain.TaskRun `json:"taskRun,omitempty"`
+	// relayed events were published by another process and republished by the eventRelay
+	relayed bool
+}
+
+// IsJobEvent reports whether the event describes a job rather than a task
+func (event Event) IsJobEvent() bool {
+	return event.TaskRunID == nil
+}
+
+// EventFilter selects which events a subscriber receives. A nil filter receives everything.
+type EventFilter func(event Event) bool
+
+// eventHistorySize is how many recent events the bus retains for resuming subscribers
+const eventHistorySize = 1024
+
+// EventBus is an in-process pub/sub for lifecycle events. Delivery is buffered and
+// non-blocking: a subscriber that falls behind has events dropped rather than stalling workers.
+// Recent events are retained so subscribers can resume after reconnecting.
+type EventBus struct {
+	mu          sync.RWMutex
+	nextID      uint64
+	subscribers map[*Subscription]struct{}
+	observers   []func(event Event)
+	history     []Event
+	closed      bool
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{
+		subscribers: make(map[*Subscription]struct{}),
+		history:     make([]Event, 0, eventHistorySize),
+	}
+}
+
+// Subscribe registers a subscriber with the given buffer size.
+func (bus *EventBus) Subscribe(buffer int, filter EventFilter) *Subscription {
+	sub := bus.newSubscription(buffer, filter)
+
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	bus.register(sub)
+	return sub
+}
+
+// Observe registers a function that Publish calls with every event, before delivering it. Unlike
+// subscribers observers never miss an event, so they must return quickly. Relayed events are
+// not observed, the process that published them already did.
+func (bus *EventBus) Observe(observer func(event Event)) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	bus.observers = append(bus.observers, observer)
+}
+
+// Resume subscribes like Subscribe and also returns the retained events after lastID that
+// match the filter, so no event is missed between the two. complete is false when events
+// after lastID are no longer retained (or lastID is unknown to this bus), in which case
+// callers should resynchronize from the repository.
+func (bus *EventBus) Resume(buffer int, filter EventFilter, lastID uint64) (sub *Subscription, missed []Event, complete bool) {
+	sub = bus.newSubscription(buffer, filter)
+
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	complete = lastID <= bus.nextID
+	if len(bus.history) > 0 && lastID+1 < bus.history[0].ID {
+		complete = false
+	}
+
+	for _, event := range bus.history {
+		if event.ID <= lastID || (filter != nil && !filter(event)) {
+			continue
+		}
+		missed = append(missed, event)
+	}
+
+	bus.register(sub)
+	return sub, missed, complete
+}
+
+func (bus *EventBus) newSubscription(buffer int, filter EventFilter) *Subscription {
+	if buffer < 1 {
+		buffer = 1
+	}
+	return &Subscription{
+		ch:     make(chan Event, buffer),
+		filter: filter,
+		bus:    bus,
+	}
+}
+
+// register must be called with mu held
+func (bus *EventBus) register(sub *Subscription) {
+	if bus.closed {
+		close(sub.ch)
+		return
+	}
+	bus.subscribers[sub] = struct{}{}
+}
+
+// Publish assigns the event an ID and timestamp and delivers it to all matching subscribers.
+func (bus *EventBus) Publish(event Event) Event {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	if bus.closed {
+		return event
+	}
+
+	bus.nextID++
+	event.ID = bus.nextID
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	if len(bus.history) == eventHistorySize {
+		copy(bus.history, bus.history[1:])
+		bus.history = bus.history[:eventHistorySize-1]
+	}
+	bus.history = append(bus.history, event)
+
+	if !event.relayed {
+		for _, observer := range bus.observers {
+			observer(event)
+		}
+	}
+
+	for sub := range bus.subscribers {
+		if sub.filter != nil && !sub.filter(event) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+	return event
+}
+
+// Close closes all subscriptions. Further publishes are ignored.
+func (bus *EventBus) Close() {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	if bus.closed {
+		return
+	}
+	bus.closed = true
+	for sub := range bus.subscribers {
+		close(sub.ch)
+		delete(bus.subscribers, sub)
+	}
+}
+
+func (bus *EventBus) unsubscribe(sub *Subscription) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	if _, ok := bus.subscribers[sub]; ok {
+		delete(bus.subscribers, sub)
+		close(sub.ch)
+	}
+}
+
+type Subscription struct {
+	ch      chan Event
+	filter  EventFilter
+	bus     *EventBus
+	dropped atomic.Uint64
+}
+
+// Events returns the channel events are delivered on. It is closed when the
+// subscription or bus is closed.
+func (sub *Subscription) Events() <-chan Event {
+	return sub.ch
+}
+
+// Dropped returns the number of events dropped because the buffer was full.
+func (sub *Subscription) Dropped() uint64 {
+	return sub.dropped.Load()
+}
+
+func (sub *Subscription) Close() {
+	sub.bus.unsubscribe(sub)
+}
+
+// --- Event constructors ---
+
+func newJobEvent(before domain.Status, job *domain.Job) Event {
+	jobCopy := *job
+	return Event{
+		Type:   jobEventType(job.State),
+		JobID:  job.ID,
+		Tags:   job.Tags,
+		Before: before,
+		After:  job.Status,
+		Job:    &jobCopy,
+	}
+}
+
+// newTaskEvent takes the tags of the TaskRun's job, so subscribers can filter task events by tag
+func newTaskEvent(eventType EventType, before domain.Status, taskRun *domain.TaskRun, tags []string) Event {
+	taskRunCopy := *taskRun
+	return Event{
+		Type:      eventType,
+		JobID:     taskRun.JobID,
+		TaskRunID: &taskRunCopy.ID,
+		Tags:      tags,
+		Before:    before,
+		After:     taskRun.Status(),
+		TaskRun:   &taskRunCopy,
+	}
+}
+
+func jobEventType(state domain.ExecutionState) EventType {
+	switch state {
+	case domain.StatePending:
+		return EventJobSubmitted
+	case domain.StateRunning:
+		return EventJobStarted
+	case domain.StateError:
+		return EventJobFailed
+	case domain.StateStopped:
+		return EventJobCancelled
+	case domain.StateExpired:
+		return EventJobExpired
+	default:
+		return EventJobFinished
+	}
+}
+
+func taskEventType(state domain.ExecutionState) EventType {
+	if state == domain.StateRunning {
+		return EventTaskStarted
+	}
+	return EventTaskFinished
+}
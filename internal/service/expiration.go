@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+)
+
+// expireJobs is a leader duty, expiring jobs that were not claimed before their deadline until
+// ctx is done. Workers expire the jobs they claim too late themselves.
+func (service *JobService) expireJobs(ctx context.Context) {
+	ticker := time.NewTicker(service.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			service.expirePendingJobs(ctx)
+		}
+	}
+}
+
+func (service *JobService) expirePendingJobs(ctx context.Context) {
+	jobIDs, err := service.repository.ExpireJobs(ctx, time.Now().UTC())
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to expire jobs", slog.Any("error", err))
+		return
+	}
+
+	for _, jobID := range jobIDs {
+		ctx := context.WithValue(ctx, domain.LKeys.JobID, jobID)
+		slog.InfoContext(ctx, "job "+string(domain.StateExpired))
+
+		job, err := service.repository.GetJob(ctx, jobID)
+		if err != nil || job == nil {
+			slog.WarnContext(ctx, "failed to get expired job", slog.Any("error", err))
+			continue
+		}
+
+		taskRuns, err := service.repository.GetTaskRuns(ctx, jobID)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to get expired taskRuns", slog.Any("error", err))
+		}
+		for i := range taskRuns {
+			if taskRuns[i].State == domain.StateExpired {
+				service.events.Publish(newTaskEvent(EventTaskFinished, domain.Status{State: domain.StatePending}, &taskRuns[i], job.Tags))
+			}
+		}
+		service.events.Publish(newJobEvent(domain.Status{State: domain.StatePending}, job))
+	}
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/mock"
+	"github.com/abikandiah/task-worker/internal/util"
+)
+
+// receiveEventTypes returns the types of the next n events of the subscription
+func receiveEventTypes(t *testing.T, sub *Subscription, n int) []EventType {
+	t.Helper()
+
+	var types []EventType
+	for range n {
+		select {
+		case event := <-sub.Events():
+			types = append(types, event.Type)
+		case <-time.After(time.Second):
+			t.Fatalf("received events %v, want %d", types, n)
+		}
+	}
+	return types
+}
+
+func saveExpiringJob(t *testing.T, repo *mock.MockRepo, expiresAt time.Time) (*domain.Job, *domain.TaskRun) {
+	t.Helper()
+	ctx := context.Background()
+
+	job, err := repo.SaveJob(ctx, domain.Job{
+		Status:     domain.Status{State: domain.StatePending},
+		SubmitDate: expiresAt.Add(-time.Minute),
+		ExpiresAt:  &expiresAt,
+	})
+	if err != nil {
+		t.Fatalf("SaveJob: %v", err)
+	}
+	taskRun, err := repo.SaveTaskRun(ctx, domain.TaskRun{JobID: job.ID, TaskName: "noop", State: domain.StatePending})
+	if err != nil {
+		t.Fatalf("SaveTaskRun: %v", err)
+	}
+	return job, taskRun
+}
+
+func TestExpirePendingJobs(t *testing.T) {
+	repo := mock.NewMockRepo()
+	deps := newTestDeps(t, repo)
+	service := &JobService{jobServiceDependencies: deps}
+	ctx := context.Background()
+
+	expired, _ := saveExpiringJob(t, repo, time.Now().UTC().Add(-time.Second))
+	future, _ := saveExpiringJob(t, repo, time.Now().UTC().Add(time.Hour))
+
+	sub := deps.events.Subscribe(16, nil)
+	defer sub.Close()
+	service.expirePendingJobs(ctx)
+
+	if job, _ := repo.GetJob(ctx, expired.ID); job.State != domain.StateExpired {
+		t.Errorf("job past its deadline = %s, want %s", job.State, domain.StateExpired)
+	}
+	if job, _ := repo.GetJob(ctx, future.ID); job.State != domain.StatePending {
+		t.Errorf("job before its deadline = %s, want %s", job.State, domain.StatePending)
+	}
+	if types := receiveEventTypes(t, sub, 2); !slices.Equal(types, []EventType{EventTaskFinished, EventJobExpired}) {
+		t.Errorf("events = %v, want the task then the job expiring", types)
+	}
+}
+
+// A job claimed after its deadline expires instead of running, unless it already started
+func TestHandleJobExpiresLateClaims(t *testing.T) {
+	repo := mock.NewMockRepo()
+	deps := newTestDeps(t, repo)
+	worker := &JobWorker{jobServiceDependencies: deps}
+	ctx := context.Background()
+
+	job, taskRun := saveExpiringJob(t, repo, time.Now().UTC().Add(-time.Second))
+
+	sub := deps.events.Subscribe(16, nil)
+	defer sub.Close()
+	worker.handleJob(ctx, job.ID)
+
+	expired, _ := repo.GetJob(ctx, job.ID)
+	if expired.State != domain.StateExpired || expired.EndDate == nil {
+		t.Errorf("job = %s ended at %v, want %s", expired.State, expired.EndDate, domain.StateExpired)
+	}
+	if expiredTaskRun, _ := repo.GetTaskRun(ctx, taskRun.ID); expiredTaskRun.State != domain.StateExpired {
+		t.Errorf("task run = %s, want %s", expiredTaskRun.State, domain.StateExpired)
+	}
+	if types := receiveEventTypes(t, sub, 2); !slices.Equal(types, []EventType{EventTaskFinished, EventJobExpired}) {
+		t.Errorf("events = %v, want the task then the job expiring", types)
+	}
+
+	// A recovered job that already started is not expired, it runs again
+	started, _ := saveExpiringJob(t, repo, time.Now().UTC().Add(-time.Second))
+	started.StartDate = util.TimePtr(time.Now().UTC())
+	if _, err := repo.SaveJob(ctx, *started); err != nil {
+		t.Fatalf("SaveJob: %v", err)
+	}
+	worker.handleJob(ctx, started.ID)
+	if job, _ := repo.GetJob(ctx, started.ID); job.State == domain.StateExpired {
+		t.Error("a job that already started expired")
+	}
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/factory"
+	"github.com/abikandiah/task-worker/internal/mock"
+)
+
+// newTestDeps returns the dependencies of a JobService backed by repo, with short intervals.
+// Tests adjust the config and register the tasks they run.
+func newTestDeps(t *testing.T, repo *mock.MockRepo) *jobServiceDependencies {
+	t.Helper()
+
+	return &jobServiceDependencies{
+		config: &Config{
+			MaxBatchSubmissions: 100,
+			// AllowedHosts lets webhooks reach the loopback address httptest servers listen on
+			Webhook: &WebhookConfig{
+				Secret:       "secret",
+				Timeout:      200 * time.Millisecond,
+				MaxAttempts:  3,
+				Backoff:      time.Second,
+				MaxBackoff:   time.Minute,
+				PollInterval: time.Second,
+				BatchSize:    10,
+				AllowedHosts: []string{"127.0.0.1"},
+			},
+			Events: &EventsConfig{
+				Enabled:      true,
+				PollInterval: 10 * time.Millisecond,
+				BufferSize:   16,
+				BatchSize:    16,
+				Retention:    time.Hour,
+			},
+		},
+		repository:  repo,
+		taskFactory: factory.NewTaskFactory(),
+		events:      NewEventBus(),
+		metrics:     noopMetrics{},
+	}
+}
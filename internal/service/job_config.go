@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidConfig is returned when a JobConfigSubmission fails validation
+var ErrInvalidConfig = errors.New("invalid job config")
+
+// ErrConfigNotFound is returned when a config, or the requested version of it, does not exist
+var ErrConfigNotFound = errors.New("job config not found")
+
+// ErrConfigInUse is returned when retiring a config version that is the default or that jobs reference
+var ErrConfigInUse = errors.New("job config version in use")
+
+func (service *JobService) CreateJobConfig(ctx context.Context, submission *domain.JobConfigSubmission) (*domain.JobConfig, error) {
+	return service.saveJobConfig(ctx, uuid.New(), submission)
+}
+
+// PublishJobConfigVersion saves the submission as the config's new version. Versions are immutable,
+// jobs keep the version they were submitted with and the default stays on its version.
+func (service *JobService) PublishJobConfigVersion(ctx context.Context, configID uuid.UUID, submission *domain.JobConfigSubmission) (*domain.JobConfig, error) {
+	if _, err := service.GetJobConfig(ctx, configID, uuid.Nil); err != nil {
+		return nil, err
+	}
+	return service.saveJobConfig(ctx, configID, submission)
+}
+
+func (service *JobService) saveJobConfig(ctx context.Context, configID uuid.UUID, submission *domain.JobConfigSubmission) (*domain.JobConfig, error) {
+	if err := validateJobConfig(submission); err != nil {
+		return nil, err
+	}
+
+	config := domain.JobConfig{
+		IdentityVersion: domain.IdentityVersion{
+			Identity: domain.Identity{ID: configID, IdentitySubmission: submission.IdentitySubmission},
+			Version:  uuid.New(),
+		},
+		CreateDate:       time.Now().UTC(),
+		JobConfigDetails: submission.JobConfigDetails,
+	}
+	return service.repository.SaveJobConfig(ctx, config)
+}
+
+// GetJobConfig returns a version of a config, the latest when version is empty
+func (service *JobService) GetJobConfig(ctx context.Context, configID uuid.UUID, version uuid.UUID) (*domain.JobConfig, error) {
+	var config *domain.JobConfig
+	var err error
+	if version == uuid.Nil {
+		config, err = service.repository.GetJobConfig(ctx, configID)
+	} else {
+		config, err = service.repository.GetJobConfigVersion(ctx, configID, version)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, ErrConfigNotFound
+	}
+	return config, nil
+}
+
+func (service *JobService) GetAllJobConfigs(ctx context.Context, input *domain.CursorInput) (*domain.CursorOutput[domain.JobConfig], error) {
+	return service.repository.GetAllJobConfigs(ctx, input)
+}
+
+func (service *JobService) GetJobConfigVersions(ctx context.Context, configID uuid.UUID, input *domain.CursorInput) (*domain.CursorOutput[domain.JobConfig], error) {
+	if _, err := service.GetJobConfig(ctx, configID, uuid.Nil); err != nil {
+		return nil, err
+	}
+	// Versions share the config's ID, order them by age unless another field is asked for
+	if len(input.Sort) == 0 {
+		input.Sort = []domain.SortOrder{{Field: "create_date", Dir: domain.SortASC}}
+	}
+	return service.repository.GetJobConfigVersions(ctx, configID, input)
+}
+
+// DiffJobConfigs lists the fields that differ between two versions of a config
+func (service *JobService) DiffJobConfigs(ctx context.Context, configID uuid.UUID, from uuid.UUID, to uuid.UUID) (*domain.JobConfigDiff, error) {
+	fromConfig, err := service.GetJobConfig(ctx, configID, from)
+	if err != nil {
+		return nil, err
+	}
+	toConfig, err := service.GetJobConfig(ctx, configID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	fromValue, err := configDiffValue(fromConfig)
+	if err != nil {
+		return nil, err
+	}
+	toValue, err := configDiffValue(toConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.JobConfigDiff{
+		ID:      configID,
+		From:    fromConfig.Version,
+		To:      toConfig.Version,
+		Changes: diffValues("", fromValue, toValue, []domain.JobConfigChange{}),
+	}, nil
+}
+
+// SetDefaultJobConfig makes a version of a config the default, the latest when version is empty.
+// Jobs submitted without a config use the default.
+func (service *JobService) SetDefaultJobConfig(ctx context.Context, configID uuid.UUID, version uuid.UUID) (*domain.JobConfig, error) {
+	config, err := service.GetJobConfig(ctx, configID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := service.repository.SetDefaultJobConfig(ctx, config.ID, config.Version)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrConfigNotFound
+	}
+	config.IsDefault = true
+	return config, nil
+}
+
+// RetireJobConfigVersion deletes a version of a config that is not the default and no job references
+func (service *JobService) RetireJobConfigVersion(ctx context.Context, configID uuid.UUID, version uuid.UUID) error {
+	config, err := service.GetJobConfig(ctx, configID, version)
+	if err != nil {
+		return err
+	}
+	if config.IsDefault {
+		return fmt.Errorf("%w: version %s is the default", ErrConfigInUse, version)
+	}
+
+	deleted, err := service.repository.DeleteJobConfigVersion(ctx, configID, version)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("%w: version %s is referenced by jobs", ErrConfigInUse, version)
+	}
+	return nil
+}
+
+// validateJobConfig checks the limits, queue, callbacks and retention of a config
+func validateJobConfig(submission *domain.JobConfigSubmission) error {
+	if submission.Name == "" {
+		return fmt.Errorf("%w: config name is required", ErrInvalidConfig)
+	}
+	if submission.JobTimeout <= 0 || submission.TaskTimeout <= 0 {
+		return fmt.Errorf("%w: jobTimeout and taskTimeout must be positive", ErrInvalidConfig)
+	}
+	if submission.EnableParallelTasks && submission.MaxParallelTasks < 1 {
+		return fmt.Errorf("%w: maxParallelTasks must be at least 1 when parallel tasks are enabled", ErrInvalidConfig)
+	}
+	if submission.MaxParallelTasks < 0 || submission.MaxTaskTimeout < 0 || submission.TaskRetryLimit() < 0 ||
+		submission.TaskPriorityLimit() < 0 || submission.ExpireAfter < 0 {
+		return fmt.Errorf("%w: maxParallelTasks, maxTaskTimeout, maxTaskRetries, maxTaskPriority and expireAfter cannot be negative", ErrInvalidConfig)
+	}
+	if submission.Queue != "" {
+		if err := validateQueueName(submission.Queue); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+		}
+	}
+	if err := validateCallbacks(submission.Callbacks); err != nil {
+		return err
+	}
+	return validateRetentionPolicy(submission.Retention)
+}
+
+func validateRetentionPolicy(policy *domain.RetentionPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	for state, age := range policy.MaxAge {
+		if !slices.Contains(domain.EndedStates, state) {
+			return fmt.Errorf("%w: retention maxAge has state %s that is not an ended state", ErrInvalidConfig, state)
+		}
+		if age <= 0 {
+			return fmt.Errorf("%w: retention maxAge of %s must be positive", ErrInvalidConfig, state)
+		}
+	}
+	if policy.MaxJobs < 0 {
+		return fmt.Errorf("%w: retention maxJobs cannot be negative", ErrInvalidConfig)
+	}
+	return nil
+}
+
+// configDiffValue is the JSON of the fields a config version sets, decoded for comparison
+func configDiffValue(config *domain.JobConfig) (any, error) {
+	data, err := json.Marshal(domain.JobConfigSubmission{
+		IdentitySubmission: config.IdentitySubmission,
+		JobConfigDetails:   config.JobConfigDetails,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode config %s version %s: %w", config.ID, config.Version, err)
+	}
+
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("failed to decode config %s version %s: %w", config.ID, config.Version, err)
+	}
+	return value, nil
+}
+
+// diffValues appends the changes between two decoded JSON values. Objects and arrays are compared
+// by key and index, so a change is reported at the deepest path that differs.
+func diffValues(path string, from any, to any, changes []domain.JobConfigChange) []domain.JobConfigChange {
+	switch fromValue := from.(type) {
+	case map[string]any:
+		if toValue, ok := to.(map[string]any); ok {
+			keys := make([]string, 0, len(fromValue)+len(toValue))
+			for key := range fromValue {
+				keys = append(keys, key)
+			}
+			for key := range toValue {
+				if _, ok := fromValue[key]; !ok {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+
+			for _, key := range keys {
+				changes = diffValues(joinDiffPath(path, key), fromValue[key], toValue[key], changes)
+			}
+			return changes
+		}
+	case []any:
+		if toValue, ok := to.([]any); ok {
+			for i := range max(len(fromValue), len(toValue)) {
+				var fromItem, toItem any
+				if i < len(fromValue) {
+					fromItem = fromValue[i]
+				}
+				if i < len(toValue) {
+					toItem = toValue[i]
+				}
+				changes = diffValues(path+"["+strconv.Itoa(i)+"]", fromItem, toItem, changes)
+			}
+			return changes
+		}
+	}
+
+	if !reflect.DeepEqual(from, to) {
+		changes = append(changes, domain.JobConfigChange{Path: path, From: from, To: to})
+	}
+	return changes
+}
+
+func joinDiffPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
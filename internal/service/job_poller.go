@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/repository"
+	"github.com/google/uuid"
+)
+
+// jobPoller claims pending jobs of one queue from the repository and feeds them to the
+// queue's JobWorkers. It only claims as many jobs as there are idle JobWorkers, so work
+// spreads across every process sharing the database.
+type jobPoller struct {
+	repository repository.JobRepository
+	workerID   string
+	queue      string
+	// capabilities of this worker, jobs requiring others are left to other workers
+	capabilities []string
+	capacity     int
+	interval     time.Duration
+	jobCh        chan<- uuid.UUID
+	active       atomic.Int32
+	notify       chan struct{}
+}
+
+func newJobPoller(repository repository.JobRepository, workerID string, queue QueueConfig, capabilities []string, interval time.Duration, jobCh chan<- uuid.UUID) *jobPoller {
+	return &jobPoller{
+		repository:   repository,
+		workerID:     workerID,
+		queue:        queue.Name,
+		capabilities: capabilities,
+		capacity:     queue.JobWorkerCount,
+		interval:     interval,
+		jobCh:        jobCh,
+		notify:       make(chan struct{}, 1),
+	}
+}
+
+// NewWorkerID returns an ID that is unique per process and readable in the jobs table
+func NewWorkerID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "worker"
+	}
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), uuid.NewString()[:8])
+}
+
+// Notify wakes the poller, e.g. after a job is submitted, without waiting for the next tick
+func (poller *jobPoller) Notify() {
+	select {
+	case poller.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Done is called by a JobWorker once it has finished with a claimed job
+func (poller *jobPoller) Done() {
+	poller.active.Add(-1)
+	poller.Notify()
+}
+
+// Run claims jobs until ctx is done. It is the only sender on jobCh.
+func (poller *jobPoller) Run(ctx context.Context) {
+	ticker := time.NewTicker(poller.interval)
+	defer ticker.Stop()
+
+	slog.InfoContext(ctx, "polling for jobs", slog.String("workerId", poller.workerID), slog.String("queue", poller.queue))
+
+	for {
+		if poller.claim(ctx) {
+			// Every idle worker got a job, there may be more waiting
+			poller.Notify()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-poller.notify:
+		case <-ticker.C:
+		}
+	}
+}
+
+// claim hands newly claimed jobs to the JobWorkers, reporting whether it filled every idle worker
+func (poller *jobPoller) claim(ctx context.Context) bool {
+	idle := poller.capacity - int(poller.active.Load())
+	if idle <= 0 {
+		return false
+	}
+
+	jobIDs, err := poller.repository.ClaimJobs(ctx, poller.workerID, poller.queue, poller.capabilities, idle)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.ErrorContext(ctx, "failed to claim jobs", slog.String("queue", poller.queue), slog.Any("error", err))
+		}
+		return false
+	}
+
+	// jobCh holds at least capacity jobs, so this never blocks
+	for _, jobID := range jobIDs {
+		poller.active.Add(1)
+		poller.jobCh <- jobID
+	}
+	return len(jobIDs) == idle
+}
@@ -4,34 +4,53 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"slices"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/abikandiah/task-worker/internal/domain"
 	"github.com/abikandiah/task-worker/internal/factory"
 	"github.com/abikandiah/task-worker/internal/repository"
+	"github.com/abikandiah/task-worker/internal/util"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type JobService struct {
 	*jobServiceDependencies
-	jobCh   chan uuid.UUID
-	taskCh  chan TaskRunRequest
-	cancel  context.CancelFunc
-	wg      *sync.WaitGroup
-	started bool
+	workerID string
+	// pools serve the queues this process subscribes to, keyed by queue name
+	pools    map[string]*queuePool
+	taskLogs *taskLogWriter
+	archiver *jobArchiver
+	// relay is nil when events are not relayed between processes
+	relay    *eventRelay
+	registry *workerRegistry
+	elector  LeaderElector
+	cancel   context.CancelFunc
+	wg       *sync.WaitGroup
+	started  bool
 }
 
 type jobServiceDependencies struct {
 	config      *Config
 	repository  repository.ServiceRepository
 	taskFactory *factory.TaskFactory
+	events      *EventBus
+	metrics     Metrics
 }
 
 type JobServiceParams struct {
 	Config      *Config
 	Repository  repository.ServiceRepository
 	TaskFactory *factory.TaskFactory
+	// Version of the application, reported in the worker registry
+	Version string
+	// LeaderElector picks the instance running singleton duties, this instance always leads when nil
+	LeaderElector LeaderElector
+	// Metrics records the service's instrumentation, nothing is recorded when nil
+	Metrics Metrics
 }
 
 func NewJobService(params *JobServiceParams) *JobService {
@@ -39,14 +58,45 @@ func NewJobService(params *JobServiceParams) *JobService {
 		config:      params.Config,
 		taskFactory: params.TaskFactory,
 		repository:  params.Repository,
+		events:      NewEventBus(),
+		metrics:     params.Metrics,
+	}
+	if jobServiceDeps.metrics == nil {
+		jobServiceDeps.metrics = noopMetrics{}
+	}
+	jobServiceDeps.events.Observe(recordEventMetrics(jobServiceDeps.metrics))
+
+	workerID := params.Config.ID
+	if workerID == "" {
+		workerID = NewWorkerID()
+	}
+
+	queues := params.Config.SubscribedQueues()
+	pools := make(map[string]*queuePool, len(queues))
+	for _, queue := range queues {
+		pools[queue.Name] = newQueuePool(jobServiceDeps, workerID, queue)
 	}
 
 	service := &JobService{
 		jobServiceDependencies: jobServiceDeps,
-		jobCh:                  make(chan uuid.UUID, params.Config.JobBufferCapacity),
-		taskCh:                 make(chan TaskRunRequest),
+		workerID:               workerID,
+		pools:                  pools,
 		wg:                     new(sync.WaitGroup),
 	}
+	service.elector = params.LeaderElector
+	if service.elector == nil {
+		service.elector = &soloElector{}
+	}
+	service.registry = newWorkerRegistry(jobServiceDeps, workerID, queues, service.elector, params.Version, service.notifyPools)
+	if params.Config.TaskLogs.Enabled {
+		service.taskLogs = newTaskLogWriter(params.Repository, params.Config.TaskLogs)
+	}
+	if params.Config.Events.Enabled {
+		service.relay = newEventRelay(jobServiceDeps, workerID)
+	}
+	if params.Config.Retention.ArchiveEnabled() {
+		service.archiver = newJobArchiver(params.Config.Retention.Archive.Directory)
+	}
 	return service
 }
 
@@ -55,40 +105,142 @@ func (service *JobService) StartWorkers(ctx context.Context) {
 	service.cancel = cancel
 	service.started = true
 
-	// Start Task Workers
-	for i := 0; i < service.config.TaskWorkerCount; i++ {
-		worker := &TaskWorker{
-			jobServiceDependencies: service.jobServiceDependencies,
-			taskCh:                 service.taskCh,
-		}
+	// Subscribe before any worker can publish, so no job events are missed. Deliveries of
+	// relayed events are recorded by the process that published them.
+	webhookSub := service.events.Subscribe(webhookEventBuffer, func(event Event) bool {
+		return event.IsJobEvent() && !event.relayed
+	})
+	dispatcher := newWebhookDispatcher(service.jobServiceDependencies)
+
+	service.wg.Add(1)
+	go func() {
+		defer service.wg.Done()
+		dispatcher.Run(ctx, webhookSub)
+	}()
 
+	if service.relay != nil {
 		service.wg.Add(1)
 		go func() {
 			defer service.wg.Done()
-			worker.Run(ctx)
+			service.relay.Run(ctx)
 		}()
 	}
 
-	// Start Job Workers
-	for i := 0; i < service.config.JobWorkerCount; i++ {
-		worker := &JobWorker{
-			jobServiceDependencies: service.jobServiceDependencies,
-			jobCh:                  service.jobCh,
-			taskCh:                 service.taskCh,
+	// Only the webhook dispatcher and event relay run on API-only instances, deliveries are leased so it is safe everywhere
+	if !service.config.Enabled {
+		slog.InfoContext(ctx, "workers disabled, jobs are left to worker processes")
+		if service.relay == nil {
+			slog.WarnContext(ctx, "workers and the event relay are disabled, event streams only see jobs submitted to this process")
 		}
+		return
+	}
+
+	// Register before claiming, other workers recover jobs held by unregistered workers
+	if err := service.registry.Register(ctx); err != nil {
+		slog.ErrorContext(ctx, "failed to register worker", slog.Any("error", err))
+	}
+
+	service.wg.Add(1)
+	go func() {
+		defer service.wg.Done()
+		service.registry.Run(ctx)
+	}()
+
+	// Singleton duties run only while this instance leads, leadership is released on shutdown
+	service.wg.Add(1)
+	go func() {
+		defer service.wg.Done()
+		service.elector.Run(ctx, func(ctx context.Context) {
+			runLeaderDuties(ctx, service.leaderDuties())
+		})
+	}()
+
+	if service.taskLogs != nil {
+		taskLogSub := service.events.Subscribe(taskLogEventBuffer, func(event Event) bool {
+			return event.Type == EventTaskFinished
+		})
 
 		service.wg.Add(1)
 		go func() {
 			defer service.wg.Done()
-			worker.Run(ctx)
+			service.taskLogs.Run(ctx, taskLogSub)
 		}()
 	}
 
-	slog.InfoContext(ctx, "started workers", "jobWorkerCount",
-		service.config.JobWorkerCount, "taskWorkerCounter", service.config.TaskWorkerCount)
+	for _, pool := range service.pools {
+		pool.Start(ctx, service.wg)
+	}
+}
+
+func (service *JobService) SubmitJob(ctx context.Context, submission *domain.JobSubmission) (job *domain.Job, err error) {
+	ctx, span := tracer.Start(ctx, "JobService.SubmitJob")
+	defer func() { endSpan(span, err) }()
+
+	job, err = service.prepareJob(ctx, submission, nil)
+	if err != nil {
+		return job, err
+	}
+	return service.saveSubmittedJob(ctx, job, submission)
+}
+
+// saveSubmittedJob saves a prepared job with the submission's task runs, replacing pending jobs
+// in the same transaction, and announces it
+func (service *JobService) saveSubmittedJob(ctx context.Context, job *domain.Job, submission *domain.JobSubmission) (*domain.Job, error) {
+	job.ID = uuid.New()
+	ctx = context.WithValue(ctx, domain.LKeys.JobID, job.ID)
+
+	// Write to DB
+	replaced, err := service.repository.SaveJobsWithTaskRuns(ctx, []domain.JobWithTaskRuns{newJobWithTaskRuns(job, submission)})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to save job", slog.Any("error", err))
+		return job, fmt.Errorf("failed to save job: %w", err)
+	}
+
+	service.jobSubmitted(ctx, job)
+	service.jobsReplaced(ctx, replaced)
+	return job, nil
+}
+
+// batchLookups is shared by the submissions of a batch. Configs are looked up once per ID, and
+// the jobs of each concurrency key that the batch queues count towards the key's limit.
+type batchLookups struct {
+	configs map[uuid.UUID]*domain.JobConfig
+	queued  map[string]int
 }
 
-func (service *JobService) SubmitJob(ctx context.Context, submission *domain.JobSubmission) (*domain.Job, error) {
+func newBatchLookups() *batchLookups {
+	return &batchLookups{
+		configs: make(map[uuid.UUID]*domain.JobConfig),
+		queued:  make(map[string]int),
+	}
+}
+
+// prepareJob validates a submission and translates it into a Job that is ready to be saved.
+// Submissions of a batch share its lookups, which are nil otherwise.
+func (service *JobService) prepareJob(ctx context.Context, submission *domain.JobSubmission, batch *batchLookups) (*domain.Job, error) {
+	var configs map[uuid.UUID]*domain.JobConfig
+	if batch != nil {
+		configs = batch.configs
+	}
+
+	if err := validateTaskConditions(submission.TaskRuns); err != nil {
+		return nil, err
+	}
+	if err := validateTags(submission.Tags); err != nil {
+		return nil, err
+	}
+	if err := validateConcurrency(submission); err != nil {
+		return nil, err
+	}
+	if submission.ExpiresAt != nil && !submission.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expiresAt must be in the future", ErrInvalidSubmission)
+	}
+	if submission.Queue != "" {
+		if err := validateQueueName(submission.Queue); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidSubmission, err)
+		}
+	}
+
 	// Translate submission into Job (validate and populate IDs etc.)
 	job := &domain.Job{
 		Identity: domain.Identity{
@@ -99,48 +251,136 @@ func (service *JobService) SubmitJob(ctx context.Context, submission *domain.Job
 		},
 		ConfigID:      submission.ConfigID,
 		ConfigVersion: submission.ConfigVersion,
+		Status:        domain.Status{State: domain.StatePending},
 		SubmitDate:    time.Now().UTC(),
+		Variables:     submission.Variables,
+		Tags:          submission.Tags,
+		TraceContext:  traceContext(ctx),
+	}
+	if submission.ConcurrencyKey != "" {
+		job.ConcurrencyKey = submission.ConcurrencyKey
+		job.ConcurrencyLimit = max(submission.ConcurrencyLimit, 1)
 	}
 
-	// Get config, revert to default if none set
-	if job.ConfigID == uuid.Nil {
+	// Get config, revert to default if none set. Only unpinned configs are cached.
+	var config *domain.JobConfig
+	cached := false
+	if job.ConfigVersion == uuid.Nil {
+		config, cached = configs[job.ConfigID]
+	}
+	if cached {
+		job.ConfigID = config.ID
+		job.ConfigVersion = config.Version
+	} else if job.ConfigID == uuid.Nil {
 		slog.InfoContext(ctx, "config not specified, using default")
 
 		if defaultConfig, err := service.repository.GetOrCreateDefaultJobConfig(ctx); err != nil {
 			slog.ErrorContext(ctx, "failed to get or create default config", slog.Any("error", err))
 			return job, fmt.Errorf("failed to get or create default config: %w", err)
 		} else {
+			config = defaultConfig
 			job.ConfigID = defaultConfig.ID
 			job.ConfigVersion = defaultConfig.Version
 		}
+	} else if job.ConfigVersion != uuid.Nil {
+		// Jobs pinned to a version are validated against it
+		jobConfig, err := service.repository.GetJobConfigVersion(ctx, job.ConfigID, job.ConfigVersion)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to get config version", slog.Any("error", err))
+			return job, fmt.Errorf("failed to get config version: %w", err)
+		}
+		if jobConfig == nil {
+			return job, fmt.Errorf("%w: config %s version %s not found", ErrInvalidSubmission, job.ConfigID, job.ConfigVersion)
+		}
+		config = jobConfig
+	} else {
+		jobConfig, err := service.repository.GetJobConfig(ctx, job.ConfigID)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to get config", slog.Any("error", err))
+			return job, fmt.Errorf("failed to get config: %w", err)
+		}
+		if jobConfig == nil {
+			return job, fmt.Errorf("%w: config %s not found", ErrInvalidSubmission, job.ConfigID)
+		}
+		config = jobConfig
+		job.ConfigVersion = jobConfig.Version
+	}
+	if configs != nil && !cached && submission.ConfigVersion == uuid.Nil {
+		configs[submission.ConfigID] = config
 	}
 
-	// Write to DB
-	job, err := service.repository.SaveJob(ctx, *job)
-	if err != nil {
-		slog.ErrorContext(ctx, "Failed to save job", slog.Any("error", err))
-		return job, fmt.Errorf("failed to save job: %w", err)
+	if err := validateTaskRunOverrides(submission.TaskRuns, config); err != nil {
+		return job, err
 	}
 
-	ctx = context.WithValue(ctx, domain.LKeys.JobID, job.ID)
+	// The submission's queue overrides the config's
+	job.Queue = submission.Queue
+	if job.Queue == "" {
+		job.Queue = config.Queue
+	}
+	if job.Queue == "" {
+		job.Queue = domain.DefaultQueue
+	}
 
-	// Populate JobID
-	for i := range submission.TaskRuns {
-		submission.TaskRuns[i].JobID = job.ID
+	// The submission's deadline overrides the config's
+	if submission.ExpiresAt != nil {
+		job.ExpiresAt = util.TimePtr(submission.ExpiresAt.UTC())
+	} else if config.ExpireAfter > 0 {
+		job.ExpiresAt = util.TimePtr(job.SubmitDate.Add(time.Duration(config.ExpireAfter) * time.Second))
 	}
-	_, err = service.repository.SaveTaskRuns(ctx, submission.TaskRuns)
-	if err != nil {
-		slog.ErrorContext(ctx, "Failed to save job taskRuns", slog.Any("error", err))
-		return job, fmt.Errorf("failed to save job taskRuns: %w", err)
+
+	// Only workers providing every capability the tasks require may claim the job
+	job.Capabilities = requiredCapabilities(service.taskFactory, submission.TaskRuns)
+
+	// Config callbacks apply to every job, submissions can add their own
+	job.Callbacks = append(slices.Clone(config.Callbacks), submission.Callbacks...)
+	if err := validateCallbacks(job.Callbacks); err != nil {
+		return job, err
 	}
 
-	// Send JobID to Job Worker
-	slog.InfoContext(ctx, "submitted job to queue")
-	service.jobCh <- job.ID
+	queued := 0
+	if batch != nil {
+		queued = batch.queued[job.ConcurrencyKey]
+	}
+	if err := service.checkConcurrencyPolicy(ctx, submission.ConcurrencyPolicy, job, queued); err != nil {
+		return job, err
+	}
 
 	return job, nil
 }
 
+// newJobWithTaskRuns pairs a prepared job with its task runs, for it to replace the pending jobs
+// of its concurrency key as it is saved under the replace policy
+func newJobWithTaskRuns(job *domain.Job, submission *domain.JobSubmission) domain.JobWithTaskRuns {
+	return domain.JobWithTaskRuns{
+		Job:            *job,
+		TaskRuns:       newJobTaskRuns(job, submission),
+		ReplacePending: job.ConcurrencyKey != "" && submission.ConcurrencyPolicy == domain.ConcurrencyReplace,
+	}
+}
+
+// newJobTaskRuns returns the submission's task runs, pending for the job
+func newJobTaskRuns(job *domain.Job, submission *domain.JobSubmission) []domain.TaskRun {
+	for i := range submission.TaskRuns {
+		submission.TaskRuns[i].JobID = job.ID
+		submission.TaskRuns[i].State = domain.StatePending
+		submission.TaskRuns[i].Attempts = 0
+	}
+	return submission.TaskRuns
+}
+
+// jobSubmitted announces a saved job
+func (service *JobService) jobSubmitted(ctx context.Context, job *domain.Job) {
+	service.events.Publish(newJobEvent(domain.Status{}, job))
+	trace.SpanFromContext(ctx).AddEvent("job queued", trace.WithAttributes(jobAttributes(job)...))
+
+	// Workers claim the job from the repository, wake the local ones rather than waiting a poll
+	slog.InfoContext(ctx, "submitted job to queue", slog.String("queue", job.Queue))
+	if pool, ok := service.pools[job.Queue]; ok && service.config.Enabled {
+		pool.poller.Notify()
+	}
+}
+
 func (service *JobService) GetJob(ctx context.Context, jobID uuid.UUID) (*domain.Job, error) {
 	job, err := service.repository.GetJob(ctx, jobID)
 	return job, err
@@ -159,18 +399,162 @@ func (service *JobService) GetAllJobs(ctx context.Context, input *domain.CursorI
 	return output, err
 }
 
-func (service *JobService) GetJobConfig(ctx context.Context, configID uuid.UUID) (*domain.JobConfig, error) {
-	job, err := service.repository.GetJobConfig(ctx, configID)
-	return job, err
+func (service *JobService) GetWebhookDeliveries(ctx context.Context, jobID uuid.UUID) ([]domain.WebhookDelivery, error) {
+	deliveries, err := service.repository.GetWebhookDeliveries(ctx, jobID)
+	return deliveries, err
+}
+
+func (service *JobService) GetTaskRun(ctx context.Context, taskRunID uuid.UUID) (*domain.TaskRun, error) {
+	taskRun, err := service.repository.GetTaskRun(ctx, taskRunID)
+	return taskRun, err
+}
+
+func (service *JobService) GetTaskRuns(ctx context.Context, jobID uuid.UUID) ([]domain.TaskRun, error) {
+	taskRuns, err := service.repository.GetTaskRuns(ctx, jobID)
+	return taskRuns, err
+}
+
+func (service *JobService) GetAllTaskRuns(ctx context.Context, input *domain.CursorInput) (*domain.CursorOutput[domain.TaskRun], error) {
+	output, err := service.repository.GetAllTaskRuns(ctx, input)
+	return output, err
+}
+
+func (service *JobService) GetTaskLogs(ctx context.Context, taskRunID uuid.UUID, afterSeq int64, limit int) ([]domain.TaskLog, error) {
+	taskLogs, err := service.repository.GetTaskLogs(ctx, taskRunID, afterSeq, limit)
+	return taskLogs, err
+}
+
+// TaskLogHandler wraps next so that logs emitted while tasks execute are also stored
+// with their TaskRun. It returns next unchanged when task log capture is disabled.
+func (service *JobService) TaskLogHandler(next slog.Handler) slog.Handler {
+	if service.taskLogs == nil {
+		return next
+	}
+	return &TaskLogHandler{
+		next:   next,
+		writer: service.taskLogs,
+		level:  service.config.TaskLogs.SlogLevel(),
+	}
+}
+
+func (service *JobService) leaderDuties() []leaderDuty {
+	duties := []leaderDuty{
+		service.registry.RecoverJobs,
+		service.failUnschedulableJobs,
+		service.expireJobs,
+	}
+	if service.relay != nil {
+		duties = append(duties, service.relay.pruneEvents)
+	}
+	if service.config.Retention.Enabled {
+		duties = append(duties, service.purgeJobs)
+	}
+	return duties
+}
+
+// IsLeader reports whether this instance runs the singleton background duties
+func (service *JobService) IsLeader() bool {
+	return service.elector.IsLeader()
+}
+
+// GetWorkers returns every registered worker with its state derived from its heartbeat
+func (service *JobService) GetWorkers(ctx context.Context) ([]domain.Worker, error) {
+	workers, err := service.repository.GetWorkers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	aliveSince := time.Now().UTC().Add(-service.config.DeadAfter)
+	for i := range workers {
+		workers[i].State = workerState(workers[i], aliveSince)
+		// The last heartbeat of a dead worker may still claim leadership
+		workers[i].Leader = workers[i].Leader && workers[i].State == domain.WorkerAlive
+	}
+	return workers, nil
+}
+
+// GetQueues returns the depth of every queue that has jobs or live workers, sorted by name
+func (service *JobService) GetQueues(ctx context.Context) ([]domain.Queue, error) {
+	depths, err := service.repository.GetQueueDepths(ctx)
+	if err != nil {
+		return nil, err
+	}
+	workers, err := service.GetWorkers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	queues := make(map[string]*domain.Queue, len(depths))
+	for i := range depths {
+		depths[i].Workers = []string{}
+		queues[depths[i].Name] = &depths[i]
+	}
+
+	for _, worker := range workers {
+		if worker.State != domain.WorkerAlive {
+			continue
+		}
+		for _, workerQueue := range worker.Queues {
+			queue, ok := queues[workerQueue.Name]
+			if !ok {
+				queue = &domain.Queue{Name: workerQueue.Name, Workers: []string{}}
+				queues[workerQueue.Name] = queue
+			}
+			queue.Workers = append(queue.Workers, worker.ID)
+			queue.JobWorkerCount += workerQueue.JobWorkerCount
+		}
+	}
+
+	result := make([]domain.Queue, 0, len(queues))
+	for _, queue := range queues {
+		result = append(result, *queue)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// MaxBatchSubmissions is how many jobs a batch submission may contain
+func (service *JobService) MaxBatchSubmissions() int {
+	return service.config.MaxBatchSubmissions
+}
+
+// WorkerID identifies this process in the jobs it claims
+func (service *JobService) WorkerID() string {
+	return service.workerID
+}
+
+// notifyPools wakes every local poller, e.g. after jobs were recovered
+func (service *JobService) notifyPools() {
+	for _, pool := range service.pools {
+		pool.poller.Notify()
+	}
+}
+
+// Events returns the bus that job and task lifecycle events are published on
+func (service *JobService) Events() *EventBus {
+	return service.events
 }
 
 func (service *JobService) Close(ctx context.Context) {
 	slog.InfoContext(ctx, "Closing job service")
-	close(service.jobCh)
-	close(service.taskCh)
-
 	if service.started {
 		service.cancel()
+	}
+	for _, pool := range service.pools {
+		pool.Stop()
+	}
+
+	if service.started {
 		service.wg.Wait()
+		if service.config.Enabled {
+			// Hand jobs that were claimed but never started back to other workers
+			if err := service.repository.ReleaseJobClaims(ctx, service.workerID); err != nil {
+				slog.ErrorContext(ctx, "failed to release job claims", slog.Any("error", err))
+			}
+			service.registry.Stop(ctx)
+		}
 	}
+	service.events.Close()
 }
@@ -12,42 +12,78 @@ import (
 	"github.com/abikandiah/task-worker/internal/domain"
 	"github.com/abikandiah/task-worker/internal/util"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type JobWorker struct {
 	*jobServiceDependencies
-	jobCh  <-chan uuid.UUID
-	taskCh chan<- TaskRunRequest
+	queue     string
+	jobCh     <-chan uuid.UUID
+	taskQueue *taskQueue
+	poller    *jobPoller
 }
 
 var ErrJobTimedOut = errors.New("task timed out")
 
 func (worker *JobWorker) Run(ctx context.Context) {
 	for jobID := range worker.jobCh {
-		ctx := context.WithValue(ctx, domain.LKeys.JobID, jobID)
+		// Jobs still queued at shutdown stay pending, their claims are released for other workers
+		if ctx.Err() == nil {
+			worker.metrics.WorkerBusy(worker.queue, JobWorkerKind)
+			worker.handleJob(ctx, jobID)
+			worker.metrics.WorkerIdle(worker.queue, JobWorkerKind)
+		}
+		worker.poller.Done()
+	}
+}
 
-		// Get and run job
-		job, err := worker.repository.GetJob(ctx, jobID)
+func (worker *JobWorker) handleJob(ctx context.Context, jobID uuid.UUID) {
+	ctx = context.WithValue(ctx, domain.LKeys.JobID, jobID)
+
+	// Get and run job
+	job, err := worker.repository.GetJob(ctx, jobID)
+	if err == nil && job != nil {
+		ctx = withJobTrace(ctx, job)
+		if job.StartDate == nil {
+			traceJobQueued(ctx, job)
+		}
+	}
+
+	if err != nil || job == nil {
+		slog.ErrorContext(ctx, "failed to fetch job", slog.Any("error", err))
+	} else if job.StartDate == nil && job.ExpiresAt != nil && time.Now().After(*job.ExpiresAt) {
+		// Stale work is dropped rather than run late, recovered jobs already started
+		worker.expireJob(ctx, job)
+	} else {
+
+		err = worker.runJob(ctx, job)
 		if err != nil {
-			slog.ErrorContext(ctx, "failed to fetch job", slog.Any("error", err))
-		} else {
+			slog.ErrorContext(ctx, "job failed", slog.Any("error", err))
 
-			err = worker.runJob(ctx, job)
-			if err != nil {
-				slog.ErrorContext(ctx, "job failed", slog.Any("error", err))
-				worker.updateJobState(ctx, job, domain.StateError)
-				worker.repository.SaveJob(ctx, *job)
+			// Jobs interrupted by shutdown are cancelled rather than failed
+			state := domain.StateError
+			if ctx.Err() != nil {
+				state = domain.StateStopped
 			}
+			job.EndDate = util.TimePtr(time.Now().UTC())
+			worker.updateJobState(ctx, job, state)
+			worker.repository.SaveJob(context.WithoutCancel(ctx), *job)
 		}
 	}
 }
 
-func (worker *JobWorker) runJob(ctx context.Context, job *domain.Job) error {
-	// Get Config
-	config, err := worker.repository.GetJobConfig(ctx, job.ConfigID)
+func (worker *JobWorker) runJob(ctx context.Context, job *domain.Job) (err error) {
+	ctx, span := tracer.Start(ctx, "JobWorker.runJob", trace.WithAttributes(jobAttributes(job)...))
+	defer func() { endSpan(span, err) }()
+
+	// Get the config version the job was submitted with
+	config, err := worker.repository.GetJobConfigVersion(ctx, job.ConfigID, job.ConfigVersion)
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to fetch config", slog.Any("error", err))
-		return fmt.Errorf("failed to fetch config %s: %w", job.ConfigID, err)
+		return fmt.Errorf("failed to fetch config %s version %s: %w", job.ConfigID, job.ConfigVersion, err)
+	}
+	if config == nil {
+		return fmt.Errorf("%w: config %s version %s", ErrConfigNotFound, job.ConfigID, job.ConfigVersion)
 	}
 
 	ctx = context.WithValue(ctx, domain.LKeys.JobName, job.Name)
@@ -76,15 +112,18 @@ func (worker *JobWorker) runJob(ctx context.Context, job *domain.Job) error {
 	}
 }
 
-func (worker *JobWorker) executeJob(ctx context.Context, job *domain.Job, config *domain.JobConfig) error {
+func (worker *JobWorker) executeJob(ctx context.Context, job *domain.Job, config *domain.JobConfig) (err error) {
 	job.StartDate = util.TimePtr(time.Now().UTC())
 	worker.updateJobState(ctx, job, domain.StateRunning)
 	worker.repository.SaveJob(ctx, *job)
 
-	// Finalize job in defer block
+	// Finalize job in defer block, failures are finalized by Run
 	defer func() {
-		worker.updateJobState(ctx, job, domain.StateFinished)
+		if err != nil || ctx.Err() != nil {
+			return
+		}
 		job.EndDate = util.TimePtr(time.Now().UTC())
+		worker.updateJobState(ctx, job, domain.StateFinished)
 		worker.repository.SaveJob(ctx, *job)
 	}()
 
@@ -99,7 +138,37 @@ func (worker *JobWorker) executeJob(ctx context.Context, job *domain.Job, config
 	wg := new(sync.WaitGroup)
 	taskCounter := new(atomic.Int32)
 
-	for _, taskRun := range taskRuns {
+	for i := range taskRuns {
+		taskRun := &taskRuns[i]
+
+		// Recovered jobs keep the outcomes of tasks their previous worker resolved
+		if taskRun.State != domain.StatePending && taskRun.State != domain.StateRunning {
+			continue
+		}
+
+		// Conditions depend on earlier outcomes, so let in-flight tasks settle first
+		if taskRun.When != nil {
+			wg.Wait()
+
+			shouldRun, err := evaluateCondition(job, taskRuns, i)
+			if err != nil {
+				slog.WarnContext(ctx, "failed to evaluate task condition", slog.Any("error", err))
+				taskRun.Error = fmt.Sprintf("failed to evaluate condition: %v", err)
+				worker.finalizeTaskRun(ctx, job, taskRun, domain.StateError)
+				continue
+			}
+			if !shouldRun {
+				worker.finalizeTaskRun(ctx, job, taskRun, domain.StateSkipped)
+				continue
+			}
+		}
+
+		// Claims match the job's capabilities, this catches tasks registered differently across versions
+		if missing := missingCapabilities(worker.taskFactory.RequiredCapabilities(taskRun.TaskName), worker.config.Capabilities); len(missing) > 0 {
+			taskRun.Error = fmt.Sprintf("unschedulable: worker %s lacks capabilities %v", job.WorkerID, missing)
+			worker.finalizeTaskRun(ctx, job, taskRun, domain.StateError)
+			continue
+		}
 
 		if int(taskCounter.Load()) == config.MaxParallelTasks && (!config.EnableParallelTasks || !taskRun.Parallel) {
 			// Wait for current task(s)
@@ -114,17 +183,32 @@ func (worker *JobWorker) executeJob(ctx context.Context, job *domain.Job, config
 				taskCounter.Add(-1)
 			}()
 
-			errCh := make(chan error)
+			// Per-run timeout overrides the config default
+			timeout := config.TaskTimeout
+			if taskRun.Timeout > 0 {
+				timeout = taskRun.Timeout
+			}
+
+			errCh := make(chan error, 1)
 			taskRequest := &TaskRunRequest{
-				data:    &taskRun,
-				timeout: config.TaskTimeout,
-				errCh:   errCh,
+				data:        taskRun,
+				spanContext: trace.SpanContextFromContext(ctx),
+				timeout:     timeout,
+				priority:    taskRun.Priority,
+				tags:        job.Tags,
+				errCh:       errCh,
 			}
 
-			worker.taskCh <- *taskRequest
-			err := <-errCh
-			if err != nil {
-				// TODO: do something with the error?
+			if !worker.taskQueue.Push(*taskRequest) {
+				return
+			}
+
+			select {
+			case err := <-errCh:
+				if err != nil {
+					// TODO: do something with the error?
+				}
+			case <-ctx.Done():
 			}
 		}()
 	}
@@ -136,6 +220,41 @@ func (worker *JobWorker) executeJob(ctx context.Context, job *domain.Job, config
 }
 
 func (worker *JobWorker) updateJobState(ctx context.Context, job *domain.Job, state domain.ExecutionState) {
+	before := job.Status
 	job.State = state
 	slog.InfoContext(ctx, "job "+string(job.State))
+	worker.events.Publish(newJobEvent(before, job))
+}
+
+// expireJob ends a job that was claimed after its deadline to start, along with its TaskRuns
+func (worker *JobWorker) expireJob(ctx context.Context, job *domain.Job) {
+	taskRuns, err := worker.repository.GetTaskRuns(ctx, job.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to fetch taskRuns", slog.Any("error", err))
+	}
+	for i := range taskRuns {
+		if taskRuns[i].State == domain.StatePending {
+			worker.finalizeTaskRun(ctx, job, &taskRuns[i], domain.StateExpired)
+		}
+	}
+
+	job.EndDate = util.TimePtr(time.Now().UTC())
+	worker.updateJobState(ctx, job, domain.StateExpired)
+	if _, err := worker.repository.SaveJob(ctx, *job); err != nil {
+		slog.ErrorContext(ctx, "failed to save job", slog.Any("error", err))
+	}
+}
+
+// finalizeTaskRun records a TaskRun that was resolved without being dispatched to a TaskWorker
+func (worker *JobWorker) finalizeTaskRun(ctx context.Context, job *domain.Job, taskRun *domain.TaskRun, state domain.ExecutionState) {
+	ctx = context.WithValue(ctx, domain.LKeys.TaskID, taskRun.ID)
+	before := taskRun.Status()
+	taskRun.State = state
+	taskRun.EndDate = util.TimePtr(time.Now().UTC())
+	slog.InfoContext(ctx, "task "+string(taskRun.State))
+	worker.events.Publish(newTaskEvent(EventTaskFinished, before, taskRun, job.Tags))
+
+	if _, err := worker.repository.SaveTaskRun(ctx, *taskRun); err != nil {
+		slog.ErrorContext(ctx, "failed to save taskRun", slog.Any("error", err))
+	}
 }
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// LeaderElector elects one instance among those sharing the repository. Run calls lead with a
+// context that is cancelled once leadership is lost and returns when ctx is done.
+type LeaderElector interface {
+	Run(ctx context.Context, lead func(ctx context.Context))
+	IsLeader() bool
+}
+
+// soloElector always leads, for a single instance that does not share its repository
+type soloElector struct {
+	leader atomic.Bool
+}
+
+func (elector *soloElector) Run(ctx context.Context, lead func(ctx context.Context)) {
+	elector.leader.Store(true)
+	defer elector.leader.Store(false)
+	lead(ctx)
+}
+
+func (elector *soloElector) IsLeader() bool {
+	return elector.leader.Load()
+}
+
+// leaderDuty is a background loop that must run on exactly one instance
+type leaderDuty func(ctx context.Context)
+
+// runLeaderDuties runs every duty until ctx is cancelled and returns once all have stopped,
+// so a new leader never overlaps with the previous one's duties
+func runLeaderDuties(ctx context.Context, duties []leaderDuty) {
+	wg := new(sync.WaitGroup)
+	for _, duty := range duties {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			duty(ctx)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"slices"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+)
+
+// WorkerKind labels the worker pool metrics of a queue
+type WorkerKind string
+
+const (
+	JobWorkerKind  WorkerKind = "job"
+	TaskWorkerKind WorkerKind = "task"
+)
+
+// Metrics records the instrumentation of the service, see the metrics package for Prometheus
+type Metrics interface {
+	// JobSubmitted counts a submitted job, JobEnded counts a job by the state it ended in
+	JobSubmitted(job *domain.Job)
+	JobEnded(job *domain.Job)
+	// TaskExecuted observes how long an ended TaskRun ran over all of its attempts
+	TaskExecuted(taskRun *domain.TaskRun)
+	// ObserveJobQueue registers the depth of a queue's claimed jobs waiting for a JobWorker
+	ObserveJobQueue(queue string, depth func() int)
+	// A worker is idle once started, busy while it handles a job or task, and idle again after
+	WorkerStarted(queue string, kind WorkerKind)
+	WorkerStopped(queue string, kind WorkerKind)
+	WorkerBusy(queue string, kind WorkerKind)
+	WorkerIdle(queue string, kind WorkerKind)
+}
+
+// noopMetrics records nothing, for services without metrics
+type noopMetrics struct{}
+
+func (noopMetrics) JobSubmitted(job *domain.Job)                   {}
+func (noopMetrics) JobEnded(job *domain.Job)                       {}
+func (noopMetrics) TaskExecuted(taskRun *domain.TaskRun)           {}
+func (noopMetrics) ObserveJobQueue(queue string, depth func() int) {}
+func (noopMetrics) WorkerStarted(queue string, kind WorkerKind)    {}
+func (noopMetrics) WorkerStopped(queue string, kind WorkerKind)    {}
+func (noopMetrics) WorkerBusy(queue string, kind WorkerKind)       {}
+func (noopMetrics) WorkerIdle(queue string, kind WorkerKind)       {}
+
+// recordEventMetrics counts job lifecycle events. It observes the bus rather than subscribing,
+// so no event is dropped from the counts.
+func recordEventMetrics(metrics Metrics) func(event Event) {
+	return func(event Event) {
+		if !event.IsJobEvent() || event.Job == nil {
+			return
+		}
+		if event.Type == EventJobSubmitted {
+			metrics.JobSubmitted(event.Job)
+		} else if slices.Contains(domain.EndedStates, event.Job.State) {
+			metrics.JobEnded(event.Job)
+		}
+	}
+}
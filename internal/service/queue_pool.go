@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// queuePool is the JobWorkers and TaskWorkers serving one queue. Each queue has its own
+// task queue, so slow tasks of one queue never hold up the tasks of another.
+type queuePool struct {
+	*jobServiceDependencies
+	queue     QueueConfig
+	jobCh     chan uuid.UUID
+	taskQueue *taskQueue
+	poller    *jobPoller
+	pollerWg  *sync.WaitGroup
+}
+
+func newQueuePool(deps *jobServiceDependencies, workerID string, queue QueueConfig) *queuePool {
+	// The poller never claims more jobs than there are JobWorkers, so jobCh must hold that many
+	jobCh := make(chan uuid.UUID, max(deps.config.JobBufferCapacity, queue.JobWorkerCount))
+	deps.metrics.ObserveJobQueue(queue.Name, func() int {
+		return len(jobCh)
+	})
+
+	return &queuePool{
+		jobServiceDependencies: deps,
+		queue:                  queue,
+		jobCh:                  jobCh,
+		taskQueue:              newTaskQueue(),
+		poller:                 newJobPoller(deps.repository, workerID, queue, deps.config.Capabilities, deps.config.PollInterval, jobCh),
+		pollerWg:               new(sync.WaitGroup),
+	}
+}
+
+// Start runs the pool's workers, tracked by wg, and its poller until ctx is done
+func (pool *queuePool) Start(ctx context.Context, wg *sync.WaitGroup) {
+	// Start Task Workers
+	for i := 0; i < pool.queue.TaskWorkerCount; i++ {
+		worker := &TaskWorker{
+			jobServiceDependencies: pool.jobServiceDependencies,
+			queue:                  pool.queue.Name,
+			taskQueue:              pool.taskQueue,
+		}
+
+		wg.Add(1)
+		pool.metrics.WorkerStarted(pool.queue.Name, TaskWorkerKind)
+		go func() {
+			defer wg.Done()
+			defer pool.metrics.WorkerStopped(pool.queue.Name, TaskWorkerKind)
+			worker.Run(ctx)
+		}()
+	}
+
+	// Start Job Workers
+	for i := 0; i < pool.queue.JobWorkerCount; i++ {
+		worker := &JobWorker{
+			jobServiceDependencies: pool.jobServiceDependencies,
+			queue:                  pool.queue.Name,
+			jobCh:                  pool.jobCh,
+			taskQueue:              pool.taskQueue,
+			poller:                 pool.poller,
+		}
+
+		wg.Add(1)
+		pool.metrics.WorkerStarted(pool.queue.Name, JobWorkerKind)
+		go func() {
+			defer wg.Done()
+			defer pool.metrics.WorkerStopped(pool.queue.Name, JobWorkerKind)
+			worker.Run(ctx)
+		}()
+	}
+
+	// Claim pending jobs, including those submitted to other processes or left over from a restart
+	pool.pollerWg.Add(1)
+	go func() {
+		defer pool.pollerWg.Done()
+		pool.poller.Run(ctx)
+	}()
+
+	slog.InfoContext(ctx, "started workers", slog.String("queue", pool.queue.Name),
+		slog.Int("jobWorkerCount", pool.queue.JobWorkerCount), slog.Int("taskWorkerCount", pool.queue.TaskWorkerCount))
+}
+
+// Stop closes the pool's queues once its poller has returned, the poller's ctx must be done
+func (pool *queuePool) Stop() {
+	// The poller sends on jobCh, so it must stop before jobCh is closed
+	pool.pollerWg.Wait()
+	close(pool.jobCh)
+	pool.taskQueue.Close()
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/google/uuid"
+)
+
+// retentionRule pairs the jobs a rule purges with its report
+type retentionRule struct {
+	filter domain.PurgeFilter
+	report domain.PurgeRule
+}
+
+// purgeJobs is a leader duty, deleting ended jobs past their retention until ctx is done
+func (service *JobService) purgeJobs(ctx context.Context) {
+	ticker := time.NewTicker(service.config.Retention.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := service.PurgeJobs(ctx, false)
+			if err != nil {
+				if ctx.Err() == nil {
+					slog.ErrorContext(ctx, "failed to purge jobs", slog.Any("error", err))
+				}
+				continue
+			}
+			if report.Jobs > 0 {
+				slog.InfoContext(ctx, "purged jobs", slog.Int64("jobs", report.Jobs), slog.Int64("taskRuns", report.TaskRuns))
+			}
+		}
+	}
+}
+
+// PurgeJobs deletes ended jobs past their config's retention, or the global retention, in batches
+// and reports how many rows were removed. A dry run reports what would be purged instead, a job
+// matching both an age and the max jobs of its config is counted by each rule.
+func (service *JobService) PurgeJobs(ctx context.Context, dryRun bool) (*domain.PurgeReport, error) {
+	rules, err := service.retentionRules(ctx, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.PurgeReport{DryRun: dryRun, Rules: []domain.PurgeRule{}}
+	for _, rule := range rules {
+		if dryRun {
+			rule.report.Jobs, rule.report.TaskRuns, err = service.repository.CountPurgeableJobs(ctx, rule.filter)
+		} else {
+			err = service.purgeRule(ctx, &rule)
+		}
+		if err != nil {
+			return report, err
+		}
+
+		report.Jobs += rule.report.Jobs
+		report.TaskRuns += rule.report.TaskRuns
+		report.Rules = append(report.Rules, rule.report)
+	}
+	return report, nil
+}
+
+// purgeRule deletes the rule's jobs a batch at a time, so other writers are not held up
+func (service *JobService) purgeRule(ctx context.Context, rule *retentionRule) error {
+	batchSize := service.config.Retention.BatchSize
+	for {
+		jobIDs, err := service.repository.GetPurgeableJobs(ctx, rule.filter, batchSize)
+		if err != nil {
+			return err
+		}
+
+		// Jobs are only deleted once archived, a failed batch is archived again by the next pass
+		if service.archiver != nil && len(jobIDs) > 0 {
+			if err := service.archiveJobs(ctx, jobIDs); err != nil {
+				return err
+			}
+		}
+
+		jobs, taskRuns, err := service.repository.DeleteJobs(ctx, jobIDs)
+		if err != nil {
+			return err
+		}
+		rule.report.Jobs += jobs
+		rule.report.TaskRuns += taskRuns
+
+		if len(jobIDs) < batchSize || ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// retentionRules resolves the rules of every config with ended jobs, the config's retention
+// overrides the global retention per state. Jobs age out by the retention of the config version
+// they ran with, the max jobs of a config counts the jobs of every version and follows its latest.
+func (service *JobService) retentionRules(ctx context.Context, now time.Time) ([]retentionRule, error) {
+	refs, err := service.repository.GetEndedJobConfigVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []retentionRule
+	var configIDs []uuid.UUID
+	for _, ref := range refs {
+		jobConfig, err := service.repository.GetJobConfigVersion(ctx, ref.ConfigID, ref.Version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get config %s version %s: %w", ref.ConfigID, ref.Version, err)
+		}
+		if !slices.Contains(configIDs, ref.ConfigID) {
+			configIDs = append(configIDs, ref.ConfigID)
+		}
+
+		maxAge := service.config.Retention.MaxAgeByState()
+		if jobConfig != nil && jobConfig.Retention != nil {
+			for state, seconds := range jobConfig.Retention.MaxAge {
+				maxAge[state] = time.Duration(seconds) * time.Second
+			}
+		}
+
+		// Iterate states in order, so reports are stable
+		for _, state := range domain.EndedStates {
+			age, ok := maxAge[state]
+			if !ok {
+				continue
+			}
+			endedBefore := now.Add(-age)
+			rules = append(rules, retentionRule{
+				filter: domain.PurgeFilter{ConfigID: ref.ConfigID, ConfigVersion: ref.Version, State: state, EndedBefore: &endedBefore},
+				report: domain.PurgeRule{ConfigID: ref.ConfigID, ConfigVersion: ref.Version, State: state, MaxAge: int(age.Seconds())},
+			})
+		}
+	}
+
+	for _, configID := range configIDs {
+		jobConfig, err := service.repository.GetJobConfig(ctx, configID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get config %s: %w", configID, err)
+		}
+
+		maxJobs := service.config.Retention.MaxJobsPerConfig
+		if jobConfig != nil && jobConfig.Retention != nil && jobConfig.Retention.MaxJobs > 0 {
+			maxJobs = jobConfig.Retention.MaxJobs
+		}
+		if maxJobs > 0 {
+			rules = append(rules, retentionRule{
+				filter: domain.PurgeFilter{ConfigID: configID, KeepNewest: maxJobs},
+				report: domain.PurgeRule{ConfigID: configID, MaxJobs: maxJobs},
+			})
+		}
+	}
+	return rules, nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/mock"
+	"github.com/google/uuid"
+)
+
+// Jobs age out by the retention of the config version they ran with, not the latest version's
+func TestPurgeJobsByConfigVersion(t *testing.T) {
+	repo := mock.NewMockRepo()
+	service := &JobService{jobServiceDependencies: newTestDeps(t, repo)}
+	service.config.Retention = &RetentionConfig{BatchSize: 10, MaxAge: map[string]time.Duration{}}
+
+	ctx := context.Background()
+	configID := uuid.New()
+	expiring := domain.JobConfig{
+		IdentityVersion: domain.IdentityVersion{Identity: domain.Identity{ID: configID}, Version: uuid.New()},
+		JobConfigDetails: domain.JobConfigDetails{
+			Retention: &domain.RetentionPolicy{MaxAge: map[domain.ExecutionState]int{domain.StateFinished: 60}},
+		},
+	}
+	latest := domain.JobConfig{
+		IdentityVersion: domain.IdentityVersion{Identity: domain.Identity{ID: configID}, Version: uuid.New()},
+	}
+	for _, jobConfig := range []domain.JobConfig{expiring, latest} {
+		if _, err := repo.SaveJobConfig(ctx, jobConfig); err != nil {
+			t.Fatalf("SaveJobConfig: %v", err)
+		}
+	}
+
+	endDate := time.Now().UTC().Add(-time.Hour)
+	jobIDs := make(map[uuid.UUID]uuid.UUID)
+	for _, version := range []uuid.UUID{expiring.Version, latest.Version} {
+		job, err := repo.SaveJob(ctx, domain.Job{
+			ConfigID:      configID,
+			ConfigVersion: version,
+			Status:        domain.Status{State: domain.StateFinished},
+			EndDate:       &endDate,
+		})
+		if err != nil {
+			t.Fatalf("SaveJob: %v", err)
+		}
+		jobIDs[version] = job.ID
+	}
+
+	report, err := service.PurgeJobs(ctx, false)
+	if err != nil {
+		t.Fatalf("PurgeJobs: %v", err)
+	}
+	if report.Jobs != 1 || len(report.Rules) != 1 || report.Rules[0].ConfigVersion != expiring.Version {
+		t.Fatalf("report = %d jobs by %+v, want 1 job by a rule of version %s", report.Jobs, report.Rules, expiring.Version)
+	}
+	if job, _ := repo.GetJob(ctx, jobIDs[expiring.Version]); job != nil {
+		t.Errorf("job of the expiring version was kept")
+	}
+	if job, _ := repo.GetJob(ctx, jobIDs[latest.Version]); job == nil {
+		t.Errorf("job of the latest version was purged")
+	}
+}
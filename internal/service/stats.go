@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+)
+
+// ErrInvalidStatsRange is returned when a stats range does not end after it starts
+var ErrInvalidStatsRange = errors.New("invalid stats range")
+
+// DefaultStatsRange is how far back stats reach when the range has no start
+const DefaultStatsRange = 24 * time.Hour
+
+// GetStats aggregates the jobs and task runs of a time range, which ends now and spans
+// DefaultStatsRange unless given
+func (service *JobService) GetStats(ctx context.Context, statsRange domain.StatsRange) (*domain.Stats, error) {
+	if statsRange.To.IsZero() {
+		statsRange.To = time.Now().UTC()
+	}
+	if statsRange.From.IsZero() {
+		statsRange.From = statsRange.To.Add(-DefaultStatsRange)
+	}
+	if !statsRange.From.Before(statsRange.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidStatsRange)
+	}
+
+	return service.repository.GetStats(ctx, statsRange)
+}
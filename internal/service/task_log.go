@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/repository"
+	"github.com/google/uuid"
+)
+
+const taskLogLimitMessage = "task log limit reached, further logs are discarded"
+
+// taskLogEventBuffer buffers the finished task events used to release per-task counters
+const taskLogEventBuffer = 256
+
+// TaskLogHandler is a slog.Handler that tees records whose context carries a task ID into
+// the repository, then passes them on to the wrapped handler. Capture has its own minimum
+// level, so task debug logs can be stored without enabling them on stderr.
+type TaskLogHandler struct {
+	next   slog.Handler
+	writer *taskLogWriter
+	level  slog.Level
+	attrs  []slog.Attr
+	prefix string
+}
+
+func (h *TaskLogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.next.Enabled(ctx, level) {
+		return true
+	}
+	_, ok := ctxTaskID(ctx)
+	return ok && level >= h.level
+}
+
+func (h *TaskLogHandler) Handle(ctx context.Context, r slog.Record) error {
+	if taskRunID, ok := ctxTaskID(ctx); ok && r.Level >= h.level {
+		h.writer.enqueue(h.newTaskLog(ctx, taskRunID, r))
+	}
+
+	if !h.next.Enabled(ctx, r.Level) {
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *TaskLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithAttrs(attrs)
+	clone.attrs = append(clone.attrs[:len(clone.attrs):len(clone.attrs)], prefixAttrs(h.prefix, attrs)...)
+	return &clone
+}
+
+func (h *TaskLogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.next = h.next.WithGroup(name)
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
+
+func (h *TaskLogHandler) newTaskLog(ctx context.Context, taskRunID uuid.UUID, r slog.Record) domain.TaskLog {
+	attrs := make(map[string]any, len(h.attrs)+r.NumAttrs())
+	for _, attr := range h.attrs {
+		addLogAttr(attrs, "", attr)
+	}
+	r.Attrs(func(attr slog.Attr) bool {
+		addLogAttr(attrs, h.prefix, attr)
+		return true
+	})
+
+	jobID, _ := ctx.Value(domain.LKeys.JobID).(uuid.UUID)
+	return domain.TaskLog{
+		JobID:     jobID,
+		TaskRunID: taskRunID,
+		Time:      r.Time.UTC(),
+		Level:     r.Level.String(),
+		Message:   truncateUTF8(r.Message, h.writer.config.MaxMessageBytes),
+		Attrs:     attrs,
+	}
+}
+
+func ctxTaskID(ctx context.Context) (uuid.UUID, bool) {
+	if ctx == nil {
+		return uuid.Nil, false
+	}
+	taskRunID, ok := ctx.Value(domain.LKeys.TaskID).(uuid.UUID)
+	return taskRunID, ok && taskRunID != uuid.Nil
+}
+
+func prefixAttrs(prefix string, attrs []slog.Attr) []slog.Attr {
+	if prefix == "" {
+		return attrs
+	}
+	prefixed := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		prefixed[i] = slog.Attr{Key: prefix + attr.Key, Value: attr.Value}
+	}
+	return prefixed
+}
+
+// addLogAttr flattens groups into dotted keys and converts values to JSON-safe forms
+func addLogAttr(attrs map[string]any, prefix string, attr slog.Attr) {
+	value := attr.Value.Resolve()
+	if attr.Key == "" && value.Kind() != slog.KindGroup {
+		return
+	}
+
+	switch value.Kind() {
+	case slog.KindGroup:
+		groupPrefix := prefix
+		if attr.Key != "" {
+			groupPrefix = prefix + attr.Key + "."
+		}
+		for _, groupAttr := range value.Group() {
+			addLogAttr(attrs, groupPrefix, groupAttr)
+		}
+	case slog.KindDuration:
+		attrs[prefix+attr.Key] = value.Duration().String()
+	case slog.KindAny:
+		val := value.Any()
+		if err, ok := val.(error); ok {
+			attrs[prefix+attr.Key] = err.Error()
+		} else if _, err := json.Marshal(val); err != nil {
+			attrs[prefix+attr.Key] = fmt.Sprint(val)
+		} else {
+			attrs[prefix+attr.Key] = val
+		}
+	default:
+		attrs[prefix+attr.Key] = value.Any()
+	}
+}
+
+func truncateUTF8(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return strings.ToValidUTF8(s[:cut], "")
+}
+
+// taskLogWriter batches captured logs and writes them to the repository asynchronously.
+// Logs are dropped rather than blocking the task when the buffer is full.
+type taskLogWriter struct {
+	repository repository.TaskLogRepository
+	config     *TaskLogConfig
+	ch         chan domain.TaskLog
+	dropped    atomic.Uint64
+
+	mu     sync.Mutex
+	counts map[uuid.UUID]int
+}
+
+func newTaskLogWriter(repository repository.TaskLogRepository, config *TaskLogConfig) *taskLogWriter {
+	return &taskLogWriter{
+		repository: repository,
+		config:     config,
+		ch:         make(chan domain.TaskLog, config.BufferSize),
+		counts:     make(map[uuid.UUID]int),
+	}
+}
+
+func (writer *taskLogWriter) enqueue(taskLog domain.TaskLog) {
+	writer.mu.Lock()
+	count := writer.counts[taskLog.TaskRunID]
+	if count > writer.config.MaxLogsPerTask {
+		writer.mu.Unlock()
+		return
+	}
+	writer.counts[taskLog.TaskRunID] = count + 1
+	writer.mu.Unlock()
+
+	// Mark where the log was cut off
+	if count == writer.config.MaxLogsPerTask {
+		taskLog.Level = slog.LevelWarn.String()
+		taskLog.Message = taskLogLimitMessage
+		taskLog.Attrs = nil
+	}
+
+	select {
+	case writer.ch <- taskLog:
+	default:
+		writer.dropped.Add(1)
+	}
+}
+
+// Run writes batches until ctx is done, then flushes what is buffered. Finished task events
+// from sub release the per-task counters.
+func (writer *taskLogWriter) Run(ctx context.Context, sub *Subscription) {
+	ticker := time.NewTicker(writer.config.FlushInterval)
+	defer ticker.Stop()
+	defer sub.Close()
+
+	events := sub.Events()
+	batch := make([]domain.TaskLog, 0, writer.config.BatchSize)
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+		if err := writer.repository.SaveTaskLogs(ctx, batch); err != nil {
+			slog.ErrorContext(ctx, "failed to save task logs", slog.Int("count", len(batch)), slog.Any("error", err))
+		}
+		batch = batch[:0]
+
+		if dropped := writer.dropped.Swap(0); dropped > 0 {
+			slog.WarnContext(ctx, "task logs dropped, buffer full", slog.Uint64("dropped", dropped))
+		}
+	}
+
+	for {
+		select {
+		case taskLog := <-writer.ch:
+			batch = append(batch, taskLog)
+			if len(batch) >= writer.config.BatchSize {
+				flush(ctx)
+			}
+
+		case <-ticker.C:
+			flush(ctx)
+
+		case event, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if event.TaskRunID != nil {
+				writer.mu.Lock()
+				delete(writer.counts, *event.TaskRunID)
+				writer.mu.Unlock()
+			}
+
+		case <-ctx.Done():
+			// Drain what tasks logged while shutting down
+			flushCtx := context.WithoutCancel(ctx)
+			for {
+				select {
+				case taskLog := <-writer.ch:
+					batch = append(batch, taskLog)
+					if len(batch) >= writer.config.BatchSize {
+						flush(flushCtx)
+					}
+				default:
+					flush(flushCtx)
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/mock"
+	"github.com/google/uuid"
+)
+
+var testTaskLogConfig = TaskLogConfig{
+	Enabled:         true,
+	Level:           "DEBUG",
+	MaxMessageBytes: 16,
+	MaxLogsPerTask:  3,
+	BufferSize:      16,
+	BatchSize:       2,
+	FlushInterval:   10 * time.Millisecond,
+}
+
+func taskContext(jobID uuid.UUID, taskRunID uuid.UUID) context.Context {
+	ctx := context.WithValue(context.Background(), domain.LKeys.JobID, jobID)
+	return context.WithValue(ctx, domain.LKeys.TaskID, taskRunID)
+}
+
+// bufferedTaskLogs returns the logs waiting in the writer's buffer
+func bufferedTaskLogs(writer *taskLogWriter) []domain.TaskLog {
+	var taskLogs []domain.TaskLog
+	for {
+		select {
+		case taskLog := <-writer.ch:
+			taskLogs = append(taskLogs, taskLog)
+		default:
+			return taskLogs
+		}
+	}
+}
+
+func TestTaskLogHandlerCapturesTaskLogs(t *testing.T) {
+	config := testTaskLogConfig
+	writer := newTaskLogWriter(mock.NewMockRepo(), &config)
+
+	var output bytes.Buffer
+	next := slog.NewTextHandler(&output, &slog.HandlerOptions{Level: slog.LevelInfo})
+	logger := slog.New(&TaskLogHandler{next: next, writer: writer, level: config.SlogLevel()}).
+		With("worker", "w1").
+		WithGroup("request")
+
+	jobID, taskRunID := uuid.New(), uuid.New()
+	ctx := taskContext(jobID, taskRunID)
+
+	logger.DebugContext(ctx, "fetching", "url", "https://example.com", slog.Group("retry", "attempt", 2))
+	logger.InfoContext(context.Background(), "outside of a task")
+	logger.InfoContext(ctx, "a message longer than the limit")
+
+	taskLogs := bufferedTaskLogs(writer)
+	if len(taskLogs) != 2 {
+		t.Fatalf("captured %d logs, want 2 logged within the task", len(taskLogs))
+	}
+
+	debug := taskLogs[0]
+	if debug.JobID != jobID || debug.TaskRunID != taskRunID || debug.Level != "DEBUG" || debug.Message != "fetching" {
+		t.Errorf("debug log = %+v, want DEBUG fetching of the task", debug)
+	}
+	wantAttrs := map[string]any{"worker": "w1", "request.url": "https://example.com", "request.retry.attempt": int64(2)}
+	if !maps.Equal(debug.Attrs, wantAttrs) {
+		t.Errorf("attrs = %v, want %v", debug.Attrs, wantAttrs)
+	}
+	if message := taskLogs[1].Message; message != "a message longer" {
+		t.Errorf("message = %q, want it truncated to %d bytes", message, config.MaxMessageBytes)
+	}
+
+	// Capture has its own level, the wrapped handler still filters debug logs
+	if bytes.Contains(output.Bytes(), []byte("fetching")) {
+		t.Errorf("debug log reached the wrapped handler: %s", output.String())
+	}
+	if !bytes.Contains(output.Bytes(), []byte("outside of a task")) {
+		t.Errorf("info log did not reach the wrapped handler: %s", output.String())
+	}
+}
+
+func TestTaskLogWriterLimitsLogsPerTask(t *testing.T) {
+	config := testTaskLogConfig
+	writer := newTaskLogWriter(mock.NewMockRepo(), &config)
+
+	taskRunID := uuid.New()
+	for range config.MaxLogsPerTask + 2 {
+		writer.enqueue(domain.TaskLog{TaskRunID: taskRunID, Level: "INFO", Message: "working"})
+	}
+	writer.enqueue(domain.TaskLog{TaskRunID: uuid.New(), Level: "INFO", Message: "another task"})
+
+	taskLogs := bufferedTaskLogs(writer)
+	if len(taskLogs) != config.MaxLogsPerTask+2 {
+		t.Fatalf("buffered %d logs, want %d of the task, its limit marker and another task's", len(taskLogs), config.MaxLogsPerTask+2)
+	}
+	marker := taskLogs[config.MaxLogsPerTask]
+	if marker.Message != taskLogLimitMessage || marker.Level != "WARN" {
+		t.Errorf("log after the limit = %+v, want the limit marker", marker)
+	}
+	if taskLogs[len(taskLogs)-1].Message != "another task" {
+		t.Errorf("other task's log was not buffered")
+	}
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	tests := []struct {
+		s        string
+		maxBytes int
+		want     string
+	}{
+		{"short", 10, "short"},
+		{"exactly", 7, "exactly"},
+		{"truncated", 5, "trunc"},
+		// é is two bytes, it is dropped rather than split
+		{"café", 4, "caf"},
+		{"日本語", 4, "日"},
+	}
+
+	for _, test := range tests {
+		if got := truncateUTF8(test.s, test.maxBytes); got != test.want {
+			t.Errorf("truncateUTF8(%q, %d) = %q, want %q", test.s, test.maxBytes, got, test.want)
+		}
+	}
+}
+
+// Logs are saved in batches, what is buffered is saved on shutdown and finished tasks release their counts
+func TestTaskLogWriterRun(t *testing.T) {
+	config := testTaskLogConfig
+	config.FlushInterval = time.Hour
+	repo := mock.NewMockRepo()
+	writer := newTaskLogWriter(repo, &config)
+	bus := NewEventBus()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		writer.Run(ctx, bus.Subscribe(taskLogEventBuffer, nil))
+	}()
+
+	taskRunID := uuid.New()
+	for _, message := range []string{"one", "two", "three"} {
+		writer.enqueue(domain.TaskLog{TaskRunID: taskRunID, Level: "INFO", Message: message})
+	}
+
+	// The first batch is full and saved without waiting for the flush interval
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		saved, _ := repo.GetTaskLogs(ctx, taskRunID, 0, 10)
+		if len(saved) >= config.BatchSize {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("saved %d logs, want a batch of %d", len(saved), config.BatchSize)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	bus.Publish(Event{Type: EventTaskFinished, TaskRunID: &taskRunID})
+	for {
+		writer.mu.Lock()
+		_, counted := writer.counts[taskRunID]
+		writer.mu.Unlock()
+		if !counted {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("finished task's log count was not released")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	<-done
+
+	saved, _ := repo.GetTaskLogs(context.Background(), taskRunID, 0, 10)
+	if len(saved) != 3 || saved[2].Message != "three" {
+		t.Fatalf("saved %+v, want the 3 logs", saved)
+	}
+}
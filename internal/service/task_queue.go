@@ -0,0 +1,124 @@
+package service
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+)
+
+// taskQueue hands TaskRunRequests from JobWorkers to TaskWorkers, highest priority first.
+// Requests with equal priority are served in submission order.
+type taskQueue struct {
+	mu     sync.Mutex
+	items  taskHeap
+	seq    uint64
+	notify chan struct{}
+	closed bool
+}
+
+func newTaskQueue() *taskQueue {
+	return &taskQueue{
+		notify: make(chan struct{}, 1),
+	}
+}
+
+// Push adds a request to the queue. Requests pushed after Close are dropped.
+func (q *taskQueue) Push(request TaskRunRequest) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return false
+	}
+
+	q.seq++
+	heap.Push(&q.items, &queuedTask{request: request, seq: q.seq})
+	q.signal()
+	return true
+}
+
+// Pop blocks until a request is available, the queue is closed or ctx is done.
+func (q *taskQueue) Pop(ctx context.Context) (TaskRunRequest, bool) {
+	for {
+		q.mu.Lock()
+		if len(q.items) > 0 {
+			item := heap.Pop(&q.items).(*queuedTask)
+			// Wake another waiter if there is more work
+			if len(q.items) > 0 {
+				q.signal()
+			}
+			q.mu.Unlock()
+			return item.request, true
+		}
+		if q.closed {
+			q.mu.Unlock()
+			return TaskRunRequest{}, false
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.notify:
+		case <-ctx.Done():
+			return TaskRunRequest{}, false
+		}
+	}
+}
+
+// Len returns the number of requests waiting for a TaskWorker.
+func (q *taskQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+func (q *taskQueue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return
+	}
+	q.closed = true
+	close(q.notify)
+}
+
+// signal must be called with mu held. Once closed, the closed notify channel wakes all waiters.
+func (q *taskQueue) signal() {
+	if q.closed {
+		return
+	}
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+type queuedTask struct {
+	request TaskRunRequest
+	seq     uint64
+}
+
+// taskHeap implements heap.Interface ordered by priority (desc) then sequence (asc)
+type taskHeap []*queuedTask
+
+func (h taskHeap) Len() int { return len(h) }
+
+func (h taskHeap) Less(i, j int) bool {
+	if h[i].request.priority != h[j].request.priority {
+		return h[i].request.priority > h[j].request.priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h taskHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *taskHeap) Push(x any) { *h = append(*h, x.(*queuedTask)) }
+
+func (h *taskHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return item
+}
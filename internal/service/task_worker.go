@@ -5,99 +5,208 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/abikandiah/task-worker/internal/domain"
 	"github.com/abikandiah/task-worker/internal/util"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type TaskRunRequest struct {
-	data    *domain.TaskRun
-	timeout int
-	errCh   chan error
+	data *domain.TaskRun
+	// spanContext of the job, TaskWorkers run on their own context so it parents the task's spans
+	spanContext trace.SpanContext
+	timeout     int
+	priority    int
+	tags        []string
+	errCh       chan error
 }
 
 type TaskWorker struct {
 	*jobServiceDependencies
-	taskCh <-chan TaskRunRequest
+	queue     string
+	taskQueue *taskQueue
 }
 
 var ErrTaskTimedOut = errors.New("task timed out")
 
 func (worker *TaskWorker) Run(ctx context.Context) {
-	for request := range worker.taskCh {
+	for {
+		request, ok := worker.taskQueue.Pop(ctx)
+		if !ok {
+			return
+		}
 
 		if request.timeout <= 0 {
 			request.timeout = 60
 		}
-		ctx := context.WithValue(ctx, domain.LKeys.TaskID, request.data.ID)
-		request.errCh <- worker.runTask(ctx, request.data, request.timeout)
+		ctx := trace.ContextWithSpanContext(ctx, request.spanContext)
+		ctx = context.WithValue(ctx, domain.LKeys.JobID, request.data.JobID)
+		ctx = context.WithValue(ctx, domain.LKeys.TaskID, request.data.ID)
+		worker.metrics.WorkerBusy(worker.queue, TaskWorkerKind)
+		err := worker.runTask(ctx, request)
+		worker.metrics.WorkerIdle(worker.queue, TaskWorkerKind)
+		request.errCh <- err
 	}
 }
 
-func (worker *TaskWorker) runTask(ctx context.Context, taskRun *domain.TaskRun, timeout int) error {
-	// Execute task with timeout
+func (worker *TaskWorker) runTask(ctx context.Context, request TaskRunRequest) error {
+	taskRun, timeout, tags := request.data, request.timeout, request.tags
+
+	taskRun.StartDate = util.TimePtr(time.Now().UTC())
+	worker.updateTaskState(ctx, taskRun, domain.StateRunning, tags)
+	worker.repository.SaveTaskRun(ctx, *taskRun)
+
+	// Update ctx with task values
+	ctx = context.WithValue(ctx, domain.LKeys.TaskName, taskRun.Name)
+
+	// Attempts abandoned on timeout keep running and may still report progress, mu orders those
+	// reports with the writes below
+	mu := new(sync.Mutex)
+
+	maxAttempts, backoff := 1, time.Duration(0)
+	if taskRun.Retry != nil {
+		maxAttempts += taskRun.Retry.MaxRetries
+		backoff = time.Duration(taskRun.Retry.Backoff) * time.Second
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		mu.Lock()
+		taskRun.Attempts = attempt
+		mu.Unlock()
+
+		var res any
+		res, err = worker.runAttempt(ctx, taskRun, timeout, tags, mu)
+
+		mu.Lock()
+		if err == nil {
+			taskRun.Result = res
+			taskRun.Error = ""
+		} else {
+			taskRun.Error = err.Error()
+		}
+		mu.Unlock()
+		if err == nil || attempt >= maxAttempts || ctx.Err() != nil {
+			break
+		}
+
+		// Exponential backoff between attempts
+		delay := backoff * time.Duration(1<<(attempt-1))
+		slog.WarnContext(ctx, "task attempt failed, retrying", slog.Int("attempt", attempt),
+			slog.Duration("backoff", delay), slog.Any("error", err))
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+		}
+	}
+
+	// Finalize task
+	mu.Lock()
+	taskRun.EndDate = util.TimePtr(time.Now().UTC())
+	if err != nil {
+		slog.ErrorContext(ctx, "task failed", slog.Any("error", err))
+		worker.updateTaskState(ctx, taskRun, domain.StateError, tags)
+	} else {
+		taskRun.Progress = 1
+		worker.updateTaskState(ctx, taskRun, domain.StateFinished, tags)
+	}
+	worker.repository.SaveTaskRun(ctx, *taskRun)
+	worker.metrics.TaskExecuted(taskRun)
+	mu.Unlock()
+
+	return err
+}
+
+// runAttempt executes a single attempt of the task with timeout. The attempt runs on a copy of
+// taskRun and reports progress through mu, so an attempt abandoned on timeout can't race later ones.
+func (worker *TaskWorker) runAttempt(ctx context.Context, taskRun *domain.TaskRun, timeout int, tags []string, mu *sync.Mutex) (any, error) {
 	ctxTimeout, cancel := context.WithTimeoutCause(ctx, (time.Duration(timeout) * time.Second), ErrTaskTimedOut)
 	defer cancel()
 
-	errCh := make(chan error, 1)
+	mu.Lock()
+	attemptRun := *taskRun
+	mu.Unlock()
+
+	attemptCtx := domain.WithProgressReporter(ctxTimeout, func(progress float32) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		// Drop reports of attempts that were abandoned or retried
+		if ctxTimeout.Err() != nil || taskRun.Attempts != attemptRun.Attempts {
+			return
+		}
+		worker.updateTaskProgress(ctx, taskRun, progress, tags)
+	})
+
+	type attemptResult struct {
+		res any
+		err error
+	}
+
+	resCh := make(chan attemptResult, 1)
 	go func() {
-		errCh <- worker.ExecuteTask(ctxTimeout, taskRun)
+		res, err := worker.ExecuteTask(attemptCtx, &attemptRun)
+		resCh <- attemptResult{res: res, err: err}
 	}()
 
 	select {
-	case err := <-errCh:
-		if err != nil {
-			slog.ErrorContext(ctx, "task failed", slog.Any("error", err))
-			worker.updateTaskState(ctx, taskRun, domain.StateError)
-			worker.repository.SaveTaskRun(ctx, *taskRun)
-		}
-		return err
+	case result := <-resCh:
+		return result.res, result.err
 
 	case <-ctxTimeout.Done():
 		// Error that cancelled the context
 		cause := context.Cause(ctxTimeout)
 
 		if errors.Is(cause, ErrTaskTimedOut) {
-			return cause
+			return nil, cause
 		}
-		return fmt.Errorf("task interrupted by upstream cancellation: %w", cause)
+		return nil, fmt.Errorf("task interrupted by upstream cancellation: %w", cause)
 	}
 }
 
-func (worker *TaskWorker) ExecuteTask(ctx context.Context, taskRun *domain.TaskRun) error {
-	taskRun.StartDate = util.TimePtr(time.Now().UTC())
-	worker.updateTaskState(ctx, taskRun, domain.StateRunning)
-	worker.repository.SaveTaskRun(ctx, *taskRun)
-
-	// Finalize task in defer block
-	defer func() {
-		worker.updateTaskState(ctx, taskRun, domain.StateFinished)
-		taskRun.EndDate = util.TimePtr(time.Now().UTC())
-		worker.repository.SaveTaskRun(ctx, *taskRun)
-	}()
-
-	// Update ctx with task values
-	ctx = context.WithValue(ctx, domain.LKeys.TaskName, taskRun.Name)
+// ExecuteTask runs an attempt of the task in a span, which tasks can start their own spans under from ctx
+func (worker *TaskWorker) ExecuteTask(ctx context.Context, taskRun *domain.TaskRun) (res any, err error) {
+	ctx, span := tracer.Start(ctx, "TaskWorker.ExecuteTask", trace.WithAttributes(
+		attribute.String("task_run.id", taskRun.ID.String()),
+		attribute.String("task.name", taskRun.TaskName),
+		attribute.Int("task_run.attempt", taskRun.Attempts),
+	))
+	defer func() { endSpan(span, err) }()
 
 	task, err := worker.taskFactory.CreateTask(taskRun.TaskName, taskRun.Params)
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to create task", slog.Any("error", err))
-		return fmt.Errorf("failed to create task %s: %w", taskRun.TaskName, err)
+		return nil, fmt.Errorf("failed to create task %s: %w", taskRun.TaskName, err)
 	}
 
-	res, err := task.Execute(ctx)
+	res, err = task.Execute(ctx)
 	if err != nil {
 		slog.ErrorContext(ctx, "task failed", slog.Any("error", err))
-		return fmt.Errorf("task failed %s: %w", taskRun.TaskName, err)
+		return nil, fmt.Errorf("task failed %s: %w", taskRun.TaskName, err)
 	}
 
-	taskRun.Result = res
-
-	return nil
+	return res, nil
 }
 
-func (worker *TaskWorker) updateTaskState(ctx context.Context, taskRun *domain.TaskRun, state domain.ExecutionState) {
+func (worker *TaskWorker) updateTaskState(ctx context.Context, taskRun *domain.TaskRun, state domain.ExecutionState, tags []string) {
+	before := taskRun.Status()
 	taskRun.State = state
 	slog.InfoContext(ctx, "task "+string(taskRun.State))
+	worker.events.Publish(newTaskEvent(taskEventType(state), before, taskRun, tags))
+}
+
+func (worker *TaskWorker) updateTaskProgress(ctx context.Context, taskRun *domain.TaskRun, progress float32, tags []string) {
+	// Ignore reports from attempts that outlived their task
+	if ctx.Err() != nil || taskRun.State != domain.StateRunning {
+		return
+	}
+
+	before := taskRun.Status()
+	taskRun.Progress = progress
+	worker.repository.SaveTaskRun(ctx, *taskRun)
+	worker.events.Publish(newTaskEvent(EventTaskProgress, before, taskRun, tags))
 }
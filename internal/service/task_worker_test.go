@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/factory"
+	"github.com/abikandiah/task-worker/internal/mock"
+	"github.com/google/uuid"
+)
+
+type stubbornParams struct {
+	Length int
+}
+
+// stubbornTask ignores cancellation, it keeps reporting progress for Length milliseconds
+type stubbornTask struct {
+	length time.Duration
+}
+
+func (task *stubbornTask) Execute(ctx context.Context) (any, error) {
+	deadline := time.Now().Add(task.length)
+	for time.Now().Before(deadline) {
+		domain.ReportProgress(ctx, 0.5)
+		time.Sleep(time.Millisecond)
+	}
+	return nil, nil
+}
+
+// Attempts abandoned on timeout keep reporting progress, which must not race the retries or
+// overwrite the ended TaskRun. Run with -race.
+func TestRunTaskDropsProgressOfAbandonedAttempts(t *testing.T) {
+	repo := mock.NewMockRepo()
+	deps := newTestDeps(t, repo)
+	factory.Register(deps.taskFactory, "stubborn", func(params *stubbornParams, deps *struct{}) (domain.Task, error) {
+		return &stubbornTask{length: time.Duration(params.Length) * time.Millisecond}, nil
+	})
+	worker := &TaskWorker{jobServiceDependencies: deps, queue: domain.DefaultQueue, taskQueue: newTaskQueue()}
+
+	params, _ := json.Marshal(stubbornParams{Length: 1500})
+	taskRun := &domain.TaskRun{
+		Identity: domain.Identity{ID: uuid.New()},
+		JobID:    uuid.New(),
+		TaskName: "stubborn",
+		State:    domain.StatePending,
+		TaskRunDetails: domain.TaskRunDetails{
+			Params: params,
+			Retry:  &domain.RetryPolicy{MaxRetries: 1},
+		},
+	}
+
+	err := worker.runTask(context.Background(), TaskRunRequest{data: taskRun, timeout: 1})
+	if err == nil {
+		t.Fatal("runTask succeeded, want the attempts to time out")
+	}
+
+	// Let the abandoned attempts report until they stop
+	time.Sleep(time.Second)
+
+	saved, err := repo.GetTaskRun(context.Background(), taskRun.ID)
+	if err != nil || saved == nil {
+		t.Fatalf("GetTaskRun: %v", err)
+	}
+	if saved.State != domain.StateError {
+		t.Errorf("saved state = %s, want %s", saved.State, domain.StateError)
+	}
+	if saved.Attempts != 2 {
+		t.Errorf("saved attempts = %d, want 2", saved.Attempts)
+	}
+	if saved.EndDate == nil {
+		t.Error("saved taskRun has no end date")
+	}
+}
@@ -0,0 +1,351 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidTemplate is returned when a JobTemplateSubmission fails validation
+var ErrInvalidTemplate = errors.New("invalid job template")
+
+// ErrTemplateNotFound is returned when a template, or the requested version of it, does not exist
+var ErrTemplateNotFound = errors.New("job template not found")
+
+var (
+	parameterNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]{0,63}$`)
+	// parameterRefPattern matches a reference to a parameter in a task run's params
+	parameterRefPattern = regexp.MustCompile(`\{\{\s*params\.([a-zA-Z0-9_]+)\s*\}\}`)
+)
+
+func (service *JobService) CreateJobTemplate(ctx context.Context, submission *domain.JobTemplateSubmission) (*domain.JobTemplate, error) {
+	return service.saveJobTemplate(ctx, uuid.New(), submission)
+}
+
+// UpdateJobTemplate saves the submission as the template's new version, earlier versions are kept
+func (service *JobService) UpdateJobTemplate(ctx context.Context, templateID uuid.UUID, submission *domain.JobTemplateSubmission) (*domain.JobTemplate, error) {
+	existing, err := service.repository.GetJobTemplate(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, ErrTemplateNotFound
+	}
+	return service.saveJobTemplate(ctx, templateID, submission)
+}
+
+func (service *JobService) saveJobTemplate(ctx context.Context, templateID uuid.UUID, submission *domain.JobTemplateSubmission) (*domain.JobTemplate, error) {
+	if err := validateJobTemplate(submission); err != nil {
+		return nil, err
+	}
+	if submission.ConfigID == uuid.Nil && submission.ConfigVersion != uuid.Nil {
+		return nil, fmt.Errorf("%w: configVersion requires configId", ErrInvalidTemplate)
+	}
+	if submission.ConfigVersion != uuid.Nil {
+		jobConfig, err := service.repository.GetJobConfigVersion(ctx, submission.ConfigID, submission.ConfigVersion)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get config version: %w", err)
+		}
+		if jobConfig == nil {
+			return nil, fmt.Errorf("%w: config %s version %s not found", ErrInvalidTemplate, submission.ConfigID, submission.ConfigVersion)
+		}
+	} else if submission.ConfigID != uuid.Nil {
+		jobConfig, err := service.repository.GetJobConfig(ctx, submission.ConfigID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get config: %w", err)
+		}
+		if jobConfig == nil {
+			return nil, fmt.Errorf("%w: config %s not found", ErrInvalidTemplate, submission.ConfigID)
+		}
+	}
+
+	template := domain.JobTemplate{
+		IdentityVersion: domain.IdentityVersion{
+			Identity: domain.Identity{ID: templateID, IdentitySubmission: submission.IdentitySubmission},
+			Version:  uuid.New(),
+		},
+		CreateDate:         time.Now().UTC(),
+		JobTemplateDetails: submission.JobTemplateDetails,
+	}
+
+	// Templates define task runs, their state belongs to the jobs instantiated from them
+	template.TaskRuns = make([]domain.TaskRun, len(submission.TaskRuns))
+	for i, taskRun := range submission.TaskRuns {
+		template.TaskRuns[i] = domain.TaskRun{
+			Identity:       domain.Identity{IdentitySubmission: taskRun.IdentitySubmission},
+			TaskName:       taskRun.TaskName,
+			TaskRunDetails: taskRun.TaskRunDetails,
+		}
+		template.TaskRuns[i].Result = nil
+		template.TaskRuns[i].Progress = 0
+		template.TaskRuns[i].Attempts = 0
+	}
+
+	return service.repository.SaveJobTemplate(ctx, template)
+}
+
+// GetJobTemplate returns a version of a template, the latest when version is empty
+func (service *JobService) GetJobTemplate(ctx context.Context, templateID uuid.UUID, version uuid.UUID) (*domain.JobTemplate, error) {
+	var template *domain.JobTemplate
+	var err error
+	if version == uuid.Nil {
+		template, err = service.repository.GetJobTemplate(ctx, templateID)
+	} else {
+		template, err = service.repository.GetJobTemplateVersion(ctx, templateID, version)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, ErrTemplateNotFound
+	}
+	return template, nil
+}
+
+func (service *JobService) GetJobTemplateVersions(ctx context.Context, templateID uuid.UUID) ([]domain.JobTemplate, error) {
+	versions, err := service.repository.GetJobTemplateVersions(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, ErrTemplateNotFound
+	}
+	return versions, nil
+}
+
+func (service *JobService) GetAllJobTemplates(ctx context.Context, input *domain.CursorInput) (*domain.CursorOutput[domain.JobTemplate], error) {
+	return service.repository.GetAllJobTemplates(ctx, input)
+}
+
+// InstantiateTemplate submits a job from a version of a template, filling the inputs into
+// its task run params. The job references the template version it came from.
+func (service *JobService) InstantiateTemplate(ctx context.Context, templateID uuid.UUID, instantiation *domain.TemplateInstantiation) (*domain.Job, error) {
+	if len(instantiation.TaskRuns) > 0 {
+		return nil, fmt.Errorf("%w: taskRuns come from the template", ErrInvalidSubmission)
+	}
+
+	template, err := service.GetJobTemplate(ctx, templateID, instantiation.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := resolveTemplateInputs(template.Parameters, instantiation.Inputs)
+	if err != nil {
+		return nil, err
+	}
+	taskRuns, err := renderTemplateTaskRuns(template.TaskRuns, values)
+	if err != nil {
+		return nil, err
+	}
+
+	// The instantiation's job fields override the template's
+	submission := instantiation.JobSubmission
+	submission.TaskRuns = taskRuns
+	if submission.Name == "" {
+		submission.Name = template.Name
+	}
+	if submission.ConfigID == uuid.Nil {
+		submission.ConfigID = template.ConfigID
+		if submission.ConfigVersion == uuid.Nil {
+			submission.ConfigVersion = template.ConfigVersion
+		}
+	}
+	if submission.Queue == "" {
+		submission.Queue = template.Queue
+	}
+	submission.Tags = mergeTags(template.Tags, submission.Tags)
+
+	job, err := service.prepareJob(ctx, &submission, nil)
+	if err != nil {
+		return job, err
+	}
+	job.TemplateID = &template.ID
+	job.TemplateVersion = &template.Version
+
+	return service.saveSubmittedJob(ctx, job, &submission)
+}
+
+// validateJobTemplate checks the declared parameters and that task run params only reference them
+func validateJobTemplate(submission *domain.JobTemplateSubmission) error {
+	if submission.Name == "" {
+		return fmt.Errorf("%w: template name is required", ErrInvalidTemplate)
+	}
+	if len(submission.TaskRuns) == 0 {
+		return fmt.Errorf("%w: template taskRuns is required", ErrInvalidTemplate)
+	}
+	if err := validateTags(submission.Tags); err != nil {
+		return err
+	}
+	if submission.Queue != "" {
+		if err := validateQueueName(submission.Queue); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidTemplate, err)
+		}
+	}
+
+	declared := make(map[string]bool, len(submission.Parameters))
+	for i, parameter := range submission.Parameters {
+		if !parameterNamePattern.MatchString(parameter.Name) {
+			return fmt.Errorf("%w: parameter %d name must be a letter or underscore followed by up to 63 letters, digits or underscores", ErrInvalidTemplate, i)
+		}
+		if declared[parameter.Name] {
+			return fmt.Errorf("%w: parameter %s is declared more than once", ErrInvalidTemplate, parameter.Name)
+		}
+		declared[parameter.Name] = true
+
+		if !validParameterType(parameter.Type) {
+			return fmt.Errorf("%w: parameter %s has unknown type %q", ErrInvalidTemplate, parameter.Name, parameter.Type)
+		}
+		if parameter.Default != nil && !parameterValueMatches(parameter.Type, parameter.Default) {
+			return fmt.Errorf("%w: parameter %s default is not of type %s", ErrInvalidTemplate, parameter.Name, parameter.Type)
+		}
+	}
+
+	for i, taskRun := range submission.TaskRuns {
+		for _, match := range parameterRefPattern.FindAllSubmatch(taskRun.Params, -1) {
+			if name := string(match[1]); !declared[name] {
+				return fmt.Errorf("%w: taskRun %d params reference undeclared parameter %s", ErrInvalidTemplate, i, name)
+			}
+		}
+	}
+	return validateTaskConditions(submission.TaskRuns)
+}
+
+func validParameterType(parameterType domain.ParameterType) bool {
+	switch parameterType {
+	case domain.ParameterString, domain.ParameterNumber, domain.ParameterInteger,
+		domain.ParameterBoolean, domain.ParameterObject, domain.ParameterArray:
+		return true
+	}
+	return false
+}
+
+// parameterValueMatches reports whether a value decoded from JSON has the parameter's type
+func parameterValueMatches(parameterType domain.ParameterType, value any) bool {
+	switch v := value.(type) {
+	case string:
+		return parameterType == domain.ParameterString
+	case bool:
+		return parameterType == domain.ParameterBoolean
+	case float64:
+		return parameterType == domain.ParameterNumber ||
+			(parameterType == domain.ParameterInteger && v == math.Trunc(v))
+	case map[string]any:
+		return parameterType == domain.ParameterObject
+	case []any:
+		return parameterType == domain.ParameterArray
+	}
+	return false
+}
+
+// resolveTemplateInputs checks the inputs against the declared parameters and fills in defaults.
+// Optional parameters without a default or input are null.
+func resolveTemplateInputs(parameters []domain.TemplateParameter, inputs map[string]any) (map[string]any, error) {
+	for name := range inputs {
+		if !slices.ContainsFunc(parameters, func(parameter domain.TemplateParameter) bool {
+			return parameter.Name == name
+		}) {
+			return nil, fmt.Errorf("%w: unknown input %s", ErrInvalidSubmission, name)
+		}
+	}
+
+	values := make(map[string]any, len(parameters))
+	for _, parameter := range parameters {
+		value, ok := inputs[parameter.Name]
+		if !ok || value == nil {
+			if parameter.Required {
+				return nil, fmt.Errorf("%w: input %s is required", ErrInvalidSubmission, parameter.Name)
+			}
+			values[parameter.Name] = parameter.Default
+			continue
+		}
+		if !parameterValueMatches(parameter.Type, value) {
+			return nil, fmt.Errorf("%w: input %s must be of type %s", ErrInvalidSubmission, parameter.Name, parameter.Type)
+		}
+		values[parameter.Name] = value
+	}
+	return values, nil
+}
+
+// renderTemplateTaskRuns copies the template's task runs, substituting parameter values into their params
+func renderTemplateTaskRuns(templateRuns []domain.TaskRun, values map[string]any) ([]domain.TaskRun, error) {
+	taskRuns := slices.Clone(templateRuns)
+	for i := range taskRuns {
+		if len(taskRuns[i].Params) == 0 {
+			continue
+		}
+
+		// Keep numbers as written, params may hold integers beyond float64 precision
+		decoder := json.NewDecoder(bytes.NewReader(taskRuns[i].Params))
+		decoder.UseNumber()
+		var params any
+		if err := decoder.Decode(&params); err != nil {
+			return nil, fmt.Errorf("failed to decode params of template taskRun %d: %w", i, err)
+		}
+
+		rendered, err := json.Marshal(substituteParameters(params, values))
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode params of taskRun %d: %w", i, err)
+		}
+		taskRuns[i].Params = rendered
+	}
+	return taskRuns, nil
+}
+
+// substituteParameters replaces parameter references in the strings of a decoded JSON value.
+// A string that is only a reference becomes the value itself, keeping its type, references
+// within longer strings are replaced by the value's text.
+func substituteParameters(value any, values map[string]any) any {
+	switch v := value.(type) {
+	case string:
+		if match := parameterRefPattern.FindStringSubmatch(v); match != nil && match[0] == strings.TrimSpace(v) {
+			return values[match[1]]
+		}
+		return parameterRefPattern.ReplaceAllStringFunc(v, func(ref string) string {
+			return parameterText(values[parameterRefPattern.FindStringSubmatch(ref)[1]])
+		})
+	case map[string]any:
+		for key, item := range v {
+			v[key] = substituteParameters(item, values)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = substituteParameters(item, values)
+		}
+		return v
+	}
+	return value
+}
+
+// parameterText is a value as it appears within a string, strings as is and other values as JSON
+func parameterText(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// mergeTags appends the tags not already in base
+func mergeTags(base []string, tags []string) []string {
+	merged := slices.Clone(base)
+	for _, tag := range tags {
+		if !slices.Contains(merged, tag) {
+			merged = append(merged, tag)
+		}
+	}
+	return merged
+}
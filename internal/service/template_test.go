@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/mock"
+)
+
+func testTemplateSubmission(params string) *domain.JobTemplateSubmission {
+	return &domain.JobTemplateSubmission{
+		IdentitySubmission: domain.IdentitySubmission{Name: "report"},
+		JobTemplateDetails: domain.JobTemplateDetails{
+			Parameters: []domain.TemplateParameter{
+				{Name: "account", Type: domain.ParameterString, Required: true},
+				{Name: "days", Type: domain.ParameterInteger, Default: float64(7)},
+			},
+			Tags:     []string{"reports"},
+			TaskRuns: []domain.TaskRun{{TaskName: "noop", TaskRunDetails: domain.TaskRunDetails{Params: json.RawMessage(params)}}},
+		},
+	}
+}
+
+func TestValidateJobTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(submission *domain.JobTemplateSubmission)
+		wantErr bool
+	}{
+		{"valid", func(submission *domain.JobTemplateSubmission) {}, false},
+		{"missing name", func(submission *domain.JobTemplateSubmission) { submission.Name = "" }, true},
+		{"no task runs", func(submission *domain.JobTemplateSubmission) { submission.TaskRuns = nil }, true},
+		{"invalid parameter name", func(submission *domain.JobTemplateSubmission) { submission.Parameters[0].Name = "1st" }, true},
+		{"duplicate parameter", func(submission *domain.JobTemplateSubmission) { submission.Parameters[1].Name = "account" }, true},
+		{"unknown type", func(submission *domain.JobTemplateSubmission) { submission.Parameters[0].Type = "date" }, true},
+		{"default of another type", func(submission *domain.JobTemplateSubmission) { submission.Parameters[1].Default = 1.5 }, true},
+		{"undeclared reference", func(submission *domain.JobTemplateSubmission) {
+			submission.TaskRuns[0].Params = json.RawMessage(`{"to": "{{ params.email }}"}`)
+		}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			submission := testTemplateSubmission(`{"account": "{{ params.account }}"}`)
+			test.modify(submission)
+
+			err := validateJobTemplate(submission)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("validateJobTemplate() = %v, want error %t", err, test.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidTemplate) {
+				t.Errorf("error %v is not ErrInvalidTemplate", err)
+			}
+		})
+	}
+}
+
+func TestResolveTemplateInputs(t *testing.T) {
+	parameters := testTemplateSubmission("").Parameters
+	parameters = append(parameters, domain.TemplateParameter{Name: "filters", Type: domain.ParameterObject})
+
+	tests := []struct {
+		name    string
+		inputs  map[string]any
+		want    map[string]any
+		wantErr bool
+	}{
+		{"defaults", map[string]any{"account": "a1"}, map[string]any{"account": "a1", "days": float64(7), "filters": nil}, false},
+		{"inputs", map[string]any{"account": "a1", "days": float64(30)}, map[string]any{"account": "a1", "days": float64(30), "filters": nil}, false},
+		{"missing required", map[string]any{"days": float64(30)}, nil, true},
+		{"null required", map[string]any{"account": nil}, nil, true},
+		{"unknown input", map[string]any{"account": "a1", "email": "x"}, nil, true},
+		{"wrong type", map[string]any{"account": float64(1)}, nil, true},
+		{"fractional integer", map[string]any{"account": "a1", "days": 1.5}, nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			values, err := resolveTemplateInputs(parameters, test.inputs)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("resolveTemplateInputs() = %v, want error %t", err, test.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrInvalidSubmission) {
+					t.Errorf("error %v is not ErrInvalidSubmission", err)
+				}
+				return
+			}
+			if len(values) != len(test.want) {
+				t.Fatalf("values = %v, want %v", values, test.want)
+			}
+			for name, want := range test.want {
+				if values[name] != want {
+					t.Errorf("%s = %v, want %v", name, values[name], want)
+				}
+			}
+		})
+	}
+}
+
+// A string that is only a reference takes the value's type, references within text become text,
+// and numbers the template wrote keep their precision
+func TestRenderTemplateTaskRuns(t *testing.T) {
+	templateRuns := []domain.TaskRun{
+		{TaskName: "noop", TaskRunDetails: domain.TaskRunDetails{
+			Params: json.RawMessage(`{"days": "{{ params.days }}", "subject": "Last {{params.days}} days of {{ params.account }}", "ids": [12345678901234567890, "{{ params.filters }}"]}`),
+		}},
+		{TaskName: "noop"},
+	}
+	values := map[string]any{"account": "a1", "days": float64(30), "filters": map[string]any{"state": "open"}}
+
+	taskRuns, err := renderTemplateTaskRuns(templateRuns, values)
+	if err != nil {
+		t.Fatalf("renderTemplateTaskRuns: %v", err)
+	}
+
+	want := `{"days":30,"ids":[12345678901234567890,{"state":"open"}],"subject":"Last 30 days of a1"}`
+	if got := string(taskRuns[0].Params); got != want {
+		t.Errorf("params = %s, want %s", got, want)
+	}
+	if len(taskRuns[1].Params) != 0 {
+		t.Errorf("params of a task run without params = %s, want none", taskRuns[1].Params)
+	}
+	if string(templateRuns[0].Params) == want {
+		t.Error("rendering modified the template's task runs")
+	}
+}
+
+// Jobs are instantiated from the requested template version and reference it
+func TestInstantiateTemplate(t *testing.T) {
+	repo := mock.NewMockRepo()
+	service := &JobService{jobServiceDependencies: newTestDeps(t, repo)}
+	ctx := context.Background()
+
+	first, err := service.CreateJobTemplate(ctx, testTemplateSubmission(`{"account": "{{ params.account }}", "days": "{{ params.days }}"}`))
+	if err != nil {
+		t.Fatalf("CreateJobTemplate: %v", err)
+	}
+	if _, err := service.UpdateJobTemplate(ctx, first.ID, testTemplateSubmission(`{"account": "{{ params.account }}"}`)); err != nil {
+		t.Fatalf("UpdateJobTemplate: %v", err)
+	}
+	if versions, err := service.GetJobTemplateVersions(ctx, first.ID); err != nil || len(versions) != 2 {
+		t.Fatalf("GetJobTemplateVersions = %d versions, %v, want 2", len(versions), err)
+	}
+
+	instantiation := &domain.TemplateInstantiation{
+		JobSubmission: domain.JobSubmission{Tags: []string{"manual", "reports"}},
+		Version:       first.Version,
+		Inputs:        map[string]any{"account": "a1"},
+	}
+	job, err := service.InstantiateTemplate(ctx, first.ID, instantiation)
+	if err != nil {
+		t.Fatalf("InstantiateTemplate: %v", err)
+	}
+	if job.Name != "report" || *job.TemplateID != first.ID || *job.TemplateVersion != first.Version {
+		t.Errorf("job %q of template %v version %v, want report of %s version %s", job.Name, job.TemplateID, job.TemplateVersion, first.ID, first.Version)
+	}
+	if !slices.Equal(job.Tags, []string{"reports", "manual"}) {
+		t.Errorf("tags = %v, want the template's merged with the instantiation's", job.Tags)
+	}
+
+	taskRuns, err := repo.GetTaskRuns(ctx, job.ID)
+	if err != nil || len(taskRuns) != 1 {
+		t.Fatalf("GetTaskRuns = %v, %v, want 1", taskRuns, err)
+	}
+	if want := `{"account":"a1","days":7}`; string(taskRuns[0].Params) != want {
+		t.Errorf("params = %s, want %s", taskRuns[0].Params, want)
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		if _, err := service.InstantiateTemplate(ctx, first.ID, &domain.TemplateInstantiation{}); !errors.Is(err, ErrInvalidSubmission) {
+			t.Errorf("instantiating without a required input = %v, want ErrInvalidSubmission", err)
+		}
+		withTaskRuns := &domain.TemplateInstantiation{JobSubmission: domain.JobSubmission{TaskRuns: []domain.TaskRun{{TaskName: "noop"}}}}
+		if _, err := service.InstantiateTemplate(ctx, first.ID, withTaskRuns); !errors.Is(err, ErrInvalidSubmission) {
+			t.Errorf("instantiating with task runs = %v, want ErrInvalidSubmission", err)
+		}
+		unknownVersion := &domain.TemplateInstantiation{Version: first.ID, Inputs: map[string]any{"account": "a1"}}
+		if _, err := service.InstantiateTemplate(ctx, first.ID, unknownVersion); !errors.Is(err, ErrTemplateNotFound) {
+			t.Errorf("instantiating an unknown version = %v, want ErrTemplateNotFound", err)
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer starts the service's spans from the global provider, which is a no-op unless tracing is set up
+var tracer = otel.Tracer("github.com/abikandiah/task-worker/internal/service")
+
+// traceContext returns the trace context of ctx's span, to be persisted on a job
+func traceContext(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+// withJobTrace continues the trace a job was submitted in, so its execution links back to the submission
+func withJobTrace(ctx context.Context, job *domain.Job) context.Context {
+	if len(job.TraceContext) == 0 {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(job.TraceContext))
+}
+
+// traceJobQueued records the time a job waited between its submission and a JobWorker picking it up
+func traceJobQueued(ctx context.Context, job *domain.Job) {
+	_, span := tracer.Start(ctx, "job queued", trace.WithTimestamp(job.SubmitDate), trace.WithAttributes(jobAttributes(job)...))
+	span.End()
+}
+
+func jobAttributes(job *domain.Job) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("job.id", job.ID.String()),
+		attribute.String("job.name", job.Name),
+		attribute.String("job.queue", job.Queue),
+		attribute.String("job.config_id", job.ConfigID.String()),
+	}
+}
+
+// endSpan ends a span, marking it failed with err
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
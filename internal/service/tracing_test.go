@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/mock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// A job carries the trace it was submitted in, which its execution continues
+func TestJobTraceContext(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+		provider.Shutdown(context.Background())
+	})
+
+	repo := mock.NewMockRepo()
+	service := &JobService{jobServiceDependencies: newTestDeps(t, repo)}
+
+	ctx, request := provider.Tracer("test").Start(context.Background(), "request")
+	job, err := service.SubmitJob(ctx, &domain.JobSubmission{})
+	request.End()
+	if err != nil {
+		t.Fatalf("SubmitJob: %v", err)
+	}
+	saved, _ := repo.GetJob(context.Background(), job.ID)
+	if len(saved.TraceContext) == 0 {
+		t.Fatal("submitted job has no trace context")
+	}
+
+	// A worker picks the job up without the request's context
+	traceJobQueued(withJobTrace(context.Background(), saved), saved)
+
+	traceID := request.SpanContext().TraceID()
+	var names []string
+	for _, span := range recorder.Ended() {
+		names = append(names, span.Name())
+		if span.SpanContext().TraceID() != traceID {
+			t.Errorf("span %q is not in the request's trace", span.Name())
+		}
+		if span.Name() == "job queued" && !span.StartTime().Equal(saved.SubmitDate) {
+			t.Errorf("job queued starts at %s, want the submit date %s", span.StartTime(), saved.SubmitDate)
+		}
+	}
+	if len(names) != 3 || names[0] != "JobService.SubmitJob" || names[2] != "job queued" {
+		t.Errorf("spans = %v, want the submission, the request and the job queued", names)
+	}
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+)
+
+// ErrInvalidSubmission is returned when a JobSubmission fails validation
+var ErrInvalidSubmission = errors.New("invalid job submission")
+
+// ErrConcurrencyConflict is returned when a submission rejects duplicates and its concurrency key is at its limit
+var ErrConcurrencyConflict = errors.New("concurrency key in use")
+
+const (
+	maxTagLength            = 64
+	maxConcurrencyKeyLength = 255
+)
+
+// validateRequiredFields checks the fields every submission must set
+func validateRequiredFields(submission *domain.JobSubmission) error {
+	if submission.Name == "" {
+		return fmt.Errorf("%w: job name is required", ErrInvalidSubmission)
+	}
+	if len(submission.TaskRuns) == 0 {
+		return fmt.Errorf("%w: job taskRuns is required", ErrInvalidSubmission)
+	}
+	return nil
+}
+
+// validateTaskRunOverrides checks per-run timeout, retry and priority overrides against
+// the maximums allowed by the job's config.
+func validateTaskRunOverrides(taskRuns []domain.TaskRun, config *domain.JobConfig) error {
+	maxTimeout := config.MaxTaskTimeout
+	if maxTimeout <= 0 {
+		maxTimeout = config.TaskTimeout
+	}
+	maxPriority := config.TaskPriorityLimit()
+	maxRetries := config.TaskRetryLimit()
+
+	for i, taskRun := range taskRuns {
+		if taskRun.Timeout < 0 || taskRun.Timeout > maxTimeout {
+			return fmt.Errorf("%w: taskRun %d timeout must be between 0 and %d", ErrInvalidSubmission, i, maxTimeout)
+		}
+		if taskRun.Priority < 0 || taskRun.Priority > maxPriority {
+			return fmt.Errorf("%w: taskRun %d priority must be between 0 and %d", ErrInvalidSubmission, i, maxPriority)
+		}
+		if retry := taskRun.Retry; retry != nil {
+			if retry.MaxRetries < 0 || retry.MaxRetries > maxRetries {
+				return fmt.Errorf("%w: taskRun %d maxRetries must be between 0 and %d", ErrInvalidSubmission, i, maxRetries)
+			}
+			if retry.Backoff < 0 {
+				return fmt.Errorf("%w: taskRun %d backoff cannot be negative", ErrInvalidSubmission, i)
+			}
+		}
+	}
+	return nil
+}
+
+// validateCallbacks checks that callback URLs are absolute http(s) URLs and that event
+// filters only name job events.
+func validateCallbacks(callbacks []domain.WebhookCallback) error {
+	for i, callback := range callbacks {
+		parsed, err := url.Parse(callback.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("%w: callback %d url must be an absolute http(s) url", ErrInvalidSubmission, i)
+		}
+		for _, eventType := range callback.Events {
+			if !slices.Contains(webhookEventTypes, EventType(eventType)) {
+				return fmt.Errorf("%w: callback %d has unknown event %q", ErrInvalidSubmission, i, eventType)
+			}
+		}
+	}
+	return nil
+}
+
+// validateTags rejects empty and oversized tags
+func validateTags(tags []string) error {
+	for i, tag := range tags {
+		if tag == "" || len(tag) > maxTagLength {
+			return fmt.Errorf("%w: tag %d must be between 1 and %d characters", ErrInvalidSubmission, i, maxTagLength)
+		}
+	}
+	return nil
+}
+
+// validateConcurrency checks the concurrency key, limit and policy of a submission
+func validateConcurrency(submission *domain.JobSubmission) error {
+	if submission.ConcurrencyKey == "" {
+		if submission.ConcurrencyLimit != 0 || submission.ConcurrencyPolicy != "" {
+			return fmt.Errorf("%w: concurrencyLimit and concurrencyPolicy require a concurrencyKey", ErrInvalidSubmission)
+		}
+		return nil
+	}
+
+	if len(submission.ConcurrencyKey) > maxConcurrencyKeyLength {
+		return fmt.Errorf("%w: concurrencyKey must be at most %d characters", ErrInvalidSubmission, maxConcurrencyKeyLength)
+	}
+	if submission.ConcurrencyLimit < 0 {
+		return fmt.Errorf("%w: concurrencyLimit cannot be negative", ErrInvalidSubmission)
+	}
+	switch submission.ConcurrencyPolicy {
+	case "", domain.ConcurrencyQueue, domain.ConcurrencyReplace, domain.ConcurrencyReject:
+	default:
+		return fmt.Errorf("%w: unknown concurrencyPolicy %q", ErrInvalidSubmission, submission.ConcurrencyPolicy)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/util"
+)
+
+func TestValidateTaskRunOverrides(t *testing.T) {
+	// A config saved before the override bounds existed has none of them set
+	legacy := &domain.JobConfig{JobConfigDetails: domain.JobConfigDetails{TaskTimeout: 60}}
+	bounded := &domain.JobConfig{JobConfigDetails: domain.JobConfigDetails{
+		TaskTimeout: 60, MaxTaskTimeout: 300, MaxTaskRetries: util.IntPtr(1), MaxTaskPriority: util.IntPtr(5),
+	}}
+	// Bounds of 0 are enforced rather than falling back to the defaults
+	zero := &domain.JobConfig{JobConfigDetails: domain.JobConfigDetails{
+		TaskTimeout: 60, MaxTaskRetries: util.IntPtr(0), MaxTaskPriority: util.IntPtr(0),
+	}}
+
+	tests := []struct {
+		name    string
+		config  *domain.JobConfig
+		taskRun domain.TaskRun
+		wantErr bool
+	}{
+		{"no overrides", legacy, domain.TaskRun{}, false},
+		{"legacy timeout up to the task timeout", legacy, domain.TaskRun{TaskRunDetails: domain.TaskRunDetails{Timeout: 60}}, false},
+		{"legacy timeout over the task timeout", legacy, domain.TaskRun{TaskRunDetails: domain.TaskRunDetails{Timeout: 61}}, true},
+		{"legacy priority up to the default", legacy, domain.TaskRun{TaskRunDetails: domain.TaskRunDetails{Priority: domain.DefaultMaxTaskPriority}}, false},
+		{"legacy priority over the default", legacy, domain.TaskRun{TaskRunDetails: domain.TaskRunDetails{Priority: domain.DefaultMaxTaskPriority + 1}}, true},
+		{"legacy retries up to the default", legacy, domain.TaskRun{TaskRunDetails: domain.TaskRunDetails{
+			Retry: &domain.RetryPolicy{MaxRetries: domain.DefaultMaxTaskRetries}}}, false},
+		{"legacy retries over the default", legacy, domain.TaskRun{TaskRunDetails: domain.TaskRunDetails{
+			Retry: &domain.RetryPolicy{MaxRetries: domain.DefaultMaxTaskRetries + 1}}}, true},
+		{"timeout up to the config's", bounded, domain.TaskRun{TaskRunDetails: domain.TaskRunDetails{Timeout: 300}}, false},
+		{"priority over the config's", bounded, domain.TaskRun{TaskRunDetails: domain.TaskRunDetails{Priority: 6}}, true},
+		{"retries over the config's", bounded, domain.TaskRun{TaskRunDetails: domain.TaskRunDetails{
+			Retry: &domain.RetryPolicy{MaxRetries: 2}}}, true},
+		{"zero bounds without overrides", zero, domain.TaskRun{TaskRunDetails: domain.TaskRunDetails{
+			Retry: &domain.RetryPolicy{MaxRetries: 0, Backoff: 5}}}, false},
+		{"priority over a zero bound", zero, domain.TaskRun{TaskRunDetails: domain.TaskRunDetails{Priority: 1}}, true},
+		{"retries over a zero bound", zero, domain.TaskRun{TaskRunDetails: domain.TaskRunDetails{
+			Retry: &domain.RetryPolicy{MaxRetries: 1}}}, true},
+		{"negative priority", bounded, domain.TaskRun{TaskRunDetails: domain.TaskRunDetails{Priority: -1}}, true},
+		{"negative backoff", bounded, domain.TaskRun{TaskRunDetails: domain.TaskRunDetails{
+			Retry: &domain.RetryPolicy{MaxRetries: 1, Backoff: -1}}}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateTaskRunOverrides([]domain.TaskRun{test.taskRun}, test.config)
+			if test.wantErr != (err != nil) {
+				t.Fatalf("validateTaskRunOverrides error = %v, want error %v", err, test.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidSubmission) {
+				t.Errorf("validateTaskRunOverrides error = %v, want ErrInvalidSubmission", err)
+			}
+		})
+	}
+}
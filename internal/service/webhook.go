@@ -0,0 +1,329 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/util"
+)
+
+// Headers sent with every webhook delivery. The signature is
+// "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body)).
+const (
+	WebhookIDHeader        = "X-Webhook-Id"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// webhookEventTypes are the events callbacks can filter on
+var webhookEventTypes = []EventType{
+	EventJobSubmitted,
+	EventJobStarted,
+	EventJobFinished,
+	EventJobFailed,
+	EventJobCancelled,
+	EventJobExpired,
+}
+
+// errWebhookAddressBlocked is returned when a callback resolves to an address outside the
+// public internet, such as loopback, private networks or the cloud metadata endpoint
+var errWebhookAddressBlocked = errors.New("webhook address is not public")
+
+// errWebhookUnsigned is returned when a callback would be sent without a signature that the
+// config does not allow
+var errWebhookUnsigned = errors.New("webhook secret is not set and unsigned callbacks are not allowed")
+
+// sharedAddressSpace is the carrier-grade NAT range, which netip does not treat as private
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// webhookEventBuffer is large so bursts of job events are recorded rather than dropped
+const webhookEventBuffer = 1024
+
+// WebhookDispatcher records a delivery for every job event matching a job's callbacks and
+// POSTs them, retrying with backoff. Deliveries are persisted before sending, so pending
+// ones are picked up again after a restart.
+type WebhookDispatcher struct {
+	*jobServiceDependencies
+	client *http.Client
+	notify chan struct{}
+}
+
+func newWebhookDispatcher(deps *jobServiceDependencies) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		jobServiceDependencies: deps,
+		client:                 newWebhookClient(deps.config.Webhook),
+		notify:                 make(chan struct{}, 1),
+	}
+}
+
+// Run records and delivers webhooks until ctx is done. The subscription must be taken before
+// workers start so no events are missed.
+func (dispatcher *WebhookDispatcher) Run(ctx context.Context, sub *Subscription) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		dispatcher.deliverLoop(ctx)
+	}()
+
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				wg.Wait()
+				return
+			}
+			dispatcher.recordDeliveries(ctx, event)
+		case <-ctx.Done():
+			if dropped := sub.Dropped(); dropped > 0 {
+				slog.WarnContext(ctx, "webhook events dropped", slog.Uint64("dropped", dropped))
+			}
+			sub.Close()
+			wg.Wait()
+			return
+		}
+	}
+}
+
+func (dispatcher *WebhookDispatcher) recordDeliveries(ctx context.Context, event Event) {
+	if event.Job == nil || len(event.Job.Callbacks) == 0 {
+		return
+	}
+	ctx = context.WithValue(ctx, domain.LKeys.JobID, event.JobID)
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to marshal webhook payload", slog.Any("error", err))
+		return
+	}
+
+	now := time.Now().UTC()
+	recorded := false
+	for _, callback := range event.Job.Callbacks {
+		if len(callback.Events) > 0 && !slices.Contains(callback.Events, string(event.Type)) {
+			continue
+		}
+
+		delivery := domain.WebhookDelivery{
+			JobID:           event.JobID,
+			URL:             callback.URL,
+			EventType:       string(event.Type),
+			Payload:         payload,
+			State:           domain.StatePending,
+			NextAttemptDate: now,
+			CreatedDate:     now,
+		}
+		if _, err := dispatcher.repository.SaveWebhookDelivery(ctx, delivery); err != nil {
+			slog.ErrorContext(ctx, "failed to save webhook delivery", slog.String("url", callback.URL), slog.Any("error", err))
+			continue
+		}
+		recorded = true
+	}
+
+	if recorded {
+		select {
+		case dispatcher.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (dispatcher *WebhookDispatcher) deliverLoop(ctx context.Context) {
+	ticker := time.NewTicker(dispatcher.config.Webhook.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		dispatcher.deliverDue(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-dispatcher.notify:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// deliverDue sends every due delivery it can claim, in parallel.
+func (dispatcher *WebhookDispatcher) deliverDue(ctx context.Context) {
+	config := dispatcher.config.Webhook
+	now := time.Now().UTC()
+
+	deliveries, err := dispatcher.repository.GetDueWebhookDeliveries(ctx, now, config.BatchSize)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get due webhook deliveries", slog.Any("error", err))
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		// Lease past the request timeout so another process does not send it concurrently
+		claimed, err := dispatcher.repository.ClaimWebhookDelivery(ctx, delivery.ID, now, now.Add(2*config.Timeout))
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to claim webhook delivery", slog.Any("error", err))
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dispatcher.attemptDelivery(ctx, delivery)
+		}()
+	}
+	wg.Wait()
+}
+
+func (dispatcher *WebhookDispatcher) attemptDelivery(ctx context.Context, delivery domain.WebhookDelivery) {
+	ctx = context.WithValue(ctx, domain.LKeys.JobID, delivery.JobID)
+	config := dispatcher.config.Webhook
+
+	statusCode, err := dispatcher.send(ctx, delivery)
+	if ctx.Err() != nil {
+		// Interrupted by shutdown, the lease expires and the delivery is retried later
+		return
+	}
+
+	now := time.Now().UTC()
+	delivery.Attempts++
+	delivery.LastStatusCode = statusCode
+	delivery.LastError = ""
+
+	switch {
+	case err == nil:
+		delivery.State = domain.StateFinished
+		delivery.DeliveredDate = util.TimePtr(now)
+		slog.InfoContext(ctx, "webhook delivered", slog.String("url", delivery.URL),
+			slog.String("event", delivery.EventType), slog.Int("statusCode", statusCode))
+
+	case delivery.Attempts >= config.MaxAttempts || errors.Is(err, errWebhookAddressBlocked):
+		delivery.State = domain.StateError
+		delivery.LastError = err.Error()
+		slog.WarnContext(ctx, "webhook delivery failed, giving up", slog.String("url", delivery.URL),
+			slog.Int("attempts", delivery.Attempts), slog.Any("error", err))
+
+	default:
+		delivery.LastError = err.Error()
+		delivery.NextAttemptDate = now.Add(webhookBackoff(config, delivery.Attempts))
+		slog.WarnContext(ctx, "webhook delivery failed, retrying", slog.String("url", delivery.URL),
+			slog.Int("attempts", delivery.Attempts), slog.Time("nextAttempt", delivery.NextAttemptDate), slog.Any("error", err))
+	}
+
+	if _, err := dispatcher.repository.SaveWebhookDelivery(ctx, delivery); err != nil {
+		slog.ErrorContext(ctx, "failed to save webhook delivery", slog.Any("error", err))
+	}
+}
+
+// send POSTs the payload and returns the response status code. Non-2xx responses are errors.
+func (dispatcher *WebhookDispatcher) send(ctx context.Context, delivery domain.WebhookDelivery) (int, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(WebhookIDHeader, delivery.ID.String())
+	request.Header.Set(WebhookEventHeader, delivery.EventType)
+	request.Header.Set(WebhookTimestampHeader, timestamp)
+	if secret := dispatcher.config.Webhook.Secret; secret != "" {
+		request.Header.Set(WebhookSignatureHeader, SignWebhookPayload(secret, timestamp, delivery.Payload))
+	} else if !dispatcher.config.Webhook.AllowUnsigned {
+		return 0, errWebhookUnsigned
+	}
+
+	response, err := dispatcher.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return response.StatusCode, fmt.Errorf("unexpected status %s", response.Status)
+	}
+	return response.StatusCode, nil
+}
+
+// newWebhookClient returns a client that refuses to connect to non-public addresses, unless the
+// callback's host is in AllowedHosts. The address is checked after DNS resolution, on every
+// connection, so redirects and rebinding cannot reach internal services. Proxies are not used
+// since they would dial on the client's behalf.
+func newWebhookClient(config *WebhookConfig) *http.Client {
+	allowed := make(map[string]bool, len(config.AllowedHosts))
+	for _, host := range config.AllowedHosts {
+		allowed[strings.ToLower(host)] = true
+	}
+
+	publicDialer := &net.Dialer{
+		Timeout: config.Timeout,
+		Control: func(network string, address string, conn syscall.RawConn) error {
+			return checkWebhookAddress(address)
+		},
+	}
+	allowedDialer := &net.Dialer{Timeout: config.Timeout}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network string, address string) (net.Conn, error) {
+		if host, _, err := net.SplitHostPort(address); err == nil && allowed[strings.ToLower(host)] {
+			return allowedDialer.DialContext(ctx, network, address)
+		}
+		return publicDialer.DialContext(ctx, network, address)
+	}
+
+	return &http.Client{Timeout: config.Timeout, Transport: transport}
+}
+
+// checkWebhookAddress rejects resolved addresses that are not public unicast addresses
+func checkWebhookAddress(address string) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errWebhookAddressBlocked, address)
+	}
+
+	addr := addrPort.Addr().Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || sharedAddressSpace.Contains(addr) {
+		return fmt.Errorf("%w: %s", errWebhookAddressBlocked, addr)
+	}
+	return nil
+}
+
+// SignWebhookPayload returns the signature header value receivers should compare against.
+func SignWebhookPayload(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff doubles the configured backoff for each failed attempt, up to MaxBackoff.
+func webhookBackoff(config *WebhookConfig, attempts int) time.Duration {
+	backoff := config.Backoff
+	for i := 1; i < attempts && backoff < config.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, config.MaxBackoff)
+}
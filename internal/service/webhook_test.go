@@ -0,0 +1,342 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+	"github.com/abikandiah/task-worker/internal/mock"
+	"github.com/google/uuid"
+)
+
+func saveTestDelivery(t *testing.T, repo *mock.MockRepo, url string, attempts int) *domain.WebhookDelivery {
+	t.Helper()
+
+	now := time.Now().UTC()
+	delivery, err := repo.SaveWebhookDelivery(context.Background(), domain.WebhookDelivery{
+		JobID:           uuid.New(),
+		URL:             url,
+		EventType:       string(EventJobFinished),
+		Payload:         []byte(`{"type":"job.finished"}`),
+		State:           domain.StatePending,
+		Attempts:        attempts,
+		NextAttemptDate: now,
+		CreatedDate:     now,
+	})
+	if err != nil {
+		t.Fatalf("SaveWebhookDelivery: %v", err)
+	}
+	return delivery
+}
+
+func getTestDelivery(t *testing.T, repo *mock.MockRepo, delivery *domain.WebhookDelivery) domain.WebhookDelivery {
+	t.Helper()
+
+	deliveries, err := repo.GetWebhookDeliveries(context.Background(), delivery.JobID)
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("GetWebhookDeliveries = %d deliveries, %v", len(deliveries), err)
+	}
+	return deliveries[0]
+}
+
+func TestWebhookDeliverySignsPayload(t *testing.T) {
+	var header http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	repo := mock.NewMockRepo()
+	dispatcher := newWebhookDispatcher(newTestDeps(t, repo))
+	delivery := saveTestDelivery(t, repo, server.URL, 0)
+
+	dispatcher.deliverDue(context.Background())
+
+	if string(body) != string(delivery.Payload) {
+		t.Errorf("body = %s, want %s", body, delivery.Payload)
+	}
+	wantHeaders := map[string]string{
+		"Content-Type":     "application/json",
+		WebhookIDHeader:    delivery.ID.String(),
+		WebhookEventHeader: delivery.EventType,
+	}
+	for name, want := range wantHeaders {
+		if got := header.Get(name); got != want {
+			t.Errorf("header %s = %q, want %q", name, got, want)
+		}
+	}
+	timestamp := header.Get(WebhookTimestampHeader)
+	if timestamp == "" {
+		t.Fatalf("header %s is missing", WebhookTimestampHeader)
+	}
+	if got, want := header.Get(WebhookSignatureHeader), SignWebhookPayload("secret", timestamp, body); got != want {
+		t.Errorf("header %s = %q, want %q", WebhookSignatureHeader, got, want)
+	}
+
+	saved := getTestDelivery(t, repo, delivery)
+	if saved.State != domain.StateFinished || saved.Attempts != 1 || saved.DeliveredDate == nil {
+		t.Errorf("saved delivery = %s after %d attempts, delivered %v, want FINISHED after 1", saved.State, saved.Attempts, saved.DeliveredDate)
+	}
+	if saved.LastStatusCode != http.StatusNoContent {
+		t.Errorf("saved status code = %d, want %d", saved.LastStatusCode, http.StatusNoContent)
+	}
+}
+
+// Without a secret, callbacks are only sent when unsigned callbacks are allowed
+func TestWebhookDeliveryUnsigned(t *testing.T) {
+	var requests atomic.Int32
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		signature = r.Header.Get(WebhookSignatureHeader)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		allowUnsigned bool
+		wantState     domain.ExecutionState
+		wantRequests  int32
+	}{
+		{"refused", false, domain.StatePending, 0},
+		{"allowed", true, domain.StateFinished, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			requests.Store(0)
+			repo := mock.NewMockRepo()
+			deps := newTestDeps(t, repo)
+			deps.config.Webhook.Secret = ""
+			deps.config.Webhook.AllowUnsigned = test.allowUnsigned
+			dispatcher := newWebhookDispatcher(deps)
+			delivery := saveTestDelivery(t, repo, server.URL, 0)
+
+			dispatcher.deliverDue(context.Background())
+
+			saved := getTestDelivery(t, repo, delivery)
+			if saved.State != test.wantState || requests.Load() != test.wantRequests {
+				t.Fatalf("saved delivery = %s after %d requests, want %s after %d", saved.State, requests.Load(), test.wantState, test.wantRequests)
+			}
+			if !test.allowUnsigned && !strings.Contains(saved.LastError, errWebhookUnsigned.Error()) {
+				t.Errorf("saved error = %q, want %v", saved.LastError, errWebhookUnsigned)
+			}
+			if test.allowUnsigned && signature != "" {
+				t.Errorf("header %s = %q, want none", WebhookSignatureHeader, signature)
+			}
+		})
+	}
+}
+
+func TestWebhookDeliveryRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantStatus int
+	}{
+		{"server error", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}, http.StatusBadGateway},
+		{"timeout", func(w http.ResponseWriter, r *http.Request) {
+			// The request is only cancelled once its body is read
+			io.Copy(io.Discard, r.Body)
+			select {
+			case <-r.Context().Done():
+			case <-time.After(2 * time.Second):
+			}
+		}, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(test.handler)
+			defer server.Close()
+
+			repo := mock.NewMockRepo()
+			dispatcher := newWebhookDispatcher(newTestDeps(t, repo))
+			delivery := saveTestDelivery(t, repo, server.URL, 1)
+
+			before := time.Now().UTC()
+			dispatcher.deliverDue(context.Background())
+			after := time.Now().UTC()
+
+			saved := getTestDelivery(t, repo, delivery)
+			if saved.State != domain.StatePending || saved.Attempts != 2 {
+				t.Fatalf("saved delivery = %s after %d attempts, want PENDING after 2", saved.State, saved.Attempts)
+			}
+			if saved.LastStatusCode != test.wantStatus || saved.LastError == "" {
+				t.Errorf("saved status code = %d, error %q, want %d with an error", saved.LastStatusCode, saved.LastError, test.wantStatus)
+			}
+			// The second failure waits twice the backoff
+			if saved.NextAttemptDate.Before(before.Add(2*time.Second)) || saved.NextAttemptDate.After(after.Add(2*time.Second)) {
+				t.Errorf("next attempt in %s, want 2s", saved.NextAttemptDate.Sub(before))
+			}
+		})
+	}
+}
+
+func TestWebhookDeliveryGivesUp(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	repo := mock.NewMockRepo()
+	dispatcher := newWebhookDispatcher(newTestDeps(t, repo))
+	delivery := saveTestDelivery(t, repo, server.URL, 2)
+
+	dispatcher.deliverDue(context.Background())
+	dispatcher.deliverDue(context.Background())
+
+	saved := getTestDelivery(t, repo, delivery)
+	if saved.State != domain.StateError || saved.Attempts != 3 {
+		t.Errorf("saved delivery = %s after %d attempts, want ERROR after 3", saved.State, saved.Attempts)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("server received %d requests, want 1", got)
+	}
+}
+
+// An attempt interrupted by shutdown stays leased, so it is not sent concurrently, and is
+// sent again once the lease expires.
+func TestWebhookDeliveryLeaseReclaim(t *testing.T) {
+	var requests atomic.Int32
+	started := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			io.Copy(io.Discard, r.Body)
+			close(started)
+			<-r.Context().Done()
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	repo := mock.NewMockRepo()
+	dispatcher := newWebhookDispatcher(newTestDeps(t, repo))
+	delivery := saveTestDelivery(t, repo, server.URL, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		dispatcher.deliverDue(ctx)
+	}()
+	<-started
+
+	claimed, err := repo.ClaimWebhookDelivery(context.Background(), delivery.ID, time.Now().UTC(), time.Now().UTC().Add(time.Minute))
+	if err != nil || claimed {
+		t.Errorf("ClaimWebhookDelivery during the lease = %v, %v, want false", claimed, err)
+	}
+
+	cancel()
+	<-done
+
+	saved := getTestDelivery(t, repo, delivery)
+	if saved.State != domain.StatePending || saved.Attempts != 0 {
+		t.Fatalf("interrupted delivery = %s after %d attempts, want PENDING after 0", saved.State, saved.Attempts)
+	}
+
+	// Wait out the lease of twice the timeout
+	time.Sleep(time.Until(saved.NextAttemptDate))
+	dispatcher.deliverDue(context.Background())
+
+	saved = getTestDelivery(t, repo, delivery)
+	if saved.State != domain.StateFinished || saved.Attempts != 1 {
+		t.Errorf("reclaimed delivery = %s after %d attempts, want FINISHED after 1", saved.State, saved.Attempts)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("server received %d requests, want 2", got)
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	config := &WebhookConfig{Backoff: 5 * time.Second, MaxBackoff: 30 * time.Second}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 5 * time.Second},
+		{2, 10 * time.Second},
+		{3, 20 * time.Second},
+		{4, 30 * time.Second},
+		{10, 30 * time.Second},
+	}
+
+	for _, test := range tests {
+		if got := webhookBackoff(config, test.attempts); got != test.want {
+			t.Errorf("webhookBackoff(%d) = %s, want %s", test.attempts, got, test.want)
+		}
+	}
+}
+
+func TestWebhookDeliveryBlocksNonPublicAddresses(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	repo := mock.NewMockRepo()
+	deps := newTestDeps(t, repo)
+	deps.config.Webhook.AllowedHosts = nil
+	dispatcher := newWebhookDispatcher(deps)
+	delivery := saveTestDelivery(t, repo, server.URL, 0)
+
+	dispatcher.deliverDue(context.Background())
+
+	saved := getTestDelivery(t, repo, delivery)
+	if saved.State != domain.StateError || saved.Attempts != 1 {
+		t.Errorf("saved delivery = %s after %d attempts, want ERROR after 1", saved.State, saved.Attempts)
+	}
+	if !strings.Contains(saved.LastError, errWebhookAddressBlocked.Error()) {
+		t.Errorf("saved error = %q, want %q", saved.LastError, errWebhookAddressBlocked)
+	}
+	if got := requests.Load(); got != 0 {
+		t.Errorf("server received %d requests, want 0", got)
+	}
+}
+
+func TestCheckWebhookAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		blocked bool
+	}{
+		{"93.184.215.14:443", false},
+		{"[2606:4700::6810:84e5]:443", false},
+		{"127.0.0.1:80", true},
+		{"[::1]:80", true},
+		{"[::ffff:127.0.0.1]:80", true},
+		{"0.0.0.0:80", true},
+		{"10.1.2.3:80", true},
+		{"172.16.0.1:80", true},
+		{"192.168.1.1:80", true},
+		{"100.64.0.1:80", true},
+		{"169.254.169.254:80", true},
+		{"[fe80::1]:80", true},
+		{"[fd00::1]:80", true},
+		{"224.0.0.1:80", true},
+	}
+
+	for _, test := range tests {
+		err := checkWebhookAddress(test.address)
+		if test.blocked != errors.Is(err, errWebhookAddressBlocked) {
+			t.Errorf("checkWebhookAddress(%s) = %v, want blocked %v", test.address, err, test.blocked)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/abikandiah/task-worker/internal/domain"
+)
+
+// workerRegistry registers this process in the repository and keeps its heartbeat fresh.
+// The leader also recovers jobs held by workers that stopped heartbeating.
+type workerRegistry struct {
+	*jobServiceDependencies
+	worker  domain.Worker
+	elector LeaderElector
+	// notify wakes the local pollers once recovered jobs can be claimed again
+	notify func()
+}
+
+func newWorkerRegistry(deps *jobServiceDependencies, workerID string, queues []QueueConfig, elector LeaderElector, version string, notify func()) *workerRegistry {
+	hostname, _ := os.Hostname()
+
+	worker := domain.Worker{
+		ID:           workerID,
+		Hostname:     hostname,
+		Version:      version,
+		TaskNames:    deps.taskFactory.GetTaskNames(),
+		Capabilities: deps.config.Capabilities,
+	}
+	for _, queue := range queues {
+		worker.Queues = append(worker.Queues, domain.WorkerQueue{
+			Name:            queue.Name,
+			JobWorkerCount:  queue.JobWorkerCount,
+			TaskWorkerCount: queue.TaskWorkerCount,
+		})
+		worker.JobWorkerCount += queue.JobWorkerCount
+		worker.TaskWorkerCount += queue.TaskWorkerCount
+	}
+
+	return &workerRegistry{
+		jobServiceDependencies: deps,
+		worker:                 worker,
+		elector:                elector,
+		notify:                 notify,
+	}
+}
+
+// Register records the worker, it must run before the worker claims any jobs
+func (registry *workerRegistry) Register(ctx context.Context) error {
+	now := time.Now().UTC()
+	registry.worker.StartedDate = now
+	registry.worker.HeartbeatDate = now
+
+	_, err := registry.repository.SaveWorker(ctx, registry.worker)
+	return err
+}
+
+// Run sends heartbeats until ctx is done
+func (registry *workerRegistry) Run(ctx context.Context) {
+	ticker := time.NewTicker(registry.config.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			registry.heartbeat(ctx)
+		}
+	}
+}
+
+// RecoverJobs is a leader duty, recovering jobs of dead workers until ctx is done
+func (registry *workerRegistry) RecoverJobs(ctx context.Context) {
+	ticker := time.NewTicker(registry.config.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			registry.recoverJobs(ctx)
+		}
+	}
+}
+
+func (registry *workerRegistry) heartbeat(ctx context.Context) {
+	now := time.Now().UTC()
+	leader := registry.elector.IsLeader()
+
+	found, err := registry.repository.UpdateWorkerHeartbeat(ctx, registry.worker.ID, now, leader)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to update worker heartbeat", slog.Any("error", err))
+		return
+	}
+	if found {
+		return
+	}
+
+	// The registration was removed, e.g. by retention, register again
+	registry.worker.HeartbeatDate = now
+	registry.worker.Leader = leader
+	if _, err := registry.repository.SaveWorker(ctx, registry.worker); err != nil {
+		slog.ErrorContext(ctx, "failed to register worker", slog.Any("error", err))
+	}
+}
+
+func (registry *workerRegistry) recoverJobs(ctx context.Context) {
+	aliveSince := time.Now().UTC().Add(-registry.config.DeadAfter)
+
+	jobIDs, err := registry.repository.RecoverJobs(ctx, aliveSince)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to recover jobs of dead workers", slog.Any("error", err))
+		return
+	}
+	if len(jobIDs) == 0 {
+		return
+	}
+
+	slog.WarnContext(ctx, "recovered jobs of dead workers", slog.Int("count", len(jobIDs)))
+	registry.notify()
+}
+
+// Stop marks the worker as stopped, so its remaining claims are recovered without waiting
+func (registry *workerRegistry) Stop(ctx context.Context) {
+	if err := registry.repository.StopWorker(ctx, registry.worker.ID, time.Now().UTC()); err != nil {
+		slog.ErrorContext(ctx, "failed to stop worker", slog.Any("error", err))
+	}
+}
+
+// workerState derives liveness from the heartbeat, the registry only stores timestamps
+func workerState(worker domain.Worker, aliveSince time.Time) domain.WorkerState {
+	if worker.StoppedDate != nil {
+		return domain.WorkerStopped
+	}
+	if worker.HeartbeatDate.Before(aliveSince) {
+		return domain.WorkerDead
+	}
+	return domain.WorkerAlive
+}
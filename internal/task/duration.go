@@ -38,6 +38,17 @@ func (task *DurationTask) Execute(ctx context.Context) (any, error) {
 	slog.InfoContext(ctx, "starting duration task")
 	slog.InfoContext(ctx, fmt.Sprintf("waiting for %d", task.Length))
 
-	<-time.After(time.Duration(task.Length) * time.Second)
+	// Report progress once per second
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for elapsed := 0; elapsed < task.Length; elapsed++ {
+		select {
+		case <-ticker.C:
+			domain.ReportProgress(ctx, float32(elapsed+1)/float32(task.Length))
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
 	return nil, nil
 }
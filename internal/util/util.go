@@ -12,6 +12,11 @@ func TimePtr(t time.Time) *time.Time {
 	return &t
 }
 
+// IntPtr converts an int to an *int, for optional fields where 0 is a meaningful value
+func IntPtr(i int) *int {
+	return &i
+}
+
 func ParseTime(timeStr string) (time.Time, error) {
 	if timeStr == "" {
 		return time.Time{}, fmt.Errorf("empty time string")
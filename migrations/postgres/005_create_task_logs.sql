@@ -0,0 +1,22 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+CREATE TABLE task_logs (
+    seq BIGSERIAL PRIMARY KEY,
+    job_id UUID NOT NULL,
+    task_run_id UUID NOT NULL,
+    time TIMESTAMP NOT NULL,
+    level TEXT NOT NULL,
+    message TEXT NOT NULL,
+    attrs TEXT,
+    FOREIGN KEY(task_run_id) 
+        REFERENCES task_runs(id) 
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_task_logs_task_run_id ON task_logs(task_run_id, seq);
+
+-- +goose Down
+-- SQL in section 'Down' is executed when this migration is rolled back
+DROP INDEX IF EXISTS idx_task_logs_task_run_id;
+
+DROP TABLE IF EXISTS task_logs;
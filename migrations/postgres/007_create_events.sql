@@ -0,0 +1,16 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+CREATE TABLE events (
+    seq BIGSERIAL PRIMARY KEY,
+    origin TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    created_date TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_events_created_date ON events(created_date);
+
+-- +goose Down
+-- SQL in section 'Down' is executed when this migration is rolled back
+DROP INDEX IF EXISTS idx_events_created_date;
+
+DROP TABLE IF EXISTS events;
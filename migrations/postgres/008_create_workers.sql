@@ -0,0 +1,20 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+CREATE TABLE workers (
+    id TEXT PRIMARY KEY,
+    hostname TEXT NOT NULL,
+    version TEXT,
+    job_worker_count INTEGER NOT NULL,
+    task_worker_count INTEGER NOT NULL,
+    task_names TEXT,
+    started_date TIMESTAMP NOT NULL,
+    heartbeat_date TIMESTAMP NOT NULL,
+    stopped_date TIMESTAMP
+);
+
+CREATE INDEX idx_workers_heartbeat_date ON workers(heartbeat_date);
+
+-- +goose Down
+-- SQL in section 'Down' is executed when this migration is rolled back
+DROP INDEX IF EXISTS idx_workers_heartbeat_date;
+DROP TABLE IF EXISTS workers;
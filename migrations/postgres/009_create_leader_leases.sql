@@ -0,0 +1,16 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+CREATE TABLE leader_leases (
+    name TEXT PRIMARY KEY,
+    holder TEXT NOT NULL,
+    acquired_date TIMESTAMP NOT NULL,
+    expires_date TIMESTAMP NOT NULL
+);
+
+ALTER TABLE workers ADD COLUMN leader BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+-- SQL in section 'Down' is executed when this migration is rolled back
+ALTER TABLE workers DROP COLUMN leader;
+
+DROP TABLE IF EXISTS leader_leases;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+ALTER TABLE jobs ADD COLUMN queue TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE workers ADD COLUMN queues TEXT;
+
+CREATE INDEX idx_jobs_queue_state ON jobs(queue, state);
+
+-- +goose Down
+-- SQL in section 'Down' is executed when this migration is rolled back
+DROP INDEX IF EXISTS idx_jobs_queue_state;
+
+ALTER TABLE workers DROP COLUMN queues;
+ALTER TABLE jobs DROP COLUMN queue;
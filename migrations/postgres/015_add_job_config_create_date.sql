@@ -0,0 +1,12 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+-- Existing versions are dated by the migration, their order among each other is by version
+ALTER TABLE job_configs ADD COLUMN create_date TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'utc');
+
+CREATE INDEX idx_job_configs_create_date ON job_configs(id, create_date);
+
+-- +goose Down
+-- SQL in section 'Down' is executed when this migration is rolled back
+DROP INDEX IF EXISTS idx_job_configs_create_date;
+
+ALTER TABLE job_configs DROP COLUMN create_date;
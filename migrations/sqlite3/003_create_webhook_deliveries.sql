@@ -0,0 +1,34 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+ALTER TABLE jobs ADD COLUMN callbacks TEXT;
+
+CREATE TABLE webhook_deliveries (
+    id BLOB PRIMARY KEY,
+    job_id BLOB NOT NULL,
+    url TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    state TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_status_code INTEGER,
+    last_error TEXT,
+    next_attempt_date TEXT NOT NULL,
+    created_date TEXT NOT NULL,
+    delivered_date TEXT,
+
+    FOREIGN KEY(job_id) 
+        REFERENCES jobs(id) 
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_webhook_deliveries_job_id ON webhook_deliveries(job_id);
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(state, next_attempt_date);
+
+-- +goose Down
+-- SQL in section 'Down' is executed when this migration is rolled back
+DROP INDEX IF EXISTS idx_webhook_deliveries_due;
+DROP INDEX IF EXISTS idx_webhook_deliveries_job_id;
+
+DROP TABLE IF EXISTS webhook_deliveries;
+
+ALTER TABLE jobs DROP COLUMN callbacks;
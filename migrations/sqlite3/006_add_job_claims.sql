@@ -0,0 +1,13 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+ALTER TABLE jobs ADD COLUMN worker_id TEXT;
+ALTER TABLE jobs ADD COLUMN claim_date TEXT;
+
+CREATE INDEX idx_jobs_worker_id ON jobs(worker_id);
+
+-- +goose Down
+-- SQL in section 'Down' is executed when this migration is rolled back
+DROP INDEX IF EXISTS idx_jobs_worker_id;
+
+ALTER TABLE jobs DROP COLUMN claim_date;
+ALTER TABLE jobs DROP COLUMN worker_id;
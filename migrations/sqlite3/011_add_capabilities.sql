@@ -0,0 +1,9 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+ALTER TABLE jobs ADD COLUMN capabilities TEXT;
+ALTER TABLE workers ADD COLUMN capabilities TEXT;
+
+-- +goose Down
+-- SQL in section 'Down' is executed when this migration is rolled back
+ALTER TABLE workers DROP COLUMN capabilities;
+ALTER TABLE jobs DROP COLUMN capabilities;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+ALTER TABLE jobs ADD COLUMN concurrency_key TEXT;
+ALTER TABLE jobs ADD COLUMN concurrency_limit INTEGER NOT NULL DEFAULT 1;
+
+CREATE INDEX idx_jobs_concurrency_key ON jobs(concurrency_key, state);
+
+-- +goose Down
+-- SQL in section 'Down' is executed when this migration is rolled back
+DROP INDEX IF EXISTS idx_jobs_concurrency_key;
+
+ALTER TABLE jobs DROP COLUMN concurrency_limit;
+ALTER TABLE jobs DROP COLUMN concurrency_key;
@@ -0,0 +1,28 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+CREATE TABLE job_templates (
+    id BLOB NOT NULL,
+    version BLOB NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT,
+    details TEXT NOT NULL,
+    create_date TEXT NOT NULL,
+    PRIMARY KEY (id, version)
+);
+
+CREATE INDEX idx_job_templates_create_date ON job_templates(id, create_date);
+
+ALTER TABLE jobs ADD COLUMN template_id BLOB;
+ALTER TABLE jobs ADD COLUMN template_version BLOB;
+
+CREATE INDEX idx_jobs_template_id ON jobs(template_id) WHERE template_id IS NOT NULL;
+
+-- +goose Down
+-- SQL in section 'Down' is executed when this migration is rolled back
+DROP INDEX IF EXISTS idx_jobs_template_id;
+
+ALTER TABLE jobs DROP COLUMN template_version;
+ALTER TABLE jobs DROP COLUMN template_id;
+
+DROP INDEX IF EXISTS idx_job_templates_create_date;
+DROP TABLE IF EXISTS job_templates;
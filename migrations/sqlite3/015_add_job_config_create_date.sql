@@ -0,0 +1,14 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+ALTER TABLE job_configs ADD COLUMN create_date TEXT NOT NULL DEFAULT '1970-01-01 00:00:00';
+
+-- Existing versions are dated by the migration, their order among each other is by version
+UPDATE job_configs SET create_date = strftime('%Y-%m-%d %H:%M:%f', 'now');
+
+CREATE INDEX idx_job_configs_create_date ON job_configs(id, create_date);
+
+-- +goose Down
+-- SQL in section 'Down' is executed when this migration is rolled back
+DROP INDEX IF EXISTS idx_job_configs_create_date;
+
+ALTER TABLE job_configs DROP COLUMN create_date;